@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gonews/pkg/gonews"
+)
+
+// benchReport is what "gonews bench" prints, as JSON, so runs can be
+// compared by scripts
+type benchReport struct {
+	GoVersion  string `json:"go_version"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	Source     string `json:"source"`
+	Docs       int    `json:"docs"`
+	Bytes      int64  `json:"bytes"` // title, summary and content text indexed
+	Terms      int    `json:"terms"`
+
+	Index   benchIndex    `json:"index"`
+	Queries *benchQueries `json:"queries,omitempty"`
+	Memory  benchMemory   `json:"memory"`
+}
+
+type benchIndex struct {
+	Seconds    float64 `json:"seconds"`
+	DocsPerSec float64 `json:"docs_per_sec"`
+	MBPerSec   float64 `json:"mb_per_sec"`
+}
+
+// benchQueries are latencies over every run of every query, in milliseconds
+type benchQueries struct {
+	Queries  int     `json:"queries"`
+	Runs     int     `json:"runs"` // per query
+	Searches int     `json:"searches"`
+	QPS      float64 `json:"qps"`
+	MeanMS   float64 `json:"mean_ms"`
+	P50MS    float64 `json:"p50_ms"`
+	P90MS    float64 `json:"p90_ms"`
+	P99MS    float64 `json:"p99_ms"`
+	MaxMS    float64 `json:"max_ms"`
+}
+
+type benchMemory struct {
+	HeapMB       float64 `json:"heap_mb"`        // live heap held by the index, docs included, after GC
+	AllocMB      float64 `json:"alloc_mb"`       // allocated while indexing
+	SysMB        float64 `json:"sys_mb"`         // obtained from the OS in total
+	NumGC        uint32  `json:"num_gc"`         // collections while indexing
+	PauseTotalMS float64 `json:"pause_total_ms"` // GC pauses while indexing
+}
+
+// runBench implements "gonews bench": index a corpus, replay a query file
+// against it and report throughput, latency percentiles and memory
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gonews bench [flags]\n\nIndexes -p, runs each query in -queries -runs times and prints a JSON report.")
+		fs.PrintDefaults()
+	}
+	path := fs.String("p", "data/news.csv", "corpus: CSV file, .jsonl/.ndjson file or directory of .txt/.md files")
+	maxDocs := fs.Int("maxdocs", 0, "index only the first N documents (0 = all)")
+	queriesPath := fs.String("queries", "", "file of queries, one per line (blank lines and # comments skipped); without it only indexing is measured")
+	runs := fs.Int("runs", 5, "times each query is run, after one warm-up run")
+	limit := fs.Int("n", 10, "results fetched per query, as with -n")
+	shards := fs.Int("shards", 0, "index and search this many shards in parallel (0 = one index)")
+	out := fs.String("o", "", "write the report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return fmt.Errorf("-runs must be at least 1")
+	}
+	logger := newLogger(os.Stderr, slog.LevelInfo, "text")
+
+	var queries []string
+	if *queriesPath != "" {
+		var err error
+		if queries, err = readQueryFile(*queriesPath); err != nil {
+			return err
+		}
+	}
+
+	loader := corpusLoader(*path, *maxDocs)
+	var base, before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&base)
+	docs, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("load %s: %w", *path, err)
+	}
+	report := benchReport{GoVersion: runtime.Version(), GOMAXPROCS: runtime.GOMAXPROCS(0), Source: *path, Docs: len(docs)}
+	for _, d := range docs {
+		report.Bytes += int64(len(d.Title) + len(d.Summary) + len(d.Content))
+	}
+	logger.Info("loaded", "docs", len(docs), "bytes", report.Bytes)
+
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var idx *gonews.Index
+	var sharded *gonews.ShardedIndex
+	if *shards > 0 {
+		sharded = gonews.NewShardedIndex(*shards, nil)
+		sharded.AddDocuments(docs)
+		for _, shard := range sharded.Shards {
+			report.Terms += len(shard.Terms) // terms in several shards count more than once
+		}
+	} else {
+		idx = gonews.NewIndex()
+		for _, d := range docs {
+			idx.AddDocument(d)
+		}
+		report.Terms = len(idx.Terms)
+	}
+	elapsed := time.Since(start).Seconds()
+	runtime.ReadMemStats(&after)
+	report.Index = benchIndex{
+		Seconds:    elapsed,
+		DocsPerSec: float64(len(docs)) / elapsed,
+		MBPerSec:   float64(report.Bytes) / (1 << 20) / elapsed,
+	}
+	report.Memory = benchMemory{
+		AllocMB:      float64(after.TotalAlloc-before.TotalAlloc) / (1 << 20),
+		NumGC:        after.NumGC - before.NumGC,
+		PauseTotalMS: float64(after.PauseTotalNs-before.PauseTotalNs) / 1e6,
+	}
+	// the index keeps what it needs of the docs
+	docs = nil
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	report.Memory.HeapMB = (float64(after.HeapAlloc) - float64(base.HeapAlloc)) / (1 << 20)
+	report.Memory.SysMB = float64(after.Sys) / (1 << 20)
+	logger.Info("indexed", "seconds", elapsed, "docs_per_sec", report.Index.DocsPerSec)
+
+	if len(queries) > 0 {
+		search := func(q string) error {
+			var err error
+			if sharded != nil {
+				_, _, err = sharded.SearchPage(q, 0, *limit)
+			} else {
+				_, _, err = idx.SearchPage(q, 0, *limit)
+			}
+			return err
+		}
+		for _, q := range queries {
+			// warm the lazily built lookup structures, and fail on a bad
+			// query before timing it
+			if err := search(q); err != nil {
+				return fmt.Errorf("query %q: %w", q, err)
+			}
+		}
+		latencies := make([]time.Duration, 0, len(queries)**runs)
+		start := time.Now()
+		for range *runs {
+			for _, q := range queries {
+				t := time.Now()
+				search(q)
+				latencies = append(latencies, time.Since(t))
+			}
+		}
+		report.Queries = latencyReport(latencies, time.Since(start))
+		report.Queries.Queries = len(queries)
+		report.Queries.Runs = *runs
+		logger.Info("searched", "searches", len(latencies), "p50_ms", report.Queries.P50MS, "p99_ms", report.Queries.P99MS)
+	}
+	runtime.KeepAlive(idx)
+	runtime.KeepAlive(sharded)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// readQueryFile reads one query per line, skipping blank lines and lines
+// starting with #
+func readQueryFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var queries []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		q := strings.TrimSpace(sc.Text())
+		if q != "" && !strings.HasPrefix(q, "#") {
+			queries = append(queries, q)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading queries %s: %w", path, err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s holds no queries", path)
+	}
+	return queries, nil
+}
+
+// latencyReport summarizes search latencies; percentiles are nearest-rank
+func latencyReport(latencies []time.Duration, wall time.Duration) *benchQueries {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	pct := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(latencies)))) - 1
+		return ms(latencies[max(0, min(i, len(latencies)-1))])
+	}
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+	return &benchQueries{
+		Searches: len(latencies),
+		QPS:      float64(len(latencies)) / wall.Seconds(),
+		MeanMS:   ms(sum) / float64(len(latencies)),
+		P50MS:    pct(0.50),
+		P90MS:    pct(0.90),
+		P99MS:    pct(0.99),
+		MaxMS:    ms(latencies[len(latencies)-1]),
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// applyConfig sets the flags of fs named in a YAML (.yaml, .yml) or TOML
+// (.toml) config file, skipping those given on the command line so they
+// override the file. Keys are flag names without the dash; maps group them
+// into sections (dataset, analyzer, scoring, server...) whose names are
+// only for the reader. Lists become comma-separated values.
+func applyConfig(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var settings map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &settings)
+	case ".toml":
+		err = toml.Unmarshal(data, &settings)
+	default:
+		return fmt.Errorf("config %s: want a .yaml, .yml or .toml file", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if err := applySettings(fs, settings, "", given); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applySettings sets fs's flags from one section of a config file; section
+// is its dotted path, for error messages
+func applySettings(fs *flag.FlagSet, settings map[string]any, section string, given map[string]bool) error {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if section != "" {
+			key = section + "." + k
+		}
+		switch v := settings[k].(type) {
+		case map[string]any:
+			if err := applySettings(fs, v, key, given); err != nil {
+				return err
+			}
+			continue
+		case nil:
+			continue // "key:" with no value leaves the default
+		}
+		if fs.Lookup(k) == nil || k == "config" {
+			return fmt.Errorf("%s: no such setting (keys are flag names, e.g. stem or bm25-k1)", key)
+		}
+		if given[k] {
+			continue
+		}
+		value := fmt.Sprint(settings[k])
+		if list, ok := settings[k].([]any); ok {
+			parts := make([]string, len(list))
+			for i, item := range list {
+				parts[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(parts, ",")
+		}
+		if err := fs.Set(k, value); err != nil {
+			return fmt.Errorf("%s: invalid value %q: %w", key, value, err)
+		}
+	}
+	return nil
+}
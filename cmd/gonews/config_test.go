@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configFlags is a flag set with a few of each kind of flag main defines
+func configFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("gonews", flag.ContinueOnError)
+	fs.String("p", "", "")
+	fs.Bool("stem", false, "")
+	fs.Float64("bm25-k1", 1.2, "")
+	fs.Int("max-results", 0, "")
+	fs.String("stopwords", "", "")
+	fs.String("config", "", "")
+	return fs
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyConfig(t *testing.T) {
+	files := map[string]string{
+		"gonews.yaml": `
+dataset:
+  p: news.csv
+analyzer:
+  stem: true
+  stopwords: [the, a, of]
+scoring:
+  bm25-k1: 2
+  max-results:
+`,
+		"gonews.toml": `
+[dataset]
+p = "news.csv"
+
+[analyzer]
+stem = true
+stopwords = ["the", "a", "of"]
+
+[scoring]
+bm25-k1 = 2
+`,
+	}
+	want := map[string]string{"p": "news.csv", "stem": "true", "stopwords": "the,a,of", "bm25-k1": "2", "max-results": "0"}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			fs := configFlags()
+			if err := applyConfig(fs, writeConfig(t, name, content)); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range want {
+				if got := fs.Lookup(k).Value.String(); got != v {
+					t.Errorf("-%s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyConfigCommandLineWins(t *testing.T) {
+	fs := configFlags()
+	if err := fs.Parse([]string{"-stem=false", "-p", "other.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, "gonews.yml", "p: news.csv\nstem: true\nbm25-k1: 2\n")
+	if err := applyConfig(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"p": "other.csv", "stem": "false", "bm25-k1": "2"} {
+		if got := fs.Lookup(k).Value.String(); got != v {
+			t.Errorf("-%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name, content string
+		wantErr       string
+	}{
+		{"gonews.yaml", "scoring:\n  bm25-k3: 2\n", "scoring.bm25-k3: no such setting"},
+		{"gonews.yaml", "config: other.yaml\n", "config: no such setting"},
+		{"gonews.toml", "[analyzer]\nstem = \"maybe\"\n", `analyzer.stem: invalid value "maybe"`},
+		{"gonews.yaml", "max-results: lots\n", `max-results: invalid value "lots"`},
+		{"gonews.yaml", "stem: [true\n", "gonews.yaml"},
+		{"gonews.json", `{"stem": true}`, "want a .yaml, .yml or .toml file"},
+	}
+	for _, tt := range tests {
+		err := applyConfig(configFlags(), writeConfig(t, tt.name, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %q: error %v, want one mentioning %q", tt.name, tt.content, err, tt.wantErr)
+		}
+	}
+	if err := applyConfig(configFlags(), filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("missing file: error %v, want not exist", err)
+	}
+}
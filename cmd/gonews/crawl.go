@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gonews/pkg/crawler"
+	"gonews/pkg/gonews"
+)
+
+// runCrawl implements "gonews crawl": fetch pages from seed URLs, index
+// them, and save the index, the pages as JSON Lines, or both. On SIGINT the
+// crawl stops and what was fetched so far is kept.
+func runCrawl(args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gonews crawl [flags] URL...\n\nFetches the seed URLs and the pages they link to, obeying robots.txt, and indexes them.")
+		fs.PrintDefaults()
+	}
+	seedsPath := fs.String("seeds", "", "file of seed URLs, one per line (blank lines and # comments skipped), besides those given as arguments")
+	maxPages := fs.Int("max-pages", crawler.DefaultMaxPages, "stop after requesting this many pages")
+	depth := fs.Int("depth", 1, "follow links this many hops from a seed (0 = the seeds only)")
+	delay := fs.Duration("delay", crawler.DefaultDelay, "least time between requests to one host (a longer robots.txt Crawl-delay wins)")
+	userAgent := fs.String("user-agent", crawler.DefaultUserAgent, "User-Agent sent, and matched against robots.txt")
+	anyHost := fs.Bool("any-host", false, "follow links to other hosts than the seeds'")
+	out := fs.String("o", "", "write the crawled pages to this JSON Lines file, loadable with -p")
+	indexOut := fs.String("index-out", "", "save the index to this file, loadable with -index-in")
+	query := fs.String("q", "", "search the crawled pages once done")
+	limit := fs.Int("n", 10, "results shown for -q")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	seeds := fs.Args()
+	if *seedsPath != "" {
+		more, err := readQueryFile(*seedsPath)
+		if err != nil {
+			return err
+		}
+		seeds = append(seeds, more...)
+	}
+	if len(seeds) == 0 {
+		fs.Usage()
+		return fmt.Errorf("no seed URLs")
+	}
+	if *out == "" && *indexOut == "" && *query == "" {
+		return fmt.Errorf("nothing to do with the pages: give -o, -index-out or -q")
+	}
+	logger := newLogger(os.Stderr, slog.LevelInfo, "text")
+
+	var enc *json.Encoder
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		enc = json.NewEncoder(f)
+	}
+	idx := gonews.NewIndex()
+	c := &crawler.Crawler{
+		MaxPages:  *maxPages,
+		MaxDepth:  *depth,
+		Delay:     *delay,
+		UserAgent: *userAgent,
+		AnyHost:   *anyHost,
+		OnPage: func(url string, err error) {
+			if err != nil {
+				logger.Warn("skipped", "url", url, "reason", err)
+				return
+			}
+			logger.Info("fetched", "url", url)
+		},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	start := time.Now()
+	err := c.Crawl(ctx, seeds, func(d gonews.Document) error {
+		idx.AddDocument(d)
+		if enc != nil {
+			return enc.Encode(d)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, context.Canceled):
+		logger.Warn("crawl interrupted, keeping the pages fetched so far")
+	case err != nil:
+		return err
+	}
+	logger.Info("crawled", "docs", idx.DocCount(), "terms", idx.TermCount(), "duration", time.Since(start))
+
+	if *indexOut != "" {
+		if err := idx.Save(*indexOut); err != nil {
+			return err
+		}
+		logger.Info("saved index", "path", *indexOut)
+	}
+	if *query != "" {
+		results, total, err := idx.SearchPage(*query, 0, *limit)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d results for %q\n", total, *query)
+		for _, r := range results {
+			d, _ := idx.Doc(r.DocID)
+			fmt.Printf("[%s] %s (score: %.4f)\n  %s\n", d.Date, d.Title, r.Score, d.SourceID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gonews/pkg/gonews"
+)
+
+// runEval implements "gonews eval": index a corpus, run a TREC-style topic
+// set against it with each scorer asked for, and report precision@k,
+// recall, MAP and nDCG@k from a qrels file
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gonews eval -qrels FILE -topics FILE [flags]\n\nScores the ranking of each topic's query against relevance judgments.")
+		fs.PrintDefaults()
+	}
+	path := fs.String("p", "data/news.csv", "corpus: CSV file, .jsonl/.ndjson file or directory of .txt/.md files")
+	maxDocs := fs.Int("maxdocs", 0, "index only the first N documents (0 = all)")
+	qrelsPath := fs.String("qrels", "", "TREC qrels file: \"topic iteration docno relevance\" lines, docno being the doc's source ID or ID")
+	topicsPath := fs.String("topics", "", "query set: \"topic query text\" lines")
+	k := fs.Int("k", 10, "cutoff for precision and nDCG")
+	depth := fs.Int("depth", gonews.DefaultEvalDepth, "results ranked per topic, for recall and MAP")
+	scorers := fs.String("scorer", "tfidf,bm25", "comma-separated scorers to compare: tfidf, bm25, blend")
+	perTopic := fs.Bool("per-topic", false, "also report each topic's scores")
+	format := fs.String("format", "text", "report format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *qrelsPath == "" || *topicsPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-qrels and -topics are required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown -format %q (want text or json)", *format)
+	}
+	names := strings.Split(*scorers, ",")
+	scoreFns := make([]gonews.ScoreFunc, len(names))
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		var err error
+		if scoreFns[i], err = scorerNamed(names[i]); err != nil {
+			return err
+		}
+	}
+	qrels, err := gonews.LoadQrels(*qrelsPath)
+	if err != nil {
+		return err
+	}
+	topics, err := gonews.LoadTopics(*topicsPath)
+	if err != nil {
+		return err
+	}
+	docs, err := corpusLoader(*path, *maxDocs).Load()
+	if err != nil {
+		return fmt.Errorf("load %s: %w", *path, err)
+	}
+	idx := gonews.NewIndex()
+	for _, d := range docs {
+		idx.AddDocument(d)
+	}
+
+	type scorerReport struct {
+		Scorer string `json:"scorer"`
+		gonews.EvalReport
+	}
+	reports := make([]scorerReport, len(names))
+	for i, name := range names {
+		idx.Scorer = scoreFns[i]
+		report, err := idx.Evaluate(topics, qrels, *k, *depth)
+		if err != nil {
+			return err
+		}
+		reports[i] = scorerReport{Scorer: name, EvalReport: report}
+		if !*perTopic {
+			reports[i].Topics = nil
+		}
+	}
+	if len(reports[0].Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d topics without relevant judgments: %s\n", len(reports[0].Skipped), strings.Join(reports[0].Skipped, " "))
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "scorer\ttopic\tP@%d\trecall\tAP\tnDCG@%d\n", *k, *k)
+	for _, r := range reports {
+		for _, e := range append(r.Topics, r.Mean) {
+			fmt.Fprintf(tw, "%s\t%s\t%.4f\t%.4f\t%.4f\t%.4f\n", r.Scorer, e.Topic, e.Precision, e.Recall, e.AP, e.NDCG)
+		}
+	}
+	return tw.Flush()
+}
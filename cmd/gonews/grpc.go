@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"gonews/pkg/gonews"
+	"gonews/pkg/grpcapi"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startGRPC serves srv's search and document API over gRPC on addr in the
+// background. The returned stop waits up to drain for in-flight calls.
+func startGRPC(logger *slog.Logger, srv *gonews.Server, addr string) (stop func(drain time.Duration), err error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	g := grpc.NewServer(grpc.UnaryInterceptor(logCalls(logger)))
+	grpcapi.Register(g, srv)
+	go func() {
+		if err := g.Serve(lis); err != nil {
+			logger.Error("gRPC server stopped", "err", err)
+		}
+	}()
+	return func(drain time.Duration) {
+		done := make(chan struct{})
+		go func() {
+			g.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(drain):
+			g.Stop()
+		}
+	}, nil
+}
+
+// logCalls logs each gRPC call like the HTTP server logs requests: method,
+// status code and duration, plus the query and hit count of searches
+func logCalls(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if sr, ok := req.(*grpcapi.SearchRequest); ok {
+			attrs = append(attrs, slog.String("query", sr.GetQuery()))
+			if r, ok := resp.(*grpcapi.SearchResponse); ok && err == nil {
+				attrs = append(attrs, slog.Int64("hits", r.GetTotal()))
+			}
+		}
+		logger.LogAttrs(ctx, level, "call", attrs...)
+		return resp, err
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{"debug", true, true},
+		{"info", false, true},
+		{"warn", false, false},
+		{"ERROR", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := parseLogLevel(tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			logger := newLogger(&buf, level, "text")
+			logger.Debug("parsed query", "rpn", "a b AND")
+			logger.Info("indexed", "docs", 3)
+			logger.Warn("slow search")
+			out := buf.String()
+			if got := strings.Contains(out, "msg=\"parsed query\""); got != tt.wantDebug {
+				t.Errorf("debug line logged = %v, want %v:\n%s", got, tt.wantDebug, out)
+			}
+			if got := strings.Contains(out, "msg=indexed docs=3"); got != tt.wantInfo {
+				t.Errorf("info line logged = %v, want %v:\n%s", got, tt.wantInfo, out)
+			}
+			if out != "" && !strings.HasSuffix(out, "\n") || strings.Count(out, "\n") != strings.Count(out, "msg=") {
+				t.Errorf("want one newline-terminated line per record:\n%q", out)
+			}
+		})
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger(&buf, slog.LevelInfo, "json").Info("searched", "query", "climate", "hits", 2)
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("line %q is not JSON: %v", buf.String(), err)
+	}
+	if rec["msg"] != "searched" || rec["query"] != "climate" || rec["hits"] != 2.0 {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestParseLogLevelRejectsUnknown(t *testing.T) {
+	if _, err := parseLogLevel("chatty"); err == nil {
+		t.Error("parseLogLevel(chatty) succeeded")
+	}
+}
@@ -0,0 +1,976 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"gonews/pkg/boltstore"
+	"gonews/pkg/crawler"
+	"gonews/pkg/gonews"
+	"gonews/pkg/sqlitestore"
+)
+
+// subcommands run instead of a search when named as the first argument
+var subcommands = map[string]func(args []string) error{
+	"bench":  runBench,
+	"crawl":  runCrawl,
+	"eval":   runEval,
+	"trends": runTrends,
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if sub, ok := subcommands[os.Args[1]]; ok {
+			if err := sub(os.Args[2:]); err != nil {
+				if err != flag.ErrHelp {
+					fmt.Fprintf(os.Stderr, "gonews %s: %v\n", os.Args[1], err)
+				}
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	path := flag.String("p", "data/news.csv", "path to news CSV file, newline-delimited JSON if it ends in .jsonl/.ndjson, or a directory of .txt/.md files")
+	colID := flag.String("col-id", "", "CSV column holding the article ID, by header name or 1-based position")
+	colTitle := flag.String("col-title", "", "CSV column holding the title, by header name or 1-based position")
+	colDate := flag.String("col-date", "", "CSV column holding the date, by header name or 1-based position")
+	colContent := flag.String("col-content", "", "CSV column holding the body text, by header name or 1-based position")
+	jsonlFields := flag.String("jsonl-fields", "", "JSON keys for a .jsonl/.ndjson -p, e.g. id=article_id,content=body (default: common names)")
+	apiURL := flag.String("api", "", "load documents from this paginated JSON API instead of -p")
+	apiPageParam := flag.String("api-page-param", "page", "query parameter carrying the page number or cursor for -api")
+	feedURLs := flag.String("feed", "", "also index the items of these comma-separated RSS/Atom feed URLs (without -p/-api, only them)")
+	pageURLs := flag.String("urls", "", "also index these comma-separated web pages, fetched obeying robots.txt (without -p/-api, only them)")
+	shards := flag.Int("shards", 0, "split the index into this many shards, built and searched in parallel (0 = one index); -q searches only")
+	maxDocs := flag.Int("maxdocs", 0, "index only the first N documents from the source (0 = all)")
+	collapse := flag.Bool("collapse-similar", false, "fold near-duplicate results (SimHash) into the best ranked one, shown as \"+N similar\"")
+	similarBits := flag.Int("similar-bits", gonews.DefaultSimilarBits, "fingerprint bits near-duplicates may differ in, for -collapse-similar")
+	dupMode := flag.String("duplicates", "index", "docs whose body repeats an indexed doc's: index, skip, merge (skip but remember the ID) or flag (index but mark)")
+	indexIn := flag.String("index-in", "", "load a prebuilt index from this file instead of indexing -p/-api")
+	indexOut := flag.String("index-out", "", "save the built index to this file for later -index-in runs")
+	dbPath := flag.String("db", "", "keep the index in this Bolt file: reopened as is when it holds one, else built from -p/-api into it")
+	storePath := flag.String("store", "", "keep article bodies in this SQLite file instead of memory, read back when showing results")
+	query := flag.String("q", "", "search query")
+	mode := flag.String("mode", "keyword", "how -q finds articles: keyword (the query language), semantic (closest in meaning, by embeddings; needs -word-vectors or -embed-url) or hybrid (both rankings fused)")
+	fusion := flag.String("fusion", "rrf", "how -mode hybrid fuses the keyword and semantic rankings: rrf (reciprocal rank fusion) or weighted (blend of scaled scores)")
+	hybridWeight := flag.Float64("hybrid-weight", gonews.DefaultHybridWeight, "semantic share of a -fusion weighted score, above 0 up to 1 (the keyword score gets the rest)")
+	rrfK := flag.Float64("rrf-k", gonews.DefaultRRFK, "rank constant of -fusion rrf; higher flattens the gap between top and lower ranks")
+	hybridCandidates := flag.Int("hybrid-candidates", gonews.DefaultHybridCandidates, "best results of each ranking that -mode hybrid fuses")
+	embeddingsPath := flag.String("embeddings", "", "JSON Lines file of document embeddings ({\"id\": 1, \"embedding\": [...]}) for semantic search; docs missing from it are embedded")
+	embeddingsOut := flag.String("embeddings-out", "", "save the document embeddings, loaded and computed, to this file for later -embeddings runs")
+	wordVectors := flag.String("word-vectors", "", "embed queries and docs by averaging these pretrained word vectors (GloVe/fastText text format)")
+	embedURL := flag.String("embed-url", "", "embed queries and docs with this OpenAI-compatible embeddings API, e.g. http://localhost:11434/v1/embeddings (key from $GONEWS_EMBED_API_KEY)")
+	embedModel := flag.String("embed-model", "", "model name sent to -embed-url")
+	limit := flag.Int("n", 10, "max results to show")
+	offset := flag.Int("offset", 0, "skip this many top results, to show later pages with -n")
+	phrase := flag.Bool("phrase", false, "treat the whole query as one exact phrase")
+	from := flag.String("from", "", "only articles dated on or after this day (e.g. 2023-01-01)")
+	to := flag.String("to", "", "only articles dated on or before this day (e.g. 2023-06-30)")
+	stripHTML := flag.Bool("html", false, "content is HTML: strip tags before indexing and snippets")
+	stem := flag.Bool("stem", false, "enable stemming (optional)")
+	idfFloor := flag.Float64("idf-floor", 0, "minimum IDF per term (0 = none)")
+	idfCeil := flag.Float64("idf-ceil", 0, "maximum IDF per term, limits very rare terms (0 = none)")
+	idfSmooth := flag.Float64("idf-smooth", 0, "smoothing added to document frequency in IDF")
+	earlyBoost := flag.Float64("early-boost", 0, "extra weight for query terms near the start of an article (0 = off)")
+	lengthNorm := flag.String("length-norm", "linear", "term frequency length normalization: linear, or pivoted (gentler on long articles)")
+	pivotSlope := flag.Float64("pivot-slope", gonews.DefaultPivotSlope, "slope for -length-norm pivoted, between 0 and 1 (1 = linear)")
+	scorer := flag.String("scorer", "tfidf", "relevance scoring: tfidf, bm25, or blend (equal parts of both)")
+	flag.StringVar(scorer, "ranker", "tfidf", "same as -scorer")
+	bm25K1 := flag.Float64("bm25-k1", gonews.DefaultBM25K1, "BM25 term frequency saturation, 0 or more; higher lets repeated terms count longer")
+	bm25B := flag.Float64("bm25-b", gonews.DefaultBM25B, "BM25 length normalization, 0 to 1 (0 ignores length, higher penalizes long articles more)")
+	titleBoost := flag.Float64("title-boost", 1, "weight of title occurrences relative to content when scoring")
+	summaryBoost := flag.Float64("summary-boost", gonews.DefaultSummaryBoost, "weight of summary occurrences when scoring")
+	contentBoost := flag.Float64("content-boost", 1, "weight of body text occurrences when scoring")
+	maxResults := flag.Int("max-results", 0, "cap on results a search returns (0 = unlimited; server defaults to 100)")
+	rank := flag.String("rank", "score", "result ordering: score, or terms (most distinct query terms matched first)")
+	sortBy := flag.String("sort", "score", "sort results by score (as -rank orders them), date_desc (newest first) or date_asc")
+	serve := flag.String("serve", "", "run an HTTP server on this address (e.g. :8080) instead of a one-off query")
+	grpcAddr := flag.String("grpc", "", "serve the search and document API over gRPC on this address (e.g. :9090), alone or next to -serve")
+	maxConcurrent := flag.Int("max-concurrent", 0, "server: max searches running at once, extra ones queue then get 429 (0 = unlimited)")
+	cacheSize := flag.Int("cache-size", 0, "server: keep the results of this many recent queries, dropping the least recently used (0 = no cache)")
+	queueWait := flag.Duration("queue-wait", time.Second, "server: how long a search waits for a free slot under -max-concurrent (0 = reject at once)")
+	drain := flag.Duration("shutdown-timeout", 10*time.Second, "on SIGINT/SIGTERM, how long the server waits for in-flight requests")
+	walPath := flag.String("wal", "", "server: log document writes to this file and replay them on startup, so they survive a crash")
+	refreshEvery := flag.Duration("refresh-every", 0, "server: re-read -p/-api, -feed and -urls this often, adding new and changed documents without a rebuild (0 = only on POST /refresh)")
+	checkpointEvery := flag.Int("checkpoint-every", 1000, "server: with -wal, save the index to -index-out (or flush -db) and empty the log after this many writes (0 = only on shutdown)")
+	sentences := flag.Bool("snippet-sentences", false, "snap snippets to whole sentences")
+	fragments := flag.Int("snippets", 0, "show the N passages of each result densest in query terms instead of the text around the first match (0 = first match)")
+	autocorrect := flag.Bool("autocorrect", false, "if the query finds nothing, retry it with misspelled words corrected")
+	facets := flag.Bool("facets", false, "after the results, count all matches per month and per source")
+	format := flag.String("format", "", "Go text/template for each printed result, with .ID .Title .Date .Score .MatchedTerms .Snippet .TitleMatch, or json for a JSON array (default: classic layout)")
+	color := flag.String("color", "auto", "mark matched terms in snippets with terminal colors: auto (when printing to a terminal), always, or never")
+	highlight := flag.Int("highlight", -1, "print this doc ID in full with the query's matches marked, instead of a result list")
+	similar := flag.Int("similar", -1, "list the -n docs most like this doc ID (more like this) instead of searching")
+	digest := flag.Int("digest", 0, "group results by day, newest first, showing this many per day (0 = off)")
+	histogram := flag.String("histogram", "", "instead of results, count the matches per day, week or month as a table (or a JSON series with -format json)")
+	clusters := flag.Int("clusters", 0, "group the top 200 hits into this many topics, labelled by their top terms, showing -n per topic (0 = off)")
+	validate := flag.Bool("validate", false, "check index consistency after indexing and report problems")
+	stopwordsPath := flag.String("stopwords", "", "file of stopwords, one per line, replacing the built-in English list")
+	noStopwords := flag.Bool("no-stopwords", false, "keep stopwords instead of dropping them")
+	minTermLen := flag.Int("min-term-len", 0, "drop words shorter than this many characters (0 = keep all)")
+	symbols := flag.Bool("symbols", false, "index #hashtags, @mentions and emoji as tokens")
+	detectLang := flag.Bool("detect-lang", false, "detect each article's language and use its stopwords; only English is stemmed")
+	split := flag.Bool("split-idents", false, "split camelCase/snake_case identifiers into sub-word tokens")
+	queryLog := flag.String("query-log", "", "file to log queries to; enables past-query suggestions in server mode")
+	verbose := flag.Bool("v", false, "verbose logging, same as -log-level debug")
+	logLevel := flag.String("log-level", "info", "least severe log level shown: debug, info, warn or error")
+	logFormat := flag.String("log-format", "", "log line format: text (key=value) or json (default: json with -serve or -grpc, else text)")
+	configPath := flag.String("config", "", "YAML or TOML file of flag settings, grouped into sections; flags given on the command line override it")
+	flag.Parse()
+
+	if *configPath != "" {
+		if err := applyConfig(flag.CommandLine, *configPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	level, err := parseLogLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	if *logFormat == "" {
+		*logFormat = "text"
+		if *serve != "" || *grpcAddr != "" {
+			*logFormat = "json" // for log collectors
+		}
+	}
+	// logs go to stderr so results on stdout stay pipeable
+	logger := newLogger(os.Stderr, level, *logFormat)
+
+	source := *path
+	if *apiURL != "" {
+		source = *apiURL
+	}
+	columns := gonews.CSVColumns{ID: *colID, Title: *colTitle, Date: *colDate, Content: *colContent}
+	var loader gonews.Loader = &gonews.CSVLoader{Path: *path, Columns: columns, MaxDocs: *maxDocs}
+	if *apiURL != "" {
+		loader = &gonews.APILoader{URL: *apiURL, PageParam: *apiPageParam, MaxDocs: *maxDocs}
+	} else if gonews.IsJSONLPath(*path) {
+		fields, err := gonews.ParseJSONLFields(*jsonlFields)
+		if err != nil {
+			logger.Error("invalid -jsonl-fields", "err", err)
+			os.Exit(1)
+		}
+		loader = &gonews.JSONLLoader{Path: *path, Fields: fields, MaxDocs: *maxDocs}
+	} else if fi, err := os.Stat(*path); err == nil && fi.IsDir() {
+		loader = &gonews.DirLoader{Path: *path, MaxDocs: *maxDocs}
+	}
+	// reportLoad logs the problems loader met reading n docs
+	reportLoad := func(n int, start time.Time) {
+		var warnings []gonews.LoadWarning
+		switch l := loader.(type) {
+		case *gonews.CSVLoader:
+			warnings = l.Warnings
+		case *gonews.JSONLLoader:
+			warnings = l.Warnings
+		}
+		for _, w := range warnings {
+			logger.Warn("load", "line", w.Line, "problem", w.Msg)
+		}
+		logger.Info("loaded", "docs", n, "source", source, "duration", time.Since(start))
+	}
+	// -feed and -urls are read next to -p/-api, or alone when neither
+	// was given
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	feeds, pages := splitURLs(*feedURLs), splitURLs(*pageURLs)
+	useLoader := given["p"] || given["api"] || len(feeds)+len(pages) == 0
+	if !useLoader {
+		source = strings.Join(append(feeds, pages...), ",")
+	}
+	// extraDocs fetches the -feed items and -urls pages, numbered from
+	// next; their feed guid or URL is the SourceID a refresh knows them by
+	extraDocs := func(next int) ([]gonews.Document, error) {
+		var docs []gonews.Document
+		for _, u := range feeds {
+			items, err := (&gonews.FeedLoader{URL: u, MaxDocs: *maxDocs}).Load()
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("loaded feed", "docs", len(items), "url", u)
+			docs = append(docs, items...)
+		}
+		if len(pages) > 0 {
+			c := &crawler.Crawler{
+				MaxPages: len(pages),
+				OnPage: func(url string, err error) {
+					if err != nil {
+						logger.Warn("skipped page", "url", url, "reason", err)
+					}
+				},
+			}
+			err := c.Crawl(context.Background(), pages, func(d gonews.Document) error {
+				docs = append(docs, d)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for i := range docs {
+			docs[i].ID = next + i
+		}
+		return docs, nil
+	}
+	// loadDocs reads the whole source into memory
+	loadDocs := func() ([]gonews.Document, error) {
+		var docs []gonews.Document
+		if useLoader {
+			start := time.Now()
+			var err error
+			if docs, err = loader.Load(); err != nil {
+				return nil, err
+			}
+			reportLoad(len(docs), start)
+		}
+		next := 0
+		for _, d := range docs {
+			next = max(next, d.ID+1)
+		}
+		extra, err := extraDocs(next)
+		if err != nil {
+			return nil, err
+		}
+		return append(docs, extra...), nil
+	}
+	// indexSource adds the source's docs to into; a loader that can stream
+	// hands them over one at a time, so a huge CSV is never held in memory
+	// whole. The server's /reload calls it again.
+	indexSource := func(into *gonews.Index) error {
+		sl, ok := loader.(gonews.StreamLoader)
+		if !ok || !useLoader {
+			docs, err := loadDocs()
+			if err != nil {
+				return err
+			}
+			for _, d := range docs {
+				into.AddDocument(d)
+			}
+			return nil
+		}
+		start := time.Now()
+		n, next := 0, 0
+		err := sl.Stream(func(d gonews.Document) error {
+			into.AddDocument(d)
+			n++
+			next = max(next, d.ID+1)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		reportLoad(n, start)
+		extra, err := extraDocs(next)
+		if err != nil {
+			return err
+		}
+		for _, d := range extra {
+			into.AddDocument(d)
+		}
+		return nil
+	}
+
+	if *shards > 0 && (*indexIn != "" || *indexOut != "" || *dbPath != "" || *serve != "" || *grpcAddr != "" || *highlight >= 0 || *similar >= 0 || *digest > 0 || *clusters > 0 || *histogram != "" || *facets || *mode != "keyword") {
+		logger.Error("-shards only works for -q searches on an index built from -p/-api")
+		os.Exit(1)
+	}
+
+	if in := walStartIndex(*walPath, *indexIn, *indexOut, *dbPath); in != *indexIn {
+		if *indexIn != "" {
+			logger.Warn("-wal: loading the -index-out checkpoint instead of -index-in", "index-in", *indexIn, "index-out", *indexOut)
+		}
+		*indexIn = in
+	}
+
+	switch *mode {
+	case "keyword":
+	case "semantic", "hybrid":
+		if *wordVectors == "" && *embedURL == "" {
+			logger.Error("-mode " + *mode + " needs -word-vectors or -embed-url to embed queries")
+			os.Exit(1)
+		}
+		if *phrase || *from != "" || *to != "" || *highlight >= 0 || *similar >= 0 || *digest > 0 || *clusters > 0 || *histogram != "" || *facets {
+			logger.Error("-mode " + *mode + " doesn't combine with -phrase, -from/-to, -highlight, -similar, -digest, -clusters, -histogram or -facets")
+			os.Exit(1)
+		}
+	default:
+		logger.Error("unknown -mode value", "mode", *mode)
+		os.Exit(1)
+	}
+
+	gonews.SnippetSentences = *sentences
+	gonews.SnippetFragments = *fragments
+
+	var duplicates gonews.DuplicateMode
+	switch *dupMode {
+	case "index":
+	case "skip":
+		duplicates = gonews.DuplicatesSkip
+	case "merge":
+		duplicates = gonews.DuplicatesMerge
+	case "flag":
+		duplicates = gonews.DuplicatesFlag
+	default:
+		logger.Error("unknown -duplicates value", "duplicates", *dupMode)
+		os.Exit(1)
+	}
+
+	var store gonews.DocStore // stays a nil interface without -store
+	if *storePath != "" {
+		s, err := sqlitestore.Open(*storePath)
+		if err != nil {
+			logger.Error("failed to open document store", "path", *storePath, "err", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+		store = s
+	}
+
+	idxStart := time.Now()
+	var dbIdx *gonews.Index // the index kept in -db, nil without it
+	if *dbPath != "" {
+		db, err := boltstore.Open(*dbPath)
+		if err != nil {
+			logger.Error("failed to open index database", "path", *dbPath, "err", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		if dbIdx, err = gonews.OpenIndex(db); err != nil {
+			logger.Error("failed to open index database", "path", *dbPath, "err", err)
+			os.Exit(1)
+		}
+	}
+	var idx *gonews.Index
+	var sharded *gonews.ShardedIndex // set with -shards; idx then only holds the settings
+	var shardDocs []gonews.Document
+	if *indexIn != "" {
+		// the saved analyzer wins over -stem etc., queries must match the index
+		loaded, err := gonews.LoadIndex(*indexIn)
+		if err != nil {
+			logger.Error("failed to load index", "path", *indexIn, "err", err)
+			os.Exit(1)
+		}
+		idx = loaded
+		idx.Duplicates = duplicates
+		idx.Store = store
+		if len(idx.StoredContent) > 0 && store == nil {
+			logger.Warn("the index keeps article bodies in a document store; pass -store to show them", "path", *indexIn)
+		}
+	} else if dbIdx != nil && dbIdx.N > 0 {
+		// like -index-in, the analyzer the index was built with wins
+		idx = dbIdx
+		idx.Duplicates = duplicates
+		idx.Store = store
+		if len(idx.StoredContent) > 0 && store == nil {
+			logger.Warn("the index keeps article bodies in a document store; pass -store to show them", "path", *dbPath)
+		}
+	} else {
+		var stopwords map[string]bool
+		if *stopwordsPath != "" {
+			var err error
+			stopwords, err = gonews.LoadStopwords(*stopwordsPath)
+			if err != nil {
+				logger.Error("failed to load stopwords", "path", *stopwordsPath, "err", err)
+				os.Exit(1)
+			}
+		}
+		idx = gonews.NewIndex()
+		if dbIdx != nil {
+			idx = dbIdx
+		}
+		// analyzer options apply to indexing and queries alike
+		idx.Analyzer = gonews.Analyzer{
+			Stemming:         *stem,
+			SplitIdentifiers: *split,
+			IndexSymbols:     *symbols,
+			MinTermLen:       *minTermLen,
+			StripHTML:        *stripHTML,
+			DetectLanguages:  *detectLang,
+			Stopwords:        stopwords,
+			NoStopwords:      *noStopwords,
+		}
+		idx.Duplicates = duplicates
+		idx.Store = store
+		var err error
+		if *shards > 0 {
+			// indexed into the shards once the settings below are in place
+			shardDocs, err = loadDocs()
+		} else {
+			err = indexSource(idx)
+		}
+		if err != nil {
+			logger.Error("failed to load dataset", "source", source, "err", err)
+			os.Exit(1)
+		}
+		if err := idx.Flush(); err != nil {
+			logger.Error("failed to flush index", "err", err)
+			os.Exit(1)
+		}
+	}
+	idx.IDFFloor = *idfFloor
+	idx.IDFCeiling = *idfCeil
+	idx.IDFSmoothing = *idfSmooth
+	idx.EarlyMentionBoost = *earlyBoost
+	idx.FieldBoosts = map[string]float64{"title": *titleBoost, "summary": *summaryBoost, "content": *contentBoost}
+	idx.MaxResults = *maxResults
+	idx.CacheSize = *cacheSize
+	idx.CollapseSimilar = *collapse
+	idx.SimilarBits = *similarBits
+	switch *lengthNorm {
+	case "linear":
+		idx.LengthNorm = gonews.LengthNormLinear
+	case "pivoted":
+		idx.LengthNorm = gonews.LengthNormPivoted
+		idx.PivotSlope = *pivotSlope
+	default:
+		logger.Error("unknown -length-norm value", "length-norm", *lengthNorm)
+		os.Exit(1)
+	}
+	if !(*bm25K1 >= 0) {
+		logger.Error("-bm25-k1 must be 0 or more", "bm25-k1", *bm25K1)
+		os.Exit(1)
+	}
+	if !(*bm25B >= 0 && *bm25B <= 1) {
+		logger.Error("-bm25-b must be between 0 and 1", "bm25-b", *bm25B)
+		os.Exit(1)
+	}
+	idx.BM25K1 = *bm25K1
+	idx.BM25B = *bm25B
+	switch *fusion {
+	case "rrf":
+		idx.Fusion = gonews.FusionRRF
+	case "weighted":
+		idx.Fusion = gonews.FusionWeighted
+	default:
+		logger.Error("unknown -fusion value", "fusion", *fusion)
+		os.Exit(1)
+	}
+	idx.HybridWeight = *hybridWeight
+	idx.RRFK = *rrfK
+	idx.HybridCandidates = *hybridCandidates
+	scoreFn, err := scorerNamed(*scorer)
+	if err != nil {
+		logger.Error("unknown -scorer value", "scorer", *scorer)
+		os.Exit(1)
+	}
+	idx.Scorer = scoreFn
+	switch *rank {
+	case "score":
+		idx.Rank = gonews.RankByScore
+	case "terms":
+		idx.Rank = gonews.RankByMatchedTerms
+	default:
+		logger.Error("unknown -rank value", "rank", *rank)
+		os.Exit(1)
+	}
+	switch *sortBy {
+	case "score":
+	case "date_desc":
+		idx.Sort = gonews.SortByDateDesc
+	case "date_asc":
+		idx.Sort = gonews.SortByDateAsc
+	default:
+		logger.Error("unknown -sort value", "sort", *sortBy)
+		os.Exit(1)
+	}
+	if *shards > 0 {
+		sharded = gonews.NewShardedIndex(*shards, idx)
+		sharded.AddDocuments(shardDocs)
+		logger.Info("indexed", "docs", sharded.DocCount(), "shards", len(sharded.Shards), "duration", time.Since(idxStart))
+	} else {
+		logger.Info("indexed", "docs", idx.N, "terms", len(idx.Terms), "duration", time.Since(idxStart))
+	}
+	if n := idx.DuplicatesDropped(); n > 0 {
+		logger.Info("dropped duplicate documents", "count", n)
+	}
+
+	switch {
+	case *wordVectors != "" && *embedURL != "":
+		logger.Error("give only one of -word-vectors and -embed-url")
+		os.Exit(1)
+	case *wordVectors != "":
+		wv, err := gonews.LoadWordVectors(*wordVectors)
+		if err != nil {
+			logger.Error("failed to load word vectors", "path", *wordVectors, "err", err)
+			os.Exit(1)
+		}
+		idx.Embedder = wv
+	case *embedURL != "":
+		idx.Embedder = &gonews.HTTPEmbedder{URL: *embedURL, Model: *embedModel, APIKey: os.Getenv("GONEWS_EMBED_API_KEY")}
+	}
+	if *embeddingsPath != "" {
+		vecs, err := gonews.LoadVectors(*embeddingsPath)
+		if err != nil {
+			logger.Error("failed to load embeddings", "path", *embeddingsPath, "err", err)
+			os.Exit(1)
+		}
+		idx.Vectors = vecs
+	}
+	// embedVectors embeds into's docs the embeddings don't cover yet or
+	// were computed from an older text
+	embedVectors := func(into *gonews.Index) error {
+		if into.Embedder == nil {
+			return nil
+		}
+		start := time.Now()
+		n, err := into.EmbedDocuments(context.Background())
+		if n > 0 {
+			logger.Info("embedded", "docs", n, "with_embeddings", into.Vectors.Len(), "duration", time.Since(start))
+		}
+		return err
+	}
+	if err := embedVectors(idx); err != nil {
+		logger.Error("failed to embed documents", "err", err)
+		os.Exit(1)
+	}
+	if *embeddingsOut != "" && idx.Vectors != nil {
+		if err := idx.Vectors.Save(*embeddingsOut); err != nil {
+			logger.Error("failed to save embeddings", "path", *embeddingsOut, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("saved embeddings", "path", *embeddingsOut)
+	}
+
+	if *indexOut != "" {
+		if err := idx.Save(*indexOut); err != nil {
+			logger.Error("failed to save index", "path", *indexOut, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("saved index", "path", *indexOut)
+	}
+
+	if *validate {
+		errs := idx.Validate()
+		for _, e := range errs {
+			logger.Warn("validate", "problem", e)
+		}
+		logger.Info("validation finished", "problems", len(errs))
+	}
+
+	if *serve != "" || *grpcAddr != "" {
+		srv := gonews.NewServer(idx)
+		srv.Logger = logger
+		srv.Reload = func() (*gonews.Index, error) {
+			fresh := idx.EmptyCopy()
+			if err := indexSource(fresh); err != nil {
+				return nil, err
+			}
+			if err := fresh.Flush(); err != nil {
+				return nil, err
+			}
+			// unchanged docs keep their embeddings, new and changed ones
+			// get theirs
+			fresh.CopyVectors(srv.Index())
+			if err := embedVectors(fresh); err != nil {
+				return nil, err
+			}
+			logger.Info("reloaded", "docs", fresh.N, "terms", len(fresh.Terms))
+			return fresh, nil
+		}
+		srv.Source = loadDocs
+		if *walPath != "" {
+			wal, err := gonews.OpenWAL(*walPath)
+			if err != nil {
+				logger.Error("failed to open write-ahead log", "path", *walPath, "err", err)
+				os.Exit(1)
+			}
+			defer wal.Close()
+			n, err := wal.Replay(idx)
+			if err != nil {
+				logger.Error("failed to replay write-ahead log", "path", *walPath, "err", err)
+				os.Exit(1)
+			}
+			if n > 0 {
+				logger.Info("replayed write-ahead log", "writes", n, "docs", idx.DocCount())
+				if err := embedVectors(idx); err != nil {
+					logger.Error("failed to embed documents", "err", err)
+					os.Exit(1)
+				}
+			}
+			srv.Log = wal
+			srv.CheckpointEvery = *checkpointEvery
+			// the snapshot is whatever the next start loads
+			switch {
+			case *dbPath != "":
+				srv.Snapshot = (*gonews.Index).Flush
+			case *indexOut != "":
+				srv.Snapshot = func(idx *gonews.Index) error { return idx.Save(*indexOut) }
+			default:
+				logger.Warn("-wal without -index-out or -db: the log is replayed on the rebuilt index and never emptied")
+			}
+		}
+		srv.MaxConcurrentSearches = *maxConcurrent
+		srv.SearchQueueWait = *queueWait
+		if *queryLog != "" {
+			qs, err := gonews.OpenQueryLog(*queryLog)
+			if err != nil {
+				logger.Error("failed to open query log", "path", *queryLog, "err", err)
+				os.Exit(1)
+			}
+			defer qs.Close()
+			srv.Queries = qs
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if *refreshEvery > 0 {
+			go srv.RefreshEvery(ctx, *refreshEvery, func(res gonews.RefreshResult, err error) {
+				if err != nil {
+					logger.Error("refresh failed", "source", source, "err", err)
+					return
+				}
+				logger.Info("refreshed", "added", res.Added, "updated", res.Updated, "docs", res.N)
+			})
+		}
+		stopGRPC := func(time.Duration) {}
+		if *grpcAddr != "" {
+			s, err := startGRPC(logger, srv, *grpcAddr)
+			if err != nil {
+				logger.Error("failed to start gRPC server", "addr", *grpcAddr, "err", err)
+				os.Exit(1)
+			}
+			stopGRPC = s
+			logger.Info("serving gRPC", "addr", *grpcAddr)
+		}
+		if *serve != "" {
+			logger.Info("serving", "addr", *serve)
+			if err := srv.ListenAndServe(ctx, *serve, *drain); err != nil {
+				logger.Error("server stopped", "err", err)
+				os.Exit(1)
+			}
+		} else {
+			<-ctx.Done()
+		}
+		stopGRPC(*drain)
+		if err := srv.Checkpoint(); err != nil {
+			logger.Error("failed to checkpoint", "err", err)
+		}
+		if err := srv.Index().Flush(); err != nil {
+			logger.Error("failed to flush index", "err", err)
+		}
+		logger.Info("server shut down")
+		return
+	}
+
+	if *query == "" && *from == "" && *to == "" && *similar < 0 {
+		logger.Warn("no query provided, use -q \"your query\"")
+		return
+	}
+
+	if *phrase {
+		*query = gonews.PhraseQuery(*query)
+	}
+	if *from != "" || *to != "" {
+		dates := gonews.DateRangeQuery(*from, *to)
+		if err := gonews.ValidateQuery(dates); err != nil {
+			logger.Error("invalid -from/-to", "err", err)
+			os.Exit(1)
+		}
+		if *query == "" {
+			*query = dates
+		} else {
+			*query = "(" + *query + ") AND " + dates
+		}
+	}
+
+	if *highlight >= 0 {
+		text, err := idx.Highlight(*query, *highlight)
+		if err != nil {
+			logger.Error("cannot highlight", "id", *highlight, "err", err)
+			os.Exit(1)
+		}
+		fmt.Println(text)
+		return
+	}
+
+	if *digest > 0 {
+		for _, c := range idx.SearchDigest(*query, *digest) {
+			day := "undated"
+			if !c.Date.IsZero() {
+				day = c.Date.Format("2006-01-02")
+			}
+			fmt.Printf("== %s ==\n", day)
+			for _, r := range c.Results {
+				d, _ := idx.Doc(r.DocID)
+				fmt.Printf("  %s (score: %.4f)\n", d.Title, r.Score)
+			}
+		}
+		return
+	}
+
+	if *histogram != "" {
+		if err := printHistogram(idx, *query, *histogram, *format == gonews.JSONResultFormat); err != nil {
+			logger.Error("cannot count matches over time", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *clusters > 0 {
+		topics, err := idx.SearchClusters(*query, *clusters)
+		if err != nil {
+			logger.Error("cannot cluster results", "err", err)
+			os.Exit(1)
+		}
+		for _, c := range topics {
+			fmt.Printf("== %s (%d) ==\n", strings.Join(c.Label, ", "), len(c.Results))
+			for i, r := range c.Results {
+				if i >= *limit {
+					break
+				}
+				d, _ := idx.Doc(r.DocID)
+				fmt.Printf("  %s (score: %.4f)\n", d.Title, r.Score)
+			}
+		}
+		return
+	}
+
+	formatter, err := gonews.NewResultFormatter(*format)
+	if err != nil {
+		logger.Error("invalid -format template", "err", err)
+		os.Exit(1)
+	}
+	formatter.Analyzer = idx.Analyzer
+	switch *color {
+	case "auto":
+		fi, err := os.Stdout.Stat()
+		formatter.Color = err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == ""
+	case "always":
+		formatter.Color = true
+	case "never":
+	default:
+		logger.Error("unknown -color value", "color", *color)
+		os.Exit(1)
+	}
+
+	// writeResults prints results with formatter
+	writeResults := func(results []gonews.SearchResult) {
+		for _, r := range results {
+			d, _ := idx.Doc(r.DocID)
+			if err := formatter.Write(os.Stdout, d, r); err != nil {
+				logger.Error("failed to format result", "err", err)
+				os.Exit(1)
+			}
+		}
+		if err := formatter.Flush(os.Stdout); err != nil {
+			logger.Error("failed to format result", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	if *similar >= 0 {
+		results, err := idx.Similar(*similar, *limit)
+		if err != nil {
+			logger.Error("cannot find similar documents", "id", *similar, "err", err)
+			os.Exit(1)
+		}
+		writeResults(results)
+		return
+	}
+
+	if *mode != "keyword" {
+		search := idx.SemanticSearch
+		if *mode == "hybrid" {
+			search = idx.HybridSearch
+		}
+		searchStart := time.Now()
+		results, err := search(context.Background(), *query, *offset+*limit)
+		if err != nil {
+			logger.Error(*mode+" search failed", "query", *query, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("search completed", "query", *query, "mode", *mode, "duration", time.Since(searchStart))
+		writeResults(results[min(*offset, len(results)):])
+		return
+	}
+
+	logger.Debug("parsed query", "query", *query, "rpn", idx.Analyzer.QueryToRPN(*query))
+	searchStart := time.Now()
+	search := func(q string) (gonews.FacetedSearch, error) {
+		if *facets {
+			return idx.SearchFacets(context.Background(), q, gonews.SearchOptions{Offset: *offset, K: *limit})
+		}
+		if sharded != nil {
+			results, total, err := sharded.SearchPage(q, *offset, *limit)
+			return gonews.FacetedSearch{Results: results, Total: total}, err
+		}
+		results, total, err := idx.SearchPage(q, *offset, *limit)
+		return gonews.FacetedSearch{Results: results, Total: total}, err
+	}
+	page, err := search(*query)
+	if err != nil {
+		msg := "search failed"
+		if errors.Is(err, gonews.ErrMalformedQuery) {
+			msg = "malformed query"
+		}
+		logger.Error(msg, "query", *query, "err", err)
+		os.Exit(1)
+	}
+	correction := ""
+	if sharded == nil && page.Total < gonews.DefaultCorrectionThreshold {
+		if fixed, ok := idx.CorrectQuery(*query); ok {
+			correction = "Did you mean: " + fixed + "?"
+			if *autocorrect {
+				if fp, err := search(fixed); err == nil && fp.Total > page.Total {
+					logger.Info("showing results for corrected query", "query", fixed)
+					page = fp
+					correction = "Showing results for: " + fixed
+				}
+			}
+		}
+	}
+	results := page.Results
+	logger.Info("search completed", "query", *query, "results", page.Total, "duration", time.Since(searchStart))
+	if correction != "" && *format != gonews.JSONResultFormat {
+		fmt.Printf("%s\n\n", correction)
+	}
+
+	// show top results
+	doc := idx.Doc
+	if sharded != nil {
+		doc = sharded.Doc
+	}
+	count := 0
+	for _, r := range results {
+		if count >= *limit {
+			break
+		}
+		d, _ := doc(r.DocID)
+		if err := formatter.Write(os.Stdout, d, r); err != nil {
+			logger.Error("failed to format result", "err", err)
+			os.Exit(1)
+		}
+		count++
+	}
+	if err := formatter.Flush(os.Stdout); err != nil {
+		logger.Error("failed to format result", "err", err)
+		os.Exit(1)
+	}
+	if *facets && *format != gonews.JSONResultFormat {
+		printFacets("By month", page.Facets.Months)
+		printFacets("By source", page.Facets.Sources)
+	}
+}
+
+// walStartIndex returns the index file a server starts from. With -wal
+// the log holds the writes since the last checkpoint to -index-out, so an
+// existing checkpoint is what they must replay onto; otherwise -index-in.
+// A -db index is its own checkpoint.
+func walStartIndex(walPath, indexIn, indexOut, dbPath string) string {
+	if walPath == "" || indexOut == "" || dbPath != "" {
+		return indexIn
+	}
+	if _, err := os.Stat(indexOut); err != nil {
+		return indexIn
+	}
+	return indexOut
+}
+
+// printFacets prints one facet's counts on a line, e.g. "By month: 2017-01 (12), ..."
+func printFacets(label string, counts []gonews.FacetCount) {
+	if len(counts) == 0 {
+		return
+	}
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s (%d)", c.Value, c.Count)
+	}
+	fmt.Printf("%s: %s\n", label, strings.Join(parts, ", "))
+}
+
+// printHistogram prints how many docs match query per interval (day,
+// week or month): a table with a bar per bucket, or a JSON series
+func printHistogram(idx *gonews.Index, query, interval string, asJSON bool) error {
+	iv, layout := gonews.TrendDay, "2006-01-02"
+	switch interval {
+	case "day":
+	case "week":
+		iv = gonews.TrendWeek
+	case "month":
+		iv, layout = gonews.TrendMonth, "2006-01"
+	default:
+		return fmt.Errorf("unknown -histogram %q (want day, week or month)", interval)
+	}
+	h, err := idx.SearchHistogram(context.Background(), query, iv)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Query    string `json:"query"`
+			Interval string `json:"interval"`
+			gonews.Histogram
+		}{query, interval, h})
+	}
+	most := 0
+	for _, b := range h.Buckets {
+		most = max(most, b.Docs)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tdocs\t\n", interval)
+	if h.Earlier > 0 {
+		fmt.Fprintf(tw, "earlier\t%d\t\n", h.Earlier)
+	}
+	for _, b := range h.Buckets {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", b.Start.Format(layout), b.Docs, strings.Repeat("#", (b.Docs*40+most-1)/most))
+	}
+	if h.Undated > 0 {
+		fmt.Fprintf(tw, "undated\t%d\t\n", h.Undated)
+	}
+	return tw.Flush()
+}
+
+// scorerNamed returns the scorer for a -scorer value; nil is TF-IDF
+func scorerNamed(name string) (gonews.ScoreFunc, error) {
+	switch name {
+	case "tfidf":
+		return nil, nil
+	case "bm25":
+		return gonews.BM25Score, nil
+	case "blend":
+		return gonews.CompositeScorer{
+			{Score: gonews.TFIDFScore, Weight: 0.5},
+			{Score: gonews.BM25Score, Weight: 0.5},
+		}.Score, nil
+	}
+	return nil, fmt.Errorf("unknown scorer %q (want tfidf, bm25 or blend)", name)
+}
+
+// corpusLoader picks the loader for a -p path: a directory of text files,
+// JSON lines by extension, else CSV
+func corpusLoader(path string, maxDocs int) gonews.Loader {
+	if gonews.IsJSONLPath(path) {
+		return &gonews.JSONLLoader{Path: path, MaxDocs: maxDocs}
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return &gonews.DirLoader{Path: path, MaxDocs: maxDocs}
+	}
+	return &gonews.CSVLoader{Path: path, MaxDocs: maxDocs}
+}
+
+// splitURLs splits a comma-separated -feed or -urls value
+func splitURLs(list string) []string {
+	var urls []string
+	for _, u := range strings.Split(list, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs main itself when re-executed by runMain, so tests can
+// check the CLI's exit status
+func TestMain(m *testing.M) {
+	if os.Getenv("GONEWS_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the gonews CLI with args in a child process and returns
+// its stderr and exit code
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GONEWS_RUN_MAIN=1")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stderr.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stderr.String(), 0
+}
+
+func TestSearchExitStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "news.csv")
+	csv := "id,title,date,content\n" +
+		"1,Ukraine talks,2024-01-02,Ukraine and its neighbours met\n" +
+		"2,Market report,2024-01-03,Stocks rose\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query    string
+		wantCode int
+		wantErr  string
+	}{
+		{"ukraine", 0, ""},
+		{"nothing matches this", 0, ""},
+		{"ukrane~x", 1, "malformed query"},
+		{"_exists_:publisher", 1, "malformed query"},
+		{"(ukraine", 1, "malformed query"},
+	}
+	for _, tt := range tests {
+		for _, extra := range [][]string{nil, {"-facets"}} {
+			t.Run(strings.Join(append([]string{tt.query}, extra...), " "), func(t *testing.T) {
+				stderr, code := runMain(t, append([]string{"-p", path, "-q", tt.query}, extra...)...)
+				if code != tt.wantCode {
+					t.Fatalf("exit status %d, want %d; stderr:\n%s", code, tt.wantCode, stderr)
+				}
+				if tt.wantErr != "" && !strings.Contains(stderr, tt.wantErr) {
+					t.Errorf("stderr does not mention %q:\n%s", tt.wantErr, stderr)
+				}
+			})
+		}
+	}
+}
+
+func TestDateFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "news.csv")
+	csv := "id,title,date,content\n1,Ukraine talks,2024-01-02,Ukraine and its neighbours met\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args     []string
+		wantCode int
+	}{
+		{[]string{"-from", "2024-01-02", "-to", "2024-01-02"}, 0},
+		{[]string{"-q", "ukraine", "-to", "2024-01-02T23:00:00-05:00"}, 0},
+		{[]string{"-from", "2024-01-32"}, 1},
+		{[]string{"-to", "last week"}, 1},
+		{[]string{"-from", "2024-01-03", "-to", "2024-01-02"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			stderr, code := runMain(t, append([]string{"-p", path}, tt.args...)...)
+			if code != tt.wantCode {
+				t.Fatalf("exit status %d, want %d; stderr:\n%s", code, tt.wantCode, stderr)
+			}
+			if code != 0 && !strings.Contains(stderr, "invalid -from/-to") {
+				t.Errorf("stderr does not mention invalid -from/-to:\n%s", stderr)
+			}
+		})
+	}
+}
+
+func TestWALStartIndex(t *testing.T) {
+	dir := t.TempDir()
+	checkpoint := filepath.Join(dir, "index.gob")
+	if err := os.WriteFile(checkpoint, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.gob")
+	tests := []struct {
+		name                             string
+		wal, indexIn, indexOut, db, want string
+	}{
+		{"checkpoint wins over -index-in", "w.wal", "old.gob", checkpoint, "", checkpoint},
+		{"checkpoint without -index-in", "w.wal", "", checkpoint, "", checkpoint},
+		{"no checkpoint yet", "w.wal", "old.gob", missing, "", "old.gob"},
+		{"no -wal", "", "old.gob", checkpoint, "", "old.gob"},
+		{"-db is its own checkpoint", "w.wal", "", checkpoint, "index.db", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walStartIndex(tt.wal, tt.indexIn, tt.indexOut, tt.db); got != tt.want {
+				t.Errorf("walStartIndex = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
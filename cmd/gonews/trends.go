@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gonews/pkg/gonews"
+)
+
+// runTrends implements "gonews trends": index a corpus (or load a saved
+// index) and report the terms whose use spiked in some day, week or month
+func runTrends(args []string) error {
+	fs := flag.NewFlagSet("trends", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: gonews trends [flags]\n\nReports the terms used in unusually many articles of one day, week or month.")
+		fs.PrintDefaults()
+	}
+	path := fs.String("p", "data/news.csv", "corpus: CSV file, .jsonl/.ndjson file or directory of .txt/.md files")
+	maxDocs := fs.Int("maxdocs", 0, "index only the first N documents (0 = all)")
+	indexIn := fs.String("index-in", "", "use an index saved with -index-out instead of indexing -p")
+	interval := fs.String("interval", "week", "time bucket to compare: day, week or month")
+	limit := fs.Int("n", 20, "terms to report (0 = all)")
+	minDocs := fs.Int("min-docs", gonews.DefaultTrendMinDocs, "least articles of a bucket that must use a term for its spike to count")
+	from := fs.String("from", "", "only report buckets starting on or after this day (e.g. 2023-01-01)")
+	to := fs.String("to", "", "only report buckets starting on or before this day")
+	format := fs.String("format", "text", "report format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts := gonews.TrendOptions{K: *limit, MinDocs: *minDocs}
+	switch *interval {
+	case "day":
+		opts.Interval = gonews.TrendDay
+	case "week":
+		opts.Interval = gonews.TrendWeek
+	case "month":
+		opts.Interval = gonews.TrendMonth
+	default:
+		return fmt.Errorf("unknown -interval %q (want day, week or month)", *interval)
+	}
+	for _, f := range []struct {
+		value string
+		into  *time.Time
+	}{{*from, &opts.From}, {*to, &opts.To}} {
+		if f.value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", f.value)
+		if err != nil {
+			return fmt.Errorf("invalid date %q (want YYYY-MM-DD)", f.value)
+		}
+		*f.into = t
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown -format %q (want text or json)", *format)
+	}
+
+	var idx *gonews.Index
+	if *indexIn != "" {
+		loaded, err := gonews.LoadIndex(*indexIn)
+		if err != nil {
+			return err
+		}
+		idx = loaded
+	} else {
+		docs, err := corpusLoader(*path, *maxDocs).Load()
+		if err != nil {
+			return fmt.Errorf("load %s: %w", *path, err)
+		}
+		idx = gonews.NewIndex()
+		for _, d := range docs {
+			idx.AddDocument(d)
+		}
+	}
+	trends := idx.Trends(opts)
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if trends == nil {
+			trends = []gonews.Trend{}
+		}
+		return enc.Encode(trends)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tterm\tdocs\texpected\tscore\n", *interval)
+	for _, t := range trends {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%.2f\n", t.Bucket.Format("2006-01-02"), t.Term, t.Docs, t.Expected, t.Score)
+	}
+	return tw.Flush()
+}
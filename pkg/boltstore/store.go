@@ -0,0 +1,85 @@
+// Package boltstore keeps a GoNews index in a Bolt file, as a
+// gonews.KVStore: an index from gonews.OpenIndex on it reads postings from
+// disk as searches need them, so it can outgrow memory and reopens without
+// re-indexing. It lives outside package gonews so only programs using it
+// depend on Bolt.
+package boltstore
+
+import (
+	"fmt"
+	"time"
+
+	"gonews/pkg/gonews"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store is a gonews.KVStore backed by one Bolt file
+type Store struct {
+	db *bolt.DB
+}
+
+var _ gonews.KVStore = (*Store)(nil)
+
+// Open opens the database at path, creating it if needed. Bolt allows one
+// process per file; Open fails after a second if another holds it.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open db %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Get returns a copy of the value of key in bucket, nil if absent
+func (s *Store) Get(bucket, key string) ([]byte, error) {
+	var v []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			if data := b.Get([]byte(key)); data != nil {
+				v = append([]byte(nil), data...)
+			}
+		}
+		return nil
+	})
+	return v, err
+}
+
+// Each calls fn with every key in bucket, in key order
+func (s *Store) Each(bucket string, fn func(key string, value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(string(k), v)
+		})
+	})
+}
+
+// Write applies batch in one transaction
+func (s *Store) Write(batch []gonews.KVWrite) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, w := range batch {
+			b, err := tx.CreateBucketIfNotExists([]byte(w.Bucket))
+			if err != nil {
+				return err
+			}
+			if w.Value == nil {
+				err = b.Delete([]byte(w.Key))
+			} else {
+				err = b.Put([]byte(w.Key), w.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
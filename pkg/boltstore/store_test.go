@@ -0,0 +1,101 @@
+package boltstore
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gonews/pkg/gonews"
+)
+
+func TestStore(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if v, err := s.Get("terms", "budget"); v != nil || err != nil {
+		t.Errorf("Get from a missing bucket = %q, %v; want nil, nil", v, err)
+	}
+	err = s.Write([]gonews.KVWrite{
+		{Bucket: "terms", Key: "vote", Value: []byte("2")},
+		{Bucket: "terms", Key: "budget", Value: []byte("1")},
+		{Bucket: "terms", Key: "storm", Value: []byte("3")},
+		{Bucket: "docs", Key: "1", Value: []byte("{}")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write([]gonews.KVWrite{{Bucket: "terms", Key: "storm"}}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get("terms", "budget"); string(v) != "1" || err != nil {
+		t.Errorf("Get(budget) = %q, %v; want 1", v, err)
+	}
+	if v, _ := s.Get("terms", "storm"); v != nil {
+		t.Errorf("Get(storm) = %q after deleting it", v)
+	}
+
+	var keys []string
+	err = s.Each("terms", func(key string, value []byte) error {
+		keys = append(keys, key+"="+string(value))
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(keys, []string{"budget=1", "vote=2"}) {
+		t.Errorf("Each = %v, %v; want budget=1 vote=2 in key order", keys, err)
+	}
+	stop := errors.New("stop")
+	if err := s.Each("terms", func(string, []byte) error { return stop }); err != stop {
+		t.Errorf("Each error %v, want fn's %v", err, stop)
+	}
+}
+
+func TestIndexReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	docs := []gonews.Document{
+		{ID: 1, Title: "Budget vote", Content: "the budget vote passed"},
+		{ID: 2, Title: "Storm warning", Content: "a storm is coming"},
+		{ID: 3, Title: "Budget storm", Content: "the budget caused a storm"},
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := gonews.OpenIndex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Analyzer = gonews.Analyzer{Stemming: true}
+	for _, d := range docs {
+		idx.AddDocument(d)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := idx.Search("budgets OR storms")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	reopened, err := gonews.OpenIndex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Analyzer.Stemming {
+		t.Error("reopened without the analyzer it was built with")
+	}
+	if reopened.DocCount() != len(docs) {
+		t.Errorf("reopened %d docs, want %d", reopened.DocCount(), len(docs))
+	}
+	if got := reopened.Search("budgets OR storms"); len(got) != len(docs) || !reflect.DeepEqual(got, want) {
+		t.Errorf("Search after reopening = %v, want %v", got, want)
+	}
+}
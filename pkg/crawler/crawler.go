@@ -0,0 +1,321 @@
+// Package crawler fetches news pages from the web for GoNews: starting from
+// seed URLs it follows links breadth-first, obeying robots.txt and waiting
+// between requests to the same host, and turns each page into a
+// gonews.Document. It lives outside package gonews so only programs using
+// it depend on the HTML parser.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gonews/pkg/gonews"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// DefaultMaxPages is the MaxPages used when it is 0
+	DefaultMaxPages = 100
+	// DefaultDelay is the Delay used when it is 0
+	DefaultDelay = time.Second
+	// DefaultUserAgent is the UserAgent used when it is empty
+	DefaultUserAgent = "GoNewsBot/1.0"
+
+	maxPageBytes   = 10 << 20 // larger pages are cut off
+	maxRobotsBytes = 512 << 10
+)
+
+var (
+	// ErrDisallowed is reported for pages robots.txt forbids fetching
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+	// ErrNotHTML is reported for pages that aren't HTML
+	ErrNotHTML = errors.New("not an HTML page")
+	// ErrNoIndex is reported for pages whose robots <meta> says noindex
+	ErrNoIndex = errors.New("page asks not to be indexed")
+	// ErrNoText is reported for pages without any article text
+	ErrNoText = errors.New("no article text")
+	// ErrRedirected is reported for pages that redirect; the crawl queues
+	// the redirect's target like a link
+	ErrRedirected = errors.New("redirected")
+)
+
+// maxRobotsRedirects is how many redirects a robots.txt request follows,
+// the five RFC 9309 asks for
+const maxRobotsRedirects = 5
+
+// Crawler fetches pages politely: one request at a time, at least Delay
+// apart per host (or the host's robots.txt Crawl-delay, if longer), and
+// none that the host's robots.txt disallows for UserAgent. The zero value
+// is ready to use; a Crawler must not run two crawls at once.
+type Crawler struct {
+	// MaxPages caps the pages requested, robots.txt files aside (0 means
+	// DefaultMaxPages)
+	MaxPages int
+
+	// MaxDepth is how many links away from a seed the crawl goes (0 = the
+	// seeds only)
+	MaxDepth int
+
+	// Delay is the least time between two requests to a host (0 means
+	// DefaultDelay)
+	Delay time.Duration
+
+	// UserAgent names the crawler to servers and robots.txt (empty means
+	// DefaultUserAgent)
+	UserAgent string
+
+	// AnyHost follows links to other hosts than the seeds'
+	AnyHost bool
+
+	// Client makes the requests (nil means one with a 30 second timeout)
+	Client *http.Client
+
+	// OnPage, when set, is called for each page requested or skipped, with
+	// why it yielded no document (nil when it did)
+	OnPage func(url string, err error)
+
+	robots map[string]robotsRules // by scheme://host
+	next   map[string]time.Time   // by host: when it may be requested again
+}
+
+// target is a URL waiting to be fetched
+type target struct {
+	u     *url.URL
+	depth int // links followed from a seed
+}
+
+// Crawl fetches seeds and the pages they link to, breadth first, and calls
+// fn with each page that has article text, numbering the documents from 0.
+// The page's URL is kept in SourceID and its site name (else its host) in
+// Source. Pages that fail to fetch are skipped; Crawl stops early when ctx
+// is done or fn fails, returning that error.
+func (c *Crawler) Crawl(ctx context.Context, seeds []string, fn func(gonews.Document) error) error {
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	c.robots = make(map[string]robotsRules)
+	c.next = make(map[string]time.Time)
+	seen := make(map[string]bool)
+	hosts := make(map[string]bool)
+	var queue []target
+	for _, s := range seeds {
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("seed %q is not an http(s) URL", s)
+		}
+		u.Fragment, u.RawFragment = "", ""
+		hosts[u.Host] = true
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			queue = append(queue, target{u, 0})
+		}
+	}
+
+	id := 0
+	for fetched := 0; len(queue) > 0 && fetched < maxPages; {
+		t := queue[0]
+		queue = queue[1:]
+		rules, err := c.robotsFor(ctx, t.u)
+		if err != nil {
+			return err
+		}
+		if !rules.allowed(t.u.RequestURI()) {
+			c.report(t.u, ErrDisallowed)
+			continue
+		}
+		fetched++
+		p, redirect, err := c.fetch(ctx, t.u, rules.delay)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			c.report(t.u, err)
+			continue
+		}
+		if redirect != nil {
+			// queued like a link, not followed, so robots.txt, the host
+			// delay and the host filter apply to it too
+			c.report(t.u, fmt.Errorf("%w to %s", ErrRedirected, redirect))
+			if (c.AnyHost || hosts[redirect.Host]) && !seen[redirect.String()] {
+				seen[redirect.String()] = true
+				queue = append(queue, target{redirect, t.depth})
+			}
+			continue
+		}
+		if !p.nofollow && t.depth < c.MaxDepth {
+			for _, l := range p.links {
+				if (c.AnyHost || hosts[l.Host]) && !seen[l.String()] {
+					seen[l.String()] = true
+					queue = append(queue, target{l, t.depth + 1})
+				}
+			}
+		}
+		switch {
+		case p.noindex:
+			c.report(t.u, ErrNoIndex)
+			continue
+		case p.text == "":
+			c.report(t.u, ErrNoText)
+			continue
+		}
+		d := gonews.Document{
+			ID:       id,
+			Title:    p.title,
+			Date:     p.date,
+			Content:  p.text,
+			Summary:  p.summary,
+			Author:   p.author,
+			Source:   firstNonEmpty(p.site, t.u.Host),
+			Lang:     p.lang,
+			SourceID: t.u.String(),
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+		id++
+		c.report(t.u, nil)
+	}
+	return nil
+}
+
+func (c *Crawler) report(u *url.URL, err error) {
+	if c.OnPage != nil {
+		c.OnPage(u.String(), err)
+	}
+}
+
+// client returns Client, or the default, set not to follow redirects:
+// Crawl queues their targets itself
+func (c *Crawler) client() *http.Client {
+	cl := http.Client{Timeout: 30 * time.Second}
+	if c.Client != nil {
+		cl = *c.Client
+	}
+	cl.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &cl
+}
+
+func (c *Crawler) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// get requests u once its host may be requested again, and sets when the
+// host may be next: delay from now, or Delay if that is longer
+func (c *Crawler) get(ctx context.Context, u *url.URL, delay time.Duration) (*http.Response, error) {
+	if wait := time.Until(c.next[u.Host]); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+	gap := c.Delay
+	if gap <= 0 {
+		gap = DefaultDelay
+	}
+	defer func() { c.next[u.Host] = time.Now().Add(max(gap, delay)) }()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	return c.client().Do(req)
+}
+
+// robotsFor returns the robots.txt rules of u's host, fetching them the
+// first time and following up to maxRobotsRedirects redirects. A missing
+// robots.txt (4xx) allows everything; one that can't be fetched disallows
+// everything, as RFC 9309 asks, and so, to be safe, does one still
+// redirecting after that.
+func (c *Crawler) robotsFor(ctx context.Context, u *url.URL) (robotsRules, error) {
+	key := u.Scheme + "://" + u.Host
+	if r, ok := c.robots[key]; ok {
+		return r, nil
+	}
+	var rules robotsRules
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := c.get(ctx, robotsURL, 0)
+	for i := 0; err == nil && i < maxRobotsRedirects; i++ {
+		next := redirectTarget(resp)
+		if next == nil {
+			break
+		}
+		resp.Body.Close()
+		resp, err = c.get(ctx, next, 0)
+	}
+	switch {
+	case ctx.Err() != nil:
+		return rules, ctx.Err()
+	case err != nil:
+		rules.disallow = []string{"/"}
+	default:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300 && err == nil:
+			rules = parseRobots(string(body), c.userAgent())
+		case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		default:
+			rules.disallow = []string{"/"}
+		}
+	}
+	c.robots[key] = rules
+	return rules, nil
+}
+
+// fetch requests an HTML page and extracts it. A redirect is not followed;
+// fetch returns its target instead.
+func (c *Crawler) fetch(ctx context.Context, u *url.URL, delay time.Duration) (page, *url.URL, error) {
+	resp, err := c.get(ctx, u, delay)
+	if err != nil {
+		return page{}, nil, err
+	}
+	defer resp.Body.Close()
+	if next := redirectTarget(resp); next != nil {
+		return page{}, next, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return page{}, nil, fmt.Errorf("%s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil && mt != "text/html" && mt != "application/xhtml+xml" {
+		return page{}, nil, ErrNotHTML
+	}
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxPageBytes), contentType)
+	if err != nil {
+		return page{}, nil, err
+	}
+	p, err := extract(body, u)
+	return p, nil, err
+}
+
+// redirectTarget returns where a redirect response points, without its
+// fragment; nil if resp isn't a redirect to an http(s) URL
+func redirectTarget(resp *http.Response) *url.URL {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil
+	}
+	u, err := resp.Location()
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u
+}
@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gonews/pkg/gonews"
+)
+
+// site is a test server: robots is served at robotsAt (/robots.txt if
+// empty), redirects maps paths to their Location, and any other path is an
+// article linking to links[path]
+type site struct {
+	robots    string
+	robotsAt  string
+	redirects map[string]string
+	links     map[string][]string
+
+	mu        sync.Mutex
+	requested []string
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requested = append(s.requested, r.URL.Path)
+	s.mu.Unlock()
+	if to, ok := s.redirects[r.URL.Path]; ok {
+		http.Redirect(w, r, to, http.StatusFound)
+		return
+	}
+	if r.URL.Path == cmp.Or(s.robotsAt, "/robots.txt") {
+		if s.robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, s.robots)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><head><title>Page %s</title></head><body><article><p>Story at %s.</p></article>", r.URL.Path, r.URL.Path)
+	for _, l := range s.links[r.URL.Path] {
+		fmt.Fprintf(w, `<a href="%s">more</a>`, l)
+	}
+	fmt.Fprint(w, "</body></html>")
+}
+
+// crawl runs a crawl of srv's / and returns the paths of the documents and
+// the error reported for each page
+func crawl(t *testing.T, srv *httptest.Server, depth int) ([]string, map[string]error) {
+	t.Helper()
+	reported := make(map[string]error)
+	c := &Crawler{
+		MaxDepth: depth,
+		Delay:    time.Nanosecond,
+		Client:   srv.Client(),
+		OnPage: func(u string, err error) {
+			reported[strings.TrimPrefix(u, srv.URL)] = err
+		},
+	}
+	var docs []string
+	err := c.Crawl(context.Background(), []string{srv.URL + "/"}, func(d gonews.Document) error {
+		docs = append(docs, strings.TrimPrefix(d.SourceID, srv.URL))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return docs, reported
+}
+
+func TestCrawlObeysRobots(t *testing.T) {
+	s := &site{
+		// only the group naming the crawler applies to it
+		robots: "User-agent: *\nDisallow: /private\n\nUser-agent: GoNewsBot\nDisallow: /secret\nAllow: /secret/open\n",
+		links:  map[string][]string{"/": {"/private/a", "/secret/b", "/secret/open/c", "/news"}},
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	docs, reported := crawl(t, srv, 1)
+	if want := []string{"/", "/private/a", "/secret/open/c", "/news"}; !slices.Equal(docs, want) {
+		t.Errorf("docs %v, want %v", docs, want)
+	}
+	if err := reported["/secret/b"]; !errors.Is(err, ErrDisallowed) {
+		t.Errorf("/secret/b reported %v, want %v", err, ErrDisallowed)
+	}
+	if slices.Contains(s.requested, "/secret/b") {
+		t.Error("requested /secret/b, which robots.txt disallows")
+	}
+	if n := strings.Count(strings.Join(s.requested, " "), "/robots.txt"); n != 1 {
+		t.Errorf("robots.txt requested %d times, want once per host", n)
+	}
+}
+
+func TestCrawlRobotsRedirectLimit(t *testing.T) {
+	tests := []struct {
+		redirects int
+		allowed   bool
+	}{
+		{0, true},
+		{maxRobotsRedirects, true},
+		// robots.txt is never reached: the host counts as unreachable
+		{maxRobotsRedirects + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.redirects), func(t *testing.T) {
+			s := &site{
+				robots:    "User-agent: *\nDisallow: /blocked\n",
+				redirects: make(map[string]string),
+				links:     map[string][]string{"/": {"/blocked"}},
+			}
+			// a chain of redirects ending at the rules
+			s.robotsAt = "/robots.txt"
+			for i := range tt.redirects {
+				to := fmt.Sprintf("/robots-%d.txt", i)
+				s.redirects[s.robotsAt] = to
+				s.robotsAt = to
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			docs, reported := crawl(t, srv, 1)
+			robots := 0
+			for _, p := range s.requested {
+				if strings.HasPrefix(p, "/robots") {
+					robots++
+				}
+			}
+			if want := min(tt.redirects, maxRobotsRedirects) + 1; robots != want {
+				t.Errorf("%d robots.txt requests, want %d", robots, want)
+			}
+			if got := slices.Equal(docs, []string{"/"}); got != tt.allowed {
+				t.Errorf("docs %v; / allowed = %v, want %v", docs, got, tt.allowed)
+			}
+			// the rules were followed, or nothing was fetched at all
+			blocked := "/blocked"
+			if !tt.allowed {
+				blocked = "/"
+			}
+			if !errors.Is(reported[blocked], ErrDisallowed) {
+				t.Errorf("%s reported %v, want %v", blocked, reported[blocked], ErrDisallowed)
+			}
+		})
+	}
+}
+
+func TestCrawlQueuesRedirects(t *testing.T) {
+	s := &site{
+		redirects: map[string]string{
+			"/old":   "/new",
+			"/away":  "http://elsewhere.invalid/story",
+			"/loop1": "/loop2",
+			"/loop2": "/loop1",
+		},
+		links: map[string][]string{"/": {"/old", "/away", "/loop1"}},
+	}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	docs, reported := crawl(t, srv, 1)
+	if want := []string{"/", "/new"}; !slices.Equal(docs, want) {
+		t.Errorf("docs %v, want %v", docs, want)
+	}
+	for _, p := range []string{"/old", "/away", "/loop1", "/loop2"} {
+		if !errors.Is(reported[p], ErrRedirected) {
+			t.Errorf("%s reported %v, want %v", p, reported[p], ErrRedirected)
+		}
+	}
+	// each page of the loop is requested once, and other hosts never
+	if _, ok := reported["http://elsewhere.invalid/story"]; ok {
+		t.Error("followed a redirect to another host")
+	}
+	for _, p := range []string{"/loop1", "/loop2"} {
+		if n := slices.Index(s.requested, p); n < 0 || slices.Contains(s.requested[n+1:], p) {
+			t.Errorf("%s requested %d times, want once", p, strings.Count(strings.Join(s.requested, " "), p))
+		}
+	}
+}
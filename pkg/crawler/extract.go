@@ -0,0 +1,233 @@
+package crawler
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// page is what extract finds in an HTML page
+type page struct {
+	title, date, text, summary, author, site, lang string
+	links                                          []*url.URL
+	noindex, nofollow                              bool
+}
+
+// metaDateKeys are the <meta> names and properties holding a publication
+// date, most specific first
+var metaDateKeys = []string{"article:published_time", "og:published_time", "datepublished", "date", "pubdate", "publishdate", "dc.date.issued", "dc.date"}
+
+// skipText are elements whose text is never part of an article
+var skipText = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Form: true, atom.Button: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+}
+
+// blocks are elements that break text into separate lines
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Br: true, atom.Blockquote: true, atom.Pre: true, atom.Tr: true,
+	atom.Figcaption: true, atom.Dd: true, atom.Dt: true,
+}
+
+// extract parses an HTML page fetched from base. The title comes from
+// og:title, <title> or the first <h1>; the date from publication <meta>
+// tags or the first <time datetime>; the text from the <article> (else
+// <main>, else <body>) without navigation, headers, footers and scripts.
+func extract(r io.Reader, base *url.URL) (page, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return page{}, err
+	}
+	var p page
+	meta := make(map[string]string)
+	var docTitle, h1, timeAttr string
+	var article, main, body *html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Html:
+				p.lang = attr(n, "lang")
+			case atom.Base:
+				if href := attr(n, "href"); href != "" {
+					if u, err := base.Parse(href); err == nil {
+						base = u
+					}
+				}
+			case atom.Title:
+				if docTitle == "" {
+					docTitle = textOf(n)
+				}
+			case atom.Meta:
+				key := strings.ToLower(attr(n, "property"))
+				if key == "" {
+					key = strings.ToLower(attr(n, "name"))
+				}
+				if key == "" {
+					key = strings.ToLower(attr(n, "itemprop"))
+				}
+				if _, seen := meta[key]; key != "" && !seen {
+					meta[key] = strings.TrimSpace(attr(n, "content"))
+				}
+			case atom.H1:
+				if h1 == "" {
+					h1 = textOf(n)
+				}
+			case atom.Time:
+				if timeAttr == "" {
+					timeAttr = attr(n, "datetime")
+				}
+			case atom.Article:
+				if article == nil {
+					article = n
+				}
+			case atom.Main:
+				if main == nil {
+					main = n
+				}
+			case atom.Body:
+				body = n
+			case atom.A:
+				if u, ok := link(base, attr(n, "href"), attr(n, "rel")); ok {
+					p.links = append(p.links, u)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	p.title = firstNonEmpty(meta["og:title"], docTitle, h1)
+	p.summary = firstNonEmpty(meta["description"], meta["og:description"])
+	p.author = firstNonEmpty(meta["author"], meta["article:author"])
+	p.site = meta["og:site_name"]
+	for _, key := range metaDateKeys {
+		if p.date = meta[key]; p.date != "" {
+			break
+		}
+	}
+	p.date = normalizeDate(firstNonEmpty(p.date, timeAttr))
+	if i := strings.IndexAny(p.lang, "-_"); i >= 0 {
+		p.lang = p.lang[:i]
+	}
+	p.lang = strings.ToLower(p.lang)
+	robots := strings.ToLower(meta["robots"])
+	p.noindex = strings.Contains(robots, "noindex") || strings.Contains(robots, "none")
+	p.nofollow = strings.Contains(robots, "nofollow") || strings.Contains(robots, "none")
+
+	for _, n := range []*html.Node{article, main, body} {
+		if n != nil {
+			p.text = mainText(n)
+			break
+		}
+	}
+	return p, nil
+}
+
+// mainText returns the visible text under n, one line per block
+func mainText(n *html.Node) string {
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(line.String()), " "); s != "" {
+			lines = append(lines, s)
+		}
+		line.Reset()
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skipText[n.DataAtom] {
+				return
+			}
+			if blocks[n.DataAtom] {
+				flush()
+				defer flush()
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// textOf returns n's text with whitespace collapsed
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// link resolves an <a href> against base; false for links not to follow:
+// rel=nofollow, non-HTTP schemes and unparseable URLs. The fragment is
+// dropped, since it names a spot on the same page.
+func link(base *url.URL, href, rel string) (*url.URL, bool) {
+	if href == "" || strings.Contains(strings.ToLower(rel), "nofollow") {
+		return nil, false
+	}
+	u, err := base.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u, true
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// normalizeDate turns a timestamp into the YYYY-MM-DD form GoNews sorts and
+// filters by; other values are kept as they are
+func normalizeDate(s string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	if len(s) > 10 {
+		if t, err := time.Parse("2006-01-02", s[:10]); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
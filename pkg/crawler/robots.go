@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules are the robots.txt rules that apply to the crawler on one host
+type robotsRules struct {
+	allow, disallow []string // path patterns, * and a trailing $ allowed
+	delay           time.Duration
+}
+
+// parseRobots returns the rules of a robots.txt for agent: those of the
+// groups naming agent's product token (the part before any '/'), or else
+// of the "*" groups
+func parseRobots(body, agent string) robotsRules {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	var mine, star robotsRules
+	foundMine := false
+	var agents []string // user-agents of the group being read
+	inRules := false    // past the group's user-agent lines
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "user-agent" {
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			continue
+		}
+		switch key {
+		case "allow", "disallow", "crawl-delay":
+		default:
+			continue // e.g. Sitemap, which belongs to no group
+		}
+		inRules = true
+		for _, a := range agents {
+			var r *robotsRules
+			switch {
+			case a == token:
+				r, foundMine = &mine, true
+			case a == "*":
+				r = &star
+			default:
+				continue
+			}
+			switch key {
+			case "allow":
+				if value != "" {
+					r.allow = append(r.allow, value)
+				}
+			case "disallow":
+				if value != "" { // an empty Disallow allows everything
+					r.disallow = append(r.disallow, value)
+				}
+			case "crawl-delay":
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					r.delay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+	}
+	if foundMine {
+		return mine
+	}
+	return star
+}
+
+// allowed reports whether path (with its query) may be fetched: the
+// longest matching pattern decides, Allow winning a tie
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, p := range r.disallow {
+		if len(p) > best && robotsMatch(p, path) {
+			best, allow = len(p), false
+		}
+	}
+	for _, p := range r.allow {
+		if len(p) >= best && robotsMatch(p, path) {
+			best, allow = len(p), true
+		}
+	}
+	return allow
+}
+
+// robotsMatch reports whether a robots.txt path pattern matches path: a
+// prefix match where * stands for any run of characters and a final $
+// anchors the end
+func robotsMatch(pattern, path string) bool {
+	if pattern == "" {
+		return false
+	}
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,403 @@
+package gonews
+
+import (
+	"bufio"
+	"html"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/snowballstem"
+	"github.com/blevesearch/snowballstem/english"
+)
+
+// words may carry an apostrophe part (don't, company's) so contractions
+// don't fall apart into junk t/s tokens; normalizeToken cleans them up
+var wordRE = regexp.MustCompile(`[a-zA-Z0-9]+(?:['’][a-zA-Z]+)*`)
+
+// identRE also keeps underscores so snake_case identifiers survive as one match
+var identRE = regexp.MustCompile(`[a-zA-Z0-9_]+(?:['’][a-zA-Z]+)*`)
+
+// symbolRE additionally matches #hashtags, @mentions and single emoji/symbols
+var symbolRE = regexp.MustCompile(`[#@][a-zA-Z0-9_]+(?:['’][a-zA-Z]+)*|[a-zA-Z0-9]+(?:['’][a-zA-Z]+)*|\p{So}`)
+var symbolIdentRE = regexp.MustCompile(`[#@][a-zA-Z0-9_]+(?:['’][a-zA-Z]+)*|[a-zA-Z0-9_]+(?:['’][a-zA-Z]+)*|\p{So}`)
+
+// the same patterns for words in any script, used with DetectLanguages
+var (
+	uniWordRE        = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+(?:['’][\p{L}\p{M}]+)*`)
+	uniIdentRE       = regexp.MustCompile(`[\p{L}\p{M}\p{N}_]+(?:['’][\p{L}\p{M}]+)*`)
+	uniSymbolRE      = regexp.MustCompile(`[#@][\p{L}\p{M}\p{N}_]+(?:['’][\p{L}\p{M}]+)*|[\p{L}\p{M}\p{N}]+(?:['’][\p{L}\p{M}]+)*|\p{So}`)
+	uniSymbolIdentRE = regexp.MustCompile(`[#@][\p{L}\p{M}\p{N}_]+(?:['’][\p{L}\p{M}]+)*|[\p{L}\p{M}\p{N}_]+(?:['’][\p{L}\p{M}]+)*|\p{So}`)
+)
+
+// Analyzer holds the options that turn text into index terms. An index
+// analyzes its docs and the queries against it with its own Analyzer, so
+// indexes built differently can be used side by side. The zero value drops
+// the built-in English stopwords and nothing else.
+type Analyzer struct {
+	Stemming         bool // reduce English words to their stem (see Stem)
+	SplitIdentifiers bool // split camelCase / snake_case identifiers (OpenAI -> openai, open, ai)
+	IndexSymbols     bool // index #hashtags, @mentions and emoji as tokens
+	MinTermLen       int  // words shorter than this (in characters) are dropped; 0 keeps everything
+	StripHTML        bool // treat Document.Content as HTML (tags stripped before analysis)
+
+	// DetectLanguages turns on per-language analysis: words in every script
+	// are tokenized, each doc's Lang is detected when unset, and its words go
+	// through that language's stopwords (LanguageStopwords) and stemmer
+	// (English only)
+	DetectLanguages bool
+
+	// Stopwords replaces the built-in English list unless nil (see
+	// LoadStopwords); NoStopwords keeps every word instead. The map must not
+	// change once the analyzer is in use.
+	Stopwords   map[string]bool
+	NoStopwords bool
+}
+
+var (
+	// script/style bodies are never visible text
+	htmlHiddenRE  = regexp.MustCompile(`(?is)<(script|style)\b[^>]*>.*?</(script|style)\s*>`)
+	htmlCommentRE = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTagRE     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// compact English stopword list, the default
+var defaultStopwords = map[string]bool{
+	"the": true, "is": true, "and": true, "a": true, "an": true, "of": true, "to": true, "in": true,
+	"for": true, "on": true, "with": true, "by": true, "that": true, "this": true, "it": true, "as": true,
+	"are": true, "was": true, "at": true, "from": true, "be": true, "has": true, "have": true,
+}
+
+// stopwords returns the words a drops (lowercase); nil keeps every word
+func (a Analyzer) stopwords() map[string]bool {
+	switch {
+	case a.NoStopwords:
+		return nil
+	case a.Stopwords != nil:
+		return a.Stopwords
+	}
+	return defaultStopwords
+}
+
+// LoadStopwords reads a stopword list with one word per line. Blank lines
+// and lines starting with # are ignored; words are lowercased.
+func LoadStopwords(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	words := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		w := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		words[normalizeApostrophes(w)] = true
+	}
+	return words, sc.Err()
+}
+
+// Tokenize returns lowercase tokens from text, filtering stopwords
+func (a Analyzer) Tokenize(text string) []string {
+	return a.TokenizeLang(text, "")
+}
+
+// TokenizeLang is Tokenize for text in language lang (an ISO 639-1 code
+// like "bn"; "" is the default analysis). The language only matters with
+// DetectLanguages.
+func (a Analyzer) TokenizeLang(text, lang string) []string {
+	var tokens []string
+	a.tokenize(text, lang, func(_ int, tok string) {
+		tokens = append(tokens, tok)
+	})
+	return tokens
+}
+
+// TokenizeFunc is Tokenize that streams each token and its position to emit
+// instead of building a slice, so huge documents index without one. All
+// tokens of one word (an identifier and its parts, a #tag and its words)
+// share that word's position.
+func (a Analyzer) TokenizeFunc(text string, emit func(pos int, tok string)) {
+	a.tokenize(text, "", emit)
+}
+
+func (a Analyzer) tokenize(text, lang string, emit func(pos int, tok string)) {
+	p := a.pipeline(lang)
+	pos := 0
+	var word []string
+	for len(text) > 0 {
+		loc := p.re.FindStringIndex(text)
+		if loc == nil {
+			return
+		}
+		word = appendTokens(word[:0], text[loc[0]:loc[1]], p)
+		for _, tok := range word {
+			emit(pos, tok)
+		}
+		if len(word) > 0 {
+			pos++
+		}
+		text = text[loc[1]:]
+	}
+}
+
+// positionTokens groups the tokens of text by position: entry i holds the
+// tokens at position i, the word's main token first
+func (a Analyzer) positionTokens(text, lang string) [][]string {
+	var groups [][]string
+	a.tokenize(text, lang, func(pos int, tok string) {
+		if pos == len(groups) {
+			groups = append(groups, nil)
+		}
+		groups[pos] = append(groups[pos], tok)
+	})
+	return groups
+}
+
+// phraseTokens is TokenizeLang keeping only each position's main token, so
+// consecutive tokens are consecutive positions, as a phrase needs
+func (a Analyzer) phraseTokens(text, lang string) []string {
+	var toks []string
+	a.tokenize(text, lang, func(pos int, tok string) {
+		if pos == len(toks) {
+			toks = append(toks, tok)
+		}
+	})
+	return toks
+}
+
+// langPipeline is how an analyzer treats one text, with the
+// language-dependent parts picked for its language
+type langPipeline struct {
+	re        *regexp.Regexp // raw words (matchRE)
+	tagWords  *regexp.Regexp // the words behind a #tag or @mention
+	split     bool           // SplitIdentifiers
+	minLen    int            // MinTermLen
+	stopwords map[string]bool
+	stem      bool
+}
+
+// pipeline returns the analysis for words of language lang. Without
+// DetectLanguages every language gets the same stopwords and stemming;
+// with it, languages with a LanguageStopwords list use that, and only
+// English (or unknown) text is stemmed, since Stem is an English stemmer.
+// An analyzer without stopwords keeps every word in every language.
+func (a Analyzer) pipeline(lang string) langPipeline {
+	p := langPipeline{
+		re:        a.matchRE(),
+		tagWords:  a.pickRE(wordRE, uniWordRE),
+		split:     a.SplitIdentifiers,
+		minLen:    a.MinTermLen,
+		stopwords: a.stopwords(),
+		stem:      a.Stemming,
+	}
+	if a.SplitIdentifiers {
+		p.tagWords = a.pickRE(identRE, uniIdentRE)
+	}
+	if !a.DetectLanguages {
+		return p
+	}
+	p.stem = a.Stemming && (lang == "" || lang == "en")
+	if sw, ok := LanguageStopwords[lang]; ok && len(p.stopwords) > 0 {
+		p.stopwords = sw
+	}
+	return p
+}
+
+// matchRE picks the raw-word regex for the enabled analyzer options
+func (a Analyzer) matchRE() *regexp.Regexp {
+	switch {
+	case a.IndexSymbols && a.SplitIdentifiers:
+		return a.pickRE(symbolIdentRE, uniSymbolIdentRE)
+	case a.IndexSymbols:
+		return a.pickRE(symbolRE, uniSymbolRE)
+	case a.SplitIdentifiers:
+		return a.pickRE(identRE, uniIdentRE)
+	}
+	return a.pickRE(wordRE, uniWordRE)
+}
+
+// pickRE returns the any-script variant of a pattern under DetectLanguages
+func (a Analyzer) pickRE(ascii, uni *regexp.Regexp) *regexp.Regexp {
+	if a.DetectLanguages {
+		return uni
+	}
+	return ascii
+}
+
+// tokenSpan is a token with its index position and the byte range of the
+// raw word it came from
+type tokenSpan struct {
+	Tok        string
+	Pos        int
+	Start, End int
+}
+
+// tokenSpans is TokenizeLang that also reports where each token sits in
+// text: its position, as TokenizeFunc numbers them, and its word's span.
+// Sub-word tokens (identifier parts, tag words) share both with their word.
+func (a Analyzer) tokenSpans(text, lang string) []tokenSpan {
+	var spans []tokenSpan
+	p := a.pipeline(lang)
+	pos := 0
+	var word []string
+	for _, loc := range p.re.FindAllStringIndex(text, -1) {
+		word = appendTokens(word[:0], text[loc[0]:loc[1]], p)
+		for _, tok := range word {
+			spans = append(spans, tokenSpan{Tok: tok, Pos: pos, Start: loc[0], End: loc[1]})
+		}
+		if len(word) > 0 {
+			pos++
+		}
+	}
+	return spans
+}
+
+// appendTokens analyzes one regex match and appends the resulting tokens,
+// each once ("#go" gives #go and go, "go_go" gives go_go and go)
+func appendTokens(tokens []string, m string, p langPipeline) []string {
+	from := len(tokens)
+	analyzeWord(m, p, func(tok string) {
+		if !slices.Contains(tokens[from:], tok) {
+			tokens = append(tokens, tok)
+		}
+	})
+	return tokens
+}
+
+// analyzeWord analyzes one regex match, emitting the resulting tokens
+func analyzeWord(m string, p langPipeline, emit func(tok string)) {
+	if len(m) > 1 && (m[0] == '#' || m[0] == '@') {
+		// keep the tag whole, then index the word(s) behind it too
+		emit(normalizeApostrophes(strings.ToLower(m)))
+		for _, w := range p.tagWords.FindAllString(m[1:], -1) {
+			analyzeWord(w, p, emit)
+		}
+		return
+	}
+	if r := []rune(m); len(r) == 1 && unicode.IsSymbol(r[0]) {
+		emit(m) // emoji or symbol, kept as-is
+		return
+	}
+	if !p.split {
+		if tok, ok := normalizeToken(strings.ToLower(m), p); ok {
+			emit(tok)
+		}
+		return
+	}
+	// keep each identifier whole and then emit its camelCase / snake_case
+	// components, so "reactJS" yields reactjs, react, js
+	m = strings.Trim(m, "_")
+	if m == "" {
+		return
+	}
+	if tok, ok := normalizeToken(strings.ToLower(m), p); ok {
+		emit(tok)
+	}
+	parts := splitIdentifier(m)
+	if len(parts) < 2 {
+		return
+	}
+	for _, part := range parts {
+		if tok, ok := normalizeToken(strings.ToLower(part), p); ok {
+			emit(tok)
+		}
+	}
+}
+
+// splitIdentifier breaks a word on underscores and case changes:
+// "gpt_4" -> gpt, 4; "reactJS" -> react, JS; "HTMLParser" -> HTML, Parser
+func splitIdentifier(w string) []string {
+	var parts []string
+	for _, seg := range strings.Split(w, "_") {
+		if seg == "" {
+			continue
+		}
+		r := []rune(seg)
+		start := 0
+		for i := 1; i < len(r); i++ {
+			lowerToUpper := unicode.IsLower(r[i-1]) && unicode.IsUpper(r[i])
+			acronymEnd := unicode.IsUpper(r[i-1]) && unicode.IsUpper(r[i]) && i+1 < len(r) && unicode.IsLower(r[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(r[start:i]))
+				start = i
+			}
+		}
+		parts = append(parts, string(r[start:]))
+	}
+	return parts
+}
+
+// normalizeToken applies apostrophe cleanup, stopword filtering, the minimum
+// length and optional stemming to a lowercase word
+func normalizeToken(m string, p langPipeline) (string, bool) {
+	m = normalizeApostrophes(m)
+	if p.stopwords[m] {
+		return "", false
+	}
+	if p.minLen > 0 && utf8.RuneCountInString(m) < p.minLen {
+		return "", false
+	}
+	if p.stem {
+		m = Stem(m)
+	}
+	return m, true
+}
+
+// normalizeApostrophes unifies curly apostrophes and drops a possessive 's,
+// so company's and company’s both become company. Other contractions
+// (don't, we'll) stay whole.
+func normalizeApostrophes(m string) string {
+	m = strings.ReplaceAll(m, "’", "'")
+	return strings.TrimSuffix(m, "'s")
+}
+
+// StripHTMLTags removes markup and decodes entities, keeping only visible text.
+// Tags are replaced by spaces so words on either side don't merge.
+func StripHTMLTags(s string) string {
+	s = htmlHiddenRE.ReplaceAllString(s, " ")
+	s = htmlCommentRE.ReplaceAllString(s, " ")
+	s = htmlTagRE.ReplaceAllString(s, " ")
+	return html.UnescapeString(s)
+}
+
+// docContent returns the analyzable body of a doc, stripping HTML if enabled
+func (a Analyzer) docContent(d Document) string {
+	if a.StripHTML {
+		return StripHTMLTags(d.Content)
+	}
+	return d.Content
+}
+
+// indexedFields names the document fields in the order they are indexed.
+// Their tokens are numbered consecutively, so a position belongs to the
+// field whose range (Index.FieldEnds) contains it.
+var indexedFields = []string{"title", "summary", "content"}
+
+// docFieldTexts returns the analyzable text of each of indexedFields
+func (a Analyzer) docFieldTexts(d Document) []string {
+	return []string{d.Title, d.Summary, a.docContent(d)}
+}
+
+// docText returns all indexed text of a doc (title + summary + body)
+func (a Analyzer) docText(d Document) string {
+	return strings.Join(a.docFieldTexts(d), " ")
+}
+
+// docTokens returns the tokens of all indexed text of a doc, in its language
+func (a Analyzer) docTokens(d Document) []string {
+	return a.TokenizeLang(a.docText(d), d.Lang)
+}
+
+// Stem reduces an English word to its Snowball (Porter2) stem, so that
+// "elections", "elected" and "electing" all index as "elect"
+func Stem(w string) string {
+	env := snowballstem.NewEnv(w)
+	english.Stem(env)
+	return env.Current()
+}
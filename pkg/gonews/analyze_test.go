@@ -0,0 +1,289 @@
+package gonews
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitIdentifiers(t *testing.T) {
+	idx := buildAnalyzed(Analyzer{SplitIdentifiers: true},
+		Document{ID: 1, Title: "OpenAI ships a reactJS SDK", Content: "The gpt_4 model is out"},
+		Document{ID: 2, Title: "Open source news", Content: "Nothing about models here"},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"openai", []int{1}},
+		{"react", []int{1}},
+		{"js", []int{1}},
+		{"reactJS", []int{1}},
+		{"gpt", []int{1}},
+		{"gpt_4", []int{1}},
+		{"open", []int{1, 2}},
+		{`"openai ships"`, []int{1}},
+		{`"reactjs sdk"`, []int{1}},
+		{`"openai reactjs"`, nil},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	if errs := idx.Validate(); len(errs) > 0 {
+		t.Errorf("Validate: %v", errs)
+	}
+}
+
+func TestSubWordTokensSharePosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer Analyzer
+		text     string
+		want     [][]string
+	}{
+		{"camelCase", Analyzer{SplitIdentifiers: true}, "OpenAI ships", [][]string{{"openai", "open", "ai"}, {"ships"}}},
+		{"snake_case", Analyzer{SplitIdentifiers: true}, "gpt_4 launch", [][]string{{"gpt_4", "gpt", "4"}, {"launch"}}},
+		{"repeated part", Analyzer{SplitIdentifiers: true}, "go_go", [][]string{{"go_go", "go"}}},
+		{"hashtag", Analyzer{IndexSymbols: true}, "#climate march", [][]string{{"#climate", "climate"}, {"march"}}},
+		{"mention", Analyzer{IndexSymbols: true}, "@reuters says", [][]string{{"@reuters", "reuters"}, {"says"}}},
+		{"plain", Analyzer{}, "OpenAI ships", [][]string{{"openai"}, {"ships"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.analyzer.positionTokens(tt.text, "")
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("positionTokens(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubWordTokensDontInflateLength(t *testing.T) {
+	doc := Document{ID: 1, Title: "OpenAI and reactJS", Content: "#climate news from @reuters"}
+	plain := buildIndex(doc)
+	split := buildAnalyzed(Analyzer{SplitIdentifiers: true, IndexSymbols: true}, doc)
+	if got, want := split.DocTokCounts[1], plain.DocTokCounts[1]; got != want {
+		t.Errorf("token count with sub-words = %d, want %d as without", got, want)
+	}
+	if !split.DeleteDocument(1) {
+		t.Fatal("DeleteDocument(1) = false")
+	}
+	if n := split.TermCount(); n != 0 {
+		t.Errorf("%d terms left after deleting the only doc", n)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	doc := Document{ID: 1, Title: "Markup", Content: `<div class="body"><p>Rates <span>rise</span> again</p>` +
+		`<a href="https://example.com">read&nbsp;more</a><script>var tracker = 1;</script><!-- hidden note --></div>`}
+	idx := buildAnalyzed(Analyzer{StripHTML: true}, doc)
+	for _, term := range []string{"div", "span", "class", "href", "https", "script", "tracker", "hidden", "p"} {
+		if _, _, found := idx.TermInfo(term); found {
+			t.Errorf("markup term %q was indexed", term)
+		}
+	}
+	for _, term := range []string{"rates", "rise", "again", "read", "more"} {
+		if _, _, found := idx.TermInfo(term); !found {
+			t.Errorf("visible term %q is missing", term)
+		}
+	}
+	if got := resultIDs(idx.Search(`"rates rise again"`)); !slices.Equal(got, []int{1}) {
+		t.Errorf("phrase across tags matched %v, want [1]", got)
+	}
+
+	if _, _, found := buildIndex(doc).TermInfo("span"); !found {
+		t.Error("without StripHTML tag names should be indexed as words")
+	}
+}
+
+func TestIndexSymbols(t *testing.T) {
+	idx := buildAnalyzed(Analyzer{IndexSymbols: true},
+		Document{ID: 1, Title: "Protest", Content: "Thousands join the #climate march 🌍 with @greenpeace"},
+		Document{ID: 2, Title: "Weather", Content: "The climate was mild 🔥"},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"#climate", []int{1}},
+		{"climate", []int{1, 2}},
+		{"🌍", []int{1}},
+		{"🔥", []int{2}},
+		{"@greenpeace", []int{1}},
+		{"greenpeace", []int{1}},
+		{`"#climate march"`, []int{1}},
+		{`"join #climate"`, []int{1}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	// the words behind tags share the tags' positions: 6 words, one emoji
+	if got := idx.DocTokCounts[1]; got != 7 {
+		t.Errorf("doc 1 has %d positions, want 7", got)
+	}
+	if errs := idx.Validate(); len(errs) > 0 {
+		t.Errorf("Validate: %v", errs)
+	}
+}
+
+func TestMinTermLen(t *testing.T) {
+	doc := Document{ID: 1, Content: "AI and a1 go beyond the EU budget"}
+	idx := buildAnalyzed(Analyzer{MinTermLen: 3}, doc)
+	for _, term := range []string{"ai", "a1", "go", "eu"} {
+		if _, _, found := idx.TermInfo(term); found {
+			t.Errorf("short term %q was indexed", term)
+		}
+		if got := idx.Search(term); len(got) != 0 {
+			t.Errorf("Search(%q) = %v, want nothing", term, resultIDs(got))
+		}
+	}
+	for _, q := range []string{"beyond", "budget", "beyond AND budget"} {
+		if got := resultIDs(idx.Search(q)); !slices.Equal(got, []int{1}) {
+			t.Errorf("Search(%q) = %v, want [1]", q, got)
+		}
+	}
+
+	if got := resultIDs(buildIndex(doc).Search("ai")); !slices.Equal(got, []int{1}) {
+		t.Errorf("without MinTermLen Search(ai) = %v, want [1]", got)
+	}
+}
+
+func TestContractionsAndPossessives(t *testing.T) {
+	a := Analyzer{NoStopwords: true}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"don't stop", []string{"don't", "stop"}},
+		{"We’ll see", []string{"we'll", "see"}},
+		{"the company's profits", []string{"the", "company", "profits"}},
+		{"the company’s profits", []string{"the", "company", "profits"}},
+		{"companies' profits", []string{"companies", "profits"}},
+		{"it's", []string{"it"}},
+	}
+	for _, tt := range tests {
+		if got := a.Tokenize(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+	idx := buildAnalyzed(a, Document{ID: 1, Title: "Company’s profits rise", Content: "They don't expect more."})
+	for _, q := range []string{"company", "company's", `"company's profits"`, "don't"} {
+		if got := resultIDs(idx.Search(q)); !slices.Equal(got, []int{1}) {
+			t.Errorf("Search(%s) = %v, want [1]", q, got)
+		}
+	}
+	if got := idx.Search("t"); len(got) != 0 {
+		t.Errorf(`Search(t) = %v, want no junk "t" token`, resultIDs(got))
+	}
+}
+
+func TestTokenizeFuncMatchesTokenize(t *testing.T) {
+	text := "OpenAI's reactJS SDK (gpt_4) ships; #climate @reuters 🌍 don't <b>panic</b> over 2.5bn HTMLParser news"
+	analyzers := []Analyzer{
+		{},
+		{Stemming: true},
+		{SplitIdentifiers: true},
+		{IndexSymbols: true, SplitIdentifiers: true},
+		{MinTermLen: 3, NoStopwords: true},
+		{DetectLanguages: true},
+	}
+	for _, a := range analyzers {
+		var toks []string
+		var positions []int
+		a.TokenizeFunc(text, func(pos int, tok string) {
+			toks = append(toks, tok)
+			positions = append(positions, pos)
+		})
+		if want := a.Tokenize(text); !slices.Equal(toks, want) {
+			t.Errorf("%+v: TokenizeFunc tokens %q, Tokenize %q", a, toks, want)
+		}
+		// positions start at 0 and each word takes the next one
+		prev := -1
+		for _, p := range positions {
+			if p != prev && p != prev+1 {
+				t.Errorf("%+v: position %d after %d", a, p, prev)
+			}
+			prev = p
+		}
+		if n := len(a.phraseTokens(text, "")); len(positions) > 0 && positions[len(positions)-1] != n-1 {
+			t.Errorf("%+v: last position %d, want %d", a, positions[len(positions)-1], n-1)
+		}
+	}
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	text := strings.Repeat("The parliament passed the budget after a long debate on reactJS and gpt_4. ", 2000)
+	b.Run("slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			Analyzer{}.Tokenize(text)
+		}
+	})
+	b.Run("func", func(b *testing.B) {
+		b.ReportAllocs()
+		n := 0
+		for range b.N {
+			Analyzer{}.TokenizeFunc(text, func(int, string) { n++ })
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			buildIndex(Document{ID: 1, Content: text})
+		}
+	})
+}
+
+func TestCustomStopwords(t *testing.T) {
+	tests := []struct {
+		a    Analyzer
+		want []string
+	}{
+		{Analyzer{}, []string{"budget", "vote"}},
+		{Analyzer{Stopwords: map[string]bool{"vote": true}}, []string{"the", "budget"}},
+		{Analyzer{Stopwords: map[string]bool{}}, []string{"the", "budget", "vote"}},
+		{Analyzer{Stopwords: map[string]bool{"vote": true}, NoStopwords: true}, []string{"the", "budget", "vote"}},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Tokenize("the budget vote"); !slices.Equal(got, tt.want) {
+			t.Errorf("%+v: Tokenize = %q, want %q", tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestIndexesKeepTheirAnalyzers(t *testing.T) {
+	doc := Document{ID: 1, Title: "Elections called", Content: "The OpenAI board met"}
+	plain := buildIndex(doc)
+	stemmed := buildAnalyzed(Analyzer{Stemming: true, SplitIdentifiers: true}, doc)
+	tests := []struct {
+		idx   *Index
+		query string
+		want  []int
+	}{
+		{plain, "elections", []int{1}},
+		{plain, "election", []int{}},
+		{plain, "ai", []int{}},
+		{plain, "the", []int{}},
+		{stemmed, "elections", []int{1}},
+		{stemmed, "election", []int{1}},
+		{stemmed, `"election called"`, []int{1}},
+		{stemmed, "ai", []int{1}},
+	}
+	for _, tt := range tests {
+		if got := resultIDs(tt.idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("%+v: Search(%s) = %v, want %v", tt.idx.Analyzer, tt.query, got, tt.want)
+		}
+	}
+	// snippets are cut and highlighted by the index's analyzer too
+	r := SearchResult{DocID: 1, MatchedTerms: []string{"elect"}}
+	if got, want := stemmed.Analyzer.HighlightSnippet(doc.Title, r.MatchedTerms, MarkupHTML), "<em>Elections</em> called"; got != want {
+		t.Errorf("stemmed HighlightSnippet = %q, want %q", got, want)
+	}
+	if got := plain.Analyzer.HighlightSnippet(doc.Title, r.MatchedTerms, MarkupHTML); got != doc.Title {
+		t.Errorf("plain HighlightSnippet = %q, want %q", got, doc.Title)
+	}
+}
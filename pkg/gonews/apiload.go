@@ -0,0 +1,195 @@
+package gonews
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP settings for LoadFromAPI
+var (
+	apiClient     = &http.Client{Timeout: 30 * time.Second}
+	apiRetries    = 3                      // attempts per page
+	apiRetryDelay = 500 * time.Millisecond // grows linearly per attempt
+	apiMaxPages   = 100000                 // guard against APIs that never stop paginating
+)
+
+// field names tried, in order, when mapping an API article to a Document
+var (
+	apiIDFields      = []string{"id", "_id", "uuid"}
+	apiTitleFields   = []string{"title", "headline"}
+	apiDateFields    = []string{"date", "published_at", "published", "created_at"}
+	apiContentFields = []string{"content", "body", "text"}
+	apiSummaryFields = []string{"summary", "abstract", "description"}
+	apiAuthorFields  = []string{"author", "byline", "creator"}
+	apiSourceFields  = []string{"source", "publisher", "section", "category"}
+	apiLangFields    = []string{"lang", "language"}
+	apiListFields    = []string{"data", "items", "articles", "results", "documents"}
+	apiCursorFields  = []string{"next_cursor", "cursor", "next"}
+)
+
+// LoadFromAPI fetches articles from a paginated JSON API. Pages are requested
+// by setting pageParam on baseURL. A page may be a bare JSON array or an
+// object holding the list (under data/items/articles/results/documents) and
+// optionally a cursor (next_cursor/cursor/next). With a cursor, the next page
+// is requested with pageParam=<cursor>; without one, pageParam counts up from
+// 1. Loading stops at an empty page or when the cursor runs out. Transient
+// failures (network errors, 429, 5xx) are retried. maxDocs > 0 stops after
+// that many documents without fetching further pages. An API returning no
+// documents at all is ErrEmptyCorpus.
+func LoadFromAPI(baseURL string, pageParam string, maxDocs int) ([]Document, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API url: %w", err)
+	}
+	var docs []Document
+	ids := newIDAllocator()
+	page := 1
+	cursor := ""
+	for n := 0; n < apiMaxPages; n++ {
+		u := *base
+		q := u.Query()
+		if cursor != "" {
+			q.Set(pageParam, cursor)
+		} else {
+			q.Set(pageParam, strconv.Itoa(page))
+		}
+		u.RawQuery = q.Encode()
+
+		body, err := fetchWithRetry(u.String())
+		if err != nil {
+			return nil, err
+		}
+		items, next, err := parseAPIPage(body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", u.String(), err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		for _, it := range items {
+			if maxDocs > 0 && len(docs) >= maxDocs {
+				break
+			}
+			d := Document{
+				Title:   apiString(it, apiTitleFields),
+				Date:    apiString(it, apiDateFields),
+				Content: apiString(it, apiContentFields),
+				Summary: apiString(it, apiSummaryFields),
+				Author:  apiString(it, apiAuthorFields),
+				Source:  apiString(it, apiSourceFields),
+				Lang:    strings.ToLower(apiString(it, apiLangFields)),
+			}
+			d.ID, d.SourceID, _ = ids.claim(apiString(it, apiIDFields), len(docs))
+			docs = append(docs, d)
+		}
+		if maxDocs > 0 && len(docs) >= maxDocs {
+			break
+		}
+		if next == "" && cursor != "" {
+			break // cursor pagination finished
+		}
+		if next != "" && next == cursor {
+			break // cursor didn't advance
+		}
+		cursor = next
+		page++
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s: %w", baseURL, ErrEmptyCorpus)
+	}
+	ids.assign(docs)
+	return docs, nil
+}
+
+// fetchWithRetry GETs url, retrying transient failures
+func fetchWithRetry(url string) ([]byte, error) {
+	var lastErr error
+	for attempt := 1; attempt <= apiRetries; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(attempt-1) * apiRetryDelay)
+		}
+		resp, err := apiClient.Get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("%s: %s", url, resp.Status)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", url, resp.Status)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("fetch failed after %d attempts: %w", apiRetries, lastErr)
+}
+
+// parseAPIPage extracts the article list and next cursor from one page
+func parseAPIPage(body []byte) ([]map[string]any, string, error) {
+	var list []map[string]any
+	if err := json.Unmarshal(body, &list); err == nil {
+		return list, "", nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, "", fmt.Errorf("page is neither a JSON array nor object: %w", err)
+	}
+	for _, f := range apiListFields {
+		if raw, ok := obj[f]; ok {
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, "", fmt.Errorf("field %q is not a list of objects: %w", f, err)
+			}
+			break
+		}
+	}
+	var next string
+	for _, f := range apiCursorFields {
+		var v any
+		if raw, ok := obj[f]; ok && json.Unmarshal(raw, &v) == nil {
+			if next = apiValueString(v); next != "" {
+				break
+			}
+		}
+	}
+	return list, next, nil
+}
+
+// apiString returns the first present field of m as a string
+func apiString(m map[string]any, fields []string) string {
+	for _, f := range fields {
+		if v, ok := m[f]; ok {
+			if s := apiValueString(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func apiValueString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool, nil:
+		return ""
+	case map[string]any:
+		return apiString(x, []string{"name"}) // e.g. "source": {"id": ..., "name": "Reuters"}
+	default:
+		return fmt.Sprint(x)
+	}
+}
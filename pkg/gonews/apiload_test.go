@@ -0,0 +1,93 @@
+package gonews
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestLoadFromAPI(t *testing.T) {
+	prevDelay := apiRetryDelay
+	apiRetryDelay = 0
+	t.Cleanup(func() { apiRetryDelay = prevDelay })
+	pages := map[string]string{
+		"1": `[{"id": 1, "title": "First", "body": "one"}, {"id": 2, "headline": "Second", "text": "two"}]`,
+		"2": `{"data": [{"id": "x9", "title": "Third", "content": "three", "source": {"name": "Reuters"}}]}`,
+		"3": `[]`,
+	}
+	cursorPages := map[string]string{
+		"":   `{"items": [{"id": 1, "title": "First"}], "next_cursor": "c2"}`,
+		"c2": `{"items": [{"id": 2, "title": "Second"}], "next_cursor": "c3"}`,
+		"c3": `{"items": [{"id": 3, "title": "Third"}]}`,
+	}
+	failures, requests := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/pages":
+			fmt.Fprint(w, pages[r.URL.Query().Get("page")])
+		case "/cursor":
+			c := r.URL.Query().Get("cursor")
+			if c == "1" {
+				c = "" // the first request counts pages
+			}
+			fmt.Fprint(w, cursorPages[c])
+		case "/flaky":
+			if failures++; failures < apiRetries {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, pages[r.URL.Query().Get("page")])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name, path, param string
+		maxDocs           int
+		want              []string
+	}{
+		{"page numbers", "/pages", "page", 0, []string{"First", "Second", "Third"}},
+		{"cursor", "/cursor", "cursor", 0, []string{"First", "Second", "Third"}},
+		{"max docs", "/pages", "page", 2, []string{"First", "Second"}},
+		{"retried", "/flaky", "page", 0, []string{"First", "Second", "Third"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := LoadFromAPI(srv.URL+tt.path, tt.param, tt.maxDocs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			ids := make(map[int]bool)
+			for _, d := range docs {
+				titles = append(titles, d.Title)
+				if ids[d.ID] {
+					t.Errorf("ID %d used twice", d.ID)
+				}
+				ids[d.ID] = true
+			}
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("loaded %q, want %q", titles, tt.want)
+			}
+		})
+	}
+
+	// a cap reached on the first page stops paging
+	requests = 0
+	if _, err := LoadFromAPI(srv.URL+"/pages", "page", 1); err != nil || requests != 1 {
+		t.Errorf("maxDocs 1 made %d requests (err %v), want 1", requests, err)
+	}
+
+	docs, _ := LoadFromAPI(srv.URL+"/pages", "page", 0)
+	if d := docs[2]; d.SourceID != "x9" || d.Source != "Reuters" || d.Content != "three" {
+		t.Errorf("third doc = %+v", d)
+	}
+	if _, err := LoadFromAPI(srv.URL+"/missing", "page", 0); err == nil {
+		t.Error("LoadFromAPI of a 404 succeeded")
+	}
+}
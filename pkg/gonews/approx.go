@@ -0,0 +1,234 @@
+package gonews
+
+import (
+	"slices"
+	"sort"
+)
+
+// ApproxSearch is a fast, approximate top-k for interactive use such as
+// search-as-you-type. Rather than evaluating the whole query, it walks the
+// postings of the most selective (lowest df) query terms, which tend to
+// hold the best matches, checks each doc there against the query and stops
+// after maxDocs matches; only those are scored, so a relevant doc may be
+// missed when more than maxDocs docs match. Queries that can't be checked
+// doc by doc (NEAR, ranges, lang:, _exists_) or that may match docs without
+// any of their terms (NOT x) are evaluated in full and the matches capped
+// the same way. maxDocs <= 0 scores every match, like Search, which gives
+// the exact ranking. A malformed query finds nothing.
+func (idx *Index) ApproxSearch(query string, k int, maxDocs int) []SearchResult {
+	if len(query) == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	rpn, err := idx.parseQuery(query)
+	if err != nil {
+		return nil
+	}
+	run := idx.newQueryRun(rpn)
+
+	docs, ok := idx.approxMatches(run, maxDocs)
+	if !ok {
+		resSet := idx.evaluateRPN(rpn)
+		if maxDocs <= 0 || len(resSet) <= maxDocs {
+			for doc := range resSet {
+				docs = append(docs, doc)
+			}
+		} else {
+			docs = idx.approxCandidates(rpn, resSet, maxDocs)
+		}
+	}
+	var results []SearchResult
+	for _, doc := range docs {
+		results = append(results, run.result(doc))
+	}
+	sort.Slice(results, func(i, j int) bool { return idx.less(results[i], results[j]) })
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// approxMatches finds up to n docs matching q's query without evaluating
+// it: the postings of its terms are walked rarest first and each doc there
+// checked with matchesDoc. ok is false when that can't be done: n <= 0, an
+// operand matchesDoc can't check, or a query that may match docs outside
+// those postings.
+func (idx *Index) approxMatches(q *queryRun, n int) (docs []int, ok bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	terms, ok := q.postingTerms()
+	if !ok {
+		return nil, false
+	}
+	seen := make(map[int]bool)
+	for _, t := range terms {
+		for it := idx.Terms[t].iter(); !it.done; it.next() {
+			if seen[it.doc] {
+				continue
+			}
+			seen[it.doc] = true
+			if q.matchesDoc(it.doc) {
+				docs = append(docs, it.doc)
+				if len(docs) == n {
+					return docs, true
+				}
+			}
+		}
+	}
+	return docs, true
+}
+
+// postingTerms lists the indexed terms whose postings hold every doc the
+// query can match, rarest first. ok is false if the query has an operand
+// matchesDoc can't check, or can match a doc containing none of its terms.
+func (q *queryRun) postingTerms() (terms []string, ok bool) {
+	var needs []bool // per stack operand: a match must contain one of terms
+	add := func(ts ...string) {
+		for _, t := range ts {
+			if _, ok := q.idx.Terms[t]; ok && !slices.Contains(terms, t) {
+				terms = append(terms, t)
+			}
+		}
+	}
+	for _, tok := range q.rpn {
+		switch tok {
+		case "AND", "OR":
+			if len(needs) < 2 {
+				continue
+			}
+			l, r := needs[len(needs)-2], needs[len(needs)-1]
+			needs = needs[:len(needs)-2]
+			if tok == "AND" {
+				needs = append(needs, l || r)
+			} else {
+				needs = append(needs, l && r)
+			}
+		case "NOT":
+			if len(needs) > 0 {
+				needs[len(needs)-1] = false
+			}
+		default:
+			if p := q.phrase(tok); p != nil {
+				add(p.tokens...)
+				for _, v := range p.variants {
+					add(v...)
+				}
+			} else if !q.checkable(tok) {
+				return nil, false
+			} else if _, term, ok := parseFieldTermToken(tok); ok {
+				add(term)
+			} else {
+				add(tok)
+			}
+			needs = append(needs, true)
+		}
+	}
+	if len(needs) > 0 && !needs[len(needs)-1] {
+		return nil, false
+	}
+	sort.SliceStable(terms, func(i, j int) bool { return q.idx.Terms[terms[i]].Len() < q.idx.Terms[terms[j]].Len() })
+	return terms, true
+}
+
+// checkable reports whether matchesDoc can check a non-phrase operand: a
+// term or field:term, not one of the clauses evaluated over all docs
+func (q *queryRun) checkable(tok string) bool {
+	if _, _, ok := parseNearToken(tok); ok {
+		return false
+	}
+	if _, ok := parseExistsToken(tok); ok {
+		return false
+	}
+	if _, ok := parseLangToken(tok); ok {
+		return false
+	}
+	if _, err := parseDateRangeToken(tok); err == nil {
+		return false
+	}
+	if _, err := parseRangeToken(tok); err == nil {
+		return false
+	}
+	return true
+}
+
+// matchesDoc evaluates the query for one doc, as evaluateRPN would; every
+// operand must be a phrase or checkable
+func (q *queryRun) matchesDoc(doc int) bool {
+	var stack []bool
+	for _, tok := range q.rpn {
+		switch tok {
+		case "AND", "OR":
+			if len(stack) < 2 {
+				continue
+			}
+			l, r := stack[len(stack)-2], stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+			if tok == "AND" {
+				stack = append(stack, l && r)
+			} else {
+				stack = append(stack, l || r)
+			}
+		case "NOT":
+			if len(stack) > 0 {
+				stack[len(stack)-1] = !stack[len(stack)-1]
+			}
+		default:
+			var m bool
+			if p := q.phrase(tok); p != nil {
+				m = len(p.tokens) > 0 && len(q.phraseStarts(doc, p)) > 0
+			} else if field, term, ok := parseFieldTermToken(tok); ok {
+				m = len(q.idx.fieldPositions(doc, term, field)) > 0
+			} else if posting, ok := q.idx.Terms[tok]; ok {
+				m = len(posting.Positions(doc)) > 0
+			}
+			stack = append(stack, m)
+		}
+	}
+	return len(stack) > 0 && stack[len(stack)-1]
+}
+
+// approxCandidates picks up to n docs of resSet, rarest query terms first
+func (idx *Index) approxCandidates(rpn []string, resSet map[int]struct{}, n int) []int {
+	var terms []string
+	for _, tok := range rpn {
+		if isOperator(tok) {
+			continue
+		}
+		if phrase, _, ok := parsePhraseToken(tok); ok {
+			terms = append(terms, idx.Analyzer.Tokenize(phrase)...)
+		} else if near, _, ok := parseNearToken(tok); ok {
+			terms = append(terms, near...)
+		} else if _, ok := idx.Terms[tok]; ok {
+			terms = append(terms, tok)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool { return idx.Terms[terms[i]].Len() < idx.Terms[terms[j]].Len() })
+
+	picked := make(map[int]bool, n)
+	var out []int
+	add := func(doc int) {
+		if _, ok := resSet[doc]; ok && !picked[doc] && len(out) < n {
+			picked[doc] = true
+			out = append(out, doc)
+		}
+	}
+	for _, t := range terms {
+		for _, doc := range idx.Terms[t].Docs() {
+			add(doc)
+			if len(out) == n {
+				return out
+			}
+		}
+	}
+	ids := make([]int, 0, len(resSet))
+	for doc := range resSet {
+		ids = append(ids, doc)
+	}
+	sort.Ints(ids)
+	for _, doc := range ids {
+		add(doc)
+	}
+	return out
+}
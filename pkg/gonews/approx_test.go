@@ -0,0 +1,110 @@
+package gonews
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// approxQueries cover the operand kinds ApproxSearch checks doc by doc and
+// those it evaluates in full
+var approxQueries = []string{
+	"budget",
+	"budget OR climate",
+	"budget AND NOT vote",
+	`"climate report"`,
+	`^"budget vote"`,
+	`"climate talks"~2`,
+	"title:budget OR summit",
+	"NOT budget",
+	"amount:>5 OR budget",
+	"nosuchword",
+}
+
+func TestApproxSearchMatchesExact(t *testing.T) {
+	idx := buildIndex(facetDocs(200)...)
+	for _, q := range approxQueries {
+		t.Run(q, func(t *testing.T) {
+			exact := idx.Search(q)
+			want := resultIDs(exact)
+			if len(want) > 10 {
+				want = want[:10]
+			}
+			// with room for every match, the approximation is exact
+			if got := resultIDs(idx.ApproxSearch(q, 10, len(exact)+1)); !slices.Equal(got, want) {
+				t.Errorf("ApproxSearch top 10 = %v, Search = %v", got, want)
+			}
+			// a tight cap scores fewer docs, but only real matches
+			matches := make(map[int]bool)
+			for _, r := range exact {
+				matches[r.DocID] = true
+			}
+			got := idx.ApproxSearch(q, 0, 7)
+			if len(got) != min(7, len(exact)) {
+				t.Errorf("ApproxSearch(maxDocs 7) returned %d docs, want %d", len(got), min(7, len(exact)))
+			}
+			for _, r := range got {
+				if !matches[r.DocID] {
+					t.Errorf("ApproxSearch returned doc %d, which doesn't match", r.DocID)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesDocAgreesWithEvaluate(t *testing.T) {
+	idx := buildIndex(facetDocs(60)...)
+	for _, q := range approxQueries {
+		run := newTestRun(t, idx, q)
+		if _, ok := run.postingTerms(); !ok {
+			continue
+		}
+		set := idx.evaluateRPN(run.rpn)
+		for id := range idx.Docs {
+			if _, want := set[id]; run.matchesDoc(id) != want {
+				t.Errorf("%s: matchesDoc(%d) = %v, want %v", q, id, !want, want)
+			}
+		}
+	}
+}
+
+func TestApproxSearchStopsEarly(t *testing.T) {
+	idx := buildIndex(facetDocs(200)...)
+	tests := []struct {
+		query string
+		early bool
+	}{
+		{"budget OR climate", true},
+		{`"climate report" AND NOT rates`, true},
+		{"NOT budget", false},
+		{"budget OR amount:>5", false},
+		{"lang:en", false},
+	}
+	for _, tt := range tests {
+		run := newTestRun(t, idx, tt.query)
+		docs, ok := idx.approxMatches(run, 5)
+		if ok != tt.early {
+			t.Errorf("%s: approxMatches ok = %v, want %v", tt.query, ok, tt.early)
+		}
+		if ok && len(docs) != 5 {
+			t.Errorf("%s: approxMatches found %d docs, want 5", tt.query, len(docs))
+		}
+	}
+}
+
+func BenchmarkApproxSearch(b *testing.B) {
+	idx := buildIndex(facetDocs(5000)...)
+	for _, q := range []string{"budget OR climate", `"climate report"`} {
+		b.Run(fmt.Sprintf("exact/%s", q), func(b *testing.B) {
+			for range b.N {
+				idx.search(context.Background(), q, SearchOptions{K: 10})
+			}
+		})
+		b.Run(fmt.Sprintf("approx/%s", q), func(b *testing.B) {
+			for range b.N {
+				idx.ApproxSearch(q, 10, 100)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package gonews
+
+import (
+	"container/list"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// resultCache is an LRU of search results keyed on the analyzed query, so
+// "Biden  Sanctions" and "biden sanctions" share an entry. It has its own
+// lock since searches only share the index read lock; index writes clear it.
+type resultCache struct {
+	mu      sync.Mutex
+	order   *list.List               // front is the most recently used
+	entries map[string]*list.Element // key -> element holding a *cachedSearch
+
+	hits, misses uint64 // since the index was created
+}
+
+type cachedSearch struct {
+	key     string
+	results []SearchResult
+	total   int
+}
+
+// searchCacheKey identifies a search by its analyzed query, should clause
+// and page; it's only used for searches without allow or deny lists
+func searchCacheKey(rpn, should []string, offset, k int) string {
+	return strconv.Itoa(offset) + "," + strconv.Itoa(k) + "\x00" + strings.Join(rpn, "\x00") + "\x01" + strings.Join(should, "\x00")
+}
+
+func (c *resultCache) get(key string) ([]SearchResult, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, 0, false
+	}
+	c.hits++
+	c.order.MoveToFront(e)
+	s := e.Value.(*cachedSearch)
+	return slices.Clone(s.results), s.total, true
+}
+
+// put stores results, evicting the least recently used entries past size
+func (c *resultCache) put(key string, results []SearchResult, total, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.order = list.New()
+		c.entries = make(map[string]*list.Element)
+	}
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushFront(&cachedSearch{key, slices.Clone(results), total})
+	for c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedSearch).key)
+	}
+}
+
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order, c.entries = nil, nil
+}
+
+// stats returns the hit and miss counts of get
+func (c *resultCache) stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
@@ -0,0 +1,104 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCacheInvalidatedByWrites(t *testing.T) {
+	newIdx := func() *Index {
+		idx := buildIndex(
+			Document{ID: 1, Title: "Budget", Content: "the budget vote"},
+			Document{ID: 2, Title: "Elections", Content: "an election was called"},
+		)
+		idx.CacheSize = 8
+		return idx
+	}
+	tests := []struct {
+		name  string
+		query string
+		write func(t *testing.T, idx *Index) *Index
+		want  []int
+	}{
+		{"add", "budget", func(t *testing.T, idx *Index) *Index {
+			idx.AddDocument(Document{ID: 3, Content: "budget cuts announced"})
+			return idx
+		}, []int{1, 3}},
+		{"update", "budget", func(t *testing.T, idx *Index) *Index {
+			if err := idx.UpdateDocument(Document{ID: 2, Content: "the election budget"}); err != nil {
+				t.Fatal(err)
+			}
+			return idx
+		}, []int{1, 2}},
+		{"delete", "budget", func(t *testing.T, idx *Index) *Index {
+			idx.DeleteDocument(1)
+			return idx
+		}, []int{}},
+		{"reanalyze", "budgets", func(t *testing.T, idx *Index) *Index {
+			return idx.Reanalyze(Analyzer{Stemming: true})
+		}, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := newIdx()
+			before := sortedIDs(idx.Search(tt.query))
+			// a repeat is answered from the cache
+			if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, before) {
+				t.Fatalf("cached Search(%s) = %v, want %v", tt.query, got, before)
+			}
+			if hits, _ := idx.CacheStats(); hits != 1 {
+				t.Fatalf("cache hits = %d before the write, want 1", hits)
+			}
+
+			idx = tt.write(t, idx)
+			if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%s) after %s = %v, want %v (was %v)", tt.query, tt.name, got, tt.want, before)
+			}
+			if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+				t.Errorf("repeated Search(%s) after %s = %v, want %v", tt.query, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	var c resultCache
+	page := func(id int) []SearchResult { return []SearchResult{{DocID: id}} }
+	c.put("a", page(1), 1, 2)
+	c.put("b", page(2), 1, 2)
+	// touching a leaves b the least recently used
+	if _, _, ok := c.get("a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	c.put("c", page(3), 1, 2)
+
+	for _, tt := range []struct {
+		key  string
+		want []int
+	}{
+		{"a", []int{1}},
+		{"b", nil},
+		{"c", []int{3}},
+	} {
+		results, _, ok := c.get(tt.key)
+		if ok != (tt.want != nil) || !slices.Equal(resultIDs(results), tt.want) {
+			t.Errorf("get(%s) = %v, %v, want %v", tt.key, resultIDs(results), ok, tt.want)
+		}
+	}
+	if hits, misses := c.stats(); hits != 3 || misses != 1 {
+		t.Errorf("stats() = %d hits, %d misses, want 3, 1", hits, misses)
+	}
+
+	// re-putting a key refreshes it instead of adding a second entry
+	c.put("a", page(4), 1, 2)
+	c.put("d", page(5), 1, 2)
+	if _, _, ok := c.get("c"); ok {
+		t.Error("c kept after a was re-put and d added, want evicted")
+	}
+	if results, _, _ := c.get("a"); !slices.Equal(resultIDs(results), []int{4}) {
+		t.Errorf("get(a) = %v after re-put, want [4]", resultIDs(results))
+	}
+	if n := c.order.Len(); n != 2 {
+		t.Errorf("%d entries, want 2", n)
+	}
+}
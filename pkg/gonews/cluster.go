@@ -0,0 +1,232 @@
+package gonews
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TopicCluster groups results about one topic
+type TopicCluster struct {
+	Label   []string // the terms weighing most in it, most first
+	Results []SearchResult
+}
+
+const (
+	// DefaultClusters is the cluster count used when k is 0
+	DefaultClusters = 5
+	// DefaultClusterHits is how many top hits SearchClusters groups
+	DefaultClusterHits = 200
+
+	clusterDocTerms   = 50 // heaviest terms kept per doc vector
+	clusterLabelTerms = 3
+	clusterRounds     = 20 // k-means iterations at most
+)
+
+// SearchClusters runs query and groups its DefaultClusterHits best hits
+// into k topics (see ClusterResults), so a query like "apple" separates
+// the company from the fruit. A query that doesn't parse is
+// ErrMalformedQuery.
+func (idx *Index) SearchClusters(query string, k int) ([]TopicCluster, error) {
+	results, _, err := idx.search(context.Background(), query, SearchOptions{K: DefaultClusterHits})
+	if err != nil {
+		return nil, err
+	}
+	return idx.ClusterResults(results, k), nil
+}
+
+// ClusterResults groups ranked results into at most k topics (0 means
+// DefaultClusters) by k-means over the docs' TF-IDF vectors, compared by
+// cosine similarity. The query's own terms, which every result shares,
+// are left out of the vectors. Each cluster is labelled with its heaviest
+// terms and keeps its results in rank order; clusters are ordered by their
+// best ranked result. The grouping is deterministic: the first centroid is
+// the top result with any terms left and each next one the result least
+// like those chosen. Results without terms can't seed a topic; if none
+// has any, they all form one cluster.
+func (idx *Index) ClusterResults(results []SearchResult, k int) []TopicCluster {
+	if k <= 0 {
+		k = DefaultClusters
+	}
+	k = min(k, len(results))
+	if k == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	skip := make(map[string]bool)
+	for _, r := range results {
+		for _, t := range r.MatchedTerms {
+			skip[t] = true
+		}
+	}
+	vecs := make([]sparseVec, len(results))
+	for i, r := range results {
+		vecs[i] = idx.clusterVector(r.DocID, skip)
+	}
+	idx.mu.RUnlock()
+
+	// farthest-first seeding, from the first result with terms
+	first := max(slices.IndexFunc(vecs, func(v sparseVec) bool { return len(v) > 0 }), 0)
+	centroids := []sparseVec{vecs[first]}
+	chosen := map[int]bool{first: true}
+	closest := make([]float64, len(vecs)) // similarity to the nearest centroid
+	for i, v := range vecs {
+		closest[i] = v.dot(centroids[0])
+	}
+	for len(centroids) < k {
+		far := -1
+		for i, v := range vecs {
+			if chosen[i] || len(v) == 0 {
+				continue
+			}
+			if far < 0 || closest[i] < closest[far] {
+				far = i
+			}
+		}
+		if far < 0 {
+			break
+		}
+		chosen[far] = true
+		centroids = append(centroids, vecs[far])
+		for i, v := range vecs {
+			closest[i] = max(closest[i], v.dot(vecs[far]))
+		}
+	}
+	k = len(centroids)
+
+	assign := make([]int, len(vecs))
+	for round := 0; round < clusterRounds; round++ {
+		changed := round == 0
+		for i, v := range vecs {
+			best, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if sim := v.dot(centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if assign[i] != best {
+				assign[i], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([]sparseVec, k)
+		for i, v := range vecs {
+			c := assign[i]
+			if sums[c] == nil {
+				sums[c] = make(sparseVec)
+			}
+			for t, w := range v {
+				sums[c][t] += w
+			}
+		}
+		for c := range centroids {
+			if sums[c] != nil { // an emptied cluster keeps its centroid
+				centroids[c] = sums[c].normalized()
+			}
+		}
+	}
+
+	byCluster := make([][]SearchResult, k)
+	var order []int // clusters by their best ranked result
+	for i, r := range results {
+		c := assign[i]
+		if byCluster[c] == nil {
+			order = append(order, c)
+		}
+		byCluster[c] = append(byCluster[c], r)
+	}
+	clusters := make([]TopicCluster, 0, len(order))
+	for _, c := range order {
+		clusters = append(clusters, TopicCluster{Label: centroids[c].label(), Results: byCluster[c]})
+	}
+	return clusters
+}
+
+// clusterVector is the normalized TF-IDF vector of doc id's heaviest terms
+// that some other doc shares, without those in skip. Call with idx.mu held.
+func (idx *Index) clusterVector(id int, skip map[string]bool) sparseVec {
+	d := idx.withContent(idx.Docs[id])
+	tf := make(map[string]int)
+	for _, t := range idx.Analyzer.docTokens(d) {
+		tf[t]++
+	}
+	v := make(sparseVec)
+	for t, n := range tf {
+		p, ok := idx.Terms[t]
+		if !ok || skip[t] {
+			continue
+		}
+		if df := idx.docFreq(t, p); df >= 2 {
+			v[t] = (1 + math.Log(float64(n))) * idx.idf(float64(df))
+		}
+	}
+	if len(v) > clusterDocTerms {
+		keep := make(sparseVec, clusterDocTerms)
+		for _, t := range v.top(clusterDocTerms) {
+			keep[t] = v[t]
+		}
+		v = keep
+	}
+	return v.normalized()
+}
+
+// sparseVec is a term-weight vector
+type sparseVec map[string]float64
+
+func (v sparseVec) dot(w sparseVec) float64 {
+	if len(w) < len(v) {
+		v, w = w, v
+	}
+	sum := 0.0
+	for t, x := range v {
+		sum += x * w[t]
+	}
+	return sum
+}
+
+// normalized returns v scaled to unit length (v itself when it is zero)
+func (v sparseVec) normalized() sparseVec {
+	norm := math.Sqrt(v.dot(v))
+	if norm == 0 {
+		return v
+	}
+	out := make(sparseVec, len(v))
+	for t, x := range v {
+		out[t] = x / norm
+	}
+	return out
+}
+
+// top returns v's n heaviest terms, heaviest first
+func (v sparseVec) top(n int) []string {
+	terms := make([]string, 0, len(v))
+	for t := range v {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if v[terms[i]] != v[terms[j]] {
+			return v[terms[i]] > v[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	return terms[:min(n, len(terms))]
+}
+
+// label returns a centroid's clusterLabelTerms heaviest terms, skipping
+// numbers, which make poor topic names
+func (v sparseVec) label() []string {
+	var label []string
+	for _, t := range v.top(len(v)) {
+		if strings.Trim(t, "0123456789") == "" {
+			continue
+		}
+		if label = append(label, t); len(label) == clusterLabelTerms {
+			break
+		}
+	}
+	return label
+}
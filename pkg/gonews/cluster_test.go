@@ -0,0 +1,43 @@
+package gonews
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestClusterResultsSkipsEmptyTop(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Apple"},
+		Document{ID: 2, Title: "Apple iphone sales"},
+		Document{ID: 3, Title: "Apple iphone launch"},
+		Document{ID: 4, Title: "Apple pie recipe"},
+		Document{ID: 5, Title: "Apple pie baking"},
+	)
+	results := idx.Search("apple")
+	slices.SortFunc(results, func(a, b SearchResult) int { return a.DocID - b.DocID }) // doc 1, with no terms left, on top
+	var got [][]int
+	for _, c := range idx.ClusterResults(results, 3) {
+		got = append(got, resultIDs(c.Results))
+	}
+	want := [][]int{{1, 2, 3}, {4, 5}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("clusters = %v, want %v", got, want)
+	}
+
+	// nothing to tell apart: one cluster
+	idx = buildIndex(
+		Document{ID: 1, Title: "Apple orchard"},
+		Document{ID: 2, Title: "Apple market"},
+	)
+	if clusters := idx.ClusterResults(idx.Search("apple"), 2); len(clusters) != 1 || len(clusters[0].Results) != 2 {
+		t.Errorf("clusters of docs without shared terms = %+v, want one with both", clusters)
+	}
+}
+
+func TestSearchClustersMalformedQuery(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "Budget vote"})
+	if _, err := idx.SearchClusters("_exists_:publisher", 2); !errors.Is(err, ErrMalformedQuery) {
+		t.Errorf("SearchClusters = %v, want ErrMalformedQuery", err)
+	}
+}
@@ -0,0 +1,88 @@
+package gonews
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedExtensions are the extensions of compressed inputs, dropped
+// before a path's format is told from its extension ("news.jsonl.gz" is
+// JSON Lines)
+var compressedExtensions = []string{".gz", ".zst"}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// trimCompressedExt returns path without a compression extension
+func trimCompressedExt(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, c := range compressedExtensions {
+		if ext == c {
+			return path[:len(path)-len(ext)]
+		}
+	}
+	return path
+}
+
+// openInput opens a corpus file for reading. A gzip or zstd file is
+// decompressed as it is read, whatever its name: the format is told from
+// its first bytes.
+func openInput(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(zstdMagic))
+	var r io.Reader = br
+	closeDecoder := func() {}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = zr
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r, closeDecoder = zr, zr.Close
+	}
+	return &inputFile{Reader: r, f: f, closeDecoder: closeDecoder}, nil
+}
+
+// inputFile is a possibly decompressing reader over an open file
+type inputFile struct {
+	io.Reader
+	f            *os.File
+	closeDecoder func()
+}
+
+func (in *inputFile) Close() error {
+	in.closeDecoder()
+	return in.f.Close()
+}
+
+// readInput reads the whole of a corpus file, decompressing it like
+// openInput
+func readInput(path string) ([]byte, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return io.ReadAll(in)
+}
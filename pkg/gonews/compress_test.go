@@ -0,0 +1,117 @@
+package gonews
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, b []byte) []byte {
+	t.Helper()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(b, nil)
+}
+
+func TestLoadCompressed(t *testing.T) {
+	// long enough that a truncated stream loses whole rows
+	var csv, jsonl bytes.Buffer
+	csv.WriteString("id,title,date,content\n")
+	for i := range 200 {
+		fmt.Fprintf(&csv, "%d,Budget vote %d,2024-01-02,\"The budget, voted on again\"\n", i, i)
+		fmt.Fprintf(&jsonl, `{"id": "x%d", "title": "Budget vote %d", "content": "The budget, voted on again"}`+"\n", i, i)
+	}
+	loaders := []struct {
+		name  string
+		ext   string
+		plain []byte
+		load  func(path string) ([]Document, error)
+	}{
+		{"csv", ".csv", csv.Bytes(), LoadCSV},
+		{"jsonl", ".jsonl", jsonl.Bytes(), func(path string) ([]Document, error) {
+			docs, _, err := LoadJSONL(path, JSONLFields{}, 0)
+			return docs, err
+		}},
+	}
+	compressions := []struct {
+		ext      string
+		compress func(*testing.T, []byte) []byte
+	}{
+		{".gz", gzipBytes},
+		{".zst", zstdBytes},
+	}
+	for _, l := range loaders {
+		want, err := l.load(writeFile(t, "news"+l.ext, string(l.plain)))
+		if err != nil {
+			t.Fatalf("%s: %v", l.name, err)
+		}
+		for _, c := range compressions {
+			packed := c.compress(t, l.plain)
+			got, err := l.load(writeFile(t, "news"+l.ext+c.ext, string(packed)))
+			if err != nil {
+				t.Errorf("%s%s: %v", l.name, c.ext, err)
+			} else if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s%s loaded %d docs unlike the plain file's %d", l.name, c.ext, len(got), len(want))
+			}
+			// the name doesn't matter, only the content
+			if got, err := l.load(writeFile(t, "news"+l.ext, string(packed))); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("%s%s without its extension: %d docs, error %v", l.name, c.ext, len(got), err)
+			}
+			if _, err := l.load(writeFile(t, "news"+l.ext+c.ext, string(packed[:len(packed)/2]))); err == nil {
+				t.Errorf("%s%s truncated: no error", l.name, c.ext)
+			}
+		}
+	}
+}
+
+func TestLoadDirCompressed(t *testing.T) {
+	dir := t.TempDir()
+	text := []byte("The budget vote passed.\n")
+	files := map[string][]byte{
+		"a.txt":     text,
+		"b.txt.gz":  gzipBytes(t, text),
+		"c.md.zst":  zstdBytes(t, text),
+		"d.json.gz": gzipBytes(t, text), // not a text file
+	}
+	for name, b := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docs, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, d := range docs {
+		titles = append(titles, d.Title)
+		if d.Content != string(text) {
+			t.Errorf("%s: Content = %q, want %q", d.SourceID, d.Content, text)
+		}
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %v, want %v", titles, want)
+	}
+}
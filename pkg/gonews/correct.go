@@ -0,0 +1,104 @@
+package gonews
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultCorrectionThreshold is the result count below which
+// SearchWithCorrection retries with a spelling-corrected query
+const DefaultCorrectionThreshold = 1
+
+// CorrectedSearch is a search result set that may come from a corrected query
+type CorrectedSearch struct {
+	Results   []SearchResult
+	Total     int
+	Corrected bool   // results are for Query, not the query as typed
+	Query     string // the query the results are for
+}
+
+// CorrectQuery replaces each plain query word missing from the index with
+// the closest indexed term (within autoFuzziness edits, most common on
+// ties). Operators, phrases and numeric clauses are left alone. It returns
+// the query and whether anything changed.
+func (idx *Index) CorrectQuery(query string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.correctQuery(query)
+}
+
+func (idx *Index) correctQuery(query string) (string, bool) {
+	toks, err := lexQuery(query)
+	if err != nil {
+		return query, false
+	}
+	out := query
+	changed := false
+	// replace right to left so earlier columns stay valid
+	for i := len(toks) - 1; i >= 0; i-- {
+		t := toks[i]
+		if t.text == "(" || t.text == ")" || t.text == excludeMarker || isOperator(t.text) ||
+			strings.HasPrefix(strings.TrimPrefix(t.text, "^"), `"`) || isRangeToken(t.text) || isDateRangeToken(t.text) || isWildcard(t.text) || strings.Contains(t.text, "~") {
+			continue
+		}
+		fix, ok := idx.correctWord(t.text)
+		if !ok {
+			continue
+		}
+		start := t.col - 1
+		out = out[:start] + fix + out[start+len(t.text):]
+		changed = true
+	}
+	return out, changed
+}
+
+// correctWord suggests a replacement for a single query word
+func (idx *Index) correctWord(word string) (string, bool) {
+	sub := idx.Analyzer.Tokenize(word)
+	if len(sub) != 1 {
+		return "", false
+	}
+	tok := sub[0]
+	if _, ok := idx.Terms[tok]; ok {
+		return "", false
+	}
+	cands := idx.fuzzyExpand(tok, autoFuzziness(tok))
+	if len(cands) == 0 {
+		return "", false
+	}
+	// candidates come closest first; among the closest, prefer the most common
+	best, bestDist := cands[0], editDistance(tok, cands[0])
+	for _, c := range cands[1:] {
+		if editDistance(tok, c) > bestDist {
+			break
+		}
+		if idx.Terms[c].Len() > idx.Terms[best].Len() {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// SearchWithCorrection runs query and, when it finds fewer than threshold
+// results (DefaultCorrectionThreshold if threshold <= 0), transparently
+// retries with CorrectQuery's suggestion, like a "showing results for" page.
+// The retry is only kept if it finds more.
+func (idx *Index) SearchWithCorrection(query string, threshold int) CorrectedSearch {
+	if threshold <= 0 {
+		threshold = DefaultCorrectionThreshold
+	}
+	results, total, _ := idx.search(context.Background(), query, SearchOptions{})
+	res := CorrectedSearch{Results: results, Total: total, Query: query}
+	if total >= threshold {
+		return res
+	}
+	fixed, ok := idx.CorrectQuery(query)
+	if !ok {
+		return res
+	}
+	results, total, _ = idx.search(context.Background(), fixed, SearchOptions{})
+	if total <= res.Total {
+		return res
+	}
+	return CorrectedSearch{Results: results, Total: total, Corrected: true, Query: fixed}
+}
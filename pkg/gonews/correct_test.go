@@ -0,0 +1,59 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCorrectQuery(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Parliament passes budget", Content: "The election is next spring."},
+		Document{ID: 2, Title: "Budget debate", Content: "Parliament will vote on the budget."},
+	)
+	tests := []struct {
+		query, want string
+		changed     bool
+	}{
+		{"budget", "budget", false},
+		{"budgte", "budget", true},
+		{"parliment AND budgte", "parliament AND budget", true},
+		{"budgte -elecion", "budget -election", true},
+		{`"parliment passes"`, `"parliment passes"`, false},
+		{"amount:>5", "amount:>5", false},
+		{"budg*", "budg*", false},
+		{"zzzzzz", "zzzzzz", false},
+	}
+	for _, tt := range tests {
+		got, changed := idx.CorrectQuery(tt.query)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("CorrectQuery(%q) = %q, %v; want %q, %v", tt.query, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestSearchWithCorrection(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Budget passes"},
+		Document{ID: 3, Title: "Budgie rescued"},
+	)
+	tests := []struct {
+		query     string
+		threshold int
+		want      []int
+		corrected bool
+	}{
+		{"budget", 0, []int{1, 2}, false},
+		{"budgte", 0, []int{1, 2}, true},
+		// indexed words are never corrected, even below the threshold
+		{"budgie", 5, []int{3}, false},
+		{"zzzzzz", 0, nil, false},
+	}
+	for _, tt := range tests {
+		res := idx.SearchWithCorrection(tt.query, tt.threshold)
+		if got := sortedIDs(res.Results); !slices.Equal(got, tt.want) || res.Corrected != tt.corrected {
+			t.Errorf("SearchWithCorrection(%q, %d) = %v corrected=%v, want %v corrected=%v",
+				tt.query, tt.threshold, got, res.Corrected, tt.want, tt.corrected)
+		}
+	}
+}
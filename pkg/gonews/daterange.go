@@ -0,0 +1,132 @@
+package gonews
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateRange is a parsed date:[from TO to] clause of whole days, held as
+// UTC midnights. Bounds are inclusive; a zero bound is open.
+type dateRange struct {
+	From, To time.Time
+}
+
+// contains reports whether day, a calendarDay, lies in the range
+func (r dateRange) contains(day time.Time) bool {
+	if !r.From.IsZero() && day.Before(r.From) {
+		return false
+	}
+	if !r.To.IsZero() && day.After(r.To) {
+		return false
+	}
+	return true
+}
+
+// calendarDay returns the date t was written with, in its own zone, as a
+// UTC midnight, so 2023-06-30T23:00:00-05:00 falls on 30 June
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DateRangeQuery builds a date:[from TO to] clause; an empty bound is open
+func DateRangeQuery(from, to string) string {
+	if from == "" {
+		from = "*"
+	}
+	if to == "" {
+		to = "*"
+	}
+	return "date:[" + from + " TO " + to + "]"
+}
+
+// isDateRangeToken reports whether a query token is a date:[...] clause
+func isDateRangeToken(tok string) bool {
+	return strings.HasPrefix(strings.ToLower(tok), "date:[")
+}
+
+// parseDateRangeToken parses date:[from TO to]. Either bound may be * for
+// open. Dates take any of dateLayouts; only their day counts, and docs are
+// compared by the day they are dated (see calendarDay).
+func parseDateRangeToken(tok string) (dateRange, error) {
+	if !isDateRangeToken(tok) || !strings.HasSuffix(tok, "]") {
+		return dateRange{}, fmt.Errorf("date range %q must look like date:[2023-01-01 TO 2023-06-30]", tok)
+	}
+	body := tok[len("date:[") : len(tok)-1]
+	i := strings.Index(strings.ToUpper(body), " TO ")
+	if i < 0 {
+		return dateRange{}, fmt.Errorf("date range %q is missing TO", tok)
+	}
+	bound := func(s string) (time.Time, error) {
+		s = strings.TrimSpace(s)
+		if s == "*" {
+			return time.Time{}, nil
+		}
+		t, ok := parseDate(s)
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid date %q in %s", s, tok)
+		}
+		return calendarDay(t), nil
+	}
+	var r dateRange
+	var err error
+	if r.From, err = bound(body[:i]); err != nil {
+		return r, err
+	}
+	if r.To, err = bound(body[i+len(" TO "):]); err != nil {
+		return r, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && r.To.Before(r.From) {
+		return r, fmt.Errorf("date range %s ends before it starts", tok)
+	}
+	return r, nil
+}
+
+// datedDoc is one entry of the index's date-ordered doc list
+type datedDoc struct {
+	day time.Time // calendarDay of the doc's date
+	id  int
+}
+
+// docsByDate returns the dated docs in order of their day, building the list on
+// first use after the docs changed (locking as termDictionary does)
+func (idx *Index) docsByDate() []datedDoc {
+	idx.lazyMu.Lock()
+	defer idx.lazyMu.Unlock()
+	if idx.byDate != nil {
+		return idx.byDate
+	}
+	byDate := make([]datedDoc, 0, len(idx.Docs))
+	for id, d := range idx.Docs {
+		if !d.ParsedDate.IsZero() {
+			byDate = append(byDate, datedDoc{calendarDay(d.ParsedDate), id})
+		}
+	}
+	sort.Slice(byDate, func(i, j int) bool {
+		if !byDate[i].day.Equal(byDate[j].day) {
+			return byDate[i].day.Before(byDate[j].day)
+		}
+		return byDate[i].id < byDate[j].id
+	})
+	idx.byDate = byDate
+	return byDate
+}
+
+// docsInDateRange returns the docs dated inside r; undated docs never match
+func (idx *Index) docsInDateRange(r dateRange) map[int]struct{} {
+	docs := idx.docsByDate()
+	start := 0
+	if !r.From.IsZero() {
+		start = sort.Search(len(docs), func(i int) bool { return !docs[i].day.Before(r.From) })
+	}
+	out := make(map[int]struct{})
+	for _, d := range docs[start:] {
+		if !r.contains(d.day) {
+			break
+		}
+		out[d.id] = struct{}{}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package gonews
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// dayString formats a range bound, "" when open
+func dayString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func TestParseDateRangeToken(t *testing.T) {
+	tests := []struct {
+		tok      string
+		from, to string // "" for open
+		bad      bool
+	}{
+		{"date:[2023-01-01 TO 2023-06-30]", "2023-01-01", "2023-06-30", false},
+		{"DATE:[2023-01-01 to 2023-06-30]", "2023-01-01", "2023-06-30", false},
+		{"date:[* TO 2023-06-30]", "", "2023-06-30", false},
+		{"date:[2023-01-01 TO *]", "2023-01-01", "", false},
+		{"date:[* TO *]", "", "", false},
+		// other layouts, and times, only count by their day
+		{"date:[Jan 2, 2023 TO 2023/06/30]", "2023-01-02", "2023-06-30", false},
+		{"date:[2023-01-01T23:30:00-05:00 TO 2023-06-30T01:00:00+09:00]", "2023-01-01", "2023-06-30", false},
+		{"date:[2023-06-30 TO 2023-06-30]", "2023-06-30", "2023-06-30", false},
+		{"date:[2023-13-01 TO *]", "", "", true},
+		{"date:[bogus TO 2023-06-30]", "", "", true},
+		{"date:[2023-01-01 2023-06-30]", "", "", true},
+		{"date:[2023-01-01 TO 2023-06-30", "", "", true},
+		{"date:[2023-06-30 TO 2023-01-01]", "", "", true},
+	}
+	for _, tt := range tests {
+		r, err := parseDateRangeToken(tt.tok)
+		if tt.bad {
+			if err == nil {
+				t.Errorf("parseDateRangeToken(%s) = %v, want an error", tt.tok, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDateRangeToken(%s): %v", tt.tok, err)
+			continue
+		}
+		if got := dayString(r.From); got != tt.from {
+			t.Errorf("parseDateRangeToken(%s) from %q, want %q", tt.tok, got, tt.from)
+		}
+		if got := dayString(r.To); got != tt.to {
+			t.Errorf("parseDateRangeToken(%s) to %q, want %q", tt.tok, got, tt.to)
+		}
+	}
+}
+
+func TestDateRangeSearch(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Date: "2022-12-31", Content: "budget"},
+		Document{ID: 2, Date: "2023-01-01", Content: "budget"},
+		Document{ID: 3, Date: "2023-03-15 08:00:00", Content: "budget"},
+		Document{ID: 4, Date: "2023-06-30", Content: "budget"},
+		// late on the 30th where it was written, 1 July in UTC
+		Document{ID: 5, Date: "2023-06-30T23:00:00-05:00", Content: "budget"},
+		// early on 1 July where it was written, still 30 June in UTC
+		Document{ID: 6, Date: "2023-07-01T01:00:00+09:00", Content: "budget"},
+		Document{ID: 7, Date: "2023-07-01", Content: "budget"},
+		Document{ID: 8, Content: "budget"}, // undated
+	)
+	tests := []struct {
+		from, to string
+		want     []int
+	}{
+		// both bounds are inclusive, by the day each doc is dated
+		{"2023-01-01", "2023-06-30", []int{2, 3, 4, 5}},
+		{"2023-06-30", "2023-06-30", []int{4, 5}},
+		{"2023-07-01", "2023-07-01", []int{6, 7}},
+		// open ends; undated docs are never in a range
+		{"", "2023-01-01", []int{1, 2}},
+		{"2023-07-01", "", []int{6, 7}},
+		{"", "", []int{1, 2, 3, 4, 5, 6, 7}},
+		// a bound's time and zone are dropped, leaving the day it names
+		{"2023-07-01T01:00:00+09:00", "", []int{6, 7}},
+		{"", "2023-06-30T23:00:00-05:00", []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		// the -from/-to flags become this clause
+		q := DateRangeQuery(tt.from, tt.to)
+		if got := sortedIDs(idx.Search(q)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", q, got, tt.want)
+		}
+		if got := sortedIDs(idx.Search("budget AND " + q)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(budget AND %s) = %v, want %v", q, got, tt.want)
+		}
+	}
+
+	for _, q := range []string{DateRangeQuery("2023-02-30", ""), DateRangeQuery("", "yesterday"), DateRangeQuery("2023-07-01", "2023-06-30")} {
+		if _, _, err := idx.SearchTotal(q); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("SearchTotal(%s) error = %v, want %v", q, err, ErrMalformedQuery)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gonews
+
+import "strings"
+
+// DuplicateMode selects what AddDocument does with a doc whose body text is
+// an exact copy (up to whitespace) of an indexed doc's, e.g. a wire story
+// reposted under a new ID. Docs without content are never duplicates.
+type DuplicateMode int
+
+const (
+	DuplicatesIndex DuplicateMode = iota // index every copy
+	DuplicatesSkip                       // drop later copies
+	DuplicatesMerge                      // drop later copies, remembering their IDs (see DuplicateOf)
+	DuplicatesFlag                       // index later copies but mark them (see DuplicateOf)
+)
+
+// contentHash is the FNV-1a hash of d's body text with runs of ASCII
+// whitespace collapsed to one space and trimmed; ok is false if the body is
+// blank. It hashes in place, since strings.Fields is slow on non-ASCII text.
+func contentHash(d Document) (h uint64, ok bool) {
+	const prime = 1099511628211
+	h = 14695981039346656037
+	space := false
+	for i := 0; i < len(d.Content); i++ {
+		c := d.Content[i]
+		if isASCIISpace(c) {
+			space = ok
+			continue
+		}
+		if space {
+			h = (h ^ ' ') * prime
+			space = false
+		}
+		h = (h ^ uint64(c)) * prime
+		ok = true
+	}
+	return h, ok
+}
+
+// sameContent reports whether a and b are equal up to ASCII whitespace runs,
+// confirming a contentHash match
+func sameContent(a, b string) bool {
+	return strings.Join(strings.FieldsFunc(a, isASCIISpaceRune), " ") == strings.Join(strings.FieldsFunc(b, isASCIISpaceRune), " ")
+}
+
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isASCIISpaceRune(r rune) bool {
+	return r < 0x80 && isASCIISpace(byte(r))
+}
+
+// findDuplicate returns the indexed doc whose content d copies, if any. The
+// hash table is built on first use, so it also covers docs indexed before
+// Duplicates was set or loaded with LoadIndex.
+func (idx *Index) findDuplicate(d Document) (int, bool) {
+	if idx.contentHashes == nil {
+		idx.contentHashes = make(map[uint64]int)
+		for id, doc := range idx.Docs {
+			idx.rememberContent(id, idx.withContent(doc))
+		}
+	}
+	h, ok := contentHash(d)
+	if !ok {
+		return 0, false
+	}
+	orig, ok := idx.contentHashes[h]
+	if !ok || !sameContent(idx.withContent(idx.Docs[orig]).Content, d.Content) {
+		return 0, false
+	}
+	return orig, true
+}
+
+// rememberContent records doc id's content hash unless an earlier doc
+// already holds it
+func (idx *Index) rememberContent(id int, d Document) {
+	if h, ok := contentHash(d); ok {
+		if _, held := idx.contentHashes[h]; !held {
+			idx.contentHashes[h] = id
+		}
+	}
+}
+
+// forgetContent drops the duplicate bookkeeping of a deleted doc. Its
+// lowest-ID flagged copy, if any, takes its place as the original.
+func (idx *Index) forgetContent(id int, d Document) {
+	delete(idx.dupOf, id)
+	heir, hasHeir := 0, false
+	for dup, orig := range idx.dupOf {
+		if _, indexed := idx.Docs[dup]; orig == id && indexed && (!hasHeir || dup < heir) {
+			heir, hasHeir = dup, true
+		}
+	}
+	for dup, orig := range idx.dupOf {
+		switch {
+		case orig != id:
+		case hasHeir && dup != heir:
+			idx.dupOf[dup] = heir
+		default:
+			delete(idx.dupOf, dup)
+		}
+	}
+	if idx.contentHashes == nil {
+		return
+	}
+	if h, ok := contentHash(d); ok && idx.contentHashes[h] == id {
+		if hasHeir {
+			idx.contentHashes[h] = heir
+		} else {
+			delete(idx.contentHashes, h)
+		}
+	}
+}
+
+// droppedDuplicate reports whether d, which isn't indexed, is a copy
+// AddDocument has already left out: under DuplicatesSkip any copy of an
+// indexed doc, under DuplicatesMerge one merged away under d's ID
+func (idx *Index) droppedDuplicate(d Document) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	switch idx.Duplicates {
+	case DuplicatesSkip:
+		_, dup := idx.findDuplicate(d)
+		return dup
+	case DuplicatesMerge:
+		orig, dup := idx.findDuplicate(d)
+		merged, ok := idx.dupOf[d.ID]
+		return dup && ok && merged == orig
+	}
+	return false
+}
+
+// DuplicateOf returns the doc that id duplicates, for docs merged away or
+// flagged under DuplicatesMerge and DuplicatesFlag
+func (idx *Index) DuplicateOf(id int) (int, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	orig, ok := idx.dupOf[id]
+	return orig, ok
+}
+
+// DuplicatesDropped is how many docs AddDocument has left out as
+// duplicates under DuplicatesSkip and DuplicatesMerge
+func (idx *Index) DuplicatesDropped() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.dupsDropped
+}
@@ -0,0 +1,65 @@
+package gonews
+
+import (
+	"context"
+	"sort"
+)
+
+// RankChange is a doc's position (1-based) in the old and new top-k;
+// 0 means absent from that list
+type RankChange struct {
+	DocID   int
+	OldRank int
+	NewRank int
+}
+
+// ResultDiff compares the top-k of one query on two index versions
+type ResultDiff struct {
+	Query   string
+	Entered []RankChange // in the new top-k only, by new rank
+	Left    []RankChange // in the old top-k only, by old rank
+	Moved   []RankChange // in both, at a different rank, by new rank
+}
+
+// Changed reports whether the two top-k lists differ at all
+func (d ResultDiff) Changed() bool {
+	return len(d.Entered)+len(d.Left)+len(d.Moved) > 0
+}
+
+// DiffResults runs query on both indexes and reports which docs entered,
+// left or changed rank in the top k (k <= 0 compares all results). Use it
+// to review a re-index or scoring change for relevance regressions.
+func DiffResults(oldIdx, newIdx *Index, query string, k int) ResultDiff {
+	ranks := func(idx *Index) map[int]int {
+		results, _ := idx.SearchContext(context.Background(), query, k)
+		out := make(map[int]int, len(results))
+		for i, r := range results {
+			out[r.DocID] = i + 1
+		}
+		return out
+	}
+	before, after := ranks(oldIdx), ranks(newIdx)
+
+	diff := ResultDiff{Query: query}
+	for doc, nr := range after {
+		or, ok := before[doc]
+		switch {
+		case !ok:
+			diff.Entered = append(diff.Entered, RankChange{DocID: doc, NewRank: nr})
+		case or != nr:
+			diff.Moved = append(diff.Moved, RankChange{DocID: doc, OldRank: or, NewRank: nr})
+		}
+	}
+	for doc, or := range before {
+		if _, ok := after[doc]; !ok {
+			diff.Left = append(diff.Left, RankChange{DocID: doc, OldRank: or})
+		}
+	}
+	byNew := func(s []RankChange) {
+		sort.Slice(s, func(i, j int) bool { return s[i].NewRank < s[j].NewRank })
+	}
+	byNew(diff.Entered)
+	byNew(diff.Moved)
+	sort.Slice(diff.Left, func(i, j int) bool { return diff.Left[i].OldRank < diff.Left[j].OldRank })
+	return diff
+}
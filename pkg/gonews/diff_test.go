@@ -0,0 +1,47 @@
+package gonews
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Budget vote", Content: "budget budget budget"},
+		{ID: 2, Title: "Budget passes", Content: "the budget passed"},
+		{ID: 3, Title: "Budget delayed", Content: "no vote yet"},
+	}
+	oldIdx := buildIndex(docs...)
+	tests := []struct {
+		name   string
+		change func(*Index)
+		k      int
+		want   ResultDiff
+	}{
+		{"unchanged", func(*Index) {}, 0, ResultDiff{Query: "budget"}},
+		{"entered", func(idx *Index) {
+			idx.AddDocument(Document{ID: 4, Title: "Budget budget budget", Content: "budget"})
+		}, 0, ResultDiff{Query: "budget",
+			Entered: []RankChange{{DocID: 4, NewRank: 1}},
+			Moved:   []RankChange{{DocID: 1, OldRank: 1, NewRank: 2}, {DocID: 2, OldRank: 2, NewRank: 3}, {DocID: 3, OldRank: 3, NewRank: 4}},
+		}},
+		{"left", func(idx *Index) { idx.DeleteDocument(1) }, 0, ResultDiff{Query: "budget",
+			Left:  []RankChange{{DocID: 1, OldRank: 1}},
+			Moved: []RankChange{{DocID: 2, OldRank: 2, NewRank: 1}, {DocID: 3, OldRank: 3, NewRank: 2}},
+		}},
+		{"top k only", func(idx *Index) { idx.DeleteDocument(3) }, 2, ResultDiff{Query: "budget"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newIdx := buildIndex(docs...)
+			tt.change(newIdx)
+			got := DiffResults(oldIdx, newIdx, "budget", tt.k)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffResults = %+v, want %+v", got, tt.want)
+			}
+			if got.Changed() != (len(tt.want.Entered)+len(tt.want.Left)+len(tt.want.Moved) > 0) {
+				t.Errorf("Changed() = %v", got.Changed())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package gonews
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DayCluster groups the results of one publication day
+type DayCluster struct {
+	Date    time.Time // midnight UTC of the day; zero for undated docs
+	Results []SearchResult
+}
+
+// SearchDigest runs query and groups the hits by publication day (from
+// ParsedDate) for a daily digest. Days are ordered newest first, each
+// keeping its perDay most relevant results (perDay <= 0 keeps all) in
+// SortByRank order, whatever the index's Sort. Docs without a parseable
+// date form a final cluster with a zero Date.
+func (idx *Index) SearchDigest(query string, perDay int) []DayCluster {
+	results, _, _ := idx.search(context.Background(), query, SearchOptions{})
+
+	idx.mu.RLock()
+	// results come in the index's Sort order, which may not be by relevance
+	sort.SliceStable(results, func(i, j int) bool { return SortByRank(idx, results[i], results[j]) })
+	byDay := make(map[time.Time][]SearchResult)
+	for _, r := range results {
+		day := time.Time{}
+		if pd := idx.Docs[r.DocID].ParsedDate; !pd.IsZero() {
+			day = time.Date(pd.Year(), pd.Month(), pd.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		if perDay <= 0 || len(byDay[day]) < perDay {
+			byDay[day] = append(byDay[day], r)
+		}
+	}
+	idx.mu.RUnlock()
+
+	clusters := make([]DayCluster, 0, len(byDay))
+	for day, rs := range byDay {
+		clusters = append(clusters, DayCluster{Date: day, Results: rs})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		a, b := clusters[i].Date, clusters[j].Date
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero() // undated last
+		}
+		return a.After(b)
+	})
+	return clusters
+}
@@ -0,0 +1,50 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearchDigest(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Date: "2024-03-01", Content: "budget"},
+		Document{ID: 2, Date: "2024-03-01", Content: "budget budget talks"},
+		Document{ID: 3, Date: "2024-03-03", Content: "budget vote"},
+		Document{ID: 4, Date: "2024-03-02T08:00:00Z", Content: "budget news in the morning"},
+		Document{ID: 5, Date: "2024-03-02T21:30:00Z", Content: "budget"},
+		Document{ID: 6, Date: "2024-03-01", Content: "budget draft circulated among ministers today"},
+		Document{ID: 7, Date: "soon", Content: "budget"},
+		Document{ID: 8, Date: "2024-03-03", Content: "weather"},
+	)
+	tests := []struct {
+		perDay int
+		days   []string
+		ids    [][]int
+	}{
+		{0, []string{"2024-03-03", "2024-03-02", "2024-03-01", ""}, [][]int{{3}, {5, 4}, {1, 2, 6}, {7}}},
+		{2, []string{"2024-03-03", "2024-03-02", "2024-03-01", ""}, [][]int{{3}, {5, 4}, {1, 2}, {7}}},
+	}
+	for _, sorted := range []bool{false, true} {
+		// a date Sort doesn't change the order within a day
+		idx.Sort = nil
+		if sorted {
+			idx.Sort = SortByDateAsc
+		}
+		for _, tt := range tests {
+			clusters := idx.SearchDigest("budget", tt.perDay)
+			var days []string
+			var ids [][]int
+			for _, c := range clusters {
+				day := ""
+				if !c.Date.IsZero() {
+					day = c.Date.Format("2006-01-02")
+				}
+				days = append(days, day)
+				ids = append(ids, resultIDs(c.Results))
+			}
+			if !slices.Equal(days, tt.days) || !slices.EqualFunc(ids, tt.ids, slices.Equal) {
+				t.Errorf("SearchDigest(budget, %d) with Sort set %v = %v %v, want %v %v", tt.perDay, sorted, days, ids, tt.days, tt.ids)
+			}
+		}
+	}
+}
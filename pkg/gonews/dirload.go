@@ -0,0 +1,87 @@
+package gonews
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// file extensions LoadDir indexes (lowercase)
+var dirExtensions = []string{".txt", ".md"}
+
+// LoadDir walks a directory tree and loads each .txt and .md file (also
+// when gzip or zstd compressed, as .txt.gz) as a Document: the file name
+// without its extensions is the title, the modification day the date and
+// the text the content. The path relative to dir is kept in SourceID, since
+// titles needn't be unique. Files are read in lexical path order and
+// numbered from 0; hidden files and directories (starting with '.') are
+// skipped. A tree without any such files is ErrEmptyCorpus.
+func LoadDir(dir string) ([]Document, error) {
+	return loadDir(dir, 0)
+}
+
+func loadDir(dir string, maxDocs int) ([]Document, error) {
+	var docs []Document
+	err := filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if maxDocs > 0 && len(docs) >= maxDocs {
+			return fs.SkipAll
+		}
+		if path != dir && strings.HasPrefix(e.Name(), ".") {
+			if e.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !e.Type().IsRegular() || !isDirDocPath(path) {
+			return nil
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		b, err := readInput(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		name := trimCompressedExt(e.Name())
+		docs = append(docs, Document{
+			ID:       len(docs),
+			Title:    strings.TrimSuffix(name, filepath.Ext(name)),
+			Date:     info.ModTime().Format("2006-01-02"),
+			Content:  string(b),
+			SourceID: filepath.ToSlash(rel),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s: %w", dir, ErrEmptyCorpus)
+	}
+	return docs, nil
+}
+
+// isDirDocPath reports whether LoadDir indexes the file at path
+func isDirDocPath(path string) bool {
+	return slices.Contains(dirExtensions, strings.ToLower(filepath.Ext(trimCompressedExt(path))))
+}
+
+// DirLoader loads the text files under a directory (see LoadDir)
+type DirLoader struct {
+	Path    string
+	MaxDocs int // 0 = all
+}
+
+func (l *DirLoader) Load() ([]Document, error) {
+	return loadDir(l.Path, l.MaxDocs)
+}
@@ -0,0 +1,8 @@
+// Package gonews is a full-text search engine for news articles: loaders
+// (CSV, JSON API), an analyzer, an in-memory inverted index with TF-IDF or
+// BM25 scoring, a boolean/phrase query parser and an HTTP server. The
+// gonews command in cmd/gonews is a thin CLI over it.
+//
+// An Index may be searched and updated from many goroutines at once; see
+// Index for exactly what is synchronized.
+package gonews
@@ -0,0 +1,320 @@
+package gonews
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Embedder turns texts into embedding vectors, one per text in order, all
+// of the same length. Texts with similar meaning should get vectors with a
+// high cosine similarity.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+const (
+	// DefaultEmbedBatch is how many docs EmbedDocuments sends to the
+	// Embedder at once
+	DefaultEmbedBatch = 32
+
+	maxEmbedRunes = 8000 // longer docs are embedded by their start
+)
+
+// EmbedDocuments computes embeddings with Embedder for the indexed docs
+// that have none in Vectors yet, or one computed from text they no longer
+// have (creating Vectors if nil), in batches of DefaultEmbedBatch, and
+// returns how many it embedded. Each doc is embedded from its title,
+// summary and the start of its content. When ctx is done it stops, keeping
+// the batches finished so far.
+func (idx *Index) EmbedDocuments(ctx context.Context) (int, error) {
+	if idx.Embedder == nil {
+		return 0, ErrNoVectors
+	}
+	if idx.Vectors == nil {
+		idx.Vectors = NewVectorIndex()
+	}
+	idx.mu.RLock()
+	var ids []int
+	for id, d := range idx.Docs {
+		_, text, ok := idx.Vectors.get(id)
+		if !ok || text != 0 && text != embedHash(idx.Analyzer.embedText(idx.withContent(d))) {
+			ids = append(ids, id)
+		}
+	}
+	idx.mu.RUnlock()
+	sort.Ints(ids)
+	return idx.embedDocs(ctx, ids)
+}
+
+// embedDocs embeds the docs ids in batches, skipping any no longer
+// indexed, and returns how many it embedded
+func (idx *Index) embedDocs(ctx context.Context, ids []int) (int, error) {
+	added := 0
+	for len(ids) > 0 {
+		if err := ctx.Err(); err != nil {
+			return added, err
+		}
+		var batch []int
+		var texts []string
+		for len(ids) > 0 && len(batch) < DefaultEmbedBatch {
+			if d, ok := idx.Doc(ids[0]); ok {
+				batch = append(batch, ids[0])
+				texts = append(texts, idx.Analyzer.embedText(d))
+			}
+			ids = ids[1:]
+		}
+		if len(batch) == 0 {
+			break
+		}
+		vecs, err := idx.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return added, err
+		}
+		if len(vecs) != len(batch) {
+			return added, fmt.Errorf("embedder returned %d embeddings for %d texts", len(vecs), len(batch))
+		}
+		for i, id := range batch {
+			if err := idx.Vectors.add(id, vecs[i], embedHash(texts[i])); err != nil {
+				return added, err
+			}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// CopyVectors gives idx the embeddings in from's Vectors of the docs whose
+// embedded text is the same in both (creating Vectors if nil), so an index
+// rebuilt from the source only needs the new and changed docs embedded. It
+// returns how many it copied.
+func (idx *Index) CopyVectors(from *Index) int {
+	if from.Vectors == nil || from == idx {
+		return 0
+	}
+	if idx.Vectors == nil {
+		idx.Vectors = NewVectorIndex()
+	}
+	from.mu.RLock()
+	defer from.mu.RUnlock()
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	copied := 0
+	for id, d := range idx.Docs {
+		vec, text, ok := from.Vectors.get(id)
+		if !ok {
+			continue
+		}
+		want := idx.Analyzer.embedText(idx.withContent(d))
+		switch old, indexed := from.Docs[id]; {
+		case text != 0:
+			ok = text == embedHash(want)
+		case !indexed || from.StoredContent[id] && from.Store == idx.Store:
+			// the shared store already holds the new body
+			ok = false
+		default:
+			ok = from.Analyzer.embedText(from.withContent(old)) == want
+		}
+		if ok && idx.Vectors.add(id, vec, embedHash(want)) == nil {
+			copied++
+		}
+	}
+	return copied
+}
+
+// vectorFits reports whether doc id's embedding, computed for the indexed
+// old, is still right for d replacing it
+func (idx *Index) vectorFits(id int, old, d Document) bool {
+	_, text, ok := idx.Vectors.get(id)
+	if !ok {
+		return true
+	}
+	want := idx.Analyzer.embedText(d)
+	if text != 0 {
+		return text == embedHash(want)
+	}
+	return idx.Analyzer.embedText(idx.withContent(old)) == want
+}
+
+// embedText is the text of d an embedding is computed from
+func (a Analyzer) embedText(d Document) string {
+	text := strings.Join(strings.Fields(a.docText(d)), " ")
+	if utf8.RuneCountInString(text) > maxEmbedRunes {
+		text = string([]rune(text)[:maxEmbedRunes])
+	}
+	return text
+}
+
+// embedHash identifies the text an embedding was computed from; never 0,
+// which stands for unknown
+func embedHash(text string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, text)
+	return max(h.Sum64(), 1)
+}
+
+// WordVectors is an Embedder that averages pretrained word vectors (GloVe,
+// fastText, word2vec in text form) over a text's words. Words missing from
+// the vocabulary and the built-in English stopwords are skipped; a text with no known words
+// embeds as a zero vector, which is similar to nothing.
+type WordVectors struct {
+	dim   int
+	words map[string][]float32
+}
+
+// LoadWordVectors reads word vectors in the common text format: one word per
+// line followed by its components, separated by spaces. A leading
+// "count dim" header line, as word2vec and fastText write, is skipped. Only
+// lowercase words are kept (the first spelling seen wins), since text is
+// lowercased before lookup. The file may be gzip or zstd compressed.
+func LoadWordVectors(path string) (*WordVectors, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	wv := &WordVectors{words: make(map[string][]float32)}
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		fields := strings.Fields(string(b))
+		switch {
+		case len(fields) == 0:
+		case line == 1 && len(fields) == 2:
+			// word2vec header
+		default:
+			word := strings.ToLower(fields[0])
+			if _, seen := wv.words[word]; !seen {
+				vec, perr := parseVector(fields[1:])
+				if perr != nil {
+					return nil, fmt.Errorf("%s:%d: %w", path, line, perr)
+				}
+				if wv.dim == 0 {
+					wv.dim = len(vec)
+				}
+				if len(vec) != wv.dim {
+					return nil, fmt.Errorf("%s:%d: %d dimensions, want %d", path, line, len(vec), wv.dim)
+				}
+				wv.words[word] = vec
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(wv.words) == 0 {
+		return nil, fmt.Errorf("%s: no word vectors", path)
+	}
+	return wv, nil
+}
+
+func parseVector(fields []string) ([]float32, error) {
+	vec := make([]float32, len(fields))
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, err
+		}
+		vec[i] = float32(x)
+	}
+	return vec, nil
+}
+
+// Dim returns the length of the word vectors
+func (wv *WordVectors) Dim() int { return wv.dim }
+
+// Embed averages the unit-length vectors of each text's known words
+func (wv *WordVectors) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		sum := make([]float32, wv.dim)
+		for _, w := range uniWordRE.FindAllString(text, -1) {
+			w = strings.ToLower(normalizeApostrophes(w))
+			if defaultStopwords[w] {
+				continue
+			}
+			if unit := normalize(wv.words[w]); unit != nil {
+				for j, x := range unit {
+					sum[j] += x
+				}
+			}
+		}
+		out[i] = sum
+	}
+	return out, nil
+}
+
+// HTTPEmbedder is an Embedder calling an OpenAI-compatible embeddings API
+// (POST {"model": ..., "input": [...]}, answered with
+// {"data": [{"index": 0, "embedding": [...]}, ...]}), which OpenAI, Ollama,
+// vLLM and llama.cpp's server all speak.
+type HTTPEmbedder struct {
+	URL    string // e.g. http://localhost:11434/v1/embeddings
+	Model  string
+	APIKey string       // sent as a bearer token when set
+	Client *http.Client // nil means one with a 60 second timeout
+}
+
+// Embed sends texts in one request
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": e.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embeddings API: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("embeddings API: %w", err)
+	}
+	vecs := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings API: index %d out of range", d.Index)
+		}
+		vecs[d.Index] = d.Embedding
+	}
+	for i, v := range vecs {
+		if v == nil {
+			return nil, fmt.Errorf("embeddings API: no embedding for input %d", i)
+		}
+	}
+	return vecs, nil
+}
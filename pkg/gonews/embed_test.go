@@ -0,0 +1,135 @@
+package gonews
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// countingEmbedder embeds a text as [1, its length] and records the texts
+type countingEmbedder struct{ texts []string }
+
+func (e *countingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.texts = append(e.texts, texts...)
+	vecs := make([][]float32, len(texts))
+	for i, t := range texts {
+		vecs[i] = []float32{1, float32(len(t))}
+	}
+	return vecs, nil
+}
+
+func TestEmbeddingsFollowDocs(t *testing.T) {
+	emb := &countingEmbedder{}
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Storm warning"},
+	)
+	idx.Embedder = emb
+	if n, err := idx.EmbedDocuments(context.Background()); err != nil || n != 2 {
+		t.Fatalf("EmbedDocuments = %d, %v, want 2", n, err)
+	}
+
+	idx.AddDocument(Document{ID: 1, Title: "Budget vote", Date: "2024-01-02"}) // same text
+	idx.AddDocument(Document{ID: 2, Title: "Storm warning lifted"})
+	if !idx.Vectors.Has(1) || idx.Vectors.Has(2) {
+		t.Errorf("after updates: has 1 %v, has 2 %v; want true, false", idx.Vectors.Has(1), idx.Vectors.Has(2))
+	}
+	idx.DeleteDocument(1)
+	if idx.Vectors.Has(1) {
+		t.Error("deleted doc kept its embedding")
+	}
+
+	emb.texts = nil
+	if n, _ := idx.EmbedDocuments(context.Background()); n != 1 || !slices.Equal(emb.texts, []string{"Storm warning lifted"}) {
+		t.Errorf("re-embedded %d docs %q, want the changed one", n, emb.texts)
+	}
+
+	// a saved embedding remembers its text, so a changed doc is redone
+	path := filepath.Join(t.TempDir(), "vectors.jsonl")
+	if err := idx.Vectors.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	fresh := buildIndex(Document{ID: 2, Title: "Storm warning over"})
+	fresh.Embedder = emb
+	var err error
+	if fresh.Vectors, err = LoadVectors(path); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := fresh.EmbedDocuments(context.Background()); n != 1 {
+		t.Errorf("embedded %d docs after loading stale embeddings, want 1", n)
+	}
+}
+
+func TestCopyVectors(t *testing.T) {
+	old := buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Storm warning"},
+	)
+	old.Embedder = &countingEmbedder{}
+	if _, err := old.EmbedDocuments(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fresh := buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Storm warning lifted"},
+		Document{ID: 3, Title: "Election called"},
+	)
+	if n := fresh.CopyVectors(old); n != 1 {
+		t.Errorf("copied %d embeddings, want 1", n)
+	}
+	if !fresh.Vectors.Has(1) || fresh.Vectors.Has(2) || fresh.Vectors.Has(3) {
+		t.Error("only the unchanged doc should keep its embedding")
+	}
+}
+
+func TestServerEmbedsWrites(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "Budget vote"})
+	idx.Embedder = &countingEmbedder{}
+	if _, err := idx.EmbedDocuments(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(idx)
+	if _, err := s.AddDocument(Document{ID: 2, Title: "Storm warning"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateDocument(Document{ID: 1, Title: "Budget vote passed"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{1, 2} {
+		if !idx.Vectors.Has(id) {
+			t.Errorf("doc %d written through the server has no embedding", id)
+		}
+	}
+}
+
+func TestHybridSearchKeepsOperators(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Apple harvest", Author: "Ann"},
+		Document{ID: 2, Title: "Fruit prices", Author: "Bob"},
+		Document{ID: 3, Title: "Orchard tour", Author: "Cy"},
+		Document{ID: 4, Title: "Cider season"},
+	)
+	idx.Embedder = &countingEmbedder{}
+	if _, err := idx.EmbedDocuments(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"apple", []int{1, 2, 3, 4}},
+		{"apple -fruit", []int{1, 3, 4}},
+		{"apple _exists_:author", []int{1, 2, 3}},
+		{"apple _exists_:author -fruit", []int{1, 3}},
+	}
+	for _, tt := range tests {
+		results, err := idx.HybridSearch(context.Background(), tt.query, 0)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.query, err)
+		}
+		if got := sortedIDs(results); !slices.Equal(got, tt.want) {
+			t.Errorf("%q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
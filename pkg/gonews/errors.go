@@ -0,0 +1,32 @@
+package gonews
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds returned by the package; test for them with errors.Is.
+// A missing input file surfaces as fs.ErrNotExist from os.
+var (
+	ErrEmptyCorpus     = errors.New("no documents loaded")
+	ErrMalformedQuery  = errors.New("malformed query")
+	ErrDocNotFound     = errors.New("document not found")
+	ErrInvalidIndex    = errors.New("invalid index")
+	ErrTooManySearches = errors.New("too many concurrent searches") // Server.MaxConcurrentSearches reached
+)
+
+// QueryError is a syntax error in a query, at a 1-based byte column.
+// It matches ErrMalformedQuery.
+type QueryError struct {
+	Col int
+	Msg string
+}
+
+func (e *QueryError) Error() string { return e.Msg }
+
+func (e *QueryError) Unwrap() error { return ErrMalformedQuery }
+
+// queryErrorf builds a QueryError; the message should name the column
+func queryErrorf(col int, format string, args ...any) error {
+	return &QueryError{Col: col, Msg: fmt.Sprintf(format, args...)}
+}
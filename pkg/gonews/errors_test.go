@@ -0,0 +1,94 @@
+package gonews
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "Budget vote"})
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"empty csv", func() error {
+			_, err := LoadCSV(writeFile(t, "news.csv", "id,title,date,content\n"))
+			return err
+		}, ErrEmptyCorpus},
+		{"missing csv", func() error {
+			_, err := LoadCSV(filepath.Join(t.TempDir(), "missing.csv"))
+			return err
+		}, fs.ErrNotExist},
+		{"bad query", func() error { return ValidateQuery("budget AND") }, ErrMalformedQuery},
+		{"SearchTotal (budget", func() error {
+			_, _, err := idx.SearchTotal("(budget")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal budget AND", func() error {
+			_, _, err := idx.SearchTotal("budget AND")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal budget OR OR vote", func() error {
+			_, _, err := idx.SearchTotal("budget OR OR vote")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal date:[2020", func() error {
+			_, _, err := idx.SearchTotal("date:[2020")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal date:[bogus TO 2023]", func() error {
+			_, _, err := idx.SearchTotal("date:[bogus TO 2023]")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal date:[2023-06-30 TO 2023-01-01]", func() error {
+			_, _, err := idx.SearchTotal("date:[2023-06-30 TO 2023-01-01]")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal amount:>", func() error {
+			_, _, err := idx.SearchTotal("amount:>")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchTotal lang:", func() error {
+			_, _, err := idx.SearchTotal("lang:")
+			return err
+		}, ErrMalformedQuery},
+		{"SearchWithOptions bad should", func() error {
+			_, _, err := idx.SearchWithOptions(context.Background(), "budget", SearchOptions{Should: "vote AND"})
+			return err
+		}, ErrMalformedQuery},
+		{"update unknown", func() error { return idx.UpdateDocument(Document{ID: 9}) }, ErrDocNotFound},
+		{"highlight unknown", func() error {
+			_, err := idx.Highlight("budget", 9)
+			return err
+		}, ErrDocNotFound},
+		{"corrupt index", func() error {
+			_, err := LoadIndex(writeFile(t, "index.gob", "not an index"))
+			return err
+		}, ErrInvalidIndex},
+		{"merge with itself", func() error {
+			_, err := MergeIndexes(idx, idx)
+			return err
+		}, ErrInvalidIndex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.err(); !errors.Is(err, tt.want) {
+				t.Errorf("error %v is not %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrorColumn(t *testing.T) {
+	var qe *QueryError
+	if err := ValidateQuery("budget AND (vote"); !errors.As(err, &qe) {
+		t.Fatalf("ValidateQuery error %v is not a *QueryError", err)
+	}
+	if qe.Col < 1 {
+		t.Errorf("column %d, want a 1-based column", qe.Col)
+	}
+}
@@ -0,0 +1,212 @@
+package gonews
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultEvalDepth is how many results per topic Evaluate ranks when depth
+// is 0, as trec_eval's usual cutoff
+const DefaultEvalDepth = 1000
+
+// Qrels are relevance judgments: topic -> document -> grade, where a grade
+// above 0 is relevant. Documents are named by SourceID when the doc has
+// one, else by ID.
+type Qrels map[string]map[string]int
+
+// Topic is one evaluation query
+type Topic struct {
+	ID    string
+	Query string
+}
+
+// LoadQrels reads a TREC qrels file: "topic iteration docno relevance"
+// lines, the iteration being ignored
+func LoadQrels(path string) (Qrels, error) {
+	qrels := make(Qrels)
+	err := readEvalLines(path, func(line string) error {
+		f := strings.Fields(line)
+		if len(f) != 4 {
+			return fmt.Errorf("want 4 fields, got %d", len(f))
+		}
+		grade, err := strconv.Atoi(f[3])
+		if err != nil {
+			return fmt.Errorf("relevance %q is not an integer", f[3])
+		}
+		if qrels[f[0]] == nil {
+			qrels[f[0]] = make(map[string]int)
+		}
+		qrels[f[0]][f[2]] = grade
+		return nil
+	})
+	return qrels, err
+}
+
+// LoadTopics reads a query set: one "topic query text" line per topic,
+// the topic ID being the first word
+func LoadTopics(path string) ([]Topic, error) {
+	var topics []Topic
+	err := readEvalLines(path, func(line string) error {
+		f := strings.Fields(line)
+		if len(f) < 2 {
+			return fmt.Errorf("want a topic ID and a query")
+		}
+		topics = append(topics, Topic{ID: f[0], Query: strings.TrimSpace(line[len(f[0]):])})
+		return nil
+	})
+	return topics, err
+}
+
+// readEvalLines calls fn with each line of path that isn't blank or a #
+// comment, adding the line number to fn's errors
+func readEvalLines(path string, fn func(line string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// TopicEval is how one topic's ranking scored
+type TopicEval struct {
+	Topic     string  `json:"topic"`
+	Retrieved int     `json:"retrieved"` // results ranked, at most the depth
+	Relevant  int     `json:"relevant"`  // judged relevant docs
+	Precision float64 `json:"precision"` // relevant share of the top K
+	Recall    float64 `json:"recall"`    // share of the relevant docs retrieved
+	AP        float64 `json:"ap"`        // average precision
+	NDCG      float64 `json:"ndcg"`      // normalized discounted cumulative gain at K
+}
+
+// EvalReport is Evaluate's result: per-topic scores and their means (MAP
+// is the mean AP)
+type EvalReport struct {
+	K       int         `json:"k"`
+	Depth   int         `json:"depth"`
+	Topics  []TopicEval `json:"topics,omitempty"`
+	Mean    TopicEval   `json:"mean"`              // Topic is "all"
+	Skipped []string    `json:"skipped,omitempty"` // topics without any relevant judgment
+}
+
+// Evaluate runs each topic's query and scores the top depth results
+// (DefaultEvalDepth if 0) against qrels: precision and nDCG at k, recall,
+// and average precision. Topics without a relevant judgment are skipped,
+// as trec_eval does. Grades weigh nDCG as 2^grade - 1. A topic whose query
+// doesn't parse fails the whole run rather than scoring as no hits.
+func (idx *Index) Evaluate(topics []Topic, qrels Qrels, k, depth int) (EvalReport, error) {
+	if depth <= 0 {
+		depth = DefaultEvalDepth
+	}
+	report := EvalReport{K: k, Depth: depth, Mean: TopicEval{Topic: "all"}}
+	for _, t := range topics {
+		judged := qrels[t.ID]
+		relevant := 0
+		for _, g := range judged {
+			if g > 0 {
+				relevant++
+			}
+		}
+		if relevant == 0 {
+			report.Skipped = append(report.Skipped, t.ID)
+			continue
+		}
+		// the engine reads a malformed query leniently, so check it first
+		if err := ValidateQuery(t.Query); err != nil {
+			return EvalReport{}, fmt.Errorf("topic %s: %w", t.ID, err)
+		}
+		results, _, err := idx.SearchPage(t.Query, 0, depth)
+		if err != nil {
+			return EvalReport{}, fmt.Errorf("topic %s: %w", t.ID, err)
+		}
+		grades := make([]int, len(results))
+		for i, r := range results {
+			d, _ := idx.Doc(r.DocID)
+			grades[i] = judged[evalDocName(d)]
+		}
+		e := scoreRanking(grades, judged, relevant, k)
+		e.Topic = t.ID
+		report.Topics = append(report.Topics, e)
+	}
+	if n := float64(len(report.Topics)); n > 0 {
+		m := &report.Mean
+		for _, e := range report.Topics {
+			m.Retrieved += e.Retrieved
+			m.Relevant += e.Relevant
+			m.Precision += e.Precision / n
+			m.Recall += e.Recall / n
+			m.AP += e.AP / n
+			m.NDCG += e.NDCG / n
+		}
+	}
+	return report, nil
+}
+
+// evalDocName is how qrels name d
+func evalDocName(d Document) string {
+	if d.SourceID != "" {
+		return d.SourceID
+	}
+	return strconv.Itoa(d.ID)
+}
+
+// scoreRanking computes a topic's metrics from the grades of its ranked
+// results
+func scoreRanking(grades []int, judged map[string]int, relevant, k int) TopicEval {
+	e := TopicEval{Retrieved: len(grades), Relevant: relevant}
+	found := 0
+	dcg := 0.0
+	for i, g := range grades {
+		if g <= 0 {
+			continue
+		}
+		found++
+		e.AP += float64(found) / float64(i+1)
+		if i < k {
+			e.Precision++
+			dcg += discountedGain(g, i)
+		}
+	}
+	e.AP /= float64(relevant)
+	e.Recall = float64(found) / float64(relevant)
+	if k > 0 {
+		e.Precision /= float64(k)
+	}
+	// the ideal ranking puts the highest grades first
+	ideal := make([]int, 0, len(judged))
+	for _, g := range judged {
+		if g > 0 {
+			ideal = append(ideal, g)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ideal)))
+	idcg := 0.0
+	for i, g := range ideal[:min(k, len(ideal))] {
+		idcg += discountedGain(g, i)
+	}
+	if idcg > 0 {
+		e.NDCG = dcg / idcg
+	}
+	return e
+}
+
+// discountedGain is the gain of a result of the given grade at rank i
+// (from 0)
+func discountedGain(grade, i int) float64 {
+	return (math.Exp2(float64(grade)) - 1) / math.Log2(float64(i+2))
+}
@@ -0,0 +1,119 @@
+package gonews
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestScoreRanking(t *testing.T) {
+	log3 := math.Log2(3)
+	tests := []struct {
+		name     string
+		grades   []int // of the ranked results, 0 for unjudged
+		judged   map[string]int
+		relevant int
+		k        int
+		want     TopicEval
+	}{
+		{
+			name:   "binary",
+			grades: []int{1, 0, 1, 0},
+			judged: map[string]int{"a": 1, "b": 1, "c": 1, "x": 0},
+			// b and c at ranks 1 and 3, a never retrieved
+			relevant: 3, k: 3,
+			want: TopicEval{
+				Retrieved: 4, Relevant: 3,
+				Precision: 2.0 / 3, Recall: 2.0 / 3,
+				AP:   (1 + 2.0/3) / 3,
+				NDCG: (1 + 1.0/2) / (1 + 1/log3 + 1.0/2),
+			},
+		},
+		{
+			name:     "graded",
+			grades:   []int{1, 3, 0},
+			judged:   map[string]int{"a": 3, "b": 1, "c": 2},
+			relevant: 3, k: 2,
+			// gains 2^g - 1: the ideal top 2 are grades 3 and 2
+			want: TopicEval{
+				Retrieved: 3, Relevant: 3,
+				Precision: 1, Recall: 2.0 / 3,
+				AP:   (1 + 1) / 3.0,
+				NDCG: (1 + 7/log3) / (7 + 3/log3),
+			},
+		},
+		{
+			name:     "relevant doc below k after unjudged ones",
+			grades:   []int{0, 0, 2},
+			judged:   map[string]int{"a": 2, "b": 0},
+			relevant: 1, k: 2,
+			want: TopicEval{Retrieved: 3, Relevant: 1, Recall: 1, AP: 1.0 / 3},
+		},
+		{
+			name:     "perfect ranking shorter than k",
+			grades:   []int{2, 1},
+			judged:   map[string]int{"a": 2, "b": 1},
+			relevant: 2, k: 5,
+			want: TopicEval{Retrieved: 2, Relevant: 2, Precision: 2.0 / 5, Recall: 1, AP: 1, NDCG: 1},
+		},
+		{
+			name:     "nothing retrieved",
+			judged:   map[string]int{"a": 1, "b": 1},
+			relevant: 2, k: 10,
+			want: TopicEval{Relevant: 2},
+		},
+	}
+	close := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		got := scoreRanking(tt.grades, tt.judged, tt.relevant, tt.k)
+		if got.Retrieved != tt.want.Retrieved || got.Relevant != tt.want.Relevant ||
+			!close(got.Precision, tt.want.Precision) || !close(got.Recall, tt.want.Recall) ||
+			!close(got.AP, tt.want.AP) || !close(got.NDCG, tt.want.NDCG) {
+			t.Errorf("%s: scoreRanking = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, SourceID: "ap-1", Title: "Budget vote", Content: "the budget vote passed"},
+		Document{ID: 2, Title: "Budget", Content: "budget talks stall"},
+		Document{ID: 3, SourceID: "ap-3", Title: "Weather", Content: "rain and a budget for flood defences"},
+	)
+	qrels := Qrels{
+		// docs are named by SourceID, or by ID without one
+		"t1":   {"ap-1": 2, "2": 0, "ap-3": 1},
+		"none": {"ap-1": 0},
+	}
+	topics := []Topic{
+		{ID: "t1", Query: "budget"},
+		{ID: "none", Query: "budget"},
+		{ID: "unjudged", Query: "rain"},
+	}
+	report, err := idx.Evaluate(topics, qrels, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Depth != DefaultEvalDepth {
+		t.Errorf("Depth = %d, want %d", report.Depth, DefaultEvalDepth)
+	}
+	if want := []string{"none", "unjudged"}; !slices.Equal(report.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", report.Skipped, want)
+	}
+	if len(report.Topics) != 1 {
+		t.Fatalf("scored %d topics, want 1", len(report.Topics))
+	}
+	e := report.Topics[0]
+	if e.Topic != "t1" || e.Retrieved != 3 || e.Relevant != 2 || e.Recall != 1 {
+		t.Errorf("t1 = %+v, want 3 retrieved, 2 relevant, recall 1", e)
+	}
+	if report.Mean.Topic != "all" || report.Mean.AP != e.AP || report.Mean.NDCG != e.NDCG {
+		t.Errorf("Mean = %+v, want t1's scores", report.Mean)
+	}
+
+	_, err = idx.Evaluate([]Topic{{ID: "bad", Query: "budget AND"}}, Qrels{"bad": {"ap-1": 1}}, 2, 0)
+	if !errors.Is(err, ErrMalformedQuery) {
+		t.Errorf("Evaluate with a malformed query: error %v, want %v", err, ErrMalformedQuery)
+	}
+}
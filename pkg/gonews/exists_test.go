@@ -0,0 +1,55 @@
+package gonews
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestExistsFilter(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote", Author: "A. Reporter", Content: "Parliament votes."},
+		Document{ID: 2, Title: "Budget passes", Summary: "It passed", Content: "Finally."},
+		Document{ID: 3, Title: "Storm warning", Author: "B. Writer", Summary: "Winds", Content: "Take care."},
+		Document{ID: 4, Title: "Untitled budget", Author: "  "},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"_exists_:author", []int{1, 3}},
+		{"_exists_:summary", []int{2, 3}},
+		{"budget AND _exists_:author", []int{1}},
+		{"budget AND NOT _exists_:author", []int{2, 4}},
+		{"_exists_:author AND _exists_:summary", []int{3}},
+		{"_EXISTS_:Author", []int{1, 3}},
+		{"_exists_:content", []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestExistsUnknownField(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "Budget vote", Author: "A. Reporter"})
+	for _, q := range []string{"_exists_:publisher", "budget AND _exists_:byline"} {
+		if err := ValidateQuery(q); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("ValidateQuery(%s) = %v, want ErrMalformedQuery", q, err)
+		}
+		if _, _, err := idx.SearchWithOptions(context.Background(), q, SearchOptions{}); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("SearchWithOptions(%s) = %v, want ErrMalformedQuery", q, err)
+		}
+		if _, _, err := idx.SearchWithOptions(context.Background(), "budget", SearchOptions{Should: q}); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("Should %s: error %v, want ErrMalformedQuery", q, err)
+		}
+		if _, err := idx.Highlight(q, 1); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("Highlight(%s) = %v, want ErrMalformedQuery", q, err)
+		}
+	}
+	if rec := serve(NewServer(idx).Handler(), "GET", "/search?q=_exists_:publisher", ""); rec.Code != 400 {
+		t.Errorf("GET /search?q=_exists_:publisher: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package gonews
+
+import (
+	"context"
+	"sort"
+)
+
+// FacetCount is how many matches share one facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets counts every match of a search, not just the returned page, by
+// publication month and by Source. Like the total, it leaves out results
+// folded away by CollapseSimilar. Undated docs are left out of Months and
+// docs without a Source out of Sources.
+type Facets struct {
+	Months  []FacetCount `json:"months"`  // "2006-01", newest first
+	Sources []FacetCount `json:"sources"` // most matches first, ties by name
+}
+
+// FacetedSearch is a page of results with facet counts over all matches
+type FacetedSearch struct {
+	Results []SearchResult
+	Total   int
+	Facets  Facets
+}
+
+// SearchFacets is SearchWithOptions that also counts the matches per month
+// and per source, e.g. to show "March 2024 (12)" filters next to results.
+// Facet searches bypass the result cache.
+func (idx *Index) SearchFacets(ctx context.Context, query string, opts SearchOptions) (FacetedSearch, error) {
+	fc := &facetCounter{months: make(map[string]int), sources: make(map[string]int)}
+	opts.facets = fc
+	results, total, err := idx.search(ctx, query, opts)
+	if err != nil {
+		return FacetedSearch{}, err
+	}
+	return FacetedSearch{Results: results, Total: total, Facets: fc.facets()}, nil
+}
+
+// facetCounter tallies matched docs once a search has ranked and collapsed them
+type facetCounter struct {
+	months  map[string]int
+	sources map[string]int
+}
+
+func (fc *facetCounter) add(d Document) {
+	if !d.ParsedDate.IsZero() {
+		fc.months[d.ParsedDate.Format("2006-01")]++
+	}
+	if d.Source != "" {
+		fc.sources[d.Source]++
+	}
+}
+
+func (fc *facetCounter) facets() Facets {
+	f := Facets{Months: facetCounts(fc.months), Sources: facetCounts(fc.sources)}
+	sort.Slice(f.Months, func(i, j int) bool { return f.Months[i].Value > f.Months[j].Value })
+	sort.Slice(f.Sources, func(i, j int) bool {
+		a, b := f.Sources[i], f.Sources[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	return f
+}
+
+func facetCounts(m map[string]int) []FacetCount {
+	out := make([]FacetCount, 0, len(m))
+	for v, n := range m {
+		out = append(out, FacetCount{v, n})
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package gonews
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// facetDocs is a corpus spread over months and sources
+func facetDocs(n int) []Document {
+	words := []string{"budget", "vote", "climate", "summit", "rates"}
+	docs := make([]Document, n)
+	for i := range docs {
+		docs[i] = Document{
+			ID:      i + 1,
+			Title:   fmt.Sprintf("%s %s report", words[i%len(words)], words[(i+2)%len(words)]),
+			Content: fmt.Sprintf("the %s talks went on while %s news broke", words[(i+1)%len(words)], words[i%len(words)]),
+			Date:    fmt.Sprintf("2024-%02d-01", i%12+1),
+			Source:  fmt.Sprintf("source%d", i%3),
+		}
+	}
+	return docs
+}
+
+func TestSearchFacetsMatchesSearch(t *testing.T) {
+	idx := buildIndex(facetDocs(60)...)
+	tests := []struct {
+		query string
+		opts  SearchOptions
+	}{
+		{"budget", SearchOptions{}},
+		{"budget OR climate", SearchOptions{K: 5}},
+		{"budget OR climate", SearchOptions{K: 5, Offset: 5}},
+		{"vote AND NOT rates", SearchOptions{Should: "summit"}},
+		{`"climate report"`, SearchOptions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			want, wantTotal, err := idx.SearchWithOptions(context.Background(), tt.query, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := idx.SearchFacets(context.Background(), tt.query, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Results, want) || got.Total != wantTotal {
+				t.Errorf("SearchFacets = %v (total %d), SearchWithOptions = %v (total %d)", got.Results, got.Total, want, wantTotal)
+			}
+			n := 0
+			for _, s := range got.Facets.Sources {
+				n += s.Count
+			}
+			if n != wantTotal {
+				t.Errorf("source facets count %d matches, want %d", n, wantTotal)
+			}
+		})
+	}
+}
+
+func TestSearchFacetsCollapseSimilar(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Inflation rises again", Content: "Prices climbed in March.", Date: "2024-03-04", Source: "wire"},
+		Document{ID: 2, Title: "Inflation rises again", Content: "Prices climbed in March.", Date: "2024-04-05", Source: "paper"},
+		Document{ID: 3, Title: "Inflation eases", Date: "2024-05-20", Source: "wire"},
+	)
+	idx.CollapseSimilar = true
+	got, err := idx.SearchFacets(context.Background(), "inflation", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the collapsed copy counts in neither facet, as in the total
+	want := Facets{
+		Months:  []FacetCount{{"2024-05", 1}, {"2024-03", 1}},
+		Sources: []FacetCount{{"wire", 2}},
+	}
+	if got.Total != 2 || !reflect.DeepEqual(got.Facets, want) {
+		t.Errorf("SearchFacets = total %d, %+v; want total 2, %+v", got.Total, got.Facets, want)
+	}
+}
+
+// countingScorer wraps TF-IDF scoring and counts its calls per doc
+type countingScorer map[int]int
+
+func (c countingScorer) score(idx *Index, doc int, matched []string) float64 {
+	c[doc]++
+	return TFIDFScore(idx, doc, matched)
+}
+
+func TestSearchScoresEachDocOnce(t *testing.T) {
+	idx := buildIndex(facetDocs(60)...)
+	tests := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+		facet bool
+	}{
+		{"plain", "budget OR climate", SearchOptions{}, false},
+		{"faceted", "budget OR climate", SearchOptions{K: 5}, true},
+		{"should repeats must", "budget", SearchOptions{Should: "budget"}, false},
+		{"faceted should repeats must", "budget OR vote", SearchOptions{Should: "vote OR budget"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := countingScorer{}
+			idx.Scorer = calls.score
+			defer func() { idx.Scorer = nil }()
+			var total int
+			var err error
+			if tt.facet {
+				var fs FacetedSearch
+				fs, err = idx.SearchFacets(context.Background(), tt.query, tt.opts)
+				total = fs.Total
+			} else {
+				_, total, err = idx.SearchWithOptions(context.Background(), tt.query, tt.opts)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total == 0 || len(calls) != total {
+				t.Fatalf("scored %d docs for %d matches", len(calls), total)
+			}
+			for doc, n := range calls {
+				if n != 1 {
+					t.Errorf("doc %d scored %d times, want once", doc, n)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearchFacets(b *testing.B) {
+	idx := buildIndex(facetDocs(2000)...)
+	opts := SearchOptions{K: 10, Should: "summit"}
+	b.Run("plain", func(b *testing.B) {
+		for range b.N {
+			idx.search(context.Background(), "budget OR climate", opts)
+		}
+	})
+	b.Run("faceted", func(b *testing.B) {
+		for range b.N {
+			idx.SearchFacets(context.Background(), "budget OR climate", opts)
+		}
+	})
+}
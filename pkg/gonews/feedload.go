@@ -0,0 +1,165 @@
+package gonews
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FeedLoader loads the items of an RSS or Atom feed (see LoadFeed)
+type FeedLoader struct {
+	URL     string
+	MaxDocs int // 0 = all
+}
+
+func (l *FeedLoader) Load() ([]Document, error) {
+	return LoadFeed(l.URL, l.MaxDocs)
+}
+
+// feedDateLayouts are the item dates feeds use: RFC 822 in RSS, RFC 3339
+// in Atom
+var feedDateLayouts = []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2 Jan 2006 15:04:05 -0700", time.RFC3339}
+
+// feedXML decodes RSS 2.0, RSS 1.0 (RDF, items beside the channel) and
+// Atom; elements are matched by local name, so content:encoded and
+// dc:creator fill Encoded and Creator
+type feedXML struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+	Description string `xml:"description"`
+	Encoded     string `xml:"encoded"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"creator"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	ID    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// LoadFeed fetches an RSS or Atom feed and returns its items numbered from
+// 0 in feed order. Each item's guid (Atom id), else its link, is kept in
+// SourceID, so the same item is recognised on the next fetch; the feed's
+// title is the Source. The full text (content:encoded, Atom content) is the
+// Content when the feed carries it, with the description as Summary;
+// otherwise the description is the Content. Either may be HTML, which the
+// analyzer's StripHTML handles. The feed must be UTF-8. maxDocs > 0 keeps
+// the first maxDocs items. A feed without items is ErrEmptyCorpus.
+func LoadFeed(url string, maxDocs int) ([]Document, error) {
+	body, err := fetchWithRetry(url)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := parseFeed(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s: %w", url, ErrEmptyCorpus)
+	}
+	if maxDocs > 0 && len(docs) > maxDocs {
+		docs = docs[:maxDocs]
+	}
+	return docs, nil
+}
+
+// parseFeed turns a feed's items into documents
+func parseFeed(body []byte) ([]Document, error) {
+	var f feedXML
+	if err := xml.NewDecoder(bytes.NewReader(body)).Decode(&f); err != nil {
+		return nil, fmt.Errorf("not an RSS or Atom feed: %w", err)
+	}
+	var docs []Document
+	switch strings.ToLower(f.XMLName.Local) {
+	case "rss", "rdf":
+		for _, it := range append(f.Channel.Items, f.Items...) {
+			d := Document{
+				ID:       len(docs),
+				Title:    strings.TrimSpace(it.Title),
+				Date:     feedDate(firstOf(it.PubDate, it.Date)),
+				Content:  strings.TrimSpace(it.Description),
+				Author:   firstOf(it.Creator, it.Author),
+				Source:   strings.TrimSpace(f.Channel.Title),
+				SourceID: firstOf(it.GUID, it.Link),
+			}
+			if encoded := strings.TrimSpace(it.Encoded); encoded != "" {
+				d.Content, d.Summary = encoded, d.Content
+			}
+			docs = append(docs, d)
+		}
+	case "feed":
+		for _, e := range f.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			d := Document{
+				ID:       len(docs),
+				Title:    strings.TrimSpace(e.Title),
+				Date:     feedDate(firstOf(e.Published, e.Updated)),
+				Content:  strings.TrimSpace(e.Summary),
+				Author:   strings.TrimSpace(e.Author.Name),
+				Source:   strings.TrimSpace(f.Title),
+				SourceID: firstOf(e.ID, link),
+			}
+			if content := strings.TrimSpace(e.Content); content != "" {
+				d.Content, d.Summary = content, d.Content
+			}
+			docs = append(docs, d)
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element <%s>", f.XMLName.Local)
+	}
+	return docs, nil
+}
+
+// feedDate turns a feed timestamp into the YYYY-MM-DD form GoNews sorts
+// and filters by; others are kept as they are
+func feedDate(s string) string {
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+// firstOf returns the first of values that isn't blank, trimmed
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
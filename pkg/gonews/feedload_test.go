@@ -0,0 +1,71 @@
+package gonews
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadFeed(t *testing.T) {
+	feeds := map[string]string{
+		"/rss": `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel><title>Daily Wire</title>
+<item><title>Budget vote</title><link>https://example.com/budget</link><guid>budget-1</guid>
+<pubDate>Tue, 02 Jan 2024 09:30:00 +0000</pubDate><description>MPs vote today</description>
+<content:encoded>&lt;p&gt;The budget vote is today.&lt;/p&gt;</content:encoded><dc:creator>A. Reporter</dc:creator></item>
+<item><title>Storm warning</title><link>https://example.com/storm</link><description>Wind and rain</description></item>
+</channel></rss>`,
+		"/atom": `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom News</title>
+<entry><title>Election called</title><id>urn:news:7</id><link rel="alternate" href="https://example.com/election"/>
+<updated>2024-03-05T12:00:00Z</updated><summary>Voters go to the polls</summary><author><name>B. Writer</name></author></entry>
+</feed>`,
+		"/empty": `<rss version="2.0"><channel><title>Nothing</title></channel></rss>`,
+		"/html":  `<html><body>not a feed</body></html>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := feeds[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	docs, err := LoadFeed(srv.URL+"/rss", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Document{
+		{ID: 0, Title: "Budget vote", Date: "2024-01-02", Content: "<p>The budget vote is today.</p>", Summary: "MPs vote today", Author: "A. Reporter", Source: "Daily Wire", SourceID: "budget-1"},
+		{ID: 1, Title: "Storm warning", Content: "Wind and rain", Source: "Daily Wire", SourceID: "https://example.com/storm"},
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("got %d docs, want %d", len(docs), len(want))
+	}
+	for i := range want {
+		if docs[i] != want[i] {
+			t.Errorf("rss doc %d = %+v, want %+v", i, docs[i], want[i])
+		}
+	}
+
+	docs, err = LoadFeed(srv.URL+"/atom", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atom := Document{ID: 0, Title: "Election called", Date: "2024-03-05", Content: "Voters go to the polls", Author: "B. Writer", Source: "Atom News", SourceID: "urn:news:7"}
+	if len(docs) != 1 || docs[0] != atom {
+		t.Errorf("atom docs = %+v, want [%+v]", docs, atom)
+	}
+
+	if _, err := LoadFeed(srv.URL+"/empty", 0); !errors.Is(err, ErrEmptyCorpus) {
+		t.Errorf("empty feed: error %v, want ErrEmptyCorpus", err)
+	}
+	if _, err := LoadFeed(srv.URL+"/html", 0); err == nil {
+		t.Error("HTML page loaded as a feed")
+	}
+}
@@ -0,0 +1,57 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFieldBoosts(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Budget vote", Content: "lawmakers met on tuesday"},
+		{ID: 2, Title: "Lawmakers met", Content: "the budget vote was close and budget talks go on"},
+		{ID: 3, Title: "Budget news", Content: "the budget passed"},
+	}
+	tests := []struct {
+		name   string
+		boosts map[string]float64
+		want   []int
+	}{
+		// title and body count alike: two body mentions beat one in the title
+		{"flat", map[string]float64{"title": 1, "content": 1}, []int{3, 2, 1}},
+		// a title mention dominates, and title+body still beats title alone
+		{"title", map[string]float64{"title": 10, "content": 1}, []int{3, 1, 2}},
+		// body mentions dominate
+		{"content", map[string]float64{"title": 0.1, "content": 10}, []int{3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := buildIndex(docs...)
+			idx.FieldBoosts = tt.boosts
+			if got := resultIDs(idx.Search("budget")); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(budget) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldTermFreqSumsFields(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "budget", Summary: "budget", Content: "budget budget"})
+	idx.FieldBoosts = map[string]float64{"title": 3}
+	// title 3*1 + summary (default 2)*1 + content 1*2
+	if got := idx.fieldTermFreq(1, idx.Terms["budget"].Positions(1)); got != 7 {
+		t.Errorf("fieldTermFreq = %v, want 7", got)
+	}
+	tests := []struct {
+		field string
+		want  float64
+	}{
+		{"title", 3},
+		{"summary", defaultFieldBoosts["summary"]},
+		{"content", 1},
+	}
+	for _, tt := range tests {
+		if got := idx.fieldBoost(tt.field); got != tt.want {
+			t.Errorf("fieldBoost(%s) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gonews
+
+import (
+	"encoding/json"
+	"io"
+	"text/template"
+)
+
+// DefaultResultFormat is the -format template reproducing the classic
+// layout; a matched title is its own preview, so it isn't printed twice
+const DefaultResultFormat = `[{{.Date}}] {{.Title}} (score: {{printf "%.4f" .Score}}){{with .Similar}} (+{{len .}} similar){{end}}
+{{if not .TitleMatch}}{{.Snippet}}
+{{end}}`
+
+// JSONResultFormat makes a ResultFormatter emit one JSON array of
+// ResultViews instead of running a template
+const JSONResultFormat = "json"
+
+// ResultView is what a result template sees for each printed result
+type ResultView struct {
+	ID           int      `json:"id"`
+	Title        string   `json:"title"`
+	Date         string   `json:"date"`
+	Score        float64  `json:"score"`
+	MatchedTerms []string `json:"matched_terms"`
+	Snippet      string   `json:"snippet"`
+	SnippetHTML  string   `json:"snippet_html"`      // Snippet with matches in <em>, HTML-escaped
+	TitleMatch   bool     `json:"title_match"`       // the query matched the title, which is the Snippet
+	Similar      []int    `json:"similar,omitempty"` // near-duplicate doc IDs collapsed into this one
+}
+
+// ResultFormatter prints search results through a text/template, or as
+// JSON (see JSONResultFormat). Call Flush after the last Write.
+type ResultFormatter struct {
+	tmpl *template.Template
+	json []ResultView // results held back until Flush, in JSON mode
+
+	// Color marks matches in template snippets with ANSI codes (see
+	// Analyzer.HighlightSnippet); JSON output is never colored
+	Color bool
+
+	// Analyzer is the analyzer of the index the results come from, which
+	// snippets and highlighting match terms with
+	Analyzer Analyzer
+}
+
+// NewResultFormatter parses a -format template; "" means DefaultResultFormat
+func NewResultFormatter(format string) (*ResultFormatter, error) {
+	if format == JSONResultFormat {
+		return &ResultFormatter{json: []ResultView{}}, nil
+	}
+	if format == "" {
+		format = DefaultResultFormat
+	}
+	t, err := template.New("result").Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	return &ResultFormatter{tmpl: t}, nil
+}
+
+// Write renders one result, followed by a newline separating it from the next
+func (f *ResultFormatter) Write(w io.Writer, d Document, r SearchResult) error {
+	snippet := f.Analyzer.ResultSnippet(d, r)
+	v := ResultView{ID: d.ID, Title: d.Title, Date: d.Date, Score: r.Score, MatchedTerms: r.MatchedTerms, Snippet: snippet,
+		SnippetHTML: f.Analyzer.HighlightSnippet(snippet, r.MatchedTerms, MarkupHTML), TitleMatch: snippet == d.Title && d.Title != "", Similar: r.Similar}
+	if v.MatchedTerms == nil {
+		v.MatchedTerms = []string{}
+	}
+	if f.tmpl == nil {
+		f.json = append(f.json, v)
+		return nil
+	}
+	if f.Color {
+		v.Snippet = f.Analyzer.HighlightSnippet(snippet, r.MatchedTerms, MarkupANSI)
+		if v.TitleMatch {
+			v.Title = v.Snippet // the default layout shows the match there
+		}
+	}
+	if err := f.tmpl.Execute(w, v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Flush writes the JSON array of everything written so far (an empty
+// array if nothing was); it does nothing for templates
+func (f *ResultFormatter) Flush(w io.Writer) error {
+	if f.tmpl != nil {
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f.json)
+}
@@ -0,0 +1,109 @@
+package gonews
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResultSnippetPrefersTitle(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Inflation hits record", Content: "Prices rose again this month across the country."},
+		{ID: 2, Title: "Markets today", Summary: "Stocks slid on inflation fears", Content: "Traders were cautious."},
+		{ID: 3, Title: "Weekly roundup", Content: "Among other things, inflation slowed slightly."},
+	}
+	idx := buildIndex(docs...)
+	want := map[int]string{
+		1: "Inflation hits record",
+		2: "Stocks slid on inflation fears",
+		3: "...among other things inflation slowed slightly...",
+	}
+	for _, r := range idx.Search("inflation") {
+		d, _ := idx.Doc(r.DocID)
+		if got := idx.Analyzer.ResultSnippet(d, r); got != want[r.DocID] {
+			t.Errorf("doc %d preview = %q, want %q", r.DocID, got, want[r.DocID])
+		}
+	}
+}
+
+func TestDefaultResultFormat(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Inflation hits record", Date: "2024-05-01", Content: "Prices rose again."},
+		Document{ID: 2, Title: "Weekly roundup", Date: "2024-05-02", Content: "Inflation slowed."},
+	)
+	f, err := NewResultFormatter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, r := range idx.Search("inflation") {
+		d, _ := idx.Doc(r.DocID)
+		r.Score = 1
+		if err := f.Write(&b, d, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "[2024-05-02] Weekly roundup (score: 1.0000)\n...inflation slowed...\n\n" +
+		"[2024-05-01] Inflation hits record (score: 1.0000)\n\n"
+	if b.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", b.String(), want)
+	}
+}
+
+func TestCustomResultFormat(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Inflation hits record", Date: "2024-05-01", Content: "Prices rose again."},
+		Document{ID: 2, Title: "Weekly roundup", Date: "2024-05-02", Content: "Inflation slowed."},
+	)
+	tests := []struct {
+		name, format, want string
+	}{
+		{"fields", "{{.ID}}|{{.Date}}|{{.Title}}", "2|2024-05-02|Weekly roundup\n1|2024-05-01|Inflation hits record\n"},
+		{"terms", `{{.ID}} {{range .MatchedTerms}}<{{.}}>{{end}}`, "2 <inflation>\n1 <inflation>\n"},
+		{"html", "{{.SnippetHTML}}", "...<em>inflation</em> slowed...\n<em>Inflation</em> hits record\n"},
+		{"json", JSONResultFormat, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewResultFormatter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			for _, r := range idx.Search("inflation") {
+				d, _ := idx.Doc(r.DocID)
+				if err := f.Write(&b, d, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.format == JSONResultFormat {
+				if b.Len() != 0 {
+					t.Errorf("JSON output before Flush: %q", b.String())
+				}
+				if err := f.Flush(&b); err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(b.String(), "[\n  {\n    \"id\": 2,") {
+					t.Errorf("JSON output = %q", b.String())
+				}
+				return
+			}
+			if b.String() != tt.want {
+				t.Errorf("output = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomResultFormatErrors(t *testing.T) {
+	if _, err := NewResultFormatter("{{.Title"); err == nil {
+		t.Error("unterminated action parsed")
+	}
+	f, err := NewResultFormatter("{{.Headline}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := f.Write(&b, Document{ID: 1, Title: "x"}, SearchResult{DocID: 1}); err == nil {
+		t.Error("unknown field rendered without error")
+	}
+}
@@ -0,0 +1,231 @@
+package gonews
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFuzzyExpansions bounds how many vocabulary terms one fuzzy word expands
+// to, keeping the cost of a fuzzy query predictable on big vocabularies
+const maxFuzzyExpansions = 50
+
+// maxFuzzyDistance is the largest edit distance a query may ask for with
+// term~N; beyond it nearly every short term matches
+const maxFuzzyDistance = 2
+
+// fuzzyToken marks a query term to expand by edit distance; dist < 0 means
+// autoFuzziness
+func fuzzyToken(term string, dist int) string {
+	return "FUZZY~" + strconv.Itoa(dist) + ":" + term
+}
+
+func parseFuzzyToken(tok string) (term string, dist int, ok bool) {
+	rest, ok := strings.CutPrefix(tok, "FUZZY~")
+	if !ok {
+		return "", 0, false
+	}
+	n, term, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", 0, false
+	}
+	dist, err := strconv.Atoi(n)
+	if err != nil {
+		return "", 0, false
+	}
+	return term, dist, true
+}
+
+// splitFuzzyWord splits a query word like ukrane~1 into the word and its
+// distance; a bare ~ gives -1 (pick by length). ok is false if the word has
+// no ~; err describes a ~ that is malformed.
+func splitFuzzyWord(w string) (word string, dist int, ok bool, err error) {
+	i := strings.LastIndexByte(w, '~')
+	if i < 0 {
+		return "", 0, false, nil
+	}
+	word, n := w[:i], w[i+1:]
+	if word == "" {
+		return "", 0, true, fmt.Errorf("'~' needs a word before it")
+	}
+	if n == "" {
+		return word, -1, true, nil
+	}
+	dist, cerr := strconv.Atoi(n)
+	if cerr != nil || dist < 0 {
+		return "", 0, true, fmt.Errorf("'~' must be followed by an edit distance")
+	}
+	if dist > maxFuzzyDistance {
+		return "", 0, true, fmt.Errorf("edit distance %d is above the maximum of %d", dist, maxFuzzyDistance)
+	}
+	return word, dist, true, nil
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and adjacent transpositions cost 1,
+// so "chnage" is 1 away from "change"
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// autoFuzziness is the edit distance tolerated for a word of this length:
+// none for short words, where one edit changes the meaning, more for long ones
+func autoFuzziness(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// fuzzyExpand returns the indexed terms within maxDist edits of term,
+// closest first, at most maxFuzzyExpansions of them. A negative maxDist
+// uses autoFuzziness.
+func (idx *Index) fuzzyExpand(term string, maxDist int) []string {
+	if maxDist < 0 {
+		maxDist = autoFuzziness(term)
+	}
+	if maxDist <= 0 {
+		if _, ok := idx.Terms[term]; ok {
+			return []string{term}
+		}
+		return nil
+	}
+	type cand struct {
+		term string
+		dist int
+	}
+	var cands []cand
+	n := utf8.RuneCountInString(term)
+	for t := range idx.Terms {
+		// length difference alone rules most terms out cheaply
+		if d := utf8.RuneCountInString(t) - n; d > maxDist || -d > maxDist {
+			continue
+		}
+		if d := editDistance(term, t); d <= maxDist {
+			cands = append(cands, cand{t, d})
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		if cands[i].dist != cands[j].dist {
+			return cands[i].dist < cands[j].dist
+		}
+		return cands[i].term < cands[j].term
+	})
+	if len(cands) > maxFuzzyExpansions {
+		cands = cands[:maxFuzzyExpansions]
+	}
+	out := make([]string, len(cands))
+	for i, c := range cands {
+		out[i] = c.term
+	}
+	return out
+}
+
+// fuzzyVariants expands each phrase word to the indexed terms it may match
+func (idx *Index) fuzzyVariants(tokens []string) [][]string {
+	variants := make([][]string, len(tokens))
+	for i, t := range tokens {
+		variants[i] = idx.fuzzyExpand(t, autoFuzziness(t))
+	}
+	return variants
+}
+
+// docsWithSloppyPhrase returns docs where the (fuzzily matched) tokens occur
+// in order with at most slop extra words between them in total
+func (idx *Index) docsWithSloppyPhrase(tokens []string, slop int) map[int]struct{} {
+	res := make(map[int]struct{})
+	if len(tokens) == 0 {
+		return res
+	}
+	variants := idx.fuzzyVariants(tokens)
+	// candidate docs contain some variant of every word
+	var candidate map[int]struct{}
+	for _, vs := range variants {
+		docs := make(map[int]struct{})
+		for _, v := range vs {
+			for _, id := range idx.Terms[v].Docs() {
+				docs[id] = struct{}{}
+			}
+		}
+		if candidate == nil {
+			candidate = docs
+		} else {
+			candidate = setIntersect(candidate, docs)
+		}
+		if len(candidate) == 0 {
+			return res
+		}
+	}
+	for doc := range candidate {
+		if len(idx.sloppyPhraseStarts(doc, variants, slop)) > 0 {
+			res[doc] = struct{}{}
+		}
+	}
+	return res
+}
+
+// sloppyPhraseStarts returns the matched token positions of every sloppy
+// occurrence in doc. For each start it greedily takes the nearest following
+// position of the next word, which uses the least of the slop budget.
+func (idx *Index) sloppyPhraseStarts(doc int, variants [][]string, slop int) [][]int {
+	if len(variants) == 0 {
+		return nil
+	}
+	lists := make([][]int, len(variants))
+	for i, vs := range variants {
+		for _, v := range vs {
+			lists[i] = append(lists[i], idx.Terms[v].Positions(doc)...)
+		}
+		if len(lists[i]) == 0 {
+			return nil
+		}
+		sort.Ints(lists[i])
+	}
+	var runs [][]int
+	for _, p := range lists[0] {
+		run := []int{p}
+		budget := slop
+		prev := p
+		for i := 1; i < len(lists); i++ {
+			j := sort.SearchInts(lists[i], prev+1)
+			if j == len(lists[i]) || lists[i][j]-prev-1 > budget {
+				run = nil
+				break
+			}
+			budget -= lists[i][j] - prev - 1
+			prev = lists[i][j]
+			run = append(run, prev)
+		}
+		if run != nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
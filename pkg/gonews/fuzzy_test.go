@@ -0,0 +1,53 @@
+package gonews
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFuzzyPhrase(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "climate policy change announced"},
+		Document{ID: 2, Content: "change of climate"},
+		Document{ID: 3, Content: "climate scientists warn that any real change takes decades"},
+		Document{ID: 4, Content: "the climate change debate"},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{`"climate chnage"~2`, []int{1, 4}},
+		{`"climate change"~1`, []int{1, 4}},
+		{`"climate change"`, []int{4}},
+		{`"climate chnage"`, nil},
+		{`"climat chnage"~1`, []int{1, 4}},
+		{`"climate chnage"~5`, []int{1, 3, 4}},
+		{`"climate xyzzy"~2`, nil},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMalformedFuzzyRejected(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Title: "Ukraine talks", Content: "ukrane x"})
+	for _, q := range []string{"ukrane~x", "ukrane~-1", "budget AND ukrane~9", "~2"} {
+		var qe *QueryError
+		if _, _, err := idx.SearchWithOptions(context.Background(), q, SearchOptions{}); !errors.As(err, &qe) {
+			t.Errorf("SearchWithOptions(%s) = %v, want a *QueryError", q, err)
+		}
+		if err := ValidateQuery(q); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("ValidateQuery(%s) = %v, want ErrMalformedQuery", q, err)
+		}
+	}
+	// well-formed distances still search
+	for _, q := range []string{"ukrane~1", "ukrane~", `"ukraine talks"~1`} {
+		if _, _, err := idx.SearchWithOptions(context.Background(), q, SearchOptions{}); err != nil {
+			t.Errorf("SearchWithOptions(%s): %v", q, err)
+		}
+	}
+}
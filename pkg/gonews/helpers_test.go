@@ -0,0 +1,47 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+// buildIndex indexes docs into a fresh index with the default analyzer
+func buildIndex(docs ...Document) *Index {
+	return buildAnalyzed(Analyzer{}, docs...)
+}
+
+// buildAnalyzed indexes docs into a fresh index analyzed with a
+func buildAnalyzed(a Analyzer, docs ...Document) *Index {
+	idx := NewIndex()
+	idx.Analyzer = a
+	for _, d := range docs {
+		idx.AddDocument(d)
+	}
+	return idx
+}
+
+// resultIDs lists the doc IDs of results in rank order
+func resultIDs(results []SearchResult) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.DocID
+	}
+	return ids
+}
+
+// sortedIDs is resultIDs in ascending order, for unranked comparisons
+func sortedIDs(results []SearchResult) []int {
+	ids := resultIDs(results)
+	slices.Sort(ids)
+	return ids
+}
+
+// newTestRun parses q against idx into a fresh query run
+func newTestRun(t testing.TB, idx *Index, q string) *queryRun {
+	t.Helper()
+	rpn, err := idx.parseQuery(q)
+	if err != nil {
+		t.Fatalf("parseQuery(%s): %v", q, err)
+	}
+	return idx.newQueryRun(rpn)
+}
@@ -0,0 +1,244 @@
+package gonews
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// markers placed around highlighted terms by Highlight
+var (
+	HighlightStart = "[["
+	HighlightEnd   = "]]"
+)
+
+// Highlight returns the full text of a doc (its non-empty fields separated by
+// blank lines) with every occurrence of the query's terms and phrases wrapped
+// in HighlightStart/HighlightEnd. Occurrences come from the index positions, so
+// this shows exactly what the index matched. An unknown doc is ErrDocNotFound.
+func (idx *Index) Highlight(query string, docID int) (string, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	d, ok := idx.Docs[docID]
+	if !ok {
+		return "", fmt.Errorf("doc %d: %w", docID, ErrDocNotFound)
+	}
+	d = idx.withContent(d)
+	var texts []string
+	for _, t := range idx.Analyzer.docFieldTexts(d) {
+		if t != "" {
+			texts = append(texts, t)
+		}
+	}
+	text := strings.Join(texts, "\n\n")
+
+	hit := make(map[int]bool) // token positions to mark
+	rpn, err := idx.parseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	for _, tok := range rpn {
+		if isOperator(tok) {
+			continue
+		}
+		if phrase, slop, ok := parsePhraseToken(tok); ok {
+			toks := idx.Analyzer.phraseTokens(phrase, d.Lang)
+			anchored := phraseAnchored(tok)
+			if slop > 0 {
+				for _, run := range idx.sloppyPhraseStarts(docID, idx.fuzzyVariants(toks), slop) {
+					if anchored && !idx.isFieldStart(docID, run[0]) {
+						continue
+					}
+					for _, p := range run {
+						hit[p] = true
+					}
+				}
+				continue
+			}
+			for _, p := range idx.phraseStarts(docID, toks) {
+				if anchored && !idx.isFieldStart(docID, p) {
+					continue
+				}
+				for i := range toks {
+					hit[p+i] = true
+				}
+			}
+			continue
+		}
+		if terms, dists, ok := parseNearToken(tok); ok {
+			for _, positions := range idx.nearPositions(docID, terms, dists) {
+				for _, p := range positions {
+					hit[p] = true
+				}
+			}
+			continue
+		}
+		positions := idx.Terms[tok].Positions(docID)
+		if field, term, ok := parseFieldTermToken(tok); ok {
+			positions = idx.fieldPositions(docID, term, field)
+		}
+		for _, p := range positions {
+			hit[p] = true
+		}
+	}
+	if len(hit) == 0 {
+		return text, nil
+	}
+
+	// collect byte ranges of hit tokens, merging ones that share a word
+	type span struct{ start, end int }
+	var ranges []span
+	for _, ts := range idx.Analyzer.tokenSpans(text, d.Lang) {
+		if !hit[ts.Pos] {
+			continue
+		}
+		if n := len(ranges); n > 0 && ts.Start < ranges[n-1].end {
+			continue
+		}
+		ranges = append(ranges, span{ts.Start, ts.End})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		b.WriteString(text[last:r.start])
+		b.WriteString(HighlightStart)
+		b.WriteString(text[r.start:r.end])
+		b.WriteString(HighlightEnd)
+		last = r.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), nil
+}
+
+// phraseStarts returns every position in doc where tokens occur consecutively
+func (idx *Index) phraseStarts(doc int, tokens []string) []int {
+	if len(tokens) == 0 {
+		return nil
+	}
+	var starts []int
+	for _, p := range idx.Terms[tokens[0]].Positions(doc) {
+		ok := true
+		for i := 1; i < len(tokens); i++ {
+			if !sortedContains(idx.Terms[tokens[i]].Positions(doc), p+i) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			starts = append(starts, p)
+		}
+	}
+	return starts
+}
+
+// sortedContains reports whether x is in the ascending slice arr
+func sortedContains(arr []int, x int) bool {
+	i := sort.SearchInts(arr, x)
+	return i < len(arr) && arr[i] == x
+}
+
+// SnippetMarkup selects how HighlightSnippet marks matches
+type SnippetMarkup int
+
+const (
+	MarkupNone SnippetMarkup = iota // leave the text as is
+	MarkupANSI                      // bold yellow ANSI escape codes, for terminals
+	MarkupHTML                      // <em>...</em>, with the rest of the text HTML-escaped
+)
+
+// HighlightSnippet marks the matched terms of a search result (its
+// MatchedTerms) in a snippet or title. Terms are compared after analysis
+// with a, which should be the index's analyzer, so "Sanctions" in the text
+// matches the term "sanction"; a multi-word term from a phrase is marked
+// only where its words appear in order, as one span.
+func (a Analyzer) HighlightSnippet(text string, terms []string, m SnippetMarkup) string {
+	if m == MarkupNone {
+		return text
+	}
+	// one entry per position: its tokens and raw word (lowercased)
+	type word struct {
+		toks       []string
+		raw        string
+		start, end int
+	}
+	var words []word
+	for _, s := range a.tokenSpans(text, "") {
+		if s.Pos == len(words) {
+			words = append(words, word{raw: strings.ToLower(text[s.Start:s.End]), start: s.Start, end: s.End})
+		}
+		words[s.Pos].toks = append(words[s.Pos].toks, s.Tok)
+	}
+	matches := func(i int, term string) bool {
+		return slices.Contains(words[i].toks, term) || words[i].raw == term
+	}
+
+	// position ranges [from, to) to mark, from each single term or phrase
+	type run struct{ from, to int }
+	var runs []run
+	for _, t := range terms {
+		phrase := a.matchedTermTokens(t)
+		if len(phrase) == 0 {
+			continue
+		}
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			ok := true
+			for j, w := range phrase {
+				if !matches(i+j, w) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				runs = append(runs, run{i, i + len(phrase)})
+			}
+		}
+	}
+	if len(runs) == 0 && m != MarkupHTML {
+		return text
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].from < runs[j].from })
+
+	// byte ranges, merging runs that overlap in the text
+	type span struct{ start, end int }
+	var ranges []span
+	for _, r := range runs {
+		s := span{words[r.from].start, words[r.to-1].end}
+		if n := len(ranges); n > 0 && s.start < ranges[n-1].end {
+			ranges[n-1].end = max(ranges[n-1].end, s.end)
+			continue
+		}
+		ranges = append(ranges, s)
+	}
+
+	before, after, escape := "\x1b[1;33m", "\x1b[0m", func(s string) string { return s }
+	if m == MarkupHTML {
+		before, after, escape = "<em>", "</em>", html.EscapeString
+	}
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		b.WriteString(escape(text[last:r.start]))
+		b.WriteString(before)
+		b.WriteString(escape(text[r.start:r.end]))
+		b.WriteString(after)
+		last = r.end
+	}
+	b.WriteString(escape(text[last:]))
+	return b.String()
+}
+
+// matchedTermTokens splits a matched term into the tokens to look for in a
+// row, one per position: a phrase (as PHRASE token or plain text) gives several
+func (a Analyzer) matchedTermTokens(t string) []string {
+	if ph, _, ok := parsePhraseToken(t); ok {
+		return a.phraseTokens(ph, "")
+	}
+	if strings.Contains(t, " ") {
+		return a.phraseTokens(t, "")
+	}
+	return []string{t}
+}
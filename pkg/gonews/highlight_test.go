@@ -0,0 +1,76 @@
+package gonews
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Climate talks", Content: "Climate change policy stalls. Talks on climate resume; the policy debate goes on."},
+		{ID: 2, Title: "OpenAI news", Content: "OpenAI ships reactJS tools"},
+	}
+	tests := []struct {
+		query    string
+		doc      int
+		analyzer Analyzer
+		want     string
+	}{
+		{"climate", 1, Analyzer{}, "[[Climate]] talks\n\n[[Climate]] change policy stalls. Talks on [[climate]] resume; the policy debate goes on."},
+		{"climate AND policy", 1, Analyzer{}, "[[Climate]] talks\n\n[[Climate]] change [[policy]] stalls. Talks on [[climate]] resume; the [[policy]] debate goes on."},
+		{`"change policy"`, 1, Analyzer{}, "Climate talks\n\nClimate [[change]] [[policy]] stalls. Talks on climate resume; the policy debate goes on."},
+		{"debate -weather", 1, Analyzer{}, "Climate talks\n\nClimate change policy stalls. Talks on climate resume; the policy [[debate]] goes on."},
+		{"react", 2, Analyzer{SplitIdentifiers: true}, "OpenAI news\n\nOpenAI ships [[reactJS]] tools"},
+		{`"openai ships"`, 2, Analyzer{SplitIdentifiers: true}, "OpenAI news\n\n[[OpenAI]] [[ships]] reactJS tools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			idx := buildAnalyzed(tt.analyzer, docs...)
+			got, err := idx.Highlight(tt.query, tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Highlight(%q, %d) =\n%q\nwant\n%q", tt.query, tt.doc, got, tt.want)
+			}
+		})
+	}
+	if _, err := buildIndex(docs...).Highlight("climate", 9); !errors.Is(err, ErrDocNotFound) {
+		t.Errorf("Highlight of an unknown doc = %v, want ErrDocNotFound", err)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	const ansiOn, ansiOff = "\x1b[1;33m", "\x1b[0m"
+	tests := []struct {
+		name     string
+		analyzer Analyzer
+		text     string
+		terms    []string
+		markup   SnippetMarkup
+		want     string
+	}{
+		{"none", Analyzer{}, "Budget <b>vote</b>", []string{"budget"}, MarkupNone, "Budget <b>vote</b>"},
+		{"ansi", Analyzer{}, "Budget vote: the budget", []string{"budget"}, MarkupANSI,
+			ansiOn + "Budget" + ansiOff + " vote: the " + ansiOn + "budget" + ansiOff},
+		{"ansi without a match", Analyzer{}, "a <b>storm</b>", []string{"budget"}, MarkupANSI, "a <b>storm</b>"},
+		{"html", Analyzer{}, "Budget vote", []string{"budget", "vote"}, MarkupHTML, "<em>Budget</em> <em>vote</em>"},
+		// the text around and inside a match is escaped
+		{"html escaping", Analyzer{}, `Tom & Jerry's <b>budget</b>`, []string{"budget"}, MarkupHTML,
+			"Tom &amp; Jerry&#39;s &lt;b&gt;<em>budget</em>&lt;/b&gt;"},
+		{"html without a match", Analyzer{}, "a < b", []string{"budget"}, MarkupHTML, "a &lt; b"},
+		// a phrase is marked as one span, only where its words are in order
+		{"phrase", Analyzer{}, "Budget vote, then a vote on the budget", []string{"budget vote"}, MarkupHTML,
+			"<em>Budget vote</em>, then a vote on the budget"},
+		{"phrase token", Analyzer{}, "Budget vote, then a vote on the budget", []string{phraseToken("budget vote", 0, false)}, MarkupHTML,
+			"<em>Budget vote</em>, then a vote on the budget"},
+		{"overlapping terms merge", Analyzer{}, "budget vote", []string{"budget vote", "vote"}, MarkupHTML, "<em>budget vote</em>"},
+		{"stemmed", Analyzer{Stemming: true}, "Elections called", []string{"elect"}, MarkupHTML, "<em>Elections</em> called"},
+		{"identifier part", Analyzer{SplitIdentifiers: true}, "reactJS tools", []string{"react"}, MarkupHTML, "<em>reactJS</em> tools"},
+	}
+	for _, tt := range tests {
+		if got := tt.analyzer.HighlightSnippet(tt.text, tt.terms, tt.markup); got != tt.want {
+			t.Errorf("%s: HighlightSnippet = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
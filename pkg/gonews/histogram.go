@@ -0,0 +1,79 @@
+package gonews
+
+import (
+	"context"
+	"time"
+)
+
+// HistogramBucket counts the matches dated within one time bucket
+type HistogramBucket struct {
+	Start time.Time `json:"start"` // midnight UTC
+	Docs  int       `json:"docs"`
+}
+
+// MaxHistogramBuckets caps the buckets of a Histogram, so one stray date
+// decades off doesn't make a series of thousands of empty days
+const MaxHistogramBuckets = 1000
+
+// Histogram is how a query's matches spread over time
+type Histogram struct {
+	Buckets []HistogramBucket `json:"buckets"` // consecutive, oldest first, empty ones included
+	Earlier int               `json:"earlier"` // matches dated before the first bucket
+	Undated int               `json:"undated"` // matches without a parseable date
+	Total   int               `json:"total"`
+}
+
+// SearchHistogram counts the docs matching query per day, week or month of
+// their ParsedDate, e.g. to chart how often "inflation" came up each
+// month. Every match counts, whatever MaxResults, but near-duplicates
+// dropped by CollapseSimilar don't. The buckets run from the oldest
+// match's to the newest's, at most the newest MaxHistogramBuckets of them;
+// matches older than those are counted in Earlier.
+func (idx *Index) SearchHistogram(ctx context.Context, query string, interval TrendInterval) (Histogram, error) {
+	hc := &histogramCounter{interval: interval, counts: make(map[time.Time]int)}
+	_, total, err := idx.search(ctx, query, SearchOptions{histogram: hc})
+	if err != nil {
+		return Histogram{}, err
+	}
+	h := Histogram{Buckets: []HistogramBucket{}, Undated: hc.undated, Total: total}
+	if len(hc.counts) == 0 {
+		return h, nil
+	}
+	first := hc.first
+	if start := interval.back(hc.last, MaxHistogramBuckets-1); first.Before(start) {
+		first = start
+		for b, n := range hc.counts {
+			if b.Before(first) {
+				h.Earlier += n
+			}
+		}
+	}
+	for b := first; !b.After(hc.last); b = interval.next(b) {
+		h.Buckets = append(h.Buckets, HistogramBucket{Start: b, Docs: hc.counts[b]})
+	}
+	return h, nil
+}
+
+// histogramCounter tallies matched docs per bucket once a search has
+// ranked and collapsed them
+type histogramCounter struct {
+	interval    TrendInterval
+	counts      map[time.Time]int
+	first, last time.Time
+	undated     int
+}
+
+func (hc *histogramCounter) add(d Document) {
+	if d.ParsedDate.IsZero() {
+		hc.undated++
+		return
+	}
+	b := hc.interval.bucket(d.ParsedDate)
+	if len(hc.counts) == 0 || b.Before(hc.first) {
+		hc.first = b
+	}
+	if len(hc.counts) == 0 || b.After(hc.last) {
+		hc.last = b
+	}
+	hc.counts[b]++
+}
@@ -0,0 +1,45 @@
+package gonews
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchHistogram(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Inflation rises again", Content: "Prices climbed in March.", Date: "2024-03-04"},
+		Document{ID: 2, Title: "Inflation rises again", Content: "Prices climbed in March.", Date: "2024-03-05"},
+		Document{ID: 3, Title: "Inflation eases", Date: "2024-05-20"},
+		Document{ID: 4, Title: "Inflation undated"},
+	)
+	idx.CollapseSimilar = true
+	h, err := idx.SearchHistogram(context.Background(), "inflation", TrendMonth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, b := range h.Buckets {
+		got = append(got, b.Docs)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 0 || got[2] != 1 || h.Undated != 1 || h.Total != 3 {
+		t.Errorf("histogram = %v, undated %d, total %d; want [1 0 1], 1, 3 (the duplicate collapsed)", got, h.Undated, h.Total)
+	}
+}
+
+func TestSearchHistogramCapsBuckets(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Inflation", Date: "1900-01-01"},
+		Document{ID: 2, Title: "Inflation", Date: "2024-01-01"},
+		Document{ID: 3, Title: "Inflation", Date: "2024-01-03"},
+	)
+	h, err := idx.SearchHistogram(context.Background(), "inflation", TrendDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Buckets) != MaxHistogramBuckets || h.Earlier != 1 {
+		t.Fatalf("%d buckets, %d earlier; want %d, 1", len(h.Buckets), h.Earlier, MaxHistogramBuckets)
+	}
+	if last := h.Buckets[len(h.Buckets)-1]; last.Start.Format("2006-01-02") != "2024-01-03" || last.Docs != 1 {
+		t.Errorf("last bucket = %v, want 2024-01-03 with 1 doc", last)
+	}
+}
@@ -0,0 +1,200 @@
+package gonews
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+// FusionMode selects how HybridSearch combines the keyword and vector
+// rankings
+type FusionMode int
+
+const (
+	FusionRRF      FusionMode = iota // reciprocal rank fusion: sum of 1/(RRFK + rank) over both rankings
+	FusionWeighted                   // HybridWeight blend of both scores, each scaled to [0, 1]
+)
+
+// SearchMode selects how a Server search ranks documents
+type SearchMode int
+
+const (
+	SearchKeyword  SearchMode = iota // the query language, scored by the index's Scorer
+	SearchSemantic                   // embedding similarity (see Index.SemanticSearch)
+	SearchHybrid                     // both fused (see Index.HybridSearch)
+)
+
+// Hybrid search defaults, used when the Index fields are 0
+const (
+	DefaultHybridWeight     = 0.5
+	DefaultRRFK             = 60
+	DefaultHybridCandidates = 100
+)
+
+// HybridSearch runs query both as a keyword search, scored by the index's
+// Scorer (TF-IDF or BM25), and as a SemanticSearch, and fuses the two
+// rankings by Fusion. Each side contributes its HybridCandidates best
+// results (at least k); a doc found by only one side still ranks, so
+// "economic downturn" finds "recession" articles while exact matches stay
+// on top. The vector side needn't contain the query's words but still
+// obeys its filters and negations: "apple -fruit lang:fr" only finds
+// French docs without "fruit". Results keep the keyword side's matched
+// terms for snippets. It returns up to k results (every fused one if k is
+// 0), capped by MaxResults. ErrNoVectors without Vectors or Embedder.
+func (idx *Index) HybridSearch(ctx context.Context, query string, k int) ([]SearchResult, error) {
+	candidates := idx.HybridCandidates
+	if candidates <= 0 {
+		candidates = DefaultHybridCandidates
+	}
+	candidates = max(candidates, k)
+	keep, err := idx.hybridFilter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	vector, err := idx.semanticSearch(ctx, query, candidates, keep)
+	if err != nil {
+		return nil, err
+	}
+	keyword, _, err := idx.SearchWithOptions(ctx, query, SearchOptions{K: candidates})
+	if err != nil {
+		return nil, err
+	}
+
+	fused := make(map[int]*SearchResult)
+	add := func(results []SearchResult, scores []float64) {
+		for i, r := range results {
+			f, ok := fused[r.DocID]
+			if !ok {
+				f = &SearchResult{DocID: r.DocID}
+				fused[r.DocID] = f
+			}
+			f.Score += scores[i]
+			if r.MatchedTerms != nil {
+				f.MatchedTerms, f.MatchedFields = r.MatchedTerms, r.MatchedFields
+			}
+		}
+	}
+	switch idx.Fusion {
+	case FusionWeighted:
+		w := idx.HybridWeight
+		if w <= 0 || w > 1 {
+			w = DefaultHybridWeight
+		}
+		add(keyword, scaledScores(keyword, 1-w))
+		add(vector, scaledScores(vector, w))
+	default:
+		rrfK := idx.RRFK
+		if rrfK <= 0 {
+			rrfK = DefaultRRFK
+		}
+		add(keyword, rrfScores(len(keyword), rrfK))
+		add(vector, rrfScores(len(vector), rrfK))
+	}
+
+	results := make([]SearchResult, 0, len(fused))
+	for _, r := range fused {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].DocID < results[j].DocID
+	})
+	if idx.MaxResults > 0 && len(results) > idx.MaxResults {
+		results = results[:idx.MaxResults]
+	}
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// rpnAllDocs is an RPN operand matching every doc, which relaxedRPN puts in
+// place of the words a vector match needn't contain
+const rpnAllDocs = "\x00ALL"
+
+// hybridFilter returns the docs the vector side of a hybrid search may
+// find: those matching query with its positive text relaxed (see
+// relaxedRPN). nil means any doc, for a query with nothing left to check.
+func (idx *Index) hybridFilter(ctx context.Context, query string) (map[int]struct{}, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	rpn, err := idx.parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	relaxed, ok := relaxedRPN(rpn)
+	if !ok {
+		return nil, nil
+	}
+	return idx.evaluateRPNContext(ctx, relaxed)
+}
+
+// relaxedRPN replaces the words, phrases and NEAR clauses of rpn that a
+// match must contain with rpnAllDocs, keeping the negated ones and the
+// filters (_exists_:, lang:, date and numeric ranges). ok is false if
+// nothing was kept, so the relaxed query matches every doc.
+func relaxedRPN(rpn []string) (relaxed []string, ok bool) {
+	negated := negatedOperands(rpn)
+	relaxed = slices.Clone(rpn)
+	for i, tok := range rpn {
+		if isOperator(tok) {
+			continue
+		}
+		if negated[i] || isFilterToken(tok) {
+			ok = true
+			continue
+		}
+		relaxed[i] = rpnAllDocs
+	}
+	return relaxed, ok
+}
+
+// isFilterToken reports whether an RPN operand tests a doc's fields or
+// language rather than its words
+func isFilterToken(tok string) bool {
+	if _, ok := parseExistsToken(tok); ok {
+		return true
+	}
+	if _, ok := parseLangToken(tok); ok {
+		return true
+	}
+	if _, err := parseDateRangeToken(tok); err == nil {
+		return true
+	}
+	_, err := parseRangeToken(tok)
+	return err == nil
+}
+
+// rrfScores returns the reciprocal rank fusion share of each of n ranked
+// results
+func rrfScores(n int, rrfK float64) []float64 {
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = 1 / (rrfK + float64(i+1))
+	}
+	return scores
+}
+
+// scaledScores min-max scales the results' scores to [0, weight], so
+// unbounded keyword scores and cosine similarities can be added; a lone
+// result, or all tied, gets the full weight
+func scaledScores(results []SearchResult, weight float64) []float64 {
+	scores := make([]float64, len(results))
+	if len(results) == 0 {
+		return scores
+	}
+	lo, hi := results[0].Score, results[0].Score
+	for _, r := range results {
+		lo, hi = min(lo, r.Score), max(hi, r.Score)
+	}
+	for i, r := range results {
+		if hi == lo {
+			scores[i] = weight
+		} else {
+			scores[i] = weight * (r.Score - lo) / (hi - lo)
+		}
+	}
+	return scores
+}
@@ -0,0 +1,1056 @@
+package gonews
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Index is the inverted index, held in memory or, from OpenIndex, in a
+// KVStore with postings loaded as needed. Its methods are safe for
+// concurrent use: searches and other reads share a read lock, while
+// AddDocument, UpdateDocument and DeleteDocument take the write lock, so a
+// search sees each write completely or not at all. Not covered are direct
+// reads of the exported maps and N (only safe while nothing writes) and the
+// settings fields (set them before sharing the index).
+type Index struct {
+	mu sync.RWMutex
+
+	// lookup structures built on demand under lazyMu, nil after a change
+	lazyMu sync.Mutex
+	dict   *termDict  // sorted terms for wildcards
+	byDate []datedDoc // dated docs in order of their day, for date ranges
+	trie   *termTrie  // terms by prefix with document frequencies, for Suggest
+
+	cache resultCache // recent search results, cleared by every write
+
+	// exact-duplicate bookkeeping for Duplicates
+	contentHashes map[uint64]int // content hash -> first doc with it; nil until needed
+	dupOf         map[int]int    // merged or flagged doc -> the doc it copies
+	dupsDropped   int
+
+	Terms        map[string]*Posting
+	Docs         map[int]Document
+	DocTokCounts map[int]int       // number of tokens in each doc (for TF normalization)
+	FieldEnds    map[int][]int     // per doc, end position (exclusive) of each of indexedFields
+	Numbers      map[int][]float64 // per doc, sorted numbers mentioned (for NumericField ranges)
+	Fingerprints map[int]uint64    // per doc with tokens, SimHash of its terms (for CollapseSimilar)
+
+	// Store, when set, holds the documents' Content instead of Docs (set it
+	// before adding docs, or after LoadIndex of an index saved with it);
+	// StoredContent lists the docs whose Content is there
+	Store         DocStore
+	StoredContent map[int]bool
+	storeErr      error // first failed Store write, see storeFailed
+
+	// kv is the store an index from OpenIndex lives in, nil for others;
+	// PostingCacheSize caps the bytes of its postings kept loaded (0 means
+	// DefaultPostingCacheSize)
+	kv               *kvBacking
+	PostingCacheSize int
+
+	sharded *ShardedIndex // set on its shards, which score with its totals
+
+	N         int // number of documents
+	totalToks int // sum of DocTokCounts, for the average doc length
+
+	// Analyzer turns docs and queries into terms; set it before adding
+	// docs, and use Reanalyze to change it afterwards
+	Analyzer Analyzer
+
+	// IDF tuning (zero values keep the plain log(1 + N/df) behaviour)
+	IDFFloor     float64 // minimum IDF a term can contribute (0 = no floor)
+	IDFCeiling   float64 // maximum IDF, stops hapax terms dominating (0 = no ceiling)
+	IDFSmoothing float64 // added to df before dividing, damps very rare terms
+
+	EarlyMentionBoost float64 // extra weight for occurrences near the start of a doc (0 = off)
+
+	// length normalization of term frequency (linear by default)
+	LengthNorm LengthNormMode
+	PivotSlope float64 // slope for LengthNormPivoted, in (0, 1]; 0 means DefaultPivotSlope
+
+	// BM25Score parameters, used as given; NewIndex sets DefaultBM25K1 /
+	// DefaultBM25B
+	BM25K1 float64 // term frequency saturation, >= 0 (0 counts a term once however often it occurs)
+	BM25B  float64 // length normalization strength, in [0, 1] (0 ignores doc length)
+
+	// FieldBoosts weights term occurrences by field (keys from indexedFields);
+	// missing fields use defaultFieldBoosts, else 1
+	FieldBoosts map[string]float64
+
+	Scorer ScoreFunc // replaces TF-IDF scoring when set (see CompositeScorer)
+
+	MaxResults int      // cap on results returned by Search (0 = unlimited)
+	Rank       RankMode // result ordering, score by default
+	Sort       SortFunc // replaces the Rank ordering when set (see SortByDateDesc)
+
+	MaxWildcardTerms int // terms a wildcard expands to at most; 0 means DefaultMaxWildcardTerms
+
+	// CacheSize is how many searches' results are kept in an LRU cache
+	// (0 = no caching). Entries hold results after MaxResults and K, so an
+	// uncapped broad query caches every match.
+	CacheSize int
+
+	Duplicates DuplicateMode // what AddDocument does with exact copies of indexed docs
+
+	// CollapseSimilar folds results whose fingerprints differ in at most
+	// SimilarBits bits (0 means DefaultSimilarBits) into the best ranked of
+	// them, which lists the others in SearchResult.Similar
+	CollapseSimilar bool
+	SimilarBits     int
+
+	SimilarTerms int // terms of the doc Similar searches with (0 means DefaultSimilarTerms)
+
+	RelatedByPMI bool // rank RelatedTerms by PMI instead of raw co-occurrence
+
+	// Vectors holds document embeddings and Embedder computes the query's,
+	// for SemanticSearch. Replacing a doc with a changed text or deleting it
+	// drops its embedding; docs added later have none until EmbedDocuments
+	// (the Server embeds the docs written through it).
+	Vectors  *VectorIndex
+	Embedder Embedder
+
+	// HybridSearch settings: Fusion combines the rankings, HybridWeight is
+	// the vector share of a FusionWeighted score (0 means
+	// DefaultHybridWeight), RRFK the FusionRRF rank constant (0 means
+	// DefaultRRFK) and HybridCandidates how many of each ranking's best are
+	// fused (0 means DefaultHybridCandidates)
+	Fusion           FusionMode
+	HybridWeight     float64
+	RRFK             float64
+	HybridCandidates int
+}
+
+func NewIndex() *Index {
+	return &Index{Terms: make(map[string]*Posting), Docs: make(map[int]Document), DocTokCounts: make(map[int]int), FieldEnds: make(map[int][]int), Numbers: make(map[int][]float64), Fingerprints: make(map[int]uint64), StoredContent: make(map[int]bool),
+		BM25K1: DefaultBM25K1, BM25B: DefaultBM25B}
+}
+
+// AddDocument tokenizes and adds to the inverted index.
+// A document with an existing ID replaces the old one.
+func (idx *Index) AddDocument(d Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.addDocument(d)
+}
+
+func (idx *Index) addDocument(d Document) {
+	if old, ok := idx.Docs[d.ID]; ok {
+		// a changed text needs a new embedding
+		if idx.Vectors != nil && !idx.vectorFits(d.ID, old, d) {
+			idx.Vectors.Delete(d.ID)
+		}
+		idx.deleteDocument(d.ID)
+	}
+	delete(idx.dupOf, d.ID) // the ID may have been merged away before
+	if idx.Duplicates == DuplicatesIndex {
+		idx.contentHashes = nil // stale once copies are indexed unchecked
+	} else if orig, ok := idx.findDuplicate(d); ok {
+		if idx.Duplicates != DuplicatesSkip {
+			if idx.dupOf == nil {
+				idx.dupOf = make(map[int]int)
+			}
+			idx.dupOf[d.ID] = orig
+		}
+		if idx.Duplicates != DuplicatesFlag {
+			idx.dupsDropped++
+			if idx.Vectors != nil {
+				idx.Vectors.Delete(d.ID)
+			}
+			return
+		}
+	} else {
+		idx.rememberContent(d.ID, d)
+	}
+	if d.ParsedDate.IsZero() {
+		d.ParsedDate, _ = parseDate(d.Date)
+	}
+	if d.Lang == "" && idx.Analyzer.DetectLanguages {
+		d.Lang = DetectLanguage(idx.Analyzer.docText(d))
+	}
+	idx.Docs[d.ID] = idx.storeContent(d)
+	idx.byDate, idx.trie = nil, nil
+	idx.cache.clear()
+	// fields are tokenized separately but numbered as one stream; positions
+	// are gathered per term first, since a posting entry is written whole
+	texts := idx.Analyzer.docFieldTexts(d)
+	ends := make([]int, len(texts))
+	positions := make(map[string][]int)
+	pos := 0
+	for i, text := range texts {
+		n := 0
+		idx.Analyzer.tokenize(text, d.Lang, func(p int, tok string) {
+			positions[tok] = append(positions[tok], pos+p)
+			n = p + 1
+		})
+		pos += n
+		ends[i] = pos
+	}
+	for tok, ps := range positions {
+		posting, ok := idx.Terms[tok]
+		if !ok {
+			posting = idx.newPosting(tok)
+			idx.Terms[tok] = posting
+			idx.dict = nil
+		}
+		posting.set(d.ID, ps)
+	}
+	idx.DocTokCounts[d.ID] = pos
+	idx.totalToks += pos
+	idx.FieldEnds[d.ID] = ends
+	if nums := extractNumbers(idx.Analyzer.docText(d)); len(nums) > 0 {
+		idx.Numbers[d.ID] = nums
+	}
+	if len(positions) > 0 {
+		idx.Fingerprints[d.ID] = simHash(positions)
+	}
+	idx.N = len(idx.Docs)
+	idx.docChanged(d.ID)
+}
+
+// DeleteDocument removes a doc and its postings; returns false if the ID is unknown
+func (idx *Index) DeleteDocument(id int) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.Vectors != nil {
+		idx.Vectors.Delete(id)
+	}
+	return idx.deleteDocument(id)
+}
+
+func (idx *Index) deleteDocument(id int) bool {
+	d, ok := idx.Docs[id]
+	if !ok {
+		return false
+	}
+	d = idx.withContent(d)
+	// re-tokenize the stored doc to find the terms it contributed
+	want, removed := 0, 0
+	covered := make(map[int]bool) // positions the removed entries held
+	counts := make(map[string]int)
+	for _, text := range idx.Analyzer.docFieldTexts(d) {
+		idx.Analyzer.tokenize(text, d.Lang, func(_ int, tok string) { counts[tok]++ })
+	}
+	for tok, n := range counts {
+		want += n
+		for _, p := range idx.Terms[tok].Positions(id) {
+			covered[p] = true
+			removed++
+		}
+		idx.dropPosting(tok, id)
+	}
+	// the analyzer changed since the doc was indexed: scan every term
+	if removed != want || len(covered) != idx.DocTokCounts[id] {
+		for tok := range idx.Terms {
+			idx.dropPosting(tok, id)
+		}
+	}
+	delete(idx.Docs, id)
+	idx.unstoreContent(id)
+	idx.forgetContent(id, d)
+	idx.byDate, idx.trie = nil, nil
+	idx.cache.clear()
+	idx.totalToks -= idx.DocTokCounts[id]
+	delete(idx.DocTokCounts, id)
+	delete(idx.FieldEnds, id)
+	delete(idx.Numbers, id)
+	delete(idx.Fingerprints, id)
+	idx.N = len(idx.Docs)
+	idx.docChanged(id)
+	return true
+}
+
+// dropPosting removes doc from a term's posting, and the term once unused
+func (idx *Index) dropPosting(tok string, doc int) {
+	posting, ok := idx.Terms[tok]
+	if !ok || !posting.remove(doc) {
+		return
+	}
+	if posting.Len() == 0 {
+		delete(idx.Terms, tok)
+		idx.dict = nil
+	}
+}
+
+// UpdateDocument replaces the stored doc with d's ID, re-indexing it.
+// Unlike AddDocument it never adds: an unknown ID is ErrDocNotFound.
+func (idx *Index) UpdateDocument(d Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.Docs[d.ID]; !ok {
+		return fmt.Errorf("document %d: %w", d.ID, ErrDocNotFound)
+	}
+	idx.addDocument(d)
+	return nil
+}
+
+// isFieldStart reports whether pos is the first token of one of doc's fields
+func (idx *Index) isFieldStart(doc, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	ends := idx.FieldEnds[doc]
+	for _, end := range ends[:max(len(ends)-1, 0)] {
+		if pos == end {
+			return true
+		}
+	}
+	return false
+}
+
+// anchoredPhraseDocs keeps the docs of s where the phrase starts a field
+func (idx *Index) anchoredPhraseDocs(s map[int]struct{}, toks []string, slop int) map[int]struct{} {
+	var variants [][]string
+	if slop > 0 {
+		variants = idx.fuzzyVariants(toks)
+	}
+	out := make(map[int]struct{})
+	for doc := range s {
+		for _, p := range idx.phraseStartsIn(doc, toks, variants, slop) {
+			if idx.isFieldStart(doc, p) {
+				out[doc] = struct{}{}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// phraseStartsIn returns the start of each occurrence of a phrase in doc;
+// variants are the fuzzy expansions of toks, used when slop > 0
+func (idx *Index) phraseStartsIn(doc int, toks []string, variants [][]string, slop int) []int {
+	if slop == 0 {
+		return idx.phraseStarts(doc, toks)
+	}
+	var starts []int
+	for _, run := range idx.sloppyPhraseStarts(doc, variants, slop) {
+		starts = append(starts, run[0])
+	}
+	return starts
+}
+
+// fieldPositions returns the positions of term in doc that lie in field
+func (idx *Index) fieldPositions(doc int, term, field string) []int {
+	var out []int
+	for _, p := range idx.Terms[term].Positions(doc) {
+		if idx.fieldOf(doc, p) == field {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// docsWithTermInField returns the docs where term occurs in field
+func (idx *Index) docsWithTermInField(term, field string) map[int]struct{} {
+	out := make(map[int]struct{})
+	for _, doc := range idx.Terms[term].Docs() {
+		if len(idx.fieldPositions(doc, term, field)) > 0 {
+			out[doc] = struct{}{}
+		}
+	}
+	return out
+}
+
+// fieldOf names the field (one of indexedFields) holding token pos of doc
+func (idx *Index) fieldOf(doc, pos int) string {
+	for i, end := range idx.FieldEnds[doc] {
+		if pos < end {
+			return indexedFields[i]
+		}
+	}
+	return ""
+}
+
+// Doc returns a stored document by ID, with its Content fetched from Store
+// if it's kept there
+func (idx *Index) Doc(id int) (Document, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	d, ok := idx.Docs[id]
+	return idx.withContent(d), ok
+}
+
+// hasDoc reports whether a doc with the given ID is indexed
+func (idx *Index) hasDoc(id int) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.Docs[id]
+	return ok
+}
+
+// DocCount returns the number of indexed documents
+func (idx *Index) DocCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.N
+}
+
+// TermCount returns the number of distinct indexed terms
+func (idx *Index) TermCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.Terms)
+}
+
+// CacheStats returns how many cacheable searches were answered from the
+// result cache and how many had to run
+func (idx *Index) CacheStats() (hits, misses uint64) {
+	return idx.cache.stats()
+}
+
+// TermInfo reports a term's document frequency (docs containing it) and
+// collection frequency (total occurrences across all docs). The term goes
+// through the analyzer first, so "Elections" finds "elections".
+func (idx *Index) TermInfo(term string) (df int, tf int, found bool) {
+	toks := idx.Analyzer.Tokenize(term)
+	if len(toks) == 0 {
+		return 0, 0, false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	posting, ok := idx.Terms[toks[0]]
+	if !ok {
+		return 0, 0, false
+	}
+	posting.Each(func(_ int, positions []int) {
+		tf += len(positions)
+	})
+	return posting.Len(), tf, true
+}
+
+// Each calls fn for every document in ascending ID order, stopping early if
+// fn returns false. It iterates a snapshot, so fn may modify the index.
+func (idx *Index) Each(fn func(Document) bool) {
+	idx.mu.RLock()
+	ids := sortedDocIDs(idx.Docs)
+	docs := make([]Document, len(ids))
+	for i, id := range ids {
+		docs[i] = idx.withContent(idx.Docs[id])
+	}
+	idx.mu.RUnlock()
+
+	for _, d := range docs {
+		if !fn(d) {
+			return
+		}
+	}
+}
+
+// SearchResult holds docID and score/matches
+type SearchResult struct {
+	DocID         int
+	Score         float64
+	MatchedTerms  []string
+	MatchedFields []string // fields (title, content) holding at least one match
+	Similar       []int    // near-duplicates folded into this result (see CollapseSimilar)
+}
+
+// Search is a full query processor: supports AND/OR/NOT and quoted phrases.
+// At most MaxResults results are returned when the cap is set. A malformed
+// query finds nothing; use SearchTotal or ValidateQuery to see why.
+func (idx *Index) Search(query string) []SearchResult {
+	results, _, _ := idx.SearchTotal(query)
+	return results
+}
+
+// SearchTotal is Search that also reports the full match count before the
+// MaxResults cap, so callers can tell when results were truncated, and an
+// error matching ErrMalformedQuery if the query doesn't parse.
+func (idx *Index) SearchTotal(query string) ([]SearchResult, int, error) {
+	return idx.search(context.Background(), query, SearchOptions{})
+}
+
+// SearchPage returns one page of results: up to limit of them (all the
+// rest if limit <= 0) starting at offset in the ranking, plus the full
+// match count. Page 3 of 10 is SearchPage(q, 20, 10). Like SearchTotal it
+// reports a malformed query as an error matching ErrMalformedQuery.
+func (idx *Index) SearchPage(query string, offset, limit int) ([]SearchResult, int, error) {
+	return idx.search(context.Background(), query, SearchOptions{Offset: offset, K: limit})
+}
+
+// SearchContext is Search with cancellation: it checks ctx while evaluating
+// and scoring and returns ctx.Err() once cancelled. k > 0 keeps only the
+// top k results (MaxResults still applies).
+func (idx *Index) SearchContext(ctx context.Context, query string, k int) ([]SearchResult, error) {
+	results, _, err := idx.search(ctx, query, SearchOptions{K: k})
+	return results, err
+}
+
+// SearchOptions narrows a search after boolean evaluation
+type SearchOptions struct {
+	K       int              // keep only the top K results (0 = all)
+	Offset  int              // skip this many top results first, for paging
+	Within  map[int]struct{} // if non-nil, only these doc IDs may match (allowlist)
+	Exclude map[int]struct{} // doc IDs that never match, e.g. already read or blocked
+
+	// Should is an optional query that only affects ranking: docs matching
+	// its terms score higher, but it never removes results
+	Should string
+
+	facets    *facetCounter     // set by SearchFacets to count every match after CollapseSimilar
+	histogram *histogramCounter // set by SearchHistogram, likewise
+}
+
+// SearchWithin is Search restricted to the given doc IDs
+func (idx *Index) SearchWithin(query string, ids map[int]struct{}) []SearchResult {
+	results, _, _ := idx.search(context.Background(), query, SearchOptions{Within: ids})
+	return results
+}
+
+// SearchBool is a must/should search: results are the docs matching must,
+// and docs that also match terms of should rank higher (should alone never
+// adds or removes a result). e.g. SearchBool("budget", "2024", 10)
+func (idx *Index) SearchBool(must, should string, k int) []SearchResult {
+	results, _, _ := idx.search(context.Background(), must, SearchOptions{K: k, Should: should})
+	return results
+}
+
+// SearchWithOptions is SearchContext with allow/deny lists; it also returns
+// the match count before MaxResults and K are applied
+func (idx *Index) SearchWithOptions(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, int, error) {
+	return idx.search(ctx, query, opts)
+}
+
+// search runs a query and returns the ranked results, the full match count
+// and any context error
+func (idx *Index) search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, int, error) {
+	if len(query) == 0 {
+		return nil, 0, nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	// parse query -> RPN tokens
+	rpn, err := idx.parseQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	var shouldRPN []string
+	if opts.Should != "" {
+		if shouldRPN, err = idx.parseQuery(opts.Should); err != nil {
+			return nil, 0, err
+		}
+	}
+	cacheKey := ""
+	if idx.CacheSize > 0 && opts.Within == nil && len(opts.Exclude) == 0 && opts.facets == nil && opts.histogram == nil {
+		cacheKey = searchCacheKey(rpn, shouldRPN, opts.Offset, opts.K)
+		if results, total, ok := idx.cache.get(cacheKey); ok {
+			return results, total, nil
+		}
+	}
+	// evaluate RPN to get set of matching docIDs
+	resSet, err := idx.evaluateRPNContext(ctx, rpn)
+	if err != nil {
+		return nil, 0, err
+	}
+	if opts.Within != nil {
+		resSet = setIntersect(resSet, opts.Within)
+	}
+	if len(opts.Exclude) > 0 {
+		resSet = setDiff(resSet, opts.Exclude)
+	}
+	// convert set to scored results
+	run := idx.newQueryRun(rpn)
+	var should *queryRun
+	if shouldRPN != nil {
+		should = run.clause(shouldRPN)
+	}
+	var results []SearchResult
+	for doc := range resSet {
+		if len(results)%256 == 0 && ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		r := run.result(doc)
+		if should != nil {
+			r = should.boost(r)
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return idx.less(results[i], results[j]) })
+	if idx.CollapseSimilar {
+		results = idx.collapseSimilar(results)
+	}
+	// facets and the histogram count what total does: every match left
+	// after collapsing, before paging
+	for _, r := range results {
+		if opts.facets != nil {
+			opts.facets.add(idx.Docs[r.DocID])
+		}
+		if opts.histogram != nil {
+			opts.histogram.add(idx.Docs[r.DocID])
+		}
+	}
+	total := len(results)
+	if idx.MaxResults > 0 && len(results) > idx.MaxResults {
+		results = results[:idx.MaxResults]
+	}
+	if opts.Offset > 0 {
+		results = results[min(opts.Offset, len(results)):]
+	}
+	if opts.K > 0 && len(results) > opts.K {
+		results = results[:opts.K]
+	}
+	if cacheKey != "" {
+		idx.cache.put(cacheKey, results, total, idx.CacheSize)
+	}
+	return results, total, nil
+}
+
+// RankMode selects how Search orders results
+type RankMode int
+
+const (
+	RankByScore        RankMode = iota // TF-IDF score only
+	RankByMatchedTerms                 // more distinct query terms first, score breaks ties
+)
+
+// less reports whether a should come before b under the index's Sort, or
+// its RankMode without one
+func (idx *Index) less(a, b SearchResult) bool {
+	if idx.Sort != nil {
+		return idx.Sort(idx, a, b)
+	}
+	return SortByRank(idx, a, b)
+}
+
+// score runs the configured Scorer, TF-IDF by default
+func (idx *Index) score(doc int, matched []string) float64 {
+	if idx.Scorer != nil {
+		return idx.Scorer(idx, doc, matched)
+	}
+	return idx.scoreDoc(doc, matched)
+}
+
+// scoreDoc: TF-IDF style scoring using matched terms
+func (idx *Index) scoreDoc(doc int, matched []string) float64 {
+	score := 0.0
+	for _, t := range matched {
+		if strings.HasPrefix(t, "PHRASE:") {
+			// give a boost for phrase matches
+			score += 2.0
+			continue
+		}
+		posting := idx.Terms[t]
+		if posting == nil {
+			continue
+		}
+		tf := idx.fieldTermFreq(doc, posting.Positions(doc))
+		df := float64(idx.docFreq(t, posting))
+		if df == 0 || idx.DocTokCounts[doc] == 0 {
+			continue
+		}
+		// normalize tf by doc length
+		tfNorm := tf / idx.docLenNorm(doc)
+		score += tfNorm * idx.idf(df)
+	}
+	return score
+}
+
+// LengthNormMode selects how scoreDoc normalizes term frequency by doc length
+type LengthNormMode int
+
+const (
+	LengthNormLinear  LengthNormMode = iota // divide by the doc's token count
+	LengthNormPivoted                       // pivot around the average length, gentler on long docs
+)
+
+// DefaultPivotSlope is the pivoted normalization slope when PivotSlope is unset
+const DefaultPivotSlope = 0.2
+
+// docLenNorm is the divisor for a doc's term frequencies. Pivoted
+// normalization uses (1-s)*avg + s*len: docs of average length are treated
+// as under linear normalization, longer ones are penalized less than
+// linearly (but still more than short ones), and s = 1 is linear again.
+func (idx *Index) docLenNorm(doc int) float64 {
+	n := float64(idx.DocTokCounts[doc])
+	if idx.LengthNorm != LengthNormPivoted || idx.N == 0 {
+		return n
+	}
+	s := idx.PivotSlope
+	if s <= 0 || s > 1 {
+		s = DefaultPivotSlope
+	}
+	return (1-s)*idx.avgDocLen() + s*n
+}
+
+// AvgDocLen is the mean number of tokens per document
+func (idx *Index) AvgDocLen() float64 {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.avgDocLen()
+}
+
+func (idx *Index) avgDocLen() float64 {
+	n := idx.collectionN()
+	if n == 0 {
+		return 0
+	}
+	return float64(idx.collectionToks()) / float64(n)
+}
+
+// earlyMentionScale is the decay length (in tokens) of EarlyMentionBoost:
+// an occurrence this far in gets about a third of the full boost
+const earlyMentionScale = 50.0
+
+// termFreq counts a term's occurrences in a doc. With EarlyMentionBoost set,
+// each occurrence is weighted 1 + boost*exp(-pos/scale), so mentions near
+// the top of an article (headline, lede) count for more.
+func (idx *Index) termFreq(positions []int) float64 {
+	if idx.EarlyMentionBoost <= 0 {
+		return float64(len(positions))
+	}
+	tf := 0.0
+	for _, p := range positions {
+		tf += 1 + idx.EarlyMentionBoost*math.Exp(-float64(p)/earlyMentionScale)
+	}
+	return tf
+}
+
+// DefaultSummaryBoost weights summary occurrences when Index.FieldBoosts
+// has no "summary" entry: summaries are short and editor-written, so a
+// mention there says more than one in the body
+const DefaultSummaryBoost = 2.0
+
+// defaultFieldBoosts apply to fields missing from Index.FieldBoosts
+var defaultFieldBoosts = map[string]float64{"summary": DefaultSummaryBoost}
+
+// fieldTermFreq sums termFreq over the fields of doc, each weighted by its
+// field boost, so a term in both title and body gets both contributions.
+// Positions are sorted, so each field's run is contiguous.
+func (idx *Index) fieldTermFreq(doc int, positions []int) float64 {
+	tf := 0.0
+	start := 0
+	for i, end := range idx.FieldEnds[doc] {
+		stop := sort.SearchInts(positions, end)
+		tf += idx.fieldBoost(indexedFields[i]) * idx.termFreq(positions[start:stop])
+		start = stop
+	}
+	return tf
+}
+
+// fieldBoost returns the weight of a field: FieldBoosts, then
+// defaultFieldBoosts, then 1
+func (idx *Index) fieldBoost(field string) float64 {
+	if b, ok := idx.FieldBoosts[field]; ok && b > 0 {
+		return b
+	}
+	if b, ok := defaultFieldBoosts[field]; ok {
+		return b
+	}
+	return 1
+}
+
+// idf computes inverse document frequency with optional smoothing and clamping
+func (idx *Index) idf(df float64) float64 {
+	v := math.Log(1 + float64(idx.collectionN())/(df+idx.IDFSmoothing))
+	if idx.IDFCeiling > 0 && v > idx.IDFCeiling {
+		v = idx.IDFCeiling
+	}
+	if idx.IDFFloor > 0 && v < idx.IDFFloor {
+		v = idx.IDFFloor
+	}
+	return v
+}
+
+// EvaluateRPN evaluates RPN query tokens and returns a set (map[int]struct{}) of matching docs
+func (idx *Index) EvaluateRPN(rpn []string) map[int]struct{} {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.evaluateRPN(rpn)
+}
+
+func (idx *Index) evaluateRPN(rpn []string) map[int]struct{} {
+	s, _ := idx.evaluateRPNContext(context.Background(), rpn)
+	return s
+}
+
+// evaluateRPNContext is evaluateRPN that stops between operands once ctx is done
+func (idx *Index) evaluateRPNContext(ctx context.Context, rpn []string) (map[int]struct{}, error) {
+	stack := []rpnOperand{}
+	var universe map[int]struct{} // built on the first NOT
+	lang := rpnLang(rpn)
+	for _, tok := range rpn {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if tok == "AND" || tok == "OR" {
+			// binary
+			if len(stack) < 2 {
+				continue
+			}
+			r := stack[len(stack)-1]
+			l := stack[len(stack)-2]
+			stack = stack[:len(stack)-2]
+			if tok == "AND" {
+				stack = append(stack, andOperands(l, r))
+			} else {
+				stack = append(stack, rpnOperand{set: setUnion(l.docs(), r.docs())})
+			}
+		} else if tok == "NOT" {
+			// unary: pop one
+			if len(stack) < 1 {
+				continue
+			}
+			a := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if universe == nil {
+				universe = idx.allDocsSet()
+			}
+			stack = append(stack, rpnOperand{set: setDiff(universe, a.docs())})
+		} else {
+			// term or phrase
+			var s map[int]struct{}
+			if tok == rpnAllDocs {
+				s = idx.allDocsSet()
+			} else if phrase, slop, ok := parsePhraseToken(tok); ok {
+				toks := idx.Analyzer.phraseTokens(phrase, lang)
+				if slop > 0 {
+					s = idx.docsWithSloppyPhrase(toks, slop)
+				} else {
+					s = idx.docsWithPhrase(toks)
+				}
+				if phraseAnchored(tok) {
+					s = idx.anchoredPhraseDocs(s, toks, slop)
+				}
+			} else if terms, dists, ok := parseNearToken(tok); ok {
+				s = idx.docsNear(terms, dists)
+			} else if field, ok := parseExistsToken(tok); ok {
+				s = make(map[int]struct{})
+				for id, d := range idx.Docs {
+					if docHasField(d, field) || field == "content" && idx.StoredContent[id] {
+						s[id] = struct{}{}
+					}
+				}
+			} else if l, ok := parseLangToken(tok); ok {
+				s = idx.docsInLang(l)
+			} else if r, err := parseDateRangeToken(tok); err == nil {
+				s = idx.docsInDateRange(r)
+			} else if r, err := parseRangeToken(tok); err == nil {
+				s = idx.docsInRange(r)
+			} else if field, term, ok := parseFieldTermToken(tok); ok {
+				s = idx.docsWithTermInField(term, field)
+			} else if posting, ok := idx.Terms[tok]; ok {
+				// kept as a posting so AND can skip through it
+				stack = append(stack, rpnOperand{posting: posting})
+				continue
+			} else {
+				s = map[int]struct{}{} // empty set
+			}
+			stack = append(stack, rpnOperand{set: s})
+		}
+	}
+	if len(stack) == 0 {
+		return map[int]struct{}{}, nil
+	}
+	return stack[len(stack)-1].docs(), nil
+}
+
+// rpnOperand is a doc set on the evaluation stack. A plain term stays a
+// posting until an operator other than AND needs its docs as a set.
+type rpnOperand struct {
+	set     map[int]struct{}
+	posting *Posting
+}
+
+func (o rpnOperand) docs() map[int]struct{} {
+	if o.posting == nil {
+		return o.set
+	}
+	s := make(map[int]struct{}, o.posting.Len())
+	for it := o.posting.iter(); !it.done; it.next() {
+		s[it.doc] = struct{}{}
+	}
+	return s
+}
+
+// andOperands intersects two operands, skipping through postings rather
+// than materializing them
+func andOperands(l, r rpnOperand) rpnOperand {
+	switch {
+	case l.posting != nil && r.posting != nil:
+		return rpnOperand{set: intersectPostings(l.posting, r.posting)}
+	case l.posting != nil:
+		return rpnOperand{set: intersectSetPosting(r.set, l.posting)}
+	case r.posting != nil:
+		return rpnOperand{set: intersectSetPosting(l.set, r.posting)}
+	}
+	return rpnOperand{set: setIntersect(l.set, r.set)}
+}
+
+// helpers to work with sets
+func (idx *Index) allDocsSet() map[int]struct{} {
+	out := make(map[int]struct{})
+	for id := range idx.Docs {
+		out[id] = struct{}{}
+	}
+	return out
+}
+
+func setIntersect(a, b map[int]struct{}) map[int]struct{} {
+	res := make(map[int]struct{})
+	// iterate smaller
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for k := range a {
+		if _, ok := b[k]; ok {
+			res[k] = struct{}{}
+		}
+	}
+	return res
+}
+
+// intersectPostings returns the docs in both postings, leapfrogging: each
+// iterator skips ahead to the other's current doc
+func intersectPostings(a, b *Posting) map[int]struct{} {
+	res := make(map[int]struct{})
+	if a.Len() > b.Len() {
+		a, b = b, a
+	}
+	ia, ib := a.iter(), b.iter()
+	for !ia.done && ib.advance(ia.doc) {
+		if ib.doc == ia.doc {
+			res[ia.doc] = struct{}{}
+			ia.next()
+		} else {
+			ia.advance(ib.doc)
+		}
+	}
+	return res
+}
+
+// intersectSetPosting returns the docs of s that are in p. A set smaller
+// than the posting is sorted and looked up by advancing through p;
+// otherwise p is walked and each doc checked in s.
+func intersectSetPosting(s map[int]struct{}, p *Posting) map[int]struct{} {
+	res := make(map[int]struct{})
+	if len(s) >= p.Len() {
+		for it := p.iter(); !it.done; it.next() {
+			if _, ok := s[it.doc]; ok {
+				res[it.doc] = struct{}{}
+			}
+		}
+		return res
+	}
+	ids := make([]int, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	it := p.iter()
+	for _, id := range ids {
+		if !it.advance(id) {
+			break
+		}
+		if it.doc == id {
+			res[id] = struct{}{}
+		}
+	}
+	return res
+}
+
+func setUnion(a, b map[int]struct{}) map[int]struct{} {
+	res := make(map[int]struct{})
+	for k := range a {
+		res[k] = struct{}{}
+	}
+	for k := range b {
+		res[k] = struct{}{}
+	}
+	return res
+}
+
+func setDiff(a, b map[int]struct{}) map[int]struct{} {
+	res := make(map[int]struct{})
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			res[k] = struct{}{}
+		}
+	}
+	return res
+}
+
+// docsWithPhrase: return docs where tokens appear consecutively
+func (idx *Index) docsWithPhrase(tokens []string) map[int]struct{} {
+	res := make(map[int]struct{})
+	if len(tokens) == 0 {
+		return res
+	}
+	// get candidate docs by intersecting postings for each token
+	var candidate []int
+	for i, t := range tokens {
+		posting, ok := idx.Terms[t]
+		if !ok {
+			return res
+		}
+		ids := posting.Docs()
+		if i == 0 {
+			candidate = ids
+		} else {
+			candidate = intersectSorted(candidate, ids)
+		}
+		if len(candidate) == 0 {
+			return res
+		}
+	}
+	for _, doc := range candidate {
+		if idx.checkPhraseInDoc(doc, tokens) {
+			res[doc] = struct{}{}
+		}
+	}
+	return res
+}
+
+// checkPhraseInDoc: walk the per-token sorted position lists in lockstep,
+// so each list is scanned at most once (O(total positions))
+func (idx *Index) checkPhraseInDoc(doc int, tokens []string) bool {
+	posLists := make([][]int, len(tokens))
+	for i, t := range tokens {
+		posLists[i] = idx.Terms[t].Positions(doc)
+		if len(posLists[i]) == 0 {
+			return false
+		}
+	}
+	ptr := make([]int, len(tokens))
+	for _, p := range posLists[0] {
+		ok := true
+		for i := 1; i < len(tokens); i++ {
+			need := p + i
+			list := posLists[i]
+			for ptr[i] < len(list) && list[ptr[i]] < need {
+				ptr[i]++
+			}
+			if ptr[i] == len(list) {
+				// list exhausted: no later start can complete the phrase
+				return false
+			}
+			if list[ptr[i]] != need {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func intersectSorted(a, b []int) []int {
+	i, j := 0, 0
+	var res []int
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			res = append(res, a[i])
+			i++
+			j++
+		} else if a[i] < b[j] {
+			i++
+		} else {
+			j++
+		}
+	}
+	return res
+}
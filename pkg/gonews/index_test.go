@@ -0,0 +1,257 @@
+package gonews
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIDFClamping(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Zyzzyva sighting", Content: "markets steady"},
+		{ID: 2, Title: "Markets", Content: "markets rally as markets reopen"},
+	}
+	for i := 3; i <= 20; i++ {
+		docs = append(docs, Document{ID: i, Title: fmt.Sprintf("Report %d", i), Content: "markets update"})
+	}
+	tests := []struct {
+		name  string
+		setup func(idx *Index)
+		top   int
+	}{
+		{"plain idf", func(*Index) {}, 1},
+		{"ceiling", func(idx *Index) { idx.IDFCeiling = 0.2 }, 2},
+		{"smoothing", func(idx *Index) { idx.IDFSmoothing = 100 }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := buildIndex(docs...)
+			tt.setup(idx)
+			results := idx.Search("zyzzyva OR markets")
+			if len(results) == 0 || results[0].DocID != tt.top {
+				t.Errorf("top result = %v, want doc %d", resultIDs(results), tt.top)
+			}
+		})
+	}
+}
+
+func TestIDFFloor(t *testing.T) {
+	idx := buildIndex(Document{ID: 1, Content: "common"}, Document{ID: 2, Content: "common"})
+	plain := idx.idf(2)
+	idx.IDFFloor = plain * 2
+	if got := idx.idf(2); got != idx.IDFFloor {
+		t.Errorf("idf with floor = %v, want %v", got, idx.IDFFloor)
+	}
+	if got := idx.idf(0.5); got < idx.IDFFloor {
+		t.Errorf("idf of a rare term = %v, below the floor %v", got, idx.IDFFloor)
+	}
+}
+
+func TestEach(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 7, Title: "seven"},
+		Document{ID: 2, Title: "two"},
+		Document{ID: 5, Title: "five"},
+	)
+	var seen []int
+	idx.Each(func(d Document) bool {
+		seen = append(seen, d.ID)
+		return true
+	})
+	if want := []int{2, 5, 7}; !slices.Equal(seen, want) {
+		t.Errorf("Each visited %v, want %v", seen, want)
+	}
+
+	seen = nil
+	idx.Each(func(d Document) bool {
+		seen = append(seen, d.ID)
+		return len(seen) < 2
+	})
+	if want := []int{2, 5}; !slices.Equal(seen, want) {
+		t.Errorf("Each stopping after two visited %v, want %v", seen, want)
+	}
+}
+
+func TestMaxResults(t *testing.T) {
+	idx := NewIndex()
+	for i := 1; i <= 30; i++ {
+		idx.AddDocument(Document{ID: i, Title: fmt.Sprintf("Market report %d", i), Content: "markets moved"})
+	}
+	idx.MaxResults = 10
+	results, total, err := idx.SearchTotal("markets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 10 || total != 30 {
+		t.Errorf("SearchTotal = %d results of %d, want 10 of 30", len(results), total)
+	}
+}
+
+// naivePhraseInDoc is the nested-loop phrase check checkPhraseInDoc
+// replaced: every start position rescans the later tokens' lists
+func naivePhraseInDoc(idx *Index, doc int, tokens []string) bool {
+	for _, p := range idx.Terms[tokens[0]].Positions(doc) {
+		ok := true
+		for i := 1; i < len(tokens) && ok; i++ {
+			ok = slices.Contains(idx.Terms[tokens[i]].Positions(doc), p+i)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// repetitiveDoc repeats "alpha beta" n times and ends with the words given
+func repetitiveDoc(n int, end string) Document {
+	return Document{ID: 1, Content: strings.Repeat("alpha beta ", n) + end}
+}
+
+func TestCheckPhraseInDoc(t *testing.T) {
+	tests := []struct {
+		end    string
+		phrase []string
+		want   bool
+	}{
+		{"alpha gamma", []string{"alpha", "gamma"}, true},
+		{"alpha gamma", []string{"beta", "gamma"}, false},
+		{"gamma alpha", []string{"alpha", "gamma"}, false},
+		{"alpha beta gamma", []string{"alpha", "beta", "gamma"}, true},
+		{"beta alpha", []string{"beta", "alpha", "beta"}, true},
+		{"gamma", []string{"beta", "beta"}, false},
+	}
+	for _, tt := range tests {
+		idx := buildIndex(repetitiveDoc(2000, tt.end))
+		if got := idx.checkPhraseInDoc(1, tt.phrase); got != tt.want {
+			t.Errorf("checkPhraseInDoc(%v) ending %q = %v, want %v", tt.phrase, tt.end, got, tt.want)
+		}
+		if got := naivePhraseInDoc(idx, 1, tt.phrase); got != tt.want {
+			t.Errorf("naive check of %v ending %q = %v, want %v", tt.phrase, tt.end, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkPhraseInDoc(b *testing.B) {
+	idx := buildIndex(repetitiveDoc(5000, "alpha gamma"))
+	phrase := []string{"alpha", "beta", "alpha", "gamma"}
+	b.Run("merge", func(b *testing.B) {
+		for range b.N {
+			idx.checkPhraseInDoc(1, phrase)
+		}
+	})
+	b.Run("nested", func(b *testing.B) {
+		for range b.N {
+			naivePhraseInDoc(idx, 1, phrase)
+		}
+	})
+}
+
+func TestRankByMatchedTerms(t *testing.T) {
+	idx := buildIndex(
+		// one query term, repeated in a short doc: high TF-IDF
+		Document{ID: 1, Content: "ukraine ukraine ukraine"},
+		// both query terms in a long doc: modest TF-IDF
+		Document{ID: 2, Content: "ukraine talks resumed while delegates from several countries met for sanctions discussions in geneva on monday"},
+		Document{ID: 3, Content: "weather report"},
+	)
+	tests := []struct {
+		rank RankMode
+		want []int
+	}{
+		{RankByScore, []int{1, 2}},
+		{RankByMatchedTerms, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		idx.Rank = tt.rank
+		if got := resultIDs(idx.Search("ukraine OR sanctions")); !slices.Equal(got, tt.want) {
+			t.Errorf("rank mode %d: results %v, want %v", tt.rank, got, tt.want)
+		}
+	}
+}
+
+// cancelAfterCtx reports itself cancelled once Err has been asked n times,
+// to cancel a search partway through
+type cancelAfterCtx struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterCtx) Err() error {
+	if c.n--; c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestSearchContextCancel(t *testing.T) {
+	idx := NewIndex()
+	for i := 1; i <= 2000; i++ {
+		idx.AddDocument(Document{ID: i, Content: fmt.Sprintf("market update number %d", i)})
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"before the search", cancelled},
+		{"while evaluating", &cancelAfterCtx{Context: context.Background(), n: 1}},
+		{"while scoring", &cancelAfterCtx{Context: context.Background(), n: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := idx.SearchContext(tt.ctx, "market AND update", 0)
+			if !errors.Is(err, context.Canceled) || results != nil {
+				t.Errorf("SearchContext = %d results, %v; want context.Canceled", len(results), err)
+			}
+		})
+	}
+	results, err := idx.SearchContext(context.Background(), "market AND update", 10)
+	if err != nil || len(results) != 10 {
+		t.Errorf("SearchContext = %d results, %v; want 10", len(results), err)
+	}
+}
+
+func TestEarlyMentionBoost(t *testing.T) {
+	filler := strings.Repeat("report ", 30)
+	idx := buildIndex(
+		Document{ID: 1, Content: filler + "inflation"},
+		Document{ID: 2, Content: "inflation " + filler},
+	)
+	results := idx.Search("inflation")
+	if len(results) != 2 || results[0].Score != results[1].Score {
+		t.Fatalf("without the boost both docs should tie: %+v", results)
+	}
+	idx.EarlyMentionBoost = 1
+	if got := resultIDs(idx.Search("inflation")); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("with EarlyMentionBoost results = %v, want the early mention first", got)
+	}
+}
+
+func TestTermInfo(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Elections", Content: "election results: the election was close"},
+		Document{ID: 2, Content: "a local election"},
+		Document{ID: 3, Content: "weather"},
+	)
+	tests := []struct {
+		term   string
+		df, tf int
+		found  bool
+	}{
+		{"election", 2, 3, true},
+		{"Elections", 1, 1, true},
+		{"weather", 1, 1, true},
+		{"the", 0, 0, false},
+		{"missing", 0, 0, false},
+	}
+	for _, tt := range tests {
+		df, tf, found := idx.TermInfo(tt.term)
+		if df != tt.df || tf != tt.tf || found != tt.found {
+			t.Errorf("TermInfo(%q) = %d, %d, %v; want %d, %d, %v", tt.term, df, tf, found, tt.df, tt.tf, tt.found)
+		}
+	}
+}
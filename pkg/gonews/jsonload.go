@@ -0,0 +1,157 @@
+package gonews
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONLFields names the keys LoadJSONL reads each Document field from.
+// An empty name tries the same aliases as LoadFromAPI (id/_id/uuid,
+// title/headline, ...).
+type JSONLFields struct {
+	ID, Title, Date, Content, Summary, Author, Source, Lang string
+}
+
+// ParseJSONLFields parses a mapping like "id=article_id,content=body" into
+// JSONLFields; keys are id, title, date, content, summary, author, source
+// and lang
+func ParseJSONLFields(s string) (JSONLFields, error) {
+	var f JSONLFields
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return f, fmt.Errorf("field mapping %q: want field=name", part)
+		}
+		switch strings.TrimSpace(key) {
+		case "id":
+			f.ID = name
+		case "title":
+			f.Title = name
+		case "date":
+			f.Date = name
+		case "content":
+			f.Content = name
+		case "summary":
+			f.Summary = name
+		case "author":
+			f.Author = name
+		case "source":
+			f.Source = name
+		case "lang":
+			f.Lang = name
+		default:
+			return f, fmt.Errorf("field mapping %q: unknown field %q", part, key)
+		}
+	}
+	return f, nil
+}
+
+// lookup returns the keys to try for one field: the configured name, else
+// the defaults
+func (f JSONLFields) lookup(name string, defaults []string) []string {
+	if name != "" {
+		return []string{name}
+	}
+	return defaults
+}
+
+// IsJSONLPath reports whether path looks like newline-delimited JSON
+// (.jsonl, .ndjson, possibly compressed as .gz or .zst), as opposed to CSV
+func IsJSONLPath(path string) bool {
+	p := strings.ToLower(trimCompressedExt(path))
+	return strings.HasSuffix(p, ".jsonl") || strings.HasSuffix(p, ".ndjson")
+}
+
+// LoadJSONL reads newline-delimited JSON, one article object per line, with
+// keys mapped to Document fields by fields. IDs are handled as in
+// LoadCSVReport. Blank lines are skipped; lines that aren't a JSON object
+// are skipped with a warning. maxDocs > 0 stops after that many documents.
+// A file without any documents is ErrEmptyCorpus.
+func LoadJSONL(path string, fields JSONLFields, maxDocs int) ([]Document, []LoadWarning, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var (
+		idFields      = fields.lookup(fields.ID, apiIDFields)
+		titleFields   = fields.lookup(fields.Title, apiTitleFields)
+		dateFields    = fields.lookup(fields.Date, apiDateFields)
+		contentFields = fields.lookup(fields.Content, apiContentFields)
+		summaryFields = fields.lookup(fields.Summary, apiSummaryFields)
+		authorFields  = fields.lookup(fields.Author, apiAuthorFields)
+		sourceFields  = fields.lookup(fields.Source, apiSourceFields)
+		langFields    = fields.lookup(fields.Lang, apiLangFields)
+	)
+
+	// bufio.Reader rather than Scanner: full articles easily pass its line limit
+	r := bufio.NewReader(f)
+	var docs []Document
+	var warnings []LoadWarning
+	var idWarnings []int // positions in warnings of synthetic-ID notes
+	ids := newIDAllocator()
+	for line := 1; maxDocs <= 0 || len(docs) < maxDocs; line++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			var m map[string]any
+			if jerr := json.Unmarshal(b, &m); jerr != nil {
+				warnings = append(warnings, LoadWarning{Line: line, Msg: "skipping line, not a JSON object: " + jerr.Error()})
+			} else {
+				d := Document{
+					Title:   apiString(m, titleFields),
+					Date:    apiString(m, dateFields),
+					Content: apiString(m, contentFields),
+					Summary: apiString(m, summaryFields),
+					Author:  apiString(m, authorFields),
+					Source:  apiString(m, sourceFields),
+					Lang:    strings.ToLower(apiString(m, langFields)),
+				}
+				var problem string
+				d.ID, d.SourceID, problem = ids.claim(apiString(m, idFields), len(docs))
+				if problem != "" {
+					idWarnings = append(idWarnings, len(warnings))
+					warnings = append(warnings, LoadWarning{Line: line, Msg: problem})
+				}
+				docs = append(docs, d)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(docs) == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", path, ErrEmptyCorpus)
+	}
+	for i, id := range ids.assign(docs) {
+		warnings[idWarnings[i]].Msg += fmt.Sprintf(" %d", id)
+	}
+	return docs, warnings, nil
+}
+
+// JSONLLoader loads a newline-delimited JSON file (see LoadJSONL).
+// Warnings from the last Load are kept for reporting.
+type JSONLLoader struct {
+	Path     string
+	Fields   JSONLFields
+	MaxDocs  int // 0 = all
+	Warnings []LoadWarning
+}
+
+func (l *JSONLLoader) Load() ([]Document, error) {
+	docs, warnings, err := LoadJSONL(l.Path, l.Fields, l.MaxDocs)
+	l.Warnings = warnings
+	return docs, err
+}
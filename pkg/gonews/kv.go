@@ -0,0 +1,367 @@
+package gonews
+
+import (
+	"cmp"
+	"container/list"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KVStore is an ordered key-value store, such as Bolt or Badger, that an
+// index can live in (see OpenIndex). Keys are grouped in named buckets.
+// Implementations must be safe for concurrent use.
+type KVStore interface {
+	Get(bucket, key string) ([]byte, error) // nil, nil if key is absent
+	// Each calls fn with every key in bucket; value is only valid during fn
+	Each(bucket string, fn func(key string, value []byte) error) error
+	Write(batch []KVWrite) error // applies every write or none
+}
+
+// KVWrite sets one key in a KVStore batch; a nil Value deletes it
+type KVWrite struct {
+	Bucket, Key string
+	Value       []byte
+}
+
+// the buckets an index is kept in
+const (
+	kvTerms = "terms" // term -> Posting.MarshalBinary
+	kvDocs  = "docs"  // doc ID -> kvDoc as JSON
+	kvMeta  = "meta"  // kvMetaKey -> kvIndexMeta as JSON
+)
+
+const kvMetaKey = "index"
+
+// DefaultPostingCacheSize is the PostingCacheSize used when it is 0
+const DefaultPostingCacheSize = 64 << 20
+
+// a KV-backed index writes its changes out after this many changed docs
+// even without Flush, so bulk indexing keeps few postings in memory
+const kvFlushDocs = 1000
+
+// kvIndexMeta describes the index a KVStore holds
+type kvIndexMeta struct {
+	Version     int
+	Analyzer    Analyzer
+	DupOf       map[int]int `json:",omitempty"`
+	DupsDropped int         `json:",omitempty"`
+}
+
+// kvDoc is everything the index keeps about one doc besides postings
+type kvDoc struct {
+	Doc           Document
+	TokCount      int
+	FieldEnds     []int     `json:",omitempty"`
+	Numbers       []float64 `json:",omitempty"`
+	Fingerprint   *uint64   `json:",omitempty"`
+	StoredContent bool      `json:",omitempty"`
+}
+
+// kvBacking ties an index to its KVStore. Postings are loaded on first
+// use into an LRU bounded by size; postings changed since the last flush
+// are held in dirty until written. Loads happen under the index's read
+// lock, so mu guards the cache itself.
+type kvBacking struct {
+	store KVStore
+	idx   *Index
+
+	mu    sync.Mutex
+	cache map[string]*list.Element // clean postings, values *kvCached
+	order *list.List               // most recently used first
+	size  int                      // bytes held by cache
+
+	dirty     map[string]*Posting // guarded by the index's write lock, and mu
+	dirtyDocs map[int]bool        // docs added or deleted since the last flush
+	err       error               // first failed load or automatic flush
+}
+
+type kvCached struct {
+	term string
+	p    *Posting
+	size int
+}
+
+// lazyPosting is what a stand-in Posting needs to load the real one
+type lazyPosting struct {
+	kv   *kvBacking
+	term string
+}
+
+// load returns the posting for reading. A store error is remembered for
+// Flush and reads as an empty posting.
+func (l *lazyPosting) load() *Posting {
+	kv := l.kv
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.lookup(l.term)
+}
+
+// loadForWrite returns the posting for changing, pinned until the next
+// flush. Callers hold the index's write lock.
+func (l *lazyPosting) loadForWrite() *Posting {
+	kv := l.kv
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	p := kv.lookup(l.term)
+	if e, ok := kv.cache[l.term]; ok {
+		kv.size -= e.Value.(*kvCached).size
+		kv.order.Remove(e)
+		delete(kv.cache, l.term)
+	}
+	kv.dirty[l.term] = p
+	return p
+}
+
+// lookup finds term's posting in memory or reads it, under mu
+func (kv *kvBacking) lookup(term string) *Posting {
+	if p, ok := kv.dirty[term]; ok {
+		return p
+	}
+	if e, ok := kv.cache[term]; ok {
+		kv.order.MoveToFront(e)
+		return e.Value.(*kvCached).p
+	}
+	p := &Posting{}
+	data, err := kv.store.Get(kvTerms, term)
+	if err == nil && data != nil {
+		err = p.UnmarshalBinary(data)
+	}
+	if err != nil {
+		kv.fail(fmt.Errorf("load posting %q: %w", term, err))
+		return &Posting{} // don't cache a half-read posting
+	}
+	kv.cacheClean(term, p)
+	return p
+}
+
+// fail remembers the first error for Flush to report, under mu
+func (kv *kvBacking) fail(err error) {
+	if kv.err == nil {
+		kv.err = err
+	}
+}
+
+// cacheClean adds an unchanged posting to the LRU, evicting the least
+// recently used ones past the index's PostingCacheSize
+func (kv *kvBacking) cacheClean(term string, p *Posting) {
+	c := &kvCached{term: term, p: p, size: len(term) + postingBytes(p)}
+	kv.cache[term] = kv.order.PushFront(c)
+	kv.size += c.size
+	limit := kv.idx.PostingCacheSize
+	if limit <= 0 {
+		limit = DefaultPostingCacheSize
+	}
+	for kv.size > limit && kv.order.Len() > 1 {
+		old := kv.order.Remove(kv.order.Back()).(*kvCached)
+		delete(kv.cache, old.term)
+		kv.size -= old.size
+	}
+}
+
+// postingBytes estimates the memory a loaded posting takes
+func postingBytes(p *Posting) int {
+	n := 64
+	for _, b := range p.blocks {
+		n += len(b.data) + 32
+	}
+	return n
+}
+
+// OpenIndex returns the index kept in store, with the Analyzer it was
+// built with. A store without an index gives an empty one; set its
+// Analyzer before adding docs.
+// Only the term list, document frequencies and per-doc data are read up
+// front; postings are read as searches need them, with the most recently
+// used kept in memory up to PostingCacheSize. Changes are written back by
+// Flush, and every so many changed docs on their own. Indexes made from it
+// (EmptyCopy, Reanalyze, MergeIndexes) live in memory.
+func OpenIndex(store KVStore) (*Index, error) {
+	idx := NewIndex()
+	kv := &kvBacking{
+		store:     store,
+		idx:       idx,
+		cache:     make(map[string]*list.Element),
+		order:     list.New(),
+		dirty:     make(map[string]*Posting),
+		dirtyDocs: make(map[int]bool),
+	}
+	idx.kv = kv
+
+	data, err := store.Get(kvMeta, kvMetaKey)
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	if data == nil {
+		return idx, nil
+	}
+	var meta kvIndexMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("open index: %v: %w", err, ErrInvalidIndex)
+	}
+	if meta.Version != indexFormatVersion {
+		return nil, fmt.Errorf("open index: format version %d, want %d: %w", meta.Version, indexFormatVersion, ErrInvalidIndex)
+	}
+
+	err = store.Each(kvTerms, func(term string, value []byte) error {
+		// MarshalBinary leads with the doc count
+		n, w := binary.Uvarint(value)
+		if w <= 0 {
+			return fmt.Errorf("posting %q: %w", term, ErrInvalidIndex)
+		}
+		idx.Terms[term] = &Posting{n: int(n), lazy: &lazyPosting{kv: kv, term: term}}
+		return nil
+	})
+	if err == nil {
+		err = store.Each(kvDocs, func(_ string, value []byte) error {
+			var rec kvDoc
+			if err := json.Unmarshal(value, &rec); err != nil {
+				return fmt.Errorf("%v: %w", err, ErrInvalidIndex)
+			}
+			idx.loadKVDoc(rec)
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	idx.Analyzer = meta.Analyzer
+	idx.dupOf = meta.DupOf
+	idx.dupsDropped = meta.DupsDropped
+	idx.N = len(idx.Docs)
+	return idx, nil
+}
+
+// loadKVDoc restores one doc's record read by OpenIndex
+func (idx *Index) loadKVDoc(rec kvDoc) {
+	d := rec.Doc
+	d.ParsedDate, _ = parseDate(d.Date)
+	idx.Docs[d.ID] = d
+	idx.DocTokCounts[d.ID] = rec.TokCount
+	idx.totalToks += rec.TokCount
+	idx.FieldEnds[d.ID] = rec.FieldEnds
+	if len(rec.Numbers) > 0 {
+		idx.Numbers[d.ID] = rec.Numbers
+	}
+	if rec.Fingerprint != nil {
+		idx.Fingerprints[d.ID] = *rec.Fingerprint
+	}
+	if rec.StoredContent {
+		idx.StoredContent[d.ID] = true
+	}
+}
+
+// newPosting returns an empty posting for a term new to the index; a
+// KV-backed index gets a stand-in, in case the store still has the term
+func (idx *Index) newPosting(term string) *Posting {
+	if idx.kv == nil {
+		return &Posting{}
+	}
+	return &Posting{lazy: &lazyPosting{kv: idx.kv, term: term}}
+}
+
+// docChanged notes a doc to write at the next flush, flushing once enough
+// have piled up. Callers hold the write lock.
+func (idx *Index) docChanged(id int) {
+	if idx.kv == nil {
+		return
+	}
+	idx.kv.dirtyDocs[id] = true
+	if len(idx.kv.dirtyDocs) >= kvFlushDocs {
+		if err := idx.flush(); err != nil {
+			idx.kv.mu.Lock()
+			idx.kv.fail(err)
+			idx.kv.mu.Unlock()
+		}
+	}
+}
+
+// Flush writes the changes made to an index from OpenIndex since the last
+// flush to its KVStore, in one batch. It also reports a failed read or
+// automatic flush since then. With a Store, Flush flushes it as well and
+// reports a Store write that failed since the last Flush.
+func (idx *Index) Flush() error {
+	storeErr := idx.flushStore()
+	if err := idx.takeStoreErr(); err != nil {
+		storeErr = err
+	}
+	if idx.kv == nil {
+		return storeErr
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	err := idx.flush()
+	idx.kv.mu.Lock()
+	if idx.kv.err != nil {
+		err, idx.kv.err = idx.kv.err, nil
+	}
+	idx.kv.mu.Unlock()
+	if err == nil {
+		err = storeErr
+	}
+	return err
+}
+
+func (idx *Index) flush() error {
+	kv := idx.kv
+	meta, err := json.Marshal(kvIndexMeta{
+		Version:     indexFormatVersion,
+		Analyzer:    idx.Analyzer,
+		DupOf:       idx.dupOf,
+		DupsDropped: idx.dupsDropped,
+	})
+	if err != nil {
+		return fmt.Errorf("flush index: %w", err)
+	}
+	batch := []KVWrite{{Bucket: kvMeta, Key: kvMetaKey, Value: meta}}
+	for term, p := range kv.dirty {
+		w := KVWrite{Bucket: kvTerms, Key: term}
+		if p.Len() > 0 {
+			if w.Value, err = p.MarshalBinary(); err != nil {
+				return fmt.Errorf("flush index: %w", err)
+			}
+		}
+		batch = append(batch, w)
+	}
+	for id := range kv.dirtyDocs {
+		w := KVWrite{Bucket: kvDocs, Key: strconv.Itoa(id)}
+		if d, ok := idx.Docs[id]; ok {
+			rec := kvDoc{
+				Doc:           d,
+				TokCount:      idx.DocTokCounts[id],
+				FieldEnds:     idx.FieldEnds[id],
+				Numbers:       idx.Numbers[id],
+				StoredContent: idx.StoredContent[id],
+			}
+			if fp, ok := idx.Fingerprints[id]; ok {
+				rec.Fingerprint = &fp
+			}
+			if w.Value, err = json.Marshal(rec); err != nil {
+				return fmt.Errorf("flush index: %w", err)
+			}
+		}
+		batch = append(batch, w)
+	}
+	// B-tree stores insert sorted keys fastest
+	slices.SortFunc(batch, func(a, b KVWrite) int {
+		return cmp.Or(strings.Compare(a.Bucket, b.Bucket), strings.Compare(a.Key, b.Key))
+	})
+	if err := kv.store.Write(batch); err != nil {
+		return fmt.Errorf("flush index: %w", err)
+	}
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	for term, p := range kv.dirty {
+		if p.Len() > 0 {
+			kv.cacheClean(term, p)
+		}
+	}
+	clear(kv.dirty)
+	clear(kv.dirtyDocs)
+	return nil
+}
@@ -0,0 +1,267 @@
+package gonews
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// memKV is a KVStore in maps. It counts Gets and Writes, and fails them
+// while failGet or failWrite is set.
+type memKV struct {
+	mu        sync.Mutex
+	buckets   map[string]map[string][]byte
+	gets      int
+	writes    int
+	failGet   error
+	failWrite error
+}
+
+func newMemKV() *memKV {
+	return &memKV{buckets: make(map[string]map[string][]byte)}
+}
+
+func (m *memKV) Get(bucket, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets++
+	if m.failGet != nil {
+		return nil, m.failGet
+	}
+	if v, ok := m.buckets[bucket][key]; ok {
+		return append([]byte(nil), v...), nil
+	}
+	return nil, nil
+}
+
+func (m *memKV) Each(bucket string, fn func(key string, value []byte) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.buckets[bucket]))
+	for k := range m.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(k, m.buckets[bucket][k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memKV) Write(batch []KVWrite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failWrite != nil {
+		return m.failWrite
+	}
+	m.writes++
+	for _, w := range batch {
+		if m.buckets[w.Bucket] == nil {
+			m.buckets[w.Bucket] = make(map[string][]byte)
+		}
+		if w.Value == nil {
+			delete(m.buckets[w.Bucket], w.Key)
+		} else {
+			m.buckets[w.Bucket][w.Key] = append([]byte(nil), w.Value...)
+		}
+	}
+	return nil
+}
+
+// keys is how many keys bucket holds
+func (m *memKV) keys(bucket string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.buckets[bucket])
+}
+
+var kvCorpus = []Document{
+	{ID: 1, Title: "Budget vote", Date: "2024-03-01", Content: "the budget vote passed after a long debate"},
+	{ID: 2, Title: "Storm warning", Date: "2024-03-02", Content: "a storm is coming to the coast"},
+	{ID: 3, Title: "Budget storm", Date: "2024-03-03", Content: "the budget caused a political storm"},
+	{ID: 4, Title: "Rates hold", Date: "2024-03-04", Content: "rates stay where they are, $2.5 million set aside"},
+}
+
+// openKV opens the index in store, failing the test on error
+func openKV(t *testing.T, store KVStore) *Index {
+	t.Helper()
+	idx, err := OpenIndex(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// sameSearch checks got ranks queries exactly as want does
+func sameSearch(t *testing.T, got, want *Index, queries ...string) {
+	t.Helper()
+	for _, q := range queries {
+		g, w := got.Search(q), want.Search(q)
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("Search(%s) = %v, want %v", q, g, w)
+		}
+	}
+}
+
+func TestOpenIndexRoundTrip(t *testing.T) {
+	store := newMemKV()
+	idx := openKV(t, store)
+	for _, d := range kvCorpus {
+		idx.AddDocument(d)
+	}
+	if store.writes != 0 {
+		t.Errorf("%d writes before Flush, want changes held until then", store.writes)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openKV(t, store)
+	mem := buildIndex(kvCorpus...)
+	if reopened.DocCount() != len(kvCorpus) || reopened.TermCount() != mem.TermCount() {
+		t.Errorf("reopened %d docs, %d terms; want %d, %d", reopened.DocCount(), reopened.TermCount(), len(kvCorpus), mem.TermCount())
+	}
+	queries := []string{"budget", "storm AND NOT vote", `"budget vote"`, "amount:>1m", "coast OR rates"}
+	sameSearch(t, reopened, mem, queries...)
+
+	// writes to a reopened index reach the store too
+	reopened.UpdateDocument(Document{ID: 2, Title: "Storm passes", Date: "2024-03-02", Content: "the storm passed"})
+	reopened.DeleteDocument(4)
+	mem.UpdateDocument(Document{ID: 2, Title: "Storm passes", Date: "2024-03-02", Content: "the storm passed"})
+	mem.DeleteDocument(4)
+	if err := reopened.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	again := openKV(t, store)
+	sameSearch(t, again, mem, append(queries, "passed", "coming")...)
+	if _, ok := again.Doc(4); ok {
+		t.Error("deleted doc 4 is back after reopening")
+	}
+	if v, _ := store.Get(kvTerms, "rates"); v != nil {
+		t.Error("the posting of a term no doc has any more is still stored")
+	}
+}
+
+func TestOpenIndexLoadsPostingsLazily(t *testing.T) {
+	store := newMemKV()
+	idx := openKV(t, store)
+	for _, d := range kvCorpus {
+		idx.AddDocument(d)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	idx = openKV(t, store)
+	store.gets = 0
+	if n := idx.Terms["budget"].Len(); n != 2 {
+		t.Errorf("budget's document frequency = %d, want 2 without loading", n)
+	}
+	if store.gets != 0 {
+		t.Errorf("%d gets to read a document frequency, want 0", store.gets)
+	}
+	idx.Search("budget")
+	idx.Search("budget")
+	if store.gets != 1 {
+		t.Errorf("%d gets for two searches of one term, want 1", store.gets)
+	}
+
+	// a cache too small for two postings evicts the least recently used
+	idx.PostingCacheSize = 1
+	idx.Search("storm")
+	idx.Search("budget")
+	if store.gets != 3 {
+		t.Errorf("%d gets, want budget loaded again after storm evicted it", store.gets)
+	}
+	idx.kv.mu.Lock()
+	cached := make([]string, 0, len(idx.kv.cache))
+	for e := idx.kv.order.Front(); e != nil; e = e.Next() {
+		cached = append(cached, e.Value.(*kvCached).term)
+	}
+	idx.kv.mu.Unlock()
+	if !reflect.DeepEqual(cached, []string{"budget"}) {
+		t.Errorf("cached postings = %v, want only the last one used", cached)
+	}
+}
+
+func TestOpenIndexFlushesEveryKVFlushDocs(t *testing.T) {
+	store := newMemKV()
+	idx := openKV(t, store)
+	for i := 1; i < kvFlushDocs; i++ {
+		idx.AddDocument(Document{ID: i, Title: fmt.Sprintf("Report %d", i), Content: "markets moved"})
+	}
+	if store.writes != 0 {
+		t.Fatalf("%d writes after %d docs, want none yet", store.writes, kvFlushDocs-1)
+	}
+	idx.AddDocument(Document{ID: kvFlushDocs, Title: "Report", Content: "markets moved"})
+	if store.writes != 1 || store.keys(kvDocs) != kvFlushDocs {
+		t.Errorf("%d writes, %d docs stored after %d docs, want 1 write of them all", store.writes, store.keys(kvDocs), kvFlushDocs)
+	}
+	if len(idx.kv.dirty) != 0 || len(idx.kv.dirtyDocs) != 0 {
+		t.Error("changes still held after the automatic flush")
+	}
+}
+
+func TestOpenIndexWriteErrors(t *testing.T) {
+	broken := errors.New("disk full")
+
+	t.Run("Flush", func(t *testing.T) {
+		store := newMemKV()
+		idx := openKV(t, store)
+		idx.AddDocument(kvCorpus[0])
+		store.failWrite = broken
+		if err := idx.Flush(); !errors.Is(err, broken) {
+			t.Fatalf("Flush error %v, want %v", err, broken)
+		}
+		// the changes are kept for the next try
+		store.failWrite = nil
+		if err := idx.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		sameSearch(t, openKV(t, store), buildIndex(kvCorpus[0]), "budget")
+	})
+
+	t.Run("automatic flush", func(t *testing.T) {
+		store := newMemKV()
+		store.failWrite = broken
+		idx := openKV(t, store)
+		for i := 1; i <= kvFlushDocs; i++ {
+			idx.AddDocument(Document{ID: i, Title: "Report", Content: "markets moved"})
+		}
+		store.failWrite = nil
+		if err := idx.Flush(); !errors.Is(err, broken) {
+			t.Fatalf("Flush error %v, want the automatic flush's %v", err, broken)
+		}
+		if err := idx.Flush(); err != nil {
+			t.Errorf("second Flush error %v, want nil", err)
+		}
+		if n := openKV(t, store).DocCount(); n != kvFlushDocs {
+			t.Errorf("reopened %d docs, want %d", n, kvFlushDocs)
+		}
+	})
+
+	t.Run("posting read", func(t *testing.T) {
+		store := newMemKV()
+		idx := openKV(t, store)
+		idx.AddDocument(kvCorpus[0])
+		if err := idx.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		idx = openKV(t, store)
+		store.failGet = broken
+		if got := idx.Search("budget"); len(got) != 0 {
+			t.Errorf("Search with a failing store = %v, want nothing", got)
+		}
+		store.failGet = nil
+		if err := idx.Flush(); !errors.Is(err, broken) {
+			t.Errorf("Flush error %v, want the failed read's %v", err, broken)
+		}
+		if got := resultIDs(idx.Search("budget")); !reflect.DeepEqual(got, []int{1}) {
+			t.Errorf("Search after the store recovered = %v, want [1]", got)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package gonews
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// how much of a doc DetectLanguage looks at; the opening is enough to tell
+const langSampleBytes = 1024
+
+// scriptLangs maps a writing system to the language it's taken to mean.
+// Scripts shared by many languages (Latin, Arabic, Cyrillic) go to the most
+// likely one in news text.
+var scriptLangs = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Bengali, "bn"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Arabic, "ar"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+	{unicode.Tamil, "ta"},
+	{unicode.Han, "zh"},
+}
+
+// latinProfiles are common function words of the Latin-script languages
+// DetectLanguage tells apart
+var latinProfiles = []struct{ lang, words string }{
+	{"en", "the and of to is in that it was for with on are this be have"},
+	{"es", "el la los las y que de en es por con para una del se al lo"},
+	{"fr", "le la les et des est que une dans pour du pas sur au qui il"},
+	{"de", "der die das und ist nicht ein eine mit den von zu auf dem sich"},
+	{"it", "il la che di e non per una sono del della con gli le al"},
+	{"pt", "o os que de e não uma com para do da em é no na"},
+	{"nl", "de het een en van is niet dat op te met zijn voor ook"},
+}
+
+// latinWords maps each profile word to the profiles it's in, bit i for
+// latinProfiles[i], so a text is scored in one pass
+var latinWords = func() map[string]uint8 {
+	m := make(map[string]uint8)
+	for i, p := range latinProfiles {
+		for _, w := range strings.Fields(p.words) {
+			m[w] |= 1 << i
+		}
+	}
+	return m
+}()
+
+// LanguageStopwords are the stopword lists used, with DetectLanguages, for
+// docs in these languages; any other language uses the Analyzer's Stopwords
+var LanguageStopwords = map[string]map[string]bool{
+	"es": wordSet("el la los las un una y o de del en es por con para que se al lo su"),
+	"fr": wordSet("le la les un une et ou de des du en est par pour que qui dans sur au il"),
+	"de": wordSet("der die das ein eine und oder ist von zu mit den dem des auf im für sich"),
+	"it": wordSet("il lo la gli le un una e o di del della in per con che è al sono"),
+	"pt": wordSet("o a os as um uma e ou de do da em no na por para com que é"),
+	"nl": wordSet("de het een en of van in is op te met dat voor zijn niet ook"),
+	"bn": wordSet("এবং ও এই সেই যে করে হয় থেকে জন্য একটি তার না কি আর"),
+	"hi": wordSet("और का की के है में से को पर यह कि एक भी था हैं"),
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// DetectLanguage guesses the language of text as an ISO 639-1 code ("en",
+// "bn", ...): by writing system for most scripts, and by common function
+// words for Latin-script text. It returns "" when it can't tell.
+func DetectLanguage(text string) string {
+	if len(text) > langSampleBytes {
+		n := langSampleBytes
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		text = text[:n]
+	}
+	counts := make(map[string]int)
+	latin, kana := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			if 'a' <= r|0x20 && r|0x20 <= 'z' {
+				latin++
+			}
+		case !unicode.IsLetter(r):
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		default:
+			for _, s := range scriptLangs {
+				if unicode.Is(s.script, r) {
+					counts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if kana > 0 {
+		// Japanese mixes kana with Han
+		counts["ja"] = kana + counts["zh"]
+		delete(counts, "zh")
+	}
+	best, bestN := "", 0
+	for lang, n := range counts {
+		if n > bestN || n == bestN && lang < best {
+			best, bestN = lang, n
+		}
+	}
+	if bestN > latin {
+		return best
+	}
+	if latin == 0 {
+		return ""
+	}
+	return detectLatin(text)
+}
+
+// detectLatin picks the Latin-script language whose function words occur
+// most in text, or "" when too few occur or another language comes close
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	var hits [8]int // one per latinWords bit
+	for _, w := range words {
+		if len(w) > 5 {
+			continue // longer than any profile word
+		}
+		mask := latinWords[strings.ToLower(w)]
+		for i := range latinProfiles {
+			if mask&(1<<i) != 0 {
+				hits[i]++
+			}
+		}
+	}
+	best, bestN, second := -1, 0, 0
+	for i, n := range hits {
+		switch {
+		case n > bestN:
+			best, bestN, second = i, n, bestN
+		case n > second:
+			second = n
+		}
+	}
+	if bestN < 2 || bestN < len(words)/20 || 2*bestN < 3*second {
+		return ""
+	}
+	return latinProfiles[best].lang
+}
+
+// langToken marks a query clause matching docs in one language
+func langToken(lang string) string {
+	return "lang:" + lang
+}
+
+// parseLangToken returns the language of a lang:xx clause
+func parseLangToken(tok string) (string, bool) {
+	return strings.CutPrefix(strings.ToLower(tok), "lang:")
+}
+
+// validLangCode reports whether lang looks like an ISO 639 code
+func validLangCode(lang string) bool {
+	if len(lang) < 2 || len(lang) > 3 {
+		return false
+	}
+	for i := 0; i < len(lang); i++ {
+		if lang[i] < 'a' || lang[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// rpnLang is the language of a query's first lang: clause, "" if none;
+// the query's words are analyzed as that language
+func rpnLang(rpn []string) string {
+	for _, tok := range rpn {
+		if lang, ok := parseLangToken(tok); ok {
+			return lang
+		}
+	}
+	return ""
+}
+
+// docsInLang returns the docs whose Lang is lang
+func (idx *Index) docsInLang(lang string) map[int]struct{} {
+	s := make(map[int]struct{})
+	for id, d := range idx.Docs {
+		if d.Lang == lang {
+			s[id] = struct{}{}
+		}
+	}
+	return s
+}
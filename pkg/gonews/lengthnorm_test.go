@@ -0,0 +1,57 @@
+package gonews
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDocLenNorm(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "one two"},
+		Document{ID: 2, Content: strings.Repeat("word ", 18)},
+	)
+	// average length 10
+	tests := []struct {
+		mode  LengthNormMode
+		slope float64
+		doc   int
+		want  float64
+	}{
+		{LengthNormLinear, 0, 1, 2},
+		{LengthNormLinear, 0, 2, 18},
+		{LengthNormPivoted, 0, 1, 0.8*10 + 0.2*2},
+		{LengthNormPivoted, 0, 2, 0.8*10 + 0.2*18},
+		{LengthNormPivoted, 0.5, 2, 0.5*10 + 0.5*18},
+		{LengthNormPivoted, 1, 2, 18},
+		{LengthNormPivoted, 3, 2, 0.8*10 + 0.2*18}, // out of range: default slope
+	}
+	for _, tt := range tests {
+		idx.LengthNorm, idx.PivotSlope = tt.mode, tt.slope
+		if got := idx.docLenNorm(tt.doc); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("mode %d slope %v: docLenNorm(%d) = %v, want %v", tt.mode, tt.slope, tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestPivotedFavorsLongDocs(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "tariffs rise"},
+		Document{ID: 2, Content: "tariffs were the main topic as tariffs on steel and tariffs on grain " + strings.Repeat("filler ", 30)},
+		Document{ID: 3, Content: "unrelated story about the weather today"},
+	)
+	tests := []struct {
+		mode LengthNormMode
+		want []int
+	}{
+		{LengthNormLinear, []int{1, 2}},
+		{LengthNormPivoted, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		idx.LengthNorm = tt.mode
+		if got := resultIDs(idx.Search("tariffs")); !slices.Equal(got, tt.want) {
+			t.Errorf("mode %d: Search(tariffs) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
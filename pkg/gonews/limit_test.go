@@ -0,0 +1,67 @@
+package gonews
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerSearchLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		wait     time.Duration
+		freeIn   time.Duration // how long the slot stays taken
+		requests int
+		want     int // status of each extra request
+	}{
+		{"reject at once", 0, 200 * time.Millisecond, 3, http.StatusTooManyRequests},
+		{"queue times out", 20 * time.Millisecond, 200 * time.Millisecond, 2, http.StatusTooManyRequests},
+		{"queued then served", time.Second, 50 * time.Millisecond, 3, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(buildIndex(Document{ID: 1, Title: "Budget vote"}))
+			s.MaxConcurrentSearches = 1
+			s.SearchQueueWait = tt.wait
+			ts := httptest.NewServer(s.Handler())
+			defer ts.Close()
+
+			// a long search holds the only slot until freeIn
+			if err := s.acquireSearch(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			released := make(chan struct{})
+			go func() {
+				defer close(released)
+				time.Sleep(tt.freeIn)
+				s.releaseSearch()
+			}()
+
+			codes := make([]int, tt.requests)
+			var wg sync.WaitGroup
+			for i := range codes {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := http.Get(ts.URL + "/search?q=budget")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					resp.Body.Close()
+					codes[i] = resp.StatusCode
+				}()
+			}
+			wg.Wait()
+			for i, code := range codes {
+				if code != tt.want {
+					t.Errorf("request %d: status %d, want %d", i, code, tt.want)
+				}
+			}
+			<-released
+		})
+	}
+}
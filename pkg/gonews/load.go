@@ -0,0 +1,381 @@
+package gonews
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document represents a news article
+type Document struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Date    string `json:"date"`
+	Content string `json:"content"`
+
+	// Summary is an editor-written abstract, indexed as its own field
+	Summary string `json:"summary,omitempty"`
+
+	// Author is the byline; stored but not indexed
+	Author string `json:"author,omitempty"`
+
+	// Source is the publisher or section; stored but not indexed, and
+	// counted by SearchFacets
+	Source string `json:"source,omitempty"`
+
+	// Lang is the ISO 639-1 code of the doc's language ("en", "bn", ...);
+	// with DetectLanguages it picks the analysis and is detected when empty
+	Lang string `json:"lang,omitempty"`
+
+	// ParsedDate is Date parsed at index time (zero if unparseable)
+	ParsedDate time.Time `json:"-"`
+
+	// SourceID keeps the original ID when the loader had to assign a
+	// synthetic one (non-integer or duplicate ID in the source)
+	SourceID string `json:"source_id,omitempty"`
+
+	// Boost multiplies the doc's relevance score (e.g. for authoritative
+	// sources); 0 means neutral, same as 1
+	Boost float64 `json:"boost,omitempty"`
+}
+
+// boostFactor returns the score multiplier for a doc, treating unset as 1
+func (d Document) boostFactor() float64 {
+	if d.Boost <= 0 {
+		return 1
+	}
+	return d.Boost
+}
+
+// dateLayouts are the Date formats recognised by parseDate
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"02 Jan 2006",
+	"2006/01/02",
+}
+
+// parseDate parses a document date in any of dateLayouts
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Loader reads a corpus of documents from some source
+type Loader interface {
+	Load() ([]Document, error)
+}
+
+// StreamLoader is a Loader that can also hand documents over one at a
+// time, without holding the whole corpus in memory
+type StreamLoader interface {
+	Loader
+	Stream(fn func(Document) error) error
+}
+
+// CSVLoader loads a CSV file (see LoadCSVColumns). Warnings from the last
+// Load are kept for reporting.
+type CSVLoader struct {
+	Path     string
+	Columns  CSVColumns
+	MaxDocs  int // 0 = all
+	Warnings []LoadWarning
+}
+
+func (l *CSVLoader) Load() ([]Document, error) {
+	docs, warnings, err := LoadCSVColumns(l.Path, l.Columns, l.MaxDocs)
+	l.Warnings = warnings
+	return docs, err
+}
+
+// Stream hands the documents to fn one at a time (see StreamCSV)
+func (l *CSVLoader) Stream(fn func(Document) error) error {
+	warnings, err := StreamCSV(l.Path, l.Columns, l.MaxDocs, fn)
+	l.Warnings = warnings
+	return err
+}
+
+// APILoader loads from a paginated JSON API (see LoadFromAPI)
+type APILoader struct {
+	URL       string
+	PageParam string
+	MaxDocs   int // 0 = all
+}
+
+func (l *APILoader) Load() ([]Document, error) {
+	return LoadFromAPI(l.URL, l.PageParam, l.MaxDocs)
+}
+
+// LoadWarning describes a source row that needed fixing up while loading
+type LoadWarning struct {
+	Line int
+	Msg  string
+}
+
+func (w LoadWarning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Msg)
+}
+
+// LoadCSV expects a CSV with header including: id,title,date,content and
+// optional fifth boost, sixth summary, seventh author, eighth source and
+// ninth lang columns. A boost that isn't a number is an error; an empty one
+// is neutral.
+func LoadCSV(path string) ([]Document, error) {
+	docs, _, err := LoadCSVReport(path, 0)
+	return docs, err
+}
+
+// LoadCSVReport is LoadCSV that also reports rows whose ID was not a unique
+// integer. Those rows get a synthetic ID above every numeric ID in the file
+// (the original is kept in SourceID), so they never collide in the index.
+// maxDocs > 0 stops after that many documents (the header doesn't count).
+// A file without any rows is ErrEmptyCorpus.
+func LoadCSVReport(path string, maxDocs int) ([]Document, []LoadWarning, error) {
+	return LoadCSVColumns(path, CSVColumns{}, maxDocs)
+}
+
+// CSVColumns names the CSV columns LoadCSVColumns reads the main Document
+// fields from, each as a header name or a 1-based position. If all are
+// empty the file is read by position (id,title,date,content, then the
+// optional columns). Otherwise an empty one means the header column of the
+// same name, if any; a file without an id column is numbered by row.
+type CSVColumns struct {
+	ID, Title, Date, Content string
+}
+
+// csvFields are the Document fields a CSV row can fill, in their positional
+// order
+var csvFields = []string{"id", "title", "date", "content", "boost", "summary", "author", "source", "lang"}
+
+// resolve returns the index of each csvFields column in a row, -1 for none
+func (c CSVColumns) resolve(header []string) ([]int, error) {
+	cols := make([]int, len(csvFields))
+	if c == (CSVColumns{}) {
+		for i := range cols {
+			cols[i] = i
+		}
+		return cols, nil
+	}
+	byName := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, dup := byName[h]; !dup {
+			byName[h] = i
+		}
+	}
+	mapped := []string{c.ID, c.Title, c.Date, c.Content}
+	for i, field := range csvFields {
+		var name string
+		if i < len(mapped) {
+			name = strings.TrimSpace(mapped[i])
+		}
+		if name == "" {
+			cols[i] = -1
+			if j, ok := byName[field]; ok {
+				cols[i] = j
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(name); err == nil {
+			if n < 1 || n > len(header) {
+				return nil, fmt.Errorf("%s column %d: the header has %d columns", field, n, len(header))
+			}
+			cols[i] = n - 1
+			continue
+		}
+		j, ok := byName[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%s column %q is not in the header", field, name)
+		}
+		cols[i] = j
+	}
+	return cols, nil
+}
+
+// LoadCSVColumns is LoadCSVReport for a file whose columns are laid out as
+// described by cols
+func LoadCSVColumns(path string, cols CSVColumns, maxDocs int) ([]Document, []LoadWarning, error) {
+	var docs []Document
+	warnings, ids, err := scanCSV(path, cols, maxDocs, func(d Document, _ bool) error {
+		docs = append(docs, d)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	// (pending rows and warnings line up one to one)
+	for i, id := range ids.assign(docs) {
+		warnings[i].Msg += fmt.Sprintf(" %d", id)
+	}
+	return docs, warnings, nil
+}
+
+// StreamCSV reads a CSV file laid out as described by cols like
+// LoadCSVColumns, but hands each document to fn as soon as it is read
+// instead of returning them all, so indexing a huge file (fn calling
+// idx.AddDocument) doesn't hold every document in memory at once. Rows that
+// need a synthetic ID are held back until the end of the file, when the
+// highest real ID is known; they get the same IDs as with LoadCSVColumns.
+// An error from fn stops the read and is returned.
+func StreamCSV(path string, cols CSVColumns, maxDocs int, fn func(Document) error) ([]LoadWarning, error) {
+	var held []Document
+	warnings, ids, err := scanCSV(path, cols, maxDocs, func(d Document, pending bool) error {
+		if pending {
+			held = append(held, d)
+			return nil
+		}
+		return fn(d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range held {
+		held[i].ID = ids.synthetic(i)
+		warnings[i].Msg += fmt.Sprintf(" %d", held[i].ID)
+		if err := fn(held[i]); err != nil {
+			return nil, err
+		}
+	}
+	return warnings, nil
+}
+
+// scanCSV reads the documents of a CSV file in order, passing each to fn
+// with pending set if its row's ID couldn't be used; those docs await an ID
+// from the returned allocator
+func scanCSV(path string, cols CSVColumns, maxDocs int, fn func(d Document, pending bool) error) ([]LoadWarning, *idAllocator, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := cols.resolve(header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var warnings []LoadWarning
+	ids := newIDAllocator()
+	n := 0
+	for ; maxDocs <= 0 || n < maxDocs; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := r.FieldPos(0)
+		// field returns the value of csvFields[i], "" if the row lacks it
+		field := func(i int) string {
+			if j := index[i]; j >= 0 && j < len(rec) {
+				return rec[j]
+			}
+			return ""
+		}
+		rawID := field(0)
+		if index[0] < 0 {
+			rawID = strconv.Itoa(n)
+		}
+		id, sourceID, problem := ids.claim(rawID, n)
+		if problem != "" {
+			warnings = append(warnings, LoadWarning{Line: line, Msg: problem})
+		}
+		var boost float64 // an empty boost is neutral
+		if v := strings.TrimSpace(field(4)); v != "" {
+			boost, err = strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(boost) || math.IsInf(boost, 0) {
+				return nil, nil, fmt.Errorf("%s: line %d: invalid boost %q", path, line, v)
+			}
+		}
+		err = fn(Document{
+			ID:       id,
+			Title:    field(1),
+			Date:     field(2),
+			Content:  field(3),
+			Summary:  field(5),
+			Author:   field(6),
+			Source:   field(7),
+			Lang:     strings.ToLower(strings.TrimSpace(field(8))),
+			SourceID: sourceID,
+			Boost:    boost,
+		}, problem != "")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if n == 0 {
+		return nil, nil, fmt.Errorf("%s: %w", path, ErrEmptyCorpus)
+	}
+	return warnings, ids, nil
+}
+
+// idAllocator hands out doc IDs while loading: rows whose raw ID is not a
+// fresh integer are queued and later given a synthetic ID above every real
+// one, so they can't collide in the index
+type idAllocator struct {
+	seen    map[int]bool
+	maxID   int
+	pending []int // positions in the docs slice awaiting an ID
+}
+
+func newIDAllocator() *idAllocator {
+	return &idAllocator{seen: make(map[int]bool), maxID: -1}
+}
+
+// claim parses raw as the ID of the doc at position pos. When it can't be
+// used, the doc is queued, raw is returned as its source ID and problem
+// describes why.
+func (a *idAllocator) claim(raw string, pos int) (id int, sourceID, problem string) {
+	id, err := strconv.Atoi(strings.TrimSpace(raw))
+	switch {
+	case err != nil:
+		problem = fmt.Sprintf("non-integer id %q, assigning a synthetic id", raw)
+	case a.seen[id]:
+		problem = fmt.Sprintf("duplicate id %d, assigning a synthetic id", id)
+	default:
+		a.seen[id] = true
+		a.maxID = max(a.maxID, id)
+		return id, "", ""
+	}
+	a.pending = append(a.pending, pos)
+	return 0, raw, problem
+}
+
+// assign gives queued docs their synthetic IDs in load order and returns them
+func (a *idAllocator) assign(docs []Document) []int {
+	out := make([]int, len(a.pending))
+	for i, pos := range a.pending {
+		docs[pos].ID = a.synthetic(i)
+		out[i] = docs[pos].ID
+	}
+	return out
+}
+
+// synthetic is the ID of the i-th queued doc, once every row is claimed
+func (a *idAllocator) synthetic(i int) int {
+	return a.maxID + 1 + i
+}
@@ -0,0 +1,157 @@
+package gonews
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to name in a temp dir and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCSVBoost(t *testing.T) {
+	tests := []struct {
+		name    string
+		boost   string
+		want    float64
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"blank", " ", 0, false},
+		{"set", "2.5", 2.5, false},
+		{"zero", "0", 0, false},
+		{"not a number", "high", 0, true},
+		{"nan", "NaN", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "news.csv", "id,title,date,content,boost\n1,Title,2024-01-02,Body,"+tt.boost+"\n")
+			docs, err := LoadCSV(path)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "line 2") {
+					t.Errorf("LoadCSV = %v, want an error naming line 2", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if docs[0].Boost != tt.want {
+				t.Errorf("Boost = %v, want %v", docs[0].Boost, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoostRanking(t *testing.T) {
+	tests := []struct {
+		name   string
+		boosts [2]float64
+		want   []int
+	}{
+		{"higher boost first", [2]float64{1, 3}, []int{2, 1}},
+		{"boost below one ranks lower", [2]float64{0.5, 0}, []int{2, 1}},
+		{"zero is neutral", [2]float64{0, 1}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := buildIndex(
+				Document{ID: 1, Content: "budget vote today", Boost: tt.boosts[0]},
+				Document{ID: 2, Content: "budget vote today", Boost: tt.boosts[1]},
+			)
+			results := idx.Search("budget")
+			if got := resultIDs(results); !slices.Equal(got, tt.want) {
+				t.Errorf("ranking = %v, want %v", got, tt.want)
+			}
+			if tt.boosts == [2]float64{0, 1} && results[0].Score != results[1].Score {
+				t.Errorf("scores %v and %v differ for neutral boosts", results[0].Score, results[1].Score)
+			}
+		})
+	}
+}
+
+func TestLoadCSVSyntheticIDs(t *testing.T) {
+	path := writeFile(t, "news.csv", "id,title,date,content\n"+
+		"a1,First,2024-01-01,one\n"+
+		"5,Second,2024-01-02,two\n"+
+		"b2,Third,2024-01-03,three\n"+
+		"5,Fourth,2024-01-04,four\n"+
+		"2,Fifth,2024-01-05,five\n")
+	docs, warnings, err := LoadCSVReport(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int]string)
+	for _, d := range docs {
+		if other, ok := seen[d.ID]; ok {
+			t.Errorf("%s and %s share ID %d", other, d.Title, d.ID)
+		}
+		seen[d.ID] = d.Title
+	}
+	want := map[string]struct {
+		id       int
+		sourceID string
+	}{
+		"First":  {6, "a1"},
+		"Second": {5, ""},
+		"Third":  {7, "b2"},
+		"Fourth": {8, "5"},
+		"Fifth":  {2, ""},
+	}
+	for _, d := range docs {
+		if w := want[d.Title]; d.ID != w.id || d.SourceID != w.sourceID {
+			t.Errorf("%s: ID %d source ID %q, want %d %q", d.Title, d.ID, d.SourceID, w.id, w.sourceID)
+		}
+	}
+	if len(warnings) != 3 {
+		t.Errorf("warnings = %v, want one per fixed-up row", warnings)
+	}
+	if idx := buildIndex(docs...); idx.DocCount() != 5 {
+		t.Errorf("indexed %d docs, want 5", idx.DocCount())
+	}
+}
+
+func TestLoadCSVMaxDocs(t *testing.T) {
+	path := writeFile(t, "news.csv", "id,title,date,content\n1,A,2024-01-01,a\n2,B,2024-01-02,b\n3,C,2024-01-03,c\n")
+	tests := []struct {
+		maxDocs int
+		want    []int
+	}{
+		{0, []int{1, 2, 3}},
+		{-1, []int{1, 2, 3}},
+		{2, []int{1, 2}},
+		{5, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		docs, _, err := LoadCSVReport(path, tt.maxDocs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, d := range docs {
+			ids = append(ids, d.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("maxDocs %d: loaded %v, want %v", tt.maxDocs, ids, tt.want)
+		}
+		var streamed []int
+		if _, err := StreamCSV(path, CSVColumns{}, tt.maxDocs, func(d Document) error {
+			streamed = append(streamed, d.ID)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(streamed, tt.want) {
+			t.Errorf("maxDocs %d: streamed %v, want %v", tt.maxDocs, streamed, tt.want)
+		}
+	}
+}
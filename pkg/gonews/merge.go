@@ -0,0 +1,141 @@
+package gonews
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// MergeIndexes combines two indexes (e.g. shards built on separate machines)
+// into a new one without re-tokenizing. Docs from b whose ID is already used
+// in a get a fresh ID above both indexes' IDs; their original ID is kept in
+// SourceID. IDF is computed from the merged postings at query time, so
+// scores match an index built from all docs at once. Settings such as the
+// analyzer, IDF tuning and ranking mode are taken from a.
+func MergeIndexes(a, b *Index) (*Index, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("merge: nil index: %w", ErrInvalidIndex)
+	}
+	if a == b {
+		return nil, fmt.Errorf("merge: cannot merge an index with itself: %w", ErrInvalidIndex)
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := a.EmptyCopy()
+
+	next := 0
+	for id := range a.Docs {
+		next = max(next, id+1)
+	}
+	for id := range b.Docs {
+		next = max(next, id+1)
+	}
+
+	for id, d := range a.Docs {
+		out.Docs[id] = d
+		out.DocTokCounts[id] = a.DocTokCounts[id]
+		out.totalToks += a.DocTokCounts[id]
+		out.FieldEnds[id] = a.FieldEnds[id]
+		if nums, ok := a.Numbers[id]; ok {
+			out.Numbers[id] = nums
+		}
+		if fp, ok := a.Fingerprints[id]; ok {
+			out.Fingerprints[id] = fp
+		}
+		if a.StoredContent[id] {
+			out.StoredContent[id] = true // out shares a's store
+		}
+	}
+	remap := make(map[int]int, len(b.Docs))
+	for _, id := range sortedDocIDs(b.Docs) {
+		d := b.Docs[id]
+		if b.StoredContent[id] {
+			d = b.withContent(d) // moved into out's store below, if it has one
+		}
+		newID := id
+		if _, clash := out.Docs[id]; clash {
+			newID = next
+			next++
+			d.ID = newID
+			if d.SourceID == "" {
+				d.SourceID = strconv.Itoa(id)
+			}
+		}
+		remap[id] = newID
+		out.Docs[newID] = out.storeContent(d)
+		out.DocTokCounts[newID] = b.DocTokCounts[id]
+		out.totalToks += b.DocTokCounts[id]
+		out.FieldEnds[newID] = b.FieldEnds[id]
+		if nums, ok := b.Numbers[id]; ok {
+			out.Numbers[newID] = nums
+		}
+		if fp, ok := b.Fingerprints[id]; ok {
+			out.Fingerprints[newID] = fp
+		}
+	}
+
+	copyPostings := func(src map[string]*Posting, mapID func(int) int) {
+		for term, posting := range src {
+			dst, ok := out.Terms[term]
+			if !ok {
+				dst = &Posting{}
+				out.Terms[term] = dst
+			}
+			posting.Each(func(id int, positions []int) {
+				dst.set(mapID(id), positions)
+			})
+		}
+	}
+	copyPostings(a.Terms, func(id int) int { return id })
+	copyPostings(b.Terms, func(id int) int { return remap[id] })
+
+	out.N = len(out.Docs)
+	return out, nil
+}
+
+// sortedDocIDs returns the doc IDs in ascending order
+func sortedDocIDs(docs map[int]Document) []int {
+	ids := make([]int, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// EmptyCopy returns a new, empty index with idx's analyzer and its scoring
+// and ranking settings
+func (idx *Index) EmptyCopy() *Index {
+	out := NewIndex()
+	out.Analyzer = idx.Analyzer
+	out.IDFFloor = idx.IDFFloor
+	out.IDFCeiling = idx.IDFCeiling
+	out.IDFSmoothing = idx.IDFSmoothing
+	out.EarlyMentionBoost = idx.EarlyMentionBoost
+	out.FieldBoosts = idx.FieldBoosts
+	out.LengthNorm = idx.LengthNorm
+	out.PivotSlope = idx.PivotSlope
+	out.BM25K1 = idx.BM25K1
+	out.BM25B = idx.BM25B
+	out.MaxResults = idx.MaxResults
+	out.MaxWildcardTerms = idx.MaxWildcardTerms
+	out.CacheSize = idx.CacheSize
+	out.Duplicates = idx.Duplicates
+	out.CollapseSimilar = idx.CollapseSimilar
+	out.SimilarBits = idx.SimilarBits
+	out.SimilarTerms = idx.SimilarTerms
+	out.Store = idx.Store
+	out.Rank = idx.Rank
+	out.Sort = idx.Sort
+	out.RelatedByPMI = idx.RelatedByPMI
+	out.Scorer = idx.Scorer
+	out.Embedder = idx.Embedder
+	out.Fusion = idx.Fusion
+	out.HybridWeight = idx.HybridWeight
+	out.RRFK = idx.RRFK
+	out.HybridCandidates = idx.HybridCandidates
+	return out
+}
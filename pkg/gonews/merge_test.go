@@ -0,0 +1,63 @@
+package gonews
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+var mergeDocs = []Document{
+	{ID: 1, Title: "Budget vote", Content: "parliament passes the budget after a long debate"},
+	{ID: 2, Title: "Election night", Content: "polls close and the count begins"},
+	{ID: 3, Title: "Budget fallout", Content: "markets react to the budget"},
+	{ID: 4, Title: "Storm warning", Content: "heavy rain expected across the coast"},
+	{ID: 5, Title: "Election result", Content: "the budget minister wins her seat"},
+}
+
+func TestMergeIndexes(t *testing.T) {
+	whole := buildIndex(mergeDocs...)
+	merged, err := MergeIndexes(buildIndex(mergeDocs[:2]...), buildIndex(mergeDocs[2:]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := merged.Validate(); errs != nil {
+		t.Fatalf("Validate: %v", errs)
+	}
+	for _, q := range []string{"budget", "election OR storm", `"budget minister"`, "budget -markets"} {
+		want, got := whole.Search(q), merged.Search(q)
+		if !slices.Equal(resultIDs(got), resultIDs(want)) {
+			t.Errorf("Search(%q) merged = %v, want %v", q, resultIDs(got), resultIDs(want))
+			continue
+		}
+		for i := range want {
+			if got[i].Score != want[i].Score {
+				t.Errorf("Search(%q) doc %d scores %v merged, %v whole", q, want[i].DocID, got[i].Score, want[i].Score)
+			}
+		}
+	}
+}
+
+func TestMergeIndexesRemapsIDs(t *testing.T) {
+	a := buildIndex(Document{ID: 1, Title: "Alpha story"}, Document{ID: 2, Title: "Beta story"})
+	b := buildIndex(Document{ID: 2, Title: "Gamma story"}, Document{ID: 3, Title: "Delta story"})
+	merged, err := MergeIndexes(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.DocCount() != 4 {
+		t.Fatalf("merged %d docs, want 4", merged.DocCount())
+	}
+	got := merged.Search("gamma")
+	if len(got) != 1 || got[0].DocID != 4 {
+		t.Fatalf("gamma found %v, want the remapped doc 4", resultIDs(got))
+	}
+	if d, _ := merged.Doc(4); d.SourceID != "2" {
+		t.Errorf("remapped doc keeps source ID %q, want 2", d.SourceID)
+	}
+	if got := resultIDs(merged.Search("beta")); !slices.Equal(got, []int{2}) {
+		t.Errorf("beta found %v, want a's doc 2", got)
+	}
+	if _, err := MergeIndexes(a, a); !errors.Is(err, ErrInvalidIndex) {
+		t.Errorf("merging an index with itself = %v, want ErrInvalidIndex", err)
+	}
+}
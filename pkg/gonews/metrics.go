@@ -0,0 +1,106 @@
+package gonews
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// searchLatencyBuckets are the upper bounds, in seconds, of the search
+// latency histogram
+var searchLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// serverMetrics are the counters behind /metrics. The zero value is ready
+// to use.
+type serverMetrics struct {
+	searchesOK, searchesRejected, searchesFailed atomic.Uint64
+	adds, updates, deletes                       atomic.Uint64
+
+	mu      sync.Mutex // guards the latency histogram
+	buckets []uint64   // per searchLatencyBuckets bound, not cumulative
+	count   uint64
+	sum     float64 // seconds
+}
+
+// observeSearch records how a search that started at start ended. A
+// search the client gave up on counts as failed.
+func (m *serverMetrics) observeSearch(start time.Time, err error) {
+	switch {
+	case errors.Is(err, ErrTooManySearches):
+		m.searchesRejected.Add(1)
+		return
+	case err != nil:
+		m.searchesFailed.Add(1)
+		return
+	}
+	m.searchesOK.Add(1)
+	secs := time.Since(start).Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets == nil {
+		m.buckets = make([]uint64, len(searchLatencyBuckets))
+	}
+	for i, bound := range searchLatencyBuckets {
+		if secs <= bound {
+			m.buckets[i]++
+			break
+		}
+	}
+	m.count++
+	m.sum += secs
+}
+
+// writeMetrics writes the metrics in the Prometheus text format
+func (s *Server) writeMetrics(w io.Writer) {
+	m := &s.metrics
+	idx := s.Index()
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	metric("gonews_searches_total", "counter", "Searches handled, by outcome (rejected: no free slot under the concurrency limit).")
+	fmt.Fprintf(w, "gonews_searches_total{outcome=\"ok\"} %d\n", m.searchesOK.Load())
+	fmt.Fprintf(w, "gonews_searches_total{outcome=\"rejected\"} %d\n", m.searchesRejected.Load())
+	fmt.Fprintf(w, "gonews_searches_total{outcome=\"error\"} %d\n", m.searchesFailed.Load())
+
+	metric("gonews_search_duration_seconds", "histogram", "Latency of successful searches, including time queued for a slot.")
+	m.mu.Lock()
+	cumulative := uint64(0)
+	for i, bound := range searchLatencyBuckets {
+		if m.buckets != nil {
+			cumulative += m.buckets[i]
+		}
+		fmt.Fprintf(w, "gonews_search_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "gonews_search_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.count)
+	fmt.Fprintf(w, "gonews_search_duration_seconds_sum %g\n", m.sum)
+	fmt.Fprintf(w, "gonews_search_duration_seconds_count %d\n", m.count)
+	m.mu.Unlock()
+
+	metric("gonews_document_writes_total", "counter", "Documents written through the API, by operation.")
+	fmt.Fprintf(w, "gonews_document_writes_total{op=\"add\"} %d\n", m.adds.Load())
+	fmt.Fprintf(w, "gonews_document_writes_total{op=\"update\"} %d\n", m.updates.Load())
+	fmt.Fprintf(w, "gonews_document_writes_total{op=\"delete\"} %d\n", m.deletes.Load())
+
+	metric("gonews_documents", "gauge", "Documents in the index being served.")
+	fmt.Fprintf(w, "gonews_documents %d\n", idx.DocCount())
+	metric("gonews_terms", "gauge", "Distinct terms in the index being served.")
+	fmt.Fprintf(w, "gonews_terms %d\n", idx.TermCount())
+
+	// the cache belongs to the index, so a reload starts these over
+	hits, misses := idx.CacheStats()
+	metric("gonews_cache_hits_total", "counter", "Searches answered from the result cache.")
+	fmt.Fprintf(w, "gonews_cache_hits_total %d\n", hits)
+	metric("gonews_cache_misses_total", "counter", "Cacheable searches that had to be run.")
+	fmt.Fprintf(w, "gonews_cache_misses_total %d\n", misses)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.writeMetrics(w)
+}
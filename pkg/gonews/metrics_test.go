@@ -0,0 +1,116 @@
+package gonews
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metricLines returns the samples of a /metrics body, without comments
+func metricLines(body string) map[string]bool {
+	lines := make(map[string]bool)
+	for _, l := range strings.Split(body, "\n") {
+		if l != "" && !strings.HasPrefix(l, "#") {
+			lines[l] = true
+		}
+	}
+	return lines
+}
+
+func TestServerMetrics(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote", Content: "parliament passes budget"},
+		Document{ID: 2, Title: "Storm warning", Content: "heavy rain tonight"},
+	)
+	idx.CacheSize = 10
+	s := NewServer(idx)
+	s.MaxConcurrentSearches = 1
+	h := s.Handler()
+
+	// the second search is answered from the cache
+	for range 2 {
+		if rec := serve(h, "GET", "/search?q=budget", ""); rec.Code != http.StatusOK {
+			t.Fatalf("GET /search?q=budget = %d %s", rec.Code, rec.Body)
+		}
+	}
+	if rec := serve(h, "GET", "/search?q=(budget", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("GET /search?q=(budget = %d, want 400", rec.Code)
+	}
+	// with the only slot taken, a search is turned away
+	if err := s.acquireSearch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(h, "GET", "/search?q=storm", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("GET /search with no free slot = %d, want 429", rec.Code)
+	}
+	s.releaseSearch()
+
+	writes := []struct{ method, path, body string }{
+		{"POST", "/documents", `{"id": 3, "title": "Election", "content": "polls open"}`},
+		{"PUT", "/documents/3", `{"title": "Election", "content": "results announced"}`},
+		{"DELETE", "/documents/2", ""},
+		{"DELETE", "/documents/9", ""}, // not found: not counted
+	}
+	for _, w := range writes {
+		serve(h, w.method, w.path, w.body)
+	}
+
+	rec := serve(h, "GET", "/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+	got := metricLines(rec.Body.String())
+	for _, want := range []string{
+		`gonews_searches_total{outcome="ok"} 2`,
+		`gonews_searches_total{outcome="rejected"} 1`,
+		`gonews_searches_total{outcome="error"} 1`,
+		`gonews_search_duration_seconds_bucket{le="+Inf"} 2`,
+		`gonews_search_duration_seconds_count 2`,
+		`gonews_document_writes_total{op="add"} 1`,
+		`gonews_document_writes_total{op="update"} 1`,
+		`gonews_document_writes_total{op="delete"} 1`,
+		`gonews_documents 2`,
+		`gonews_cache_hits_total 1`,
+		`gonews_cache_misses_total 1`,
+	} {
+		if !got[want] {
+			t.Errorf("/metrics lacks %s:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestSearchLatencyHistogram(t *testing.T) {
+	s := NewServer(buildIndex())
+	now := time.Now()
+	s.metrics.observeSearch(now, nil)
+	s.metrics.observeSearch(now.Add(-30*time.Millisecond), nil)
+	s.metrics.observeSearch(now.Add(-time.Minute), nil) // past every bound
+	// failures aren't timed
+	s.metrics.observeSearch(now, ErrTooManySearches)
+	s.metrics.observeSearch(now, context.Canceled)
+
+	var buf bytes.Buffer
+	s.writeMetrics(&buf)
+	got := metricLines(buf.String())
+	// buckets are cumulative
+	for _, want := range []string{
+		`gonews_search_duration_seconds_bucket{le="0.025"} 1`,
+		`gonews_search_duration_seconds_bucket{le="0.05"} 2`,
+		`gonews_search_duration_seconds_bucket{le="5"} 2`,
+		`gonews_search_duration_seconds_bucket{le="+Inf"} 3`,
+		`gonews_search_duration_seconds_count 3`,
+		`gonews_searches_total{outcome="ok"} 3`,
+		`gonews_searches_total{outcome="rejected"} 1`,
+		`gonews_searches_total{outcome="error"} 1`,
+	} {
+		if !got[want] {
+			t.Errorf("metrics lack %s:\n%s", want, buf.String())
+		}
+	}
+}
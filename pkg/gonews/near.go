@@ -0,0 +1,157 @@
+package gonews
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nearOperator parses a proximity operator NEAR/N (case-insensitive, N >= 1).
+// A bare "near" is an ordinary word.
+func nearOperator(t string) (int, bool) {
+	n, ok := strings.CutPrefix(strings.ToUpper(t), "NEAR/")
+	if !ok {
+		return 0, false
+	}
+	dist, err := strconv.Atoi(n)
+	if err != nil || dist < 1 {
+		return 0, false
+	}
+	return dist, true
+}
+
+// nearToken is the RPN token for a chain of terms where each must occur
+// within dists[i] tokens of the next: NEAR/5:biden sanctions, or
+// NEAR/5,2:biden sanctions russia for biden NEAR/5 sanctions NEAR/2 russia
+func nearToken(terms []string, dists []int) string {
+	ds := make([]string, len(dists))
+	for i, d := range dists {
+		ds[i] = strconv.Itoa(d)
+	}
+	return "NEAR/" + strings.Join(ds, ",") + ":" + strings.Join(terms, " ")
+}
+
+func parseNearToken(tok string) (terms []string, dists []int, ok bool) {
+	rest, ok := strings.CutPrefix(tok, "NEAR/")
+	if !ok {
+		return nil, nil, false
+	}
+	n, list, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, nil, false
+	}
+	for _, d := range strings.Split(n, ",") {
+		dist, err := strconv.Atoi(d)
+		if err != nil {
+			return nil, nil, false
+		}
+		dists = append(dists, dist)
+	}
+	terms = strings.Fields(list)
+	if len(terms) != len(dists)+1 {
+		return nil, nil, false
+	}
+	return terms, dists, true
+}
+
+// isPlainTerm reports whether an analyzed query token is an ordinary term,
+// not an operator, parenthesis or special clause (those all hold a ':')
+func isPlainTerm(tok string) bool {
+	if tok == "(" || tok == ")" || isOperator(tok) || strings.Contains(tok, ":") {
+		return false
+	}
+	_, near := nearOperator(tok)
+	return !near
+}
+
+// foldNear merges "a NEAR/N b" in analyzed query tokens into one NEAR token;
+// chains (a NEAR/3 b NEAR/1 c) become one token over all their terms, each
+// distance applying to the pair it stands between. NEAR/N between anything
+// but plain terms falls back to AND.
+func foldNear(toks []string) []string {
+	var out []string
+	for i := 0; i < len(toks); i++ {
+		dist, ok := nearOperator(toks[i])
+		if !ok {
+			out = append(out, toks[i])
+			continue
+		}
+		n := len(out)
+		if n == 0 || i+1 == len(toks) || !isPlainTerm(toks[i+1]) {
+			out = append(out, "AND")
+			continue
+		}
+		left := out[n-1]
+		if terms, dists, ok := parseNearToken(left); ok {
+			out[n-1] = nearToken(append(terms, toks[i+1]), append(dists, dist))
+		} else if isPlainTerm(left) {
+			out[n-1] = nearToken([]string{left, toks[i+1]}, []int{dist})
+		} else {
+			out = append(out, "AND")
+			continue
+		}
+		i++ // right operand consumed
+	}
+	return out
+}
+
+// nearPositions finds the occurrences of a chain of terms in doc where
+// each term is within dists[i] tokens of the next, in either order, and
+// returns for each term its ascending positions that are part of such a
+// chain; nil if there is none
+func (idx *Index) nearPositions(doc int, terms []string, dists []int) [][]int {
+	if len(terms) == 0 || len(dists) != len(terms)-1 {
+		return nil
+	}
+	// within reports whether some position of ps is at most d from p
+	within := func(ps []int, p, d int) bool {
+		i := sort.SearchInts(ps, p-d)
+		return i < len(ps) && ps[i] <= p+d
+	}
+	// forward: the positions of term i reachable from a chain of terms
+	// 0..i; backward: those of them that also reach the last term
+	reach := make([][]int, len(terms))
+	reach[0] = idx.Terms[terms[0]].Positions(doc)
+	for i := 1; i < len(terms); i++ {
+		for _, p := range idx.Terms[terms[i]].Positions(doc) {
+			if within(reach[i-1], p, dists[i-1]) {
+				reach[i] = append(reach[i], p)
+			}
+		}
+	}
+	if len(reach[len(terms)-1]) == 0 {
+		return nil
+	}
+	out := make([][]int, len(terms))
+	out[len(terms)-1] = reach[len(terms)-1]
+	for i := len(terms) - 2; i >= 0; i-- {
+		for _, p := range reach[i] {
+			if within(out[i+1], p, dists[i]) {
+				out[i] = append(out[i], p)
+			}
+		}
+	}
+	return out
+}
+
+// docsNear returns docs holding a chain of terms each within dists[i]
+// tokens of the next
+func (idx *Index) docsNear(terms []string, dists []int) map[int]struct{} {
+	res := make(map[int]struct{})
+	if len(terms) == 0 {
+		return res
+	}
+	// only docs holding every term can match; start from the rarest
+	rarest := terms[0]
+	for _, t := range terms[1:] {
+		if idx.Terms[t].Len() < idx.Terms[rarest].Len() {
+			rarest = t
+		}
+	}
+	for _, doc := range idx.Terms[rarest].Docs() {
+		if idx.nearPositions(doc, terms, dists) != nil {
+			res[doc] = struct{}{}
+		}
+	}
+	return res
+}
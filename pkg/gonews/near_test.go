@@ -0,0 +1,80 @@
+package gonews
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNearToRPN(t *testing.T) {
+	tests := []struct {
+		query string
+		rpn   []string
+	}{
+		{"biden NEAR/5 sanctions", []string{"NEAR/5:biden sanctions"}},
+		{"biden near/5 Sanctions", []string{"NEAR/5:biden sanctions"}},
+		{"biden NEAR/1 announced NEAR/3 sanctions", []string{"NEAR/1,3:biden announced sanctions"}},
+		{"biden NEAR/2 sanctions OR tariffs", []string{"NEAR/2:biden sanctions", "tariffs", "OR"}},
+		// a bare near is a word, and NEAR/0 isn't an operator
+		{"near miss", []string{"near", "miss"}},
+		{"biden near sanctions", []string{"biden", "near", "sanctions"}},
+		// only plain words fold; anything else is ANDed (searches reject it)
+		{`"joe biden" NEAR/2 sanctions`, []string{"PHRASE:joe biden", "sanctions", "AND"}},
+		{"title:biden NEAR/2 sanctions", []string{"FIELD:title:biden", "sanctions", "AND"}},
+		{"biden NEAR/2 (sanctions)", []string{"biden", "sanctions", "AND"}},
+	}
+	var a Analyzer
+	for _, tt := range tests {
+		if got := a.QueryToRPN(tt.query); !slices.Equal(got, tt.rpn) {
+			t.Errorf("QueryToRPN(%q) = %q, want %q", tt.query, got, tt.rpn)
+		}
+	}
+}
+
+func TestNearSearch(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "biden announced sanctions today"},
+		Document{ID: 2, Content: "sanctions were announced by biden"},
+		Document{ID: 3, Content: "biden spoke at length before he finally announced new sanctions"},
+		Document{ID: 4, Content: "biden announced nothing, while allies said sanctions may follow later"},
+		Document{ID: 5, Content: "near miss for biden"},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"biden NEAR/1 announced", []int{1, 2, 4}},
+		{"biden near/1 announced", []int{1, 2, 4}},
+		{"sanctions NEAR/1 biden", nil},
+		{"sanctions NEAR/2 biden", []int{1}},
+		{"sanctions NEAR/3 biden", []int{1, 2}},
+		{"biden NEAR/6 sanctions", []int{1, 2, 4}},
+		// each distance holds between its own pair, not across the chain
+		{"biden NEAR/1 announced NEAR/1 sanctions", []int{1}},
+		{"announced NEAR/1 biden NEAR/1 sanctions", nil},
+		{"biden NEAR/1 announced NEAR/5 sanctions", []int{1, 2, 4}},
+		{"biden NEAR/6 announced NEAR/2 sanctions", []int{1, 2, 3}},
+		{"near", []int{5}},
+		{"near NEAR/2 biden", []int{5}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// the chain's terms are highlighted where they satisfy it
+	got, err := idx.Highlight("biden NEAR/1 announced NEAR/5 sanctions", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[[biden]] [[announced]] nothing, while allies said [[sanctions]] may follow later"; got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+
+	for _, q := range []string{`"joe biden" NEAR/2 sanctions`, "title:biden NEAR/2 sanctions", "NEAR/2 sanctions"} {
+		if _, _, err := idx.SearchTotal(q); !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("SearchTotal(%s) error = %v, want %v", q, err, ErrMalformedQuery)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package gonews
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NumericField is the query prefix for numeric range clauses over the
+// numbers mentioned in a doc: amount:>1000000, amount:<=5m, amount:1000..5000
+var NumericField = "amount"
+
+// numberRE matches a number with optional thousands separators and scale
+// suffix: 1,200,000 / 2.5bn / 3 million
+var numberRE = regexp.MustCompile(`(?i)(\d[\d,]*(?:\.\d+)?)(?:(k|m|bn|b)\b|\s+(thousand|million|billion|trillion)\b)?`)
+
+var numberScales = map[string]float64{
+	"k": 1e3, "thousand": 1e3,
+	"m": 1e6, "million": 1e6,
+	"b": 1e9, "bn": 1e9, "billion": 1e9,
+	"trillion": 1e12,
+}
+
+// extractNumbers returns the numeric values mentioned in text, sorted
+func extractNumbers(text string) []float64 {
+	var out []float64
+	for _, m := range numberRE.FindAllStringSubmatch(text, -1) {
+		if v, ok := scaledNumber(m); ok {
+			out = append(out, v)
+		}
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// scaledNumber converts a numberRE submatch to its value
+func scaledNumber(m []string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	suffix := m[2]
+	if suffix == "" {
+		suffix = m[3]
+	}
+	if s, ok := numberScales[strings.ToLower(suffix)]; ok {
+		v *= s
+	}
+	return v, true
+}
+
+// parseNumber parses a whole string as a (possibly scaled) number
+func parseNumber(s string) (float64, bool) {
+	m := numberRE.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return 0, false
+	}
+	return scaledNumber(m)
+}
+
+// numRange is a parsed numeric range clause
+type numRange struct {
+	Lo, Hi         float64
+	HasLo, HasHi   bool
+	LoIncl, HiIncl bool
+}
+
+func (r numRange) contains(v float64) bool {
+	if r.HasLo && (v < r.Lo || v == r.Lo && !r.LoIncl) {
+		return false
+	}
+	if r.HasHi && (v > r.Hi || v == r.Hi && !r.HiIncl) {
+		return false
+	}
+	return true
+}
+
+// isRangeToken reports whether a query token is aimed at NumericField
+func isRangeToken(tok string) bool {
+	return NumericField != "" && strings.HasPrefix(strings.ToLower(tok), strings.ToLower(NumericField)+":")
+}
+
+// parseRangeToken parses amount:>N, >=N, <N, <=N, N..M (inclusive) or N (exact)
+func parseRangeToken(tok string) (numRange, error) {
+	if !isRangeToken(tok) {
+		return numRange{}, fmt.Errorf("not a %s: clause", NumericField)
+	}
+	expr := tok[len(NumericField)+1:]
+	num := func(s string) (float64, error) {
+		v, ok := parseNumber(s)
+		if !ok {
+			return 0, fmt.Errorf("invalid number %q in %s", s, tok)
+		}
+		return v, nil
+	}
+	var r numRange
+	var err error
+	switch {
+	case strings.HasPrefix(expr, ">="):
+		r.Lo, err = num(expr[2:])
+		r.HasLo, r.LoIncl = true, true
+	case strings.HasPrefix(expr, ">"):
+		r.Lo, err = num(expr[1:])
+		r.HasLo = true
+	case strings.HasPrefix(expr, "<="):
+		r.Hi, err = num(expr[2:])
+		r.HasHi, r.HiIncl = true, true
+	case strings.HasPrefix(expr, "<"):
+		r.Hi, err = num(expr[1:])
+		r.HasHi = true
+	case strings.Contains(expr, ".."):
+		lo, hi, _ := strings.Cut(expr, "..")
+		if r.Lo, err = num(lo); err != nil {
+			return r, err
+		}
+		r.Hi, err = num(hi)
+		r.HasLo, r.LoIncl, r.HasHi, r.HiIncl = true, true, true, true
+	default:
+		r.Lo, err = num(expr)
+		r.Hi = r.Lo
+		r.HasLo, r.LoIncl, r.HasHi, r.HiIncl = true, true, true, true
+	}
+	return r, err
+}
+
+// docsInRange returns docs mentioning at least one number inside r
+func (idx *Index) docsInRange(r numRange) map[int]struct{} {
+	out := make(map[int]struct{})
+	for id, nums := range idx.Numbers {
+		for _, v := range nums {
+			if r.contains(v) {
+				out[id] = struct{}{}
+				break
+			}
+		}
+	}
+	return out
+}
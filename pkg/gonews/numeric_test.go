@@ -0,0 +1,58 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractNumbers(t *testing.T) {
+	tests := []struct {
+		text string
+		want []float64
+	}{
+		{"no numbers here", nil},
+		{"deal worth 1,200,000 dollars", []float64{1200000}},
+		{"a $2.5 million grant and 7bn in loans", []float64{2.5e6, 7e9}},
+		{"raised 300k, then 3 thousand more", []float64{3000, 300000}},
+	}
+	for _, tt := range tests {
+		if got := extractNumbers(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("extractNumbers(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAmountQuery(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Acquisition closes", Content: "The acquisition was valued at $2.5 billion."},
+		Document{ID: 2, Title: "Small grant", Content: "A grant of 1,000 dollars was awarded."},
+		Document{ID: 3, Title: "Startup acquisition", Content: "Bought for 5m in cash."},
+		Document{ID: 4, Title: "No figures", Content: "An acquisition with undisclosed terms."},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"amount:>1000000", []int{1, 3}},
+		{"amount:>=1000", []int{1, 2, 3}},
+		{"amount:>1000", []int{1, 3}},
+		{"amount:<=1000", []int{2}},
+		{"amount:<1000", nil},
+		{"amount:1000..5m", []int{2, 3}},
+		{"amount:5000000", []int{3}},
+		{"acquisition AND amount:>1bn", []int{1}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseRangeTokenErrors(t *testing.T) {
+	for _, tok := range []string{"amount:>lots", "amount:1..", "amount:"} {
+		if _, err := parseRangeToken(tok); err == nil {
+			t.Errorf("parseRangeToken(%q) succeeded", tok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package gonews
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSearchWithinExclude(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Budget passes"},
+		Document{ID: 3, Title: "Budget delayed"},
+		Document{ID: 4, Title: "Election day"},
+	)
+	set := func(ids ...int) map[int]struct{} {
+		m := make(map[int]struct{})
+		for _, id := range ids {
+			m[id] = struct{}{}
+		}
+		return m
+	}
+	tests := []struct {
+		name      string
+		opts      SearchOptions
+		want      []int
+		wantTotal int
+	}{
+		{"none", SearchOptions{}, []int{1, 2, 3}, 3},
+		{"within", SearchOptions{Within: set(1, 3, 4)}, []int{1, 3}, 2},
+		{"empty within", SearchOptions{Within: set()}, nil, 0},
+		{"exclude", SearchOptions{Exclude: set(2)}, []int{1, 3}, 2},
+		{"exclude wins", SearchOptions{Within: set(1, 2), Exclude: set(2)}, []int{1}, 1},
+		{"exclude with paging", SearchOptions{Exclude: set(1), K: 1, Offset: 1}, []int{3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, total, err := idx.SearchWithOptions(context.Background(), "budget", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := sortedIDs(results)
+			if tt.opts.K > 0 {
+				got = resultIDs(results)
+			}
+			if !slices.Equal(got, tt.want) || total != tt.wantTotal {
+				t.Errorf("got %v (total %d), want %v (total %d)", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestServerExclude(t *testing.T) {
+	h := NewServer(buildIndex(
+		Document{ID: 1, Title: "Budget vote"},
+		Document{ID: 2, Title: "Budget passes"},
+	)).Handler()
+	rec := serve(h, "GET", "/search?q=budget&exclude=1", "")
+	var resp SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("exclude=1: %d %s", rec.Code, rec.Body)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].ID != 2 || resp.Total != 1 {
+		t.Errorf("exclude=1: got %+v, want only doc 2", resp)
+	}
+	if rec := serve(h, "GET", "/search?q=budget&exclude=1,x", ""); rec.Code != 400 {
+		t.Errorf("exclude=1,x: status %d, want 400", rec.Code)
+	}
+}
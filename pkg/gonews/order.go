@@ -0,0 +1,40 @@
+package gonews
+
+// SortFunc reports whether result a should come before b. Like ScoreFunc it
+// runs with the index read-locked, so it may read idx.Docs directly but must
+// not call methods that lock.
+type SortFunc func(idx *Index, a, b SearchResult) bool
+
+// SortByRank is the default ordering: by the index's RankMode, then score,
+// then doc ID
+func SortByRank(idx *Index, a, b SearchResult) bool {
+	if idx.Rank == RankByMatchedTerms && len(a.MatchedTerms) != len(b.MatchedTerms) {
+		return len(a.MatchedTerms) > len(b.MatchedTerms)
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.DocID < b.DocID // stable order for ties
+}
+
+// SortByDateDesc puts the newest docs (by ParsedDate) first and undated ones
+// last; docs from the same moment keep SortByRank order
+func SortByDateDesc(idx *Index, a, b SearchResult) bool {
+	return sortByDate(idx, a, b, true)
+}
+
+// SortByDateAsc is SortByDateDesc oldest first; undated docs still come last
+func SortByDateAsc(idx *Index, a, b SearchResult) bool {
+	return sortByDate(idx, a, b, false)
+}
+
+func sortByDate(idx *Index, a, b SearchResult, newestFirst bool) bool {
+	da, db := idx.Docs[a.DocID].ParsedDate, idx.Docs[b.DocID].ParsedDate
+	switch {
+	case da.IsZero() != db.IsZero():
+		return db.IsZero()
+	case !da.Equal(db):
+		return da.After(db) == newestFirst
+	}
+	return SortByRank(idx, a, b)
+}
@@ -0,0 +1,127 @@
+package gonews
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// indexFormatVersion is bumped whenever savedIndex changes shape
+const indexFormatVersion = 5
+
+// savedIndex is what Save writes: the index contents plus the analyzer
+// they were built with. Scoring settings are not saved; they are cheap to
+// set again and callers usually want their own.
+type savedIndex struct {
+	Version      int
+	Analyzer     Analyzer
+	Terms        map[string]*Posting // delta + varint encoded (Posting.MarshalBinary)
+	Docs         map[int]Document
+	DocTokCounts map[int]int
+	FieldEnds    map[int][]int
+	Numbers      map[int][]float64
+	Fingerprints map[int]uint64
+
+	StoredContent map[int]bool // docs saved without Content, which is in the DocStore
+	DupOf         map[int]int  // see DuplicateOf
+	DupsDropped   int
+}
+
+// Save writes the index to path as gzipped gob, together with its
+// analyzer, so LoadIndex can skip re-tokenizing the source. The
+// file is written to a temporary name and renamed, so a failed Save never
+// leaves a truncated index behind.
+func (idx *Index) Save(path string) error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	zw := gzip.NewWriter(tmp)
+	err = gob.NewEncoder(zw).Encode(savedIndex{
+		Version:      indexFormatVersion,
+		Analyzer:     idx.Analyzer,
+		Terms:        idx.Terms,
+		Docs:         idx.Docs,
+		DocTokCounts: idx.DocTokCounts,
+		FieldEnds:    idx.FieldEnds,
+		Numbers:      idx.Numbers,
+		Fingerprints: idx.Fingerprints,
+
+		StoredContent: idx.StoredContent,
+		DupOf:         idx.dupOf,
+		DupsDropped:   idx.dupsDropped,
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("save index: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadIndex reads an index written by Save, with the Analyzer it was built
+// with, so queries are analyzed the same way as the index. Other settings
+// such as IDF tuning and the scorer start at their defaults. An index saved with a
+// Store needs the same store set again before its docs' Content is read.
+func LoadIndex(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("load index %s: %v: %w", path, err, ErrInvalidIndex)
+	}
+	var s savedIndex
+	if err := gob.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("load index %s: %v: %w", path, err, ErrInvalidIndex)
+	}
+	if s.Version != indexFormatVersion {
+		return nil, fmt.Errorf("load index %s: format version %d, want %d: %w", path, s.Version, indexFormatVersion, ErrInvalidIndex)
+	}
+
+	idx := NewIndex()
+	idx.Analyzer = s.Analyzer
+	// gob leaves empty maps nil
+	if s.Terms != nil {
+		idx.Terms = s.Terms
+	}
+	if s.Docs != nil {
+		idx.Docs = s.Docs
+	}
+	if s.DocTokCounts != nil {
+		idx.DocTokCounts = s.DocTokCounts
+	}
+	if s.FieldEnds != nil {
+		idx.FieldEnds = s.FieldEnds
+	}
+	if s.Numbers != nil {
+		idx.Numbers = s.Numbers
+	}
+	if s.Fingerprints != nil {
+		idx.Fingerprints = s.Fingerprints
+	}
+	if s.StoredContent != nil {
+		idx.StoredContent = s.StoredContent
+	}
+	idx.dupOf = s.DupOf
+	idx.dupsDropped = s.DupsDropped
+	idx.N = len(idx.Docs)
+	for _, n := range idx.DocTokCounts {
+		idx.totalToks += n
+	}
+	return idx, nil
+}
@@ -0,0 +1,50 @@
+package gonews
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSaveKeepsDuplicates(t *testing.T) {
+	idx := NewIndex()
+	idx.Duplicates = DuplicatesMerge
+	idx.AddDocument(Document{ID: 1, Title: "a", Content: "budget vote today"})
+	idx.AddDocument(Document{ID: 2, Title: "b", Content: "budget  vote today"})
+	path := filepath.Join(t.TempDir(), "index.gob")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orig, ok := loaded.DuplicateOf(2); !ok || orig != 1 {
+		t.Errorf("DuplicateOf(2) = %d, %v, want 1, true", orig, ok)
+	}
+	if n := loaded.DuplicatesDropped(); n != 1 {
+		t.Errorf("DuplicatesDropped() = %d, want 1", n)
+	}
+}
+
+func TestSaveKeepsAnalyzer(t *testing.T) {
+	a := Analyzer{Stemming: true, Stopwords: map[string]bool{"vote": true}}
+	idx := buildAnalyzed(a, Document{ID: 1, Title: "Elections", Content: "the vote was held"})
+	path := filepath.Join(t.TempDir(), "index.gob")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Analyzer, a) {
+		t.Errorf("loaded Analyzer = %+v, want %+v", loaded.Analyzer, a)
+	}
+	for _, q := range []string{"election", "the"} {
+		if got := resultIDs(loaded.Search(q)); !slices.Equal(got, []int{1}) {
+			t.Errorf("Search(%s) after loading = %v, want [1]", q, got)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package gonews
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// postingBlockSize is how many docs a Posting block takes by appending; a
+// block split from an insert holds up to twice that. A lookup decodes at
+// most one block.
+const postingBlockSize = 64
+
+// Posting is the posting list of one term: the docs containing it in
+// ascending ID order, each with its ascending token positions. Entries are
+// delta and varint encoded, usually a few bytes each instead of a map slot
+// and slice per doc, and grouped in blocks whose first and last doc IDs
+// let a lookup binary search to one block and decode only that. A nil
+// *Posting is an empty list.
+type Posting struct {
+	blocks []postingBlock
+	n      int // number of docs
+
+	// lazy makes this a stand-in for a posting kept in a KVStore (see
+	// OpenIndex): only n is held, and every other method loads the posting
+	lazy *lazyPosting
+}
+
+// postingBlock is a run of entries: uvarint(doc - previous doc), then
+// uvarint(number of positions), then each position as a uvarint delta from
+// the previous one. The first entry's doc delta is taken from first.
+type postingBlock struct {
+	first, last int
+	n           int
+	data        []byte
+}
+
+// postingEntry is one decoded doc of a posting
+type postingEntry struct {
+	doc       int
+	positions []int
+}
+
+// Len is the number of docs in the posting (the term's document frequency)
+func (p *Posting) Len() int {
+	if p == nil {
+		return 0
+	}
+	return p.n
+}
+
+// Positions returns the positions of the term in doc, nil if it's absent
+func (p *Posting) Positions(doc int) []int {
+	if p == nil {
+		return nil
+	}
+	if p.lazy != nil {
+		return p.lazy.load().Positions(doc)
+	}
+	i := p.blockFor(doc)
+	if i == len(p.blocks) || p.blocks[i].first > doc {
+		return nil
+	}
+	var out []int
+	p.blocks[i].each(func(d int, positions func() []int) bool {
+		if d == doc {
+			out = positions()
+		}
+		return d < doc
+	})
+	return out
+}
+
+// Docs returns the IDs of the docs in the posting, ascending
+func (p *Posting) Docs() []int {
+	if p == nil {
+		return nil
+	}
+	if p.lazy != nil {
+		return p.lazy.load().Docs()
+	}
+	ids := make([]int, 0, p.n)
+	for i := range p.blocks {
+		p.blocks[i].each(func(d int, _ func() []int) bool {
+			ids = append(ids, d)
+			return true
+		})
+	}
+	return ids
+}
+
+// Each calls fn for every doc in ascending ID order with its positions
+func (p *Posting) Each(fn func(doc int, positions []int)) {
+	if p == nil {
+		return
+	}
+	if p.lazy != nil {
+		p.lazy.load().Each(fn)
+		return
+	}
+	for i := range p.blocks {
+		p.blocks[i].each(func(d int, positions func() []int) bool {
+			fn(d, positions())
+			return true
+		})
+	}
+}
+
+// blockFor returns the first block whose last doc is >= doc
+func (p *Posting) blockFor(doc int) int {
+	return sort.Search(len(p.blocks), func(i int) bool { return p.blocks[i].last >= doc })
+}
+
+// set stores doc's positions (ascending), replacing any already there.
+// Appending docs in ascending ID order is cheapest.
+func (p *Posting) set(doc int, positions []int) {
+	if p.lazy != nil {
+		loaded := p.lazy.loadForWrite()
+		loaded.set(doc, positions)
+		p.n = loaded.n
+		return
+	}
+	if n := len(p.blocks); n == 0 || doc > p.blocks[n-1].last {
+		if n == 0 || p.blocks[n-1].n >= postingBlockSize {
+			p.blocks = append(p.blocks, postingBlock{first: doc, last: doc})
+			n++
+		}
+		p.blocks[n-1].append(doc, positions)
+		p.n++
+		return
+	}
+	i := p.blockFor(doc)
+	entries := p.blocks[i].entries()
+	j := sort.Search(len(entries), func(j int) bool { return entries[j].doc >= doc })
+	if j < len(entries) && entries[j].doc == doc {
+		entries[j].positions = positions
+	} else {
+		entries = append(entries, postingEntry{})
+		copy(entries[j+1:], entries[j:])
+		entries[j] = postingEntry{doc, positions}
+		p.n++
+	}
+	p.replaceBlock(i, entries)
+}
+
+// remove deletes doc from the posting; false if it wasn't there
+func (p *Posting) remove(doc int) bool {
+	if p.lazy != nil {
+		loaded := p.lazy.loadForWrite()
+		ok := loaded.remove(doc)
+		p.n = loaded.n
+		return ok
+	}
+	i := p.blockFor(doc)
+	if i == len(p.blocks) || p.blocks[i].first > doc {
+		return false
+	}
+	entries := p.blocks[i].entries()
+	j := sort.Search(len(entries), func(j int) bool { return entries[j].doc >= doc })
+	if j == len(entries) || entries[j].doc != doc {
+		return false
+	}
+	p.n--
+	p.replaceBlock(i, append(entries[:j], entries[j+1:]...))
+	return true
+}
+
+// replaceBlock re-encodes block i from entries, dropping it when empty and
+// splitting it when it has grown past twice the block size
+func (p *Posting) replaceBlock(i int, entries []postingEntry) {
+	if len(entries) == 0 {
+		p.blocks = append(p.blocks[:i], p.blocks[i+1:]...)
+		return
+	}
+	if len(entries) <= 2*postingBlockSize {
+		p.blocks[i] = encodeBlock(entries)
+		return
+	}
+	half := len(entries) / 2
+	p.blocks = append(p.blocks, postingBlock{})
+	copy(p.blocks[i+2:], p.blocks[i+1:])
+	p.blocks[i] = encodeBlock(entries[:half])
+	p.blocks[i+1] = encodeBlock(entries[half:])
+}
+
+func encodeBlock(entries []postingEntry) postingBlock {
+	b := postingBlock{first: entries[0].doc, last: entries[0].doc}
+	for _, e := range entries {
+		b.append(e.doc, e.positions)
+	}
+	return b
+}
+
+// append adds an entry for a doc above every doc in the block
+func (b *postingBlock) append(doc int, positions []int) {
+	prev := b.last
+	if b.n == 0 {
+		prev = b.first
+	}
+	b.data = binary.AppendUvarint(b.data, uint64(doc-prev))
+	b.data = binary.AppendUvarint(b.data, uint64(len(positions)))
+	last := 0
+	for _, p := range positions {
+		b.data = binary.AppendUvarint(b.data, uint64(p-last))
+		last = p
+	}
+	b.last = doc
+	b.n++
+}
+
+// each decodes the block in order, calling fn with each doc and a function
+// decoding its positions (skipped if not called) until fn returns false
+func (b *postingBlock) each(fn func(doc int, positions func() []int) bool) {
+	data := b.data
+	doc := b.first
+	for range b.n {
+		delta, k := binary.Uvarint(data)
+		data = data[k:]
+		doc += int(delta)
+		count, k := binary.Uvarint(data)
+		data = data[k:]
+		start := data
+		for range count {
+			_, k := binary.Uvarint(data)
+			data = data[k:]
+		}
+		posBytes := start[:len(start)-len(data)]
+		positions := func() []int {
+			out := make([]int, count)
+			last, rest := 0, posBytes
+			for i := range out {
+				d, k := binary.Uvarint(rest)
+				rest = rest[k:]
+				last += int(d)
+				out[i] = last
+			}
+			return out
+		}
+		if !fn(doc, positions) {
+			return
+		}
+	}
+}
+
+func (b *postingBlock) entries() []postingEntry {
+	out := make([]postingEntry, 0, b.n+1)
+	b.each(func(d int, positions func() []int) bool {
+		out = append(out, postingEntry{d, positions()})
+		return true
+	})
+	return out
+}
+
+// postingIter walks a Posting's doc IDs in ascending order without
+// decoding positions. advance skips whole blocks by their last doc ID,
+// galloping then binary searching, so intersecting a rare term with a
+// common one decodes only the common term's blocks it lands in.
+type postingIter struct {
+	p    *Posting
+	bi   int    // current block
+	data []byte // undecoded rest of the current block
+	left int    // entries left in the current block
+	doc  int    // current doc, valid until done
+	done bool
+}
+
+// iter returns an iterator positioned on the posting's first doc
+func (p *Posting) iter() *postingIter {
+	if p != nil && p.lazy != nil {
+		p = p.lazy.load()
+	}
+	it := &postingIter{p: p, bi: -1}
+	if p == nil {
+		it.done = true
+		return it
+	}
+	it.next()
+	return it
+}
+
+// next moves to the following doc; false once the posting is exhausted
+func (it *postingIter) next() bool {
+	if it.done {
+		return false
+	}
+	for it.left == 0 {
+		if it.bi+1 >= len(it.p.blocks) {
+			it.done = true
+			return false
+		}
+		it.bi++
+		b := &it.p.blocks[it.bi]
+		it.data, it.left, it.doc = b.data, b.n, b.first
+	}
+	delta, k := binary.Uvarint(it.data)
+	it.data = it.data[k:]
+	it.doc += int(delta)
+	count, k := binary.Uvarint(it.data)
+	it.data = it.data[k:]
+	for range count {
+		_, k := binary.Uvarint(it.data)
+		it.data = it.data[k:]
+	}
+	it.left--
+	return true
+}
+
+// advance moves to the first doc >= target; false if there is none
+func (it *postingIter) advance(target int) bool {
+	if it.done {
+		return false
+	}
+	if it.doc >= target {
+		return true
+	}
+	blocks := it.p.blocks
+	if cur := it.bi; blocks[cur].last < target {
+		// gallop: blocks[cur].last < target, and blocks[hi].last >= target
+		// or hi is past the end
+		hi, step := cur+1, 1
+		for hi < len(blocks) && blocks[hi].last < target {
+			cur = hi
+			hi += step
+			step *= 2
+		}
+		hi = min(hi, len(blocks))
+		j := cur + 1 + sort.Search(hi-cur-1, func(i int) bool { return blocks[cur+1+i].last >= target })
+		if j == len(blocks) {
+			it.done = true
+			return false
+		}
+		it.bi, it.left = j-1, 0
+		it.next()
+	}
+	for it.doc < target {
+		if !it.next() {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary encodes the posting as one delta-encoded run of entries
+// (the block layout is rebuilt on load), used by Index.Save through gob
+func (p *Posting) MarshalBinary() ([]byte, error) {
+	all := postingBlock{}
+	p.Each(func(doc int, positions []int) {
+		all.append(doc, positions)
+	})
+	return append(binary.AppendUvarint(nil, uint64(all.n)), all.data...), nil
+}
+
+// UnmarshalBinary decodes MarshalBinary's output, checking it as it goes
+// since the bytes come from a file
+func (p *Posting) UnmarshalBinary(data []byte) error {
+	*p = Posting{}
+	next := func() (int, bool) {
+		v, k := binary.Uvarint(data)
+		if k <= 0 {
+			return 0, false
+		}
+		data = data[k:]
+		return int(v), true
+	}
+	n, ok := next()
+	if !ok {
+		return errors.New("posting: bad entry count")
+	}
+	doc := 0
+	for i := range n {
+		delta, ok := next()
+		if !ok || i > 0 && delta <= 0 {
+			return errors.New("posting: bad doc id")
+		}
+		doc += delta
+		count, ok := next()
+		if !ok || count > len(data) {
+			return errors.New("posting: bad position count")
+		}
+		positions := make([]int, count)
+		last := 0
+		for j := range positions {
+			d, ok := next()
+			if !ok {
+				return errors.New("posting: bad position")
+			}
+			last += d
+			positions[j] = last
+		}
+		p.set(doc, positions)
+	}
+	if len(data) > 0 {
+		return errors.New("posting: trailing bytes")
+	}
+	return nil
+}
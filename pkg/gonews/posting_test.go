@@ -0,0 +1,268 @@
+package gonews
+
+import (
+	"bytes"
+	"encoding/gob"
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// postingOf builds a posting from docs, set in the given order
+func postingOf(order []int, positions map[int][]int) *Posting {
+	p := &Posting{}
+	for _, d := range order {
+		p.set(d, positions[d])
+	}
+	return p
+}
+
+// seqDocs returns docs 0, step, 2*step, ... (n of them), each with a few
+// positions that grow with the doc ID
+func seqDocs(n, step int) ([]int, map[int][]int) {
+	order := make([]int, n)
+	positions := make(map[int][]int, n)
+	for i := range order {
+		d := i * step
+		order[i] = d
+		positions[d] = []int{i % 3, i%3 + 1 + d, 2*d + 300}
+	}
+	return order, positions
+}
+
+// checkPosting checks p holds exactly want and its blocks are well formed
+func checkPosting(t *testing.T, p *Posting, want map[int][]int) {
+	t.Helper()
+	if p.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", p.Len(), len(want))
+	}
+	docs := slices.Sorted(maps.Keys(want))
+	if got := p.Docs(); !slices.Equal(got, docs) {
+		t.Errorf("Docs = %v, want %v", got, docs)
+	}
+	for _, d := range docs {
+		if got := p.Positions(d); !slices.Equal(got, want[d]) {
+			t.Errorf("Positions(%d) = %v, want %v", d, got, want[d])
+		}
+		if _, ok := want[d+1]; !ok && p.Positions(d+1) != nil {
+			t.Errorf("Positions(%d) = %v for an absent doc", d+1, p.Positions(d+1))
+		}
+	}
+	got := make(map[int][]int)
+	p.Each(func(d int, positions []int) { got[d] = positions })
+	if len(want) > 0 && !reflect.DeepEqual(got, want) {
+		t.Errorf("Each = %v, want %v", got, want)
+	}
+
+	n, prevLast := 0, -1
+	for i, b := range p.blocks {
+		entries := b.entries()
+		if len(entries) == 0 || len(entries) != b.n || len(entries) > 2*postingBlockSize {
+			t.Fatalf("block %d holds %d entries (n = %d)", i, len(entries), b.n)
+		}
+		if entries[0].doc != b.first || entries[len(entries)-1].doc != b.last || b.first <= prevLast {
+			t.Errorf("block %d spans %d..%d after %d, entries %d..%d", i, b.first, b.last, prevLast, entries[0].doc, entries[len(entries)-1].doc)
+		}
+		prevLast = b.last
+		n += b.n
+	}
+	if n != p.Len() {
+		t.Errorf("blocks hold %d entries, Len = %d", n, p.Len())
+	}
+}
+
+func TestPostingRoundTrip(t *testing.T) {
+	shuffled, shuffledPos := seqDocs(300, 3)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	tests := []struct {
+		name       string
+		order      []int
+		positions  map[int][]int
+		wantBlocks int
+	}{
+		{"empty", nil, nil, 0},
+		{"doc 0 without positions", []int{0}, map[int][]int{0: {}}, 1},
+		{"large IDs and positions", []int{7, 1 << 40}, map[int][]int{7: {0, 1 << 33}, 1 << 40: {5}}, 1},
+		{"one full block", nil, nil, 1},
+		{"block boundary", nil, nil, 2},
+		{"many blocks", nil, nil, 5},
+		{"descending inserts", nil, nil, -1},
+		{"shuffled inserts", shuffled, shuffledPos, -1},
+	}
+	tests[3].order, tests[3].positions = seqDocs(postingBlockSize, 1)
+	tests[4].order, tests[4].positions = seqDocs(postingBlockSize+1, 2)
+	tests[5].order, tests[5].positions = seqDocs(5*postingBlockSize, 5)
+	desc, descPos := seqDocs(3*postingBlockSize, 1)
+	slices.Reverse(desc)
+	tests[6].order, tests[6].positions = desc, descPos
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.positions
+			if want == nil {
+				want = map[int][]int{}
+			}
+			p := postingOf(tt.order, want)
+			checkPosting(t, p, want)
+			if tt.wantBlocks >= 0 && len(p.blocks) != tt.wantBlocks {
+				t.Errorf("%d blocks, want %d", len(p.blocks), tt.wantBlocks)
+			}
+		})
+	}
+}
+
+func TestPostingSetReplaces(t *testing.T) {
+	order, want := seqDocs(3*postingBlockSize, 2)
+	p := postingOf(order, want)
+	for _, d := range []int{0, 2 * postingBlockSize, 2 * (postingBlockSize - 1), order[len(order)-1]} {
+		want[d] = []int{1, 2, 3, 4}
+		p.set(d, want[d])
+	}
+	// odd IDs fall between existing docs, in every block
+	for d := 1; d < 6*postingBlockSize; d += 2 * postingBlockSize / 4 {
+		want[d] = []int{d}
+		p.set(d, want[d])
+	}
+	checkPosting(t, p, want)
+}
+
+func TestPostingRemove(t *testing.T) {
+	tests := []struct {
+		name       string
+		remove     func(docs []int) []int
+		wantBlocks int
+	}{
+		{"absent doc", func([]int) []int { return []int{1, 1 << 30} }, 3},
+		{"first doc of each block", func(docs []int) []int {
+			return []int{docs[0], docs[postingBlockSize], docs[2*postingBlockSize]}
+		}, 3},
+		{"empty the middle block", func(docs []int) []int { return docs[postingBlockSize : 2*postingBlockSize] }, 2},
+		{"empty the last block", func(docs []int) []int { return docs[2*postingBlockSize:] }, 2},
+		{"everything", func(docs []int) []int { return docs }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, want := seqDocs(3*postingBlockSize, 2)
+			p := postingOf(docs, want)
+			for _, d := range tt.remove(slices.Clone(docs)) {
+				_, had := want[d]
+				if got := p.remove(d); got != had {
+					t.Errorf("remove(%d) = %v, want %v", d, got, had)
+				}
+				delete(want, d)
+			}
+			checkPosting(t, p, want)
+			if len(p.blocks) != tt.wantBlocks {
+				t.Errorf("%d blocks, want %d", len(p.blocks), tt.wantBlocks)
+			}
+			// the posting keeps working after blocks are dropped
+			p.set(1<<20, []int{9})
+			want[1<<20] = []int{9}
+			checkPosting(t, p, want)
+		})
+	}
+}
+
+func TestPostingIterAdvance(t *testing.T) {
+	docs, positions := seqDocs(10*postingBlockSize, 3) // 0, 3, ..., 1917
+	p := postingOf(docs, positions)
+	last := docs[len(docs)-1]
+	tests := []struct {
+		name   string
+		target int
+		want   int // -1: exhausted
+	}{
+		{"before the first doc", -5, 0},
+		{"first doc", 0, 0},
+		{"between docs", 4, 6},
+		{"last doc of the first block", docs[postingBlockSize-1], docs[postingBlockSize-1]},
+		{"first doc of the next block", docs[postingBlockSize-1] + 1, docs[postingBlockSize]},
+		{"gallop several blocks", docs[7*postingBlockSize+5] - 1, docs[7*postingBlockSize+5]},
+		{"last doc", last, last},
+		{"past the last block", last + 1, -1},
+		{"far past the end", 1 << 40, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := p.iter()
+			ok := it.advance(tt.target)
+			if tt.want < 0 {
+				if ok || !it.done {
+					t.Fatalf("advance(%d) = %v at doc %d, want exhausted", tt.target, ok, it.doc)
+				}
+				if it.advance(tt.target) || it.next() {
+					t.Error("iterator moved after running out")
+				}
+				return
+			}
+			if !ok || it.doc != tt.want {
+				t.Fatalf("advance(%d) = %v at doc %d, want %d", tt.target, ok, it.doc, tt.want)
+			}
+			// advancing to a lower target stays put; next continues in order
+			if !it.advance(tt.target-10) || it.doc != tt.want {
+				t.Errorf("advance backwards moved to %d", it.doc)
+			}
+			if it.next() != (tt.want != last) || tt.want != last && it.doc != tt.want+3 {
+				t.Errorf("next after %d = doc %d", tt.want, it.doc)
+			}
+		})
+	}
+
+	var seen []int
+	for it := p.iter(); !it.done; it.next() {
+		seen = append(seen, it.doc)
+	}
+	if !slices.Equal(seen, docs) {
+		t.Errorf("walking with next = %d docs, want %d", len(seen), len(docs))
+	}
+	var nilPosting *Posting
+	if it := nilPosting.iter(); !it.done || it.advance(0) {
+		t.Error("iterator over a nil posting isn't exhausted")
+	}
+}
+
+func TestPostingMarshalBinary(t *testing.T) {
+	// the saved-index format: uvarint count, then per doc uvarint(ID delta
+	// from the previous doc, from 0 for the first), uvarint(position
+	// count) and uvarint position deltas
+	p := postingOf([]int{10, 3}, map[int][]int{3: {1, 5}, 10: {2}})
+	golden := []byte{2, 3, 2, 1, 4, 7, 1, 2}
+	got, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, golden) {
+		t.Errorf("MarshalBinary = %v, want %v", got, golden)
+	}
+	var decoded Posting
+	if err := decoded.UnmarshalBinary(golden); err != nil {
+		t.Fatal(err)
+	}
+	checkPosting(t, &decoded, map[int][]int{3: {1, 5}, 10: {2}})
+
+	// a multi-block posting survives gob, block layout and all
+	docs, want := seqDocs(4*postingBlockSize+7, 11)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(map[string]*Posting{"t": postingOf(docs, want)}); err != nil {
+		t.Fatal(err)
+	}
+	var loaded map[string]*Posting
+	if err := gob.NewDecoder(&buf).Decode(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	checkPosting(t, loaded["t"], want)
+
+	for _, bad := range [][]byte{
+		nil,                      // no count
+		{2, 3, 1, 1},             // second doc missing
+		{2, 3, 0, 0, 0},          // repeated doc ID
+		{1, 3, 9, 1},             // more positions than bytes
+		{1, 3, 1, 1, 0},          // trailing bytes
+		{1, 3, 2, 1, 0x80, 0x80}, // unterminated varint
+	} {
+		if err := new(Posting).UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(%v) accepted a bad encoding", bad)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package gonews
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryToRPN: parse a user query into RPN tokens supporting:
+//   - quoted phrases: "small cat" -> token PHRASE:small cat
+//   - sloppy fuzzy phrases: "small cat"~2 -> token PHRASE~2:small cat (up to 2
+//     extra words in between, and each word may be slightly misspelled)
+//   - anchored phrases: ^"small cat" -> token PHRASE^:small cat (must start the
+//     title or the content)
+//   - operators: AND, OR, NOT (case-insensitive)
+//   - parentheses ( )
+//   - leading minus as exclusion: climate -policy, climate -"global warming"
+//   - numeric ranges on NumericField: amount:>1000000, amount:1000..5000
+//   - field-scoped terms: title:election, summary:merger -> FIELD:title:election
+//   - field existence: _exists_:author matches docs with a non-empty author
+//   - date ranges: date:[2023-01-01 TO 2023-06-30], * for an open end
+//   - wildcards: covid*, *tion, co*id -> WILD:covid* (expanded by the index)
+//   - fuzzy terms: ukrane~1 -> FUZZY~1:ukrane, any term within 1 edit; a
+//     bare ukrane~ picks the distance from the word's length; searches
+//     reject a malformed distance like ukrane~x
+//   - proximity: biden NEAR/5 sanctions -> NEAR/5:biden sanctions, both words
+//     within 5 tokens of each other in either order; in a chain each
+//     distance holds for its own pair: a NEAR/1 b NEAR/3 c -> NEAR/1,3:a b c
+//   - language: lang:bn matches docs whose Lang is bn; the query's words are
+//     then analyzed as that language (see DetectLanguages)
+//
+// Words are analyzed with a, which must be the analyzer of the index the
+// query runs against. Bad syntax is read as well as it can be;
+// ValidateQuery, and searches, reject it instead.
+func (a Analyzer) QueryToRPN(q string) []string {
+	rpn, _ := a.compileQuery(q)
+	return rpn
+}
+
+// compileQuery is QueryToRPN that also returns the first syntax error, as
+// ValidateQuery reports it. Both read the tokens of lexQuery, so a query
+// that passes is parsed exactly as it was checked.
+func (a Analyzer) compileQuery(q string) ([]string, error) {
+	lexed, err := lexQuery(q)
+	if err == nil {
+		err = checkQuery(lexed)
+	}
+	toks := make([]string, len(lexed))
+	for i, t := range lexed {
+		toks[i] = t.text
+		if text, slop, anchored, ok := lexedPhrase(t.text); ok {
+			toks[i] = phraseToken(text, slop, anchored)
+		}
+	}
+
+	// expand exclusion markers: "a -b" -> a AND NOT b, a leading "-b" -> NOT b
+	var expanded []string
+	for _, t := range toks {
+		if t != excludeMarker {
+			expanded = append(expanded, t)
+			continue
+		}
+		if n := len(expanded); n > 0 && expanded[n-1] != "(" && !isOperator(expanded[n-1]) {
+			expanded = append(expanded, "AND")
+		}
+		expanded = append(expanded, "NOT")
+	}
+	toks = expanded
+
+	// words are analyzed in the language the query filters on, if any
+	lang := ""
+	for _, t := range toks {
+		if l, ok := parseLangToken(t); ok {
+			lang = l
+			break
+		}
+	}
+
+	// normalize operators
+	for i, t := range toks {
+		t := strings.ToUpper(t)
+		if t == "AND" || t == "OR" || t == "NOT" || t == "(" || t == ")" || isPhraseToken(toks[i]) {
+			// keep as-is (phrase keeps case inside)
+		} else if _, ok := nearOperator(t); ok {
+			// proximity operator, folded with its operands below
+			toks[i] = t
+		} else if isDateRangeToken(t) {
+			// date range, evaluated against the docs' parsed dates
+		} else if _, ok := parseExistsToken(t); ok {
+			// field existence filter
+			toks[i] = strings.ToLower(toks[i])
+		} else if l, ok := parseLangToken(t); ok {
+			// language filter, evaluated against the docs' Lang
+			toks[i] = langToken(l)
+		} else if isRangeToken(t) {
+			// numeric range clause, evaluated against Index.Numbers
+			toks[i] = strings.ToLower(toks[i])
+		} else if field, word, ok := splitFieldTerm(t); ok {
+			// field:word -> the word only counts inside that field
+			if sub := a.TokenizeLang(word, lang); len(sub) > 0 {
+				toks[i] = fieldTermToken(field, sub[0])
+			} else {
+				toks[i] = fieldTermToken(field, strings.ToLower(word))
+			}
+		} else if word, dist, ok, err := splitFuzzyWord(t); ok && err == nil {
+			// expanded to nearby indexed terms at search time
+			word = strings.ToLower(word)
+			if sub := a.TokenizeLang(word, lang); len(sub) == 1 {
+				word = sub[0]
+			}
+			toks[i] = fuzzyToken(word, dist)
+		} else if isWildcard(t) {
+			// matched against the term dictionary at search time
+			toks[i] = wildcardToken(strings.ToLower(t))
+		} else {
+			// normal token -> lowercase + tokenization step
+			t = strings.ToLower(t)
+			// break token into word tokens if it contains non-word chars
+			sub := a.TokenizeLang(t, lang)
+			if len(sub) == 0 {
+				// keep original token
+				toks[i] = t
+			} else if len(sub) == 1 {
+				toks[i] = sub[0]
+			} else if sub[0] == t {
+				// kept whole by the analyzer (identifier like gpt_4, or #tag); match it directly
+				toks[i] = sub[0]
+			} else {
+				// if tokenization produced multiple tokens, join with _
+				toks[i] = strings.Join(sub, "_")
+			}
+		}
+	}
+
+	toks = foldNear(toks)
+
+	// shunting-yard to convert to RPN
+	prec := map[string]int{"OR": 1, "AND": 2, "NOT": 3}
+	var out []string
+	var opstack []string
+	pushOp := func(op string) { opstack = append(opstack, op) }
+	popOp := func() string {
+		op := opstack[len(opstack)-1]
+		opstack = opstack[:len(opstack)-1]
+		return op
+	}
+	for _, tk := range toks {
+		if tk == "(" {
+			pushOp(tk)
+			continue
+		}
+		if tk == ")" {
+			for len(opstack) > 0 && opstack[len(opstack)-1] != "(" {
+				out = append(out, popOp())
+			}
+			if len(opstack) > 0 && opstack[len(opstack)-1] == "(" {
+				popOp()
+			}
+			continue
+		}
+		u := strings.ToUpper(tk)
+		if u == "AND" || u == "OR" || u == "NOT" {
+			for len(opstack) > 0 {
+				op := opstack[len(opstack)-1]
+				if op == "(" {
+					break
+				}
+				if prec[strings.ToUpper(op)] >= prec[u] {
+					out = append(out, popOp())
+				} else {
+					break
+				}
+			}
+			pushOp(u)
+			continue
+		}
+		// term or phrase
+		out = append(out, tk)
+	}
+	for len(opstack) > 0 {
+		out = append(out, popOp())
+	}
+	return out, err
+}
+
+// PhraseQuery turns free text into a single exact-phrase query, so operators
+// and quotes inside it are taken literally: climate change policy -> "climate change policy"
+func PhraseQuery(q string) string {
+	q = strings.TrimSpace(strings.ReplaceAll(q, `"`, " "))
+	if q == "" {
+		return ""
+	}
+	return `"` + q + `"`
+}
+
+// phraseToken builds the RPN token for a quoted phrase: PHRASE:text, with
+// ^ after PHRASE when anchored to a field start and ~N when sloppy
+func phraseToken(text string, slop int, anchored bool) string {
+	tok := "PHRASE"
+	if anchored {
+		tok += "^"
+	}
+	if slop > 0 {
+		tok += "~" + strconv.Itoa(slop)
+	}
+	return tok + ":" + text
+}
+
+// isPhraseToken reports whether an RPN token is a phrase
+func isPhraseToken(tok string) bool {
+	return strings.HasPrefix(tok, "PHRASE:") || strings.HasPrefix(tok, "PHRASE~") || strings.HasPrefix(tok, "PHRASE^")
+}
+
+// phraseAnchored reports whether a phrase token must start a field
+func phraseAnchored(tok string) bool {
+	return strings.HasPrefix(tok, "PHRASE^")
+}
+
+// parsePhraseToken splits a phrase RPN token into its text and slop
+func parsePhraseToken(tok string) (text string, slop int, ok bool) {
+	if !isPhraseToken(tok) {
+		return "", 0, false
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(tok, "PHRASE"), "^")
+	if strings.HasPrefix(rest, ":") {
+		return rest[1:], 0, true
+	}
+	n, text, found := strings.Cut(strings.TrimPrefix(rest, "~"), ":")
+	if !found {
+		return "", 0, false
+	}
+	slop, err := strconv.Atoi(n)
+	if err != nil {
+		return "", 0, false
+	}
+	return text, slop, true
+}
+
+// splitFieldTerm splits a field:word query term naming one of indexedFields
+func splitFieldTerm(t string) (field, word string, ok bool) {
+	field, word, ok = strings.Cut(t, ":")
+	if !ok || word == "" || !slices.Contains(indexedFields, strings.ToLower(field)) {
+		return "", "", false
+	}
+	return strings.ToLower(field), word, true
+}
+
+// fieldTermToken builds the RPN token for a field-scoped term
+func fieldTermToken(field, term string) string {
+	return "FIELD:" + field + ":" + term
+}
+
+// parseFieldTermToken splits a field-scoped RPN token into field and term
+func parseFieldTermToken(tok string) (field, term string, ok bool) {
+	rest, ok := strings.CutPrefix(tok, "FIELD:")
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
+
+// existsFields are the Document fields _exists_: can test
+var existsFields = []string{"title", "date", "summary", "content", "author"}
+
+// parseExistsToken returns the field of an _exists_:field clause
+func parseExistsToken(tok string) (string, bool) {
+	field, ok := strings.CutPrefix(strings.ToLower(tok), "_exists_:")
+	return field, ok
+}
+
+// docHasField reports whether doc d has a non-empty value for field
+func docHasField(d Document, field string) bool {
+	var v string
+	switch field {
+	case "title":
+		v = d.Title
+	case "date":
+		v = d.Date
+	case "summary":
+		v = d.Summary
+	case "content":
+		v = d.Content
+	case "author":
+		v = d.Author
+	}
+	return strings.TrimSpace(v) != ""
+}
+
+// excludeMarker stands for a leading minus (-term, -"phrase") until it is
+// expanded to NOT; a "-" standing on its own is an ordinary word instead
+const excludeMarker = "\x00-"
+
+// isOperator helper
+func isOperator(t string) bool {
+	u := strings.ToUpper(t)
+	return u == "AND" || u == "OR" || u == "NOT"
+}
+
+// ResultSnippet is the preview shown for a search result: the headline or
+// summary when the query matched it, otherwise a content snippet around the
+// match. a should be the analyzer of the index the result came from.
+func (a Analyzer) ResultSnippet(d Document, r SearchResult) string {
+	if slices.Contains(r.MatchedFields, "title") && d.Title != "" {
+		return d.Title
+	}
+	if slices.Contains(r.MatchedFields, "summary") && d.Summary != "" {
+		return d.Summary
+	}
+	return a.MakeSnippet(a.docContent(d), r.MatchedTerms)
+}
+
+// MakeSnippet returns a small preview around first matched term(s)
+func (a Analyzer) MakeSnippet(content string, terms []string) string {
+	if len(content) == 0 {
+		return ""
+	}
+	if SnippetFragments > 0 {
+		if ps := a.BestPassages(content, terms, SnippetFragments); len(ps) > 0 {
+			return "..." + strings.Join(ps, " ... ") + "..."
+		}
+	}
+	if SnippetSentences {
+		if s := a.sentenceSnippet(content, terms); s != "" {
+			return s
+		}
+	}
+	// tokenize content (lowercase tokens)
+	toks := a.Tokenize(content)
+	first := -1
+	for i, w := range toks {
+		for _, t := range terms {
+			// if phrase term, check first token
+			if ph, _, ok := parsePhraseToken(t); ok {
+				phToks := a.Tokenize(ph)
+				if len(phToks) > 0 && w == phToks[0] {
+					first = i
+					break
+				}
+			} else {
+				if w == t {
+					first = i
+					break
+				}
+			}
+		}
+		if first != -1 {
+			break
+		}
+	}
+	if first == -1 {
+		// fallback: return start of doc up to 30 tokens
+		end := 30
+		if end > len(toks) {
+			end = len(toks)
+		}
+		return strings.Join(toks[:end], " ") + "..."
+	}
+	start := first - 8
+	if start < 0 {
+		start = 0
+	}
+	end := first + 12
+	if end > len(toks) {
+		end = len(toks)
+	}
+	snippet := strings.Join(toks[start:end], " ")
+	return "..." + snippet + "..."
+}
+
+// SnippetFragments, when > 0, makes MakeSnippet show that many of the best
+// passages (see BestPassages) instead of the window around the first match.
+// It takes precedence over SnippetSentences.
+var SnippetFragments = 0
+
+// passageWindow is the length in tokens of a BestPassages passage, the same
+// as MakeSnippet's window
+const passageWindow = 20
+
+// BestPassages returns up to n non-overlapping passages of content holding
+// the most matches of terms, in document order; nil if nothing matches. A
+// passage scores a point for each distinct term in it and a tenth for each
+// repeat, so one covering more of the query beats one repeating a word.
+func (a Analyzer) BestPassages(content string, terms []string, n int) []string {
+	toks := a.positionTokens(content, "")
+	type hit struct{ pos, end, term int }
+	var hits []hit
+	for ti, t := range terms {
+		phrase := a.matchedTermTokens(t)
+		if len(phrase) == 0 {
+			continue
+		}
+		for i := 0; i+len(phrase) <= len(toks); i++ {
+			if matchesAt(toks[i:], phrase) {
+				hits = append(hits, hit{i, i + len(phrase), ti})
+			}
+		}
+	}
+	if len(hits) == 0 || n <= 0 {
+		return nil
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].pos < hits[j].pos })
+
+	// candidate passages start a little before each hit, as MakeSnippet does
+	type passage struct {
+		start int
+		score float64
+	}
+	var cands []passage
+	for _, h := range hits {
+		start := max(min(h.pos-8, len(toks)-passageWindow), 0)
+		if len(cands) > 0 && cands[len(cands)-1].start == start {
+			continue
+		}
+		seen := make(map[int]bool)
+		score := 0.0
+		for _, o := range hits {
+			if o.pos < start || o.end > start+passageWindow {
+				continue
+			}
+			if seen[o.term] {
+				score += 0.1
+			} else {
+				seen[o.term] = true
+				score++
+			}
+		}
+		cands = append(cands, passage{start, score})
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+
+	var chosen []passage
+	for _, c := range cands {
+		if len(chosen) == n {
+			break
+		}
+		overlaps := slices.ContainsFunc(chosen, func(o passage) bool {
+			return c.start < o.start+passageWindow && o.start < c.start+passageWindow
+		})
+		if !overlaps {
+			chosen = append(chosen, c)
+		}
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].start < chosen[j].start })
+	out := make([]string, len(chosen))
+	for i, c := range chosen {
+		words := toks[c.start:min(c.start+passageWindow, len(toks))]
+		main := make([]string, len(words))
+		for j, w := range words {
+			main[j] = w[0]
+		}
+		out[i] = strings.Join(main, " ")
+	}
+	return out
+}
+
+// matchesAt reports whether phrase[j] is among the tokens at position j of
+// toks for every j
+func matchesAt(toks [][]string, phrase []string) bool {
+	for j, w := range phrase {
+		if !slices.Contains(toks[j], w) {
+			return false
+		}
+	}
+	return true
+}
+
+// SnippetSentences makes MakeSnippet expand/trim its window to whole sentences
+var SnippetSentences = false
+
+// how far (in bytes) to look for a sentence boundary before falling back
+const sentenceSearchLimit = 300
+
+// sentenceSnippet cuts the raw content around the first match at the
+// nearest '.', '!' or '?' on each side. Each side falls back to the usual
+// token window when no boundary is close. Returns "" if nothing matches.
+func (a Analyzer) sentenceSnippet(content string, terms []string) string {
+	// a term matches a word by any of its tokens, a phrase by its first word
+	want := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		if toks := a.matchedTermTokens(t); len(toks) > 0 {
+			want[toks[0]] = true
+		}
+	}
+	// the byte range of each position's word, as the index numbers them
+	var words [][2]int
+	hit := -1
+	for _, s := range a.tokenSpans(content, "") {
+		if s.Pos == len(words) {
+			words = append(words, [2]int{s.Start, s.End})
+		}
+		if hit == -1 && want[s.Tok] {
+			hit = s.Pos
+		}
+	}
+	if hit == -1 {
+		return ""
+	}
+	matchStart, matchEnd := words[hit][0], words[hit][1]
+
+	// token-window fallback bounds, same size as MakeSnippet's
+	winStart := words[max(hit-8, 0)][0]
+	winEnd := words[min(hit+12, len(words))-1][1]
+
+	prefix, suffix := "", ""
+	var start, end int
+	lo := max(matchStart-sentenceSearchLimit, 0)
+	if i := strings.LastIndexAny(content[lo:matchStart], ".!?"); i != -1 {
+		start = lo + i + 1
+	} else if lo == 0 {
+		start = 0 // the content itself starts the sentence
+	} else {
+		start = winStart
+		prefix = "..."
+	}
+	hi := min(matchEnd+sentenceSearchLimit, len(content))
+	if i := strings.IndexAny(content[matchEnd:hi], ".!?"); i != -1 {
+		end = matchEnd + i + 1
+	} else if hi == len(content) {
+		end = len(content)
+	} else {
+		end = winEnd
+		suffix = "..."
+	}
+	return prefix + strings.Join(strings.Fields(content[start:end]), " ") + suffix
+}
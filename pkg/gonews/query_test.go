@@ -0,0 +1,164 @@
+package gonews
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestExclusionShorthand(t *testing.T) {
+	tests := []struct {
+		query string
+		rpn   []string
+	}{
+		{"climate -policy", []string{"climate", "policy", "NOT", "AND"}},
+		{`climate -"global warming"`, []string{"climate", "PHRASE:global warming", "NOT", "AND"}},
+		{"-policy", []string{"policy", "NOT"}},
+		{"(climate OR weather) -policy", []string{"climate", "weather", "OR", "policy", "NOT", "AND"}},
+		// a minus on its own is a word, not an exclusion
+		{"climate -", []string{"climate", "-"}},
+		{"climate - policy", []string{"climate", "-", "policy"}},
+	}
+	var a Analyzer
+	for _, tt := range tests {
+		if got := a.QueryToRPN(tt.query); !slices.Equal(got, tt.rpn) {
+			t.Errorf("QueryToRPN(%q) = %q, want %q", tt.query, got, tt.rpn)
+		}
+	}
+
+	idx := buildIndex(
+		Document{ID: 1, Content: "climate policy debate"},
+		Document{ID: 2, Content: "climate science"},
+		Document{ID: 3, Content: "global warming and climate"},
+	)
+	searches := []struct {
+		query string
+		want  []int
+	}{
+		{"climate -policy", []int{2, 3}},
+		{`climate -"global warming"`, []int{1, 2}},
+		{`climate -"warming global"`, []int{1, 2, 3}},
+	}
+	for _, tt := range searches {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestValidateQueryMinus(t *testing.T) {
+	tests := []struct {
+		query string
+		ok    bool
+	}{
+		{"climate -policy", true},
+		{`climate -"global warming"`, true},
+		{"climate - policy", true},
+		{"climate -", true},
+		{"climate -(", false},
+		{"climate NOT", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateQuery(tt.query); (err == nil) != tt.ok {
+			t.Errorf("ValidateQuery(%q) = %v, want ok %v", tt.query, err, tt.ok)
+		}
+	}
+}
+
+func TestPhraseQuery(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "the climate change policy was announced"},
+		Document{ID: 2, Content: "policy on climate change"},
+		Document{ID: 3, Content: "climate change hurts policy"},
+	)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"climate change policy", []int{1}},
+		{"climate change", []int{1, 2, 3}},
+		{`climate "change" OR policy`, nil},
+		{"policy NOT climate", nil},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(PhraseQuery(tt.text))); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(PhraseQuery(%q)) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	resp, err := NewServer(idx).Search(context.Background(), SearchRequest{Query: "climate change policy", Phrase: true, N: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || resp.Results[0].ID != 1 {
+		t.Errorf("phrase search request found %+v, want only doc 1", resp.Results)
+	}
+}
+
+func TestAnchoredPhrase(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Breaking news: storm hits coast", Content: "Winds reached 120 km/h."},
+		Document{ID: 2, Title: "Storm update", Content: "Breaking news from the coast tonight."},
+		Document{ID: 3, Title: "Storm update", Content: "We interrupt with breaking news tonight."},
+		Document{ID: 4, Content: "Breaking news, with no headline."},
+		Document{ID: 5, Title: "Storm update", Summary: "Breaking news in brief", Content: "Details later."},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{`"breaking news"`, []int{1, 2, 3, 4, 5}},
+		{`^"breaking news"`, []int{1, 2, 4, 5}},
+		{`^"breaking news" AND tonight`, []int{2}},
+		{`"breaking news" AND NOT ^"breaking news"`, []int{3}},
+		{`^"storm update"`, []int{2, 3, 5}},
+		{`^"news storm"`, nil},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFieldScopedTerms(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Merger talks", Summary: "Two banks", Content: "The banks confirmed nothing."},
+		Document{ID: 2, Title: "Bank results", Summary: "A merger is off the table", Content: "Profits rose."},
+		Document{ID: 3, Title: "Storm warning", Content: "A merger of two storm systems is expected."},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"merger", []int{1, 2, 3}},
+		{"title:merger", []int{1}},
+		{"summary:merger", []int{2}},
+		{"content:merger", []int{3}},
+		{"TITLE:Merger", []int{1}},
+		{"title:merger OR summary:merger", []int{1, 2}},
+		{"merger AND NOT content:merger", []int{1, 2}},
+		{"content:storm AND title:storm", []int{3}},
+		{"author:merger", nil},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryBoost(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Banks", Summary: "merger announced", Content: "details to follow soon"},
+		Document{ID: 2, Title: "Banks", Summary: "deal announced", Content: "merger details follow soon"},
+	)
+	if got := resultIDs(idx.Search("merger")); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("default boosts: Search(merger) = %v, want the summary match first", got)
+	}
+	idx.FieldBoosts = map[string]float64{"summary": 0.5}
+	if got := resultIDs(idx.Search("merger")); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("summary boost 0.5: Search(merger) = %v, want the content match first", got)
+	}
+}
@@ -0,0 +1,253 @@
+package gonews
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// queryRun is the state of one search call: the parsed query plus memoized
+// per-query and per-doc work, so phrase analysis and fuzzy expansion happen
+// once per query and each doc is matched and scored once, however many
+// passes (ranking, faceting, a should clause) ask for it. Every search makes
+// a new run, so nothing cached here outlives an index mutation. Callers must
+// hold the index read lock while using it.
+type queryRun struct {
+	idx     *Index
+	rpn     []string
+	phrases map[string]*runPhrase // phrase RPN token -> analyzed phrase
+	negated []bool                // per RPN token: an operand under NOT
+	results map[int]SearchResult  // doc -> matched and scored result
+	scores  map[string]float64    // doc and matched terms -> score, shared by a search's clauses
+}
+
+// runPhrase is an analyzed phrase from the query
+type runPhrase struct {
+	text     string
+	tokens   []string
+	slop     int
+	anchored bool       // must start a field
+	variants [][]string // fuzzy variants of tokens (sloppy phrases only)
+}
+
+func (idx *Index) newQueryRun(rpn []string) *queryRun {
+	return &queryRun{
+		idx:     idx,
+		rpn:     rpn,
+		phrases: make(map[string]*runPhrase),
+		negated: negatedOperands(rpn),
+		results: make(map[int]SearchResult),
+		scores:  make(map[string]float64),
+	}
+}
+
+// clause returns a run for another clause of the same search (its should
+// query), sharing q's scores: a doc matching the same terms in both is
+// scored once
+func (q *queryRun) clause(rpn []string) *queryRun {
+	c := q.idx.newQueryRun(rpn)
+	c.scores = q.scores
+	return c
+}
+
+// negatedOperands marks the operands of rpn that sit under an odd number of
+// NOTs: a doc containing them matches in spite of them, not because of them,
+// so they earn no score (vote in "budget -vote" or a should clause's
+// "NOT vote"). Operators are never marked.
+func negatedOperands(rpn []string) []bool {
+	negated := make([]bool, len(rpn))
+	var stack [][]int // per stack entry: the RPN indexes of its operands
+	for i, tok := range rpn {
+		switch tok {
+		case "AND", "OR":
+			if len(stack) < 2 {
+				continue
+			}
+			r := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			stack[len(stack)-1] = append(stack[len(stack)-1], r...)
+		case "NOT":
+			if len(stack) < 1 {
+				continue
+			}
+			for _, j := range stack[len(stack)-1] {
+				negated[j] = !negated[j]
+			}
+		default:
+			stack = append(stack, []int{i})
+		}
+	}
+	return negated
+}
+
+// phrase analyzes a phrase RPN token once per run; nil if tok isn't a phrase
+func (q *queryRun) phrase(tok string) *runPhrase {
+	if p, ok := q.phrases[tok]; ok {
+		return p
+	}
+	text, slop, ok := parsePhraseToken(tok)
+	if !ok {
+		return nil
+	}
+	p := &runPhrase{text: text, tokens: q.idx.Analyzer.phraseTokens(text, rpnLang(q.rpn)), slop: slop, anchored: phraseAnchored(tok)}
+	if slop > 0 {
+		p.variants = q.idx.fuzzyVariants(p.tokens)
+	}
+	q.phrases[tok] = p
+	return p
+}
+
+// phraseStarts returns the start position of each occurrence of p in doc
+// (only those starting a field when p is anchored)
+func (q *queryRun) phraseStarts(doc int, p *runPhrase) []int {
+	starts := q.idx.phraseStartsIn(doc, p.tokens, p.variants, p.slop)
+	if !p.anchored {
+		return starts
+	}
+	var out []int
+	for _, s := range starts {
+		if q.idx.isFieldStart(doc, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// result matches and scores doc, memoized for the run
+func (q *queryRun) result(doc int) SearchResult {
+	if r, ok := q.results[doc]; ok {
+		return r
+	}
+	// gather matched terms: any query term present in doc
+	matched := q.matchedTerms(doc)
+	score := q.score(doc, matched) * q.idx.Docs[doc].boostFactor()
+	r := SearchResult{DocID: doc, Score: score, MatchedTerms: matched, MatchedFields: q.matchedFields(doc)}
+	q.results[doc] = r
+	return r
+}
+
+// score runs the index's scorer on doc's matched terms once per search
+func (q *queryRun) score(doc int, matched []string) float64 {
+	key := strconv.Itoa(doc) + "\x00" + strings.Join(matched, "\x00")
+	if s, ok := q.scores[key]; ok {
+		return s
+	}
+	s := q.idx.score(doc, matched)
+	q.scores[key] = s
+	return s
+}
+
+// boost adds this run's score for r's doc to r, as a should clause: the
+// doc's matched terms and fields gain those of this run
+func (q *queryRun) boost(r SearchResult) SearchResult {
+	extra := q.result(r.DocID)
+	if len(extra.MatchedTerms) == 0 {
+		return r
+	}
+	r.Score += extra.Score
+	r.MatchedTerms = union(r.MatchedTerms, extra.MatchedTerms)
+	var fields []string
+	for _, f := range indexedFields {
+		if slices.Contains(r.MatchedFields, f) || slices.Contains(extra.MatchedFields, f) {
+			fields = append(fields, f)
+		}
+	}
+	r.MatchedFields = fields
+	return r
+}
+
+// union returns a followed by the elements of b not in a
+func union(a, b []string) []string {
+	out := slices.Clone(a)
+	for _, s := range b {
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// matchedTerms extracts which query terms (non-operators, not negated)
+// appear in the doc, ordered by first occurrence in the doc, then
+// alphabetically, so scores and snippets don't depend on map iteration order
+func (q *queryRun) matchedTerms(doc int) []string {
+	first := map[string]int{} // term -> first position in doc
+	note := func(term string, positions []int) {
+		if len(positions) == 0 {
+			return
+		}
+		if p, ok := first[term]; !ok || positions[0] < p {
+			first[term] = positions[0]
+		}
+	}
+	for i, tok := range q.rpn {
+		if isOperator(tok) || q.negated[i] { // skip
+			continue
+		}
+		if p := q.phrase(tok); p != nil {
+			note(p.text, q.phraseStarts(doc, p))
+		} else if terms, dists, ok := parseNearToken(tok); ok {
+			for i, positions := range q.idx.nearPositions(doc, terms, dists) {
+				note(terms[i], positions)
+			}
+		} else if field, term, ok := parseFieldTermToken(tok); ok {
+			note(term, q.idx.fieldPositions(doc, term, field))
+		} else {
+			// normal token
+			note(tok, q.idx.Terms[tok].Positions(doc))
+		}
+	}
+	out := make([]string, 0, len(first))
+	for t := range first {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if first[out[i]] != first[out[j]] {
+			return first[out[i]] < first[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// matchedFields lists, in indexedFields order, the fields of doc where a
+// query term or phrase that isn't negated occurs
+func (q *queryRun) matchedFields(doc int) []string {
+	in := make(map[string]bool)
+	for i, tok := range q.rpn {
+		if isOperator(tok) || q.negated[i] {
+			continue
+		}
+		if p := q.phrase(tok); p != nil {
+			for _, s := range q.phraseStarts(doc, p) {
+				in[q.idx.fieldOf(doc, s)] = true
+			}
+			continue
+		}
+		if terms, dists, ok := parseNearToken(tok); ok {
+			for _, positions := range q.idx.nearPositions(doc, terms, dists) {
+				for _, pos := range positions {
+					in[q.idx.fieldOf(doc, pos)] = true
+				}
+			}
+			continue
+		}
+		if field, term, ok := parseFieldTermToken(tok); ok {
+			if len(q.idx.fieldPositions(doc, term, field)) > 0 {
+				in[field] = true
+			}
+			continue
+		}
+		for _, pos := range q.idx.Terms[tok].Positions(doc) {
+			in[q.idx.fieldOf(doc, pos)] = true
+		}
+	}
+	var out []string
+	for _, f := range indexedFields {
+		if in[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNegatedOperands(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string // negated operands
+	}{
+		{"budget", nil},
+		{"budget AND NOT vote", []string{"vote"}},
+		{"budget -vote", []string{"vote"}},
+		{"NOT (vote OR poll)", []string{"vote", "poll"}},
+		{"NOT (vote AND NOT poll)", []string{"vote"}},
+		{`budget AND NOT "vote count"`, []string{"PHRASE:vote count"}},
+	}
+	for _, tt := range tests {
+		rpn := Analyzer{}.QueryToRPN(tt.query)
+		var got []string
+		for i, neg := range negatedOperands(rpn) {
+			if neg {
+				got = append(got, rpn[i])
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("negatedOperands(%s) = %q, want %q (rpn %q)", tt.query, got, tt.want, rpn)
+		}
+	}
+}
+
+func TestSearchBool(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Apple harvest", Content: "fruit growers report a record apple crop"},
+		Document{ID: 2, Title: "Apple earnings", Content: "the apple iphone maker beat forecasts"},
+		Document{ID: 3, Title: "Pie recipes", Content: "an apple pie and a cherry pie"},
+		Document{ID: 4, Title: "Iphone launch", Content: "no apples here"},
+	)
+	scores := func(results []SearchResult) map[int]float64 {
+		m := make(map[int]float64)
+		for _, r := range results {
+			m[r.DocID] = r.Score
+		}
+		return m
+	}
+	plain := scores(idx.SearchBool("apple", "", 0))
+	tests := []struct {
+		should  string
+		boosted []int // docs whose score should rises
+	}{
+		{"iphone", []int{2}},
+		{"pie OR fruit", []int{1, 3}},
+		// negated should terms never boost, even in docs that contain them
+		{"pie -fruit", []int{3}},
+		{"NOT fruit", nil},
+		{"iphone AND NOT (pie OR fruit)", []int{2}},
+	}
+	for _, tt := range tests {
+		results := idx.SearchBool("apple", tt.should, 0)
+		if got := sortedIDs(results); !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("should %q changed the matches: %v", tt.should, got)
+		}
+		var boosted []int
+		for id, s := range scores(results) {
+			if s > plain[id] {
+				boosted = append(boosted, id)
+			} else if s < plain[id] {
+				t.Errorf("should %q lowered doc %d", tt.should, id)
+			}
+		}
+		slices.Sort(boosted)
+		if !slices.Equal(boosted, tt.boosted) {
+			t.Errorf("should %q boosted %v, want %v", tt.should, boosted, tt.boosted)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package gonews
+
+// Reanalyze rebuilds the index from its stored documents under a different
+// analyzer, e.g. to turn on stemming without reloading the source. The new
+// index analyzes its queries with a; idx is left as it was and keeps
+// searching with its own analyzer, so callers can swap in the result (as
+// the server does on reload) while searches of idx run. Doc IDs and
+// settings carry over.
+func (idx *Index) Reanalyze(a Analyzer) *Index {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	out := idx.EmptyCopy()
+	out.Analyzer = a
+	for _, id := range sortedDocIDs(idx.Docs) {
+		out.addDocument(idx.withContent(idx.Docs[id]))
+	}
+	return out
+}
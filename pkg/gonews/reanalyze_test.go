@@ -0,0 +1,92 @@
+package gonews
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"running", "run"},
+		{"elections", "elect"},
+		{"elected", "elect"},
+		{"markets", "market"},
+		{"news", "news"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestReanalyzeStemming(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Title: "Elections called", Content: "Parliament was dissolved."},
+		Document{ID: 2, Title: "Markets rally", Content: "Traders expected an election."},
+		Document{ID: 3, Title: "Weather", Content: "Rain expected."},
+	)
+	tests := []struct {
+		query      string
+		plain, stm []int
+	}{
+		{"election", []int{2}, []int{1, 2}},
+		{"elections", []int{1}, []int{1, 2}},
+		{"expecting", nil, []int{2, 3}},
+		{`"markets rallied"`, nil, []int{2}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.plain) {
+			t.Errorf("unstemmed Search(%s) = %v, want %v", tt.query, got, tt.plain)
+		}
+	}
+	stemmed := idx.Reanalyze(Analyzer{Stemming: true})
+	for _, tt := range tests {
+		if got := sortedIDs(stemmed.Search(tt.query)); !slices.Equal(got, tt.stm) {
+			t.Errorf("stemmed Search(%s) = %v, want %v", tt.query, got, tt.stm)
+		}
+		// the original keeps its own analyzer
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.plain) {
+			t.Errorf("unstemmed Search(%s) after Reanalyze = %v, want %v", tt.query, got, tt.plain)
+		}
+	}
+	if idx.Analyzer.Stemming || !stemmed.Analyzer.Stemming {
+		t.Errorf("Analyzer after Reanalyze = %+v, reanalyzed %+v", idx.Analyzer, stemmed.Analyzer)
+	}
+	if stemmed.DocCount() != idx.DocCount() {
+		t.Errorf("reanalyzed %d docs, want %d", stemmed.DocCount(), idx.DocCount())
+	}
+}
+
+// run with -race: searches may go on while the index is reanalyzed, and
+// find what they did before
+func TestReanalyzeWhileSearching(t *testing.T) {
+	idx := buildIndex(facetDocs(50)...)
+	const query = "budgets OR #climate"
+	want := sortedIDs(idx.Search(query))
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					if got := sortedIDs(idx.Search(query)); !slices.Equal(got, want) {
+						t.Errorf("Search(%s) during Reanalyze = %v, want %v", query, got, want)
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := range 10 {
+		idx.Reanalyze(Analyzer{Stemming: i%2 == 0, SplitIdentifiers: i%3 == 0, IndexSymbols: true, MinTermLen: i % 3})
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,196 @@
+package gonews
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RefreshResult counts what a Refresh changed
+type RefreshResult struct {
+	Added   int `json:"added"`   // docs not indexed before
+	Updated int `json:"updated"` // docs whose fields changed
+	N       int `json:"n"`       // docs in the index afterwards
+}
+
+// Refresh reads Source and brings the index being served up to date
+// without rebuilding it: new documents are added and those whose fields
+// changed are replaced, each through AddDocument so Log records them.
+// Unchanged documents are left alone, and documents gone from the source
+// stay indexed. It waits for any reload to finish first. Without Source it
+// does nothing.
+//
+// Source documents are matched to indexed ones by a key that survives the
+// source renumbering its rows: their SourceID when they have one, else a
+// hash of their title and content; an unmatched one takes the indexed doc
+// with its ID only if no other source document matched that doc. Copies
+// AddDocument already dropped under DuplicatesSkip or DuplicatesMerge are
+// not added (and logged) again.
+func (s *Server) Refresh() (RefreshResult, error) {
+	if s.Source == nil {
+		return RefreshResult{}, nil
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	docs, err := s.Source()
+	if err != nil {
+		return RefreshResult{}, err
+	}
+	idx := s.Index()
+	if s.refreshIDs == nil {
+		s.refreshIDs = idx.refreshKeys()
+	}
+
+	// match docs by key first, so an unmatched doc never takes an ID a
+	// matched one holds
+	keys := refreshKeysOf(docs)
+	claimed := make(map[int]bool)
+	next := idx.maxDocID() + 1
+	for i, d := range docs {
+		if id, ok := s.refreshIDs[keys[i]]; ok {
+			claimed[id] = true
+			next = max(next, id+1)
+		}
+		next = max(next, d.ID+1)
+	}
+	for i := range docs {
+		if id, ok := s.refreshIDs[keys[i]]; ok {
+			docs[i].ID = id
+			continue
+		}
+		if claimed[docs[i].ID] {
+			docs[i].ID = next
+			next++
+		}
+		claimed[docs[i].ID] = true
+	}
+
+	var res RefreshResult
+	for i, d := range docs {
+		s.refreshIDs[keys[i]] = d.ID
+		old, ok := idx.Doc(d.ID)
+		if ok && sameDocument(old, d) || !ok && idx.droppedDuplicate(d) {
+			continue
+		}
+		if _, err := s.AddDocument(d); err != nil {
+			return res, err
+		}
+		switch {
+		case ok:
+			res.Updated++
+		case idx.hasDoc(d.ID): // not dropped as a duplicate
+			res.Added++
+		}
+	}
+	res.N = idx.DocCount()
+	return res, nil
+}
+
+// refreshKey is the key Refresh matches d by: its SourceID, which loaders
+// keep stable, else a hash of its title and content, since its ID may be
+// a row number or a synthetic ID that shifts when rows are inserted
+func refreshKey(d Document) string {
+	if d.SourceID != "" {
+		return "source:" + d.SourceID
+	}
+	h := fnv.New64a()
+	io.WriteString(h, d.Title)
+	h.Write([]byte{0})
+	io.WriteString(h, d.Content)
+	return "hash:" + strconv.FormatUint(h.Sum64(), 16)
+}
+
+// refreshKeysOf returns the refreshKey of each of docs, numbering repeats
+// of a key in order so each doc's key is unique
+func refreshKeysOf(docs []Document) []string {
+	keys := make([]string, len(docs))
+	seen := make(map[string]int)
+	for i, d := range docs {
+		key := refreshKey(d)
+		if seen[key]++; seen[key] > 1 {
+			key += "#" + strconv.Itoa(seen[key])
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// refreshKeys maps the refresh key of each indexed doc to its ID, taking
+// the docs in ID order, which is the order a loader numbered them in
+func (idx *Index) refreshKeys() map[string]int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ids := slices.Sorted(maps.Keys(idx.Docs))
+	docs := make([]Document, len(ids))
+	for i, id := range ids {
+		docs[i] = idx.withContent(idx.Docs[id])
+	}
+	keys := make(map[string]int, len(ids))
+	for i, key := range refreshKeysOf(docs) {
+		keys[key] = ids[i]
+	}
+	return keys
+}
+
+// maxDocID is the highest ID indexed or merged away as a duplicate, -1
+// for none
+func (idx *Index) maxDocID() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	top := -1
+	for id := range idx.Docs {
+		top = max(top, id)
+	}
+	for id := range idx.dupOf {
+		top = max(top, id)
+	}
+	return top
+}
+
+// sameDocument reports whether indexed doc a has the fields of source doc
+// b. ParsedDate, and Lang when DetectLanguages filled it in, are derived at
+// index time, so they don't count.
+func sameDocument(a, b Document) bool {
+	a.ParsedDate, b.ParsedDate = time.Time{}, time.Time{}
+	if b.Lang == "" {
+		a.Lang = ""
+	}
+	return a == b
+}
+
+// RefreshEvery calls Refresh every interval until ctx is done, passing each
+// outcome to report (which may be nil). A refresh still running when the
+// next is due delays it rather than overlapping.
+func (s *Server) RefreshEvery(ctx context.Context, interval time.Duration, report func(RefreshResult, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		res, err := s.Refresh()
+		if report != nil {
+			report(res, err)
+		}
+	}
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if s.Source == nil {
+		writeError(w, http.StatusNotImplemented, "refresh not configured")
+		return
+	}
+	res, err := s.Refresh()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
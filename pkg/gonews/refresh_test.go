@@ -0,0 +1,76 @@
+package gonews
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRefresh(t *testing.T) {
+	budget := Document{ID: 0, Title: "Budget vote", Content: "the budget passed"}
+	storm := Document{ID: 1, Title: "Storm warning", Content: "a storm is coming"}
+	tests := []struct {
+		name        string
+		source      []Document // after the index was built from budget and storm
+		added, upd  int
+		wantStorm   int // ID storm's doc has afterwards
+		wantElected bool
+	}{
+		{"unchanged", []Document{budget, storm}, 0, 0, 1, false},
+		// a row inserted at the top renumbers the rest, which still match
+		{"shifted row numbers", []Document{
+			{ID: 0, Title: "Election called", Content: "voters go to the polls"},
+			{ID: 1, Title: budget.Title, Content: budget.Content},
+			{ID: 2, Title: storm.Title, Content: storm.Content},
+		}, 1, 0, 1, true},
+		// an edited row keeps its ID
+		{"edited", []Document{budget, {ID: 1, Title: storm.Title, Content: "the storm has passed"}}, 0, 1, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(buildIndex(budget, storm))
+			s.Source = func() ([]Document, error) { return slices.Clone(tt.source), nil }
+			res, err := s.Refresh()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Added != tt.added || res.Updated != tt.upd {
+				t.Errorf("added %d, updated %d, want %d, %d", res.Added, res.Updated, tt.added, tt.upd)
+			}
+			if ids := resultIDs(s.Index().Search("storm")); !slices.Equal(ids, []int{tt.wantStorm}) {
+				t.Errorf("storm is doc %v, want [%d]", ids, tt.wantStorm)
+			}
+			if got := len(s.Index().Search("election")) > 0; got != tt.wantElected {
+				t.Errorf("election found: %v, want %v", got, tt.wantElected)
+			}
+		})
+	}
+}
+
+func TestRefreshSkipsDroppedDuplicates(t *testing.T) {
+	idx := NewIndex()
+	idx.Duplicates = DuplicatesSkip
+	source := []Document{
+		{ID: 1, Title: "Budget vote", Content: "the budget passed"},
+		{ID: 2, Title: "Budget vote (wire)", Content: "the budget passed"},
+	}
+	for _, d := range source {
+		idx.AddDocument(d)
+	}
+	wal, err := OpenWAL(filepath.Join(t.TempDir(), "news.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	s := NewServer(idx)
+	s.Log = wal
+	s.Source = func() ([]Document, error) { return slices.Clone(source), nil }
+	for i := range 3 {
+		if _, err := s.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		if n := wal.Len(); n != 0 {
+			t.Fatalf("refresh %d logged %d writes, want 0", i+1, n)
+		}
+	}
+}
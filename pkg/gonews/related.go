@@ -0,0 +1,67 @@
+package gonews
+
+import (
+	"math"
+	"sort"
+)
+
+// TermStat is a term with its co-occurrence count and ranking score
+type TermStat struct {
+	Term  string
+	Count int     // number of docs containing both terms
+	Score float64 // Count, or PMI when RelatedByPMI is set
+}
+
+// RelatedTerms returns up to n terms that most often appear in the same
+// documents as term. The term itself and stopwords are excluded.
+// With RelatedByPMI set, terms are ranked by pointwise mutual information
+// instead of raw counts, which favours specific over merely common terms.
+func (idx *Index) RelatedTerms(term string, n int) []TermStat {
+	toks := idx.Analyzer.Tokenize(term)
+	if len(toks) == 0 || n <= 0 {
+		return nil
+	}
+	term = toks[0]
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	posting, ok := idx.Terms[term]
+	if !ok {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, id := range posting.Docs() {
+		d := idx.withContent(idx.Docs[id])
+		seen := make(map[string]bool)
+		for _, t := range idx.Analyzer.docTokens(d) {
+			if t == term || seen[t] {
+				continue
+			}
+			seen[t] = true
+			counts[t]++
+		}
+	}
+
+	stats := make([]TermStat, 0, len(counts))
+	for t, c := range counts {
+		score := float64(c)
+		if idx.RelatedByPMI {
+			// log( P(a,b) / (P(a) P(b)) )
+			dfA := float64(posting.Len())
+			dfB := float64(idx.Terms[t].Len())
+			score = math.Log(float64(c) * float64(idx.N) / (dfA * dfB))
+		}
+		stats = append(stats, TermStat{Term: t, Count: c, Score: score})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Score != stats[j].Score {
+			return stats[i].Score > stats[j].Score
+		}
+		return stats[i].Term < stats[j].Term
+	})
+	if len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
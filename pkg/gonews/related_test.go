@@ -0,0 +1,48 @@
+package gonews
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRelatedTerms(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "ukraine sanctions announced by the union"},
+		Document{ID: 2, Content: "new sanctions on ukraine exports"},
+		Document{ID: 3, Content: "ukraine sanctions widen as talks stall"},
+		Document{ID: 4, Content: "exports grow in spring"},
+		Document{ID: 5, Content: "talks resume"},
+	)
+	tests := []struct {
+		pmi bool
+		top float64 // sanctions' expected score, the best
+	}{
+		{false, 3},
+		{true, math.Log(3 * 5 / (3 * 3.0))}, // as for every term found only with ukraine
+	}
+	for _, tt := range tests {
+		idx.RelatedByPMI = tt.pmi
+		stats := idx.RelatedTerms("Ukraine", 20)
+		if len(stats) == 0 || stats[0].Score != tt.top {
+			t.Fatalf("RelatedTerms(Ukraine) with PMI %v = %+v, want top score %v", tt.pmi, stats, tt.top)
+		}
+		found := false
+		for _, s := range stats {
+			if s.Term == "ukraine" || defaultStopwords[s.Term] {
+				t.Errorf("RelatedTerms(Ukraine) with PMI %v lists %q", tt.pmi, s.Term)
+			}
+			if s.Term == "sanctions" {
+				found = s.Count == 3 && s.Score == tt.top
+			}
+			if s.Term == "exports" && s.Score >= tt.top {
+				t.Errorf("with PMI %v exports, in one of ukraine's docs, scores %v", tt.pmi, s.Score)
+			}
+		}
+		if !found {
+			t.Errorf("RelatedTerms(Ukraine) with PMI %v = %+v, want sanctions in 3 docs at the top score", tt.pmi, stats)
+		}
+	}
+	if stats := idx.RelatedTerms("missing", 3); stats != nil {
+		t.Errorf("RelatedTerms of an unknown term = %+v, want nil", stats)
+	}
+}
@@ -0,0 +1,73 @@
+package gonews
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestAttrsKey is the context key of the *[]slog.Attr a request's
+// handler adds to its log line
+type requestAttrsKey struct{}
+
+// noteRequest adds attrs to the log line of the request ctx belongs to, if
+// it is being logged
+func noteRequest(ctx context.Context, attrs ...slog.Attr) {
+	if p, ok := ctx.Value(requestAttrsKey{}).(*[]slog.Attr); ok {
+		*p = append(*p, attrs...)
+	}
+}
+
+// statusRecorder remembers the status and body size a handler wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// logRequests logs each request to next with Logger once it is answered:
+// method, path, status, response size and duration, plus whatever the
+// handler noted, such as a search's query and hit count. Server errors log
+// at error level, client errors at warn, the rest at info.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var noted []slog.Attr
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestAttrsKey{}, &noted)))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case rec.status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := append([]slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote", r.RemoteAddr),
+		}, noted...)
+		s.Logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
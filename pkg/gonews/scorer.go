@@ -0,0 +1,81 @@
+package gonews
+
+import (
+	"math"
+	"time"
+)
+
+// ScoreFunc scores a matching doc given the query terms it matched. It runs
+// with the index read-locked, so it may read the index fields directly but
+// must not call methods that lock (Search, Doc, ...).
+type ScoreFunc func(idx *Index, doc int, matched []string) float64
+
+// TFIDFScore is the default scorer (see Index.scoreDoc)
+func TFIDFScore(idx *Index, doc int, matched []string) float64 {
+	return idx.scoreDoc(doc, matched)
+}
+
+// Default BM25 parameters, which NewIndex sets Index.BM25K1 / Index.BM25B to
+const (
+	DefaultBM25K1 = 1.2
+	DefaultBM25B  = 0.75
+)
+
+// BM25Score is Okapi BM25 over the matched terms, honouring field boosts.
+// k1 and b come from the index (see BM25K1, BM25B).
+func BM25Score(idx *Index, doc int, matched []string) float64 {
+	n := float64(idx.collectionN())
+	if n == 0 {
+		return 0
+	}
+	k1, b := idx.BM25K1, idx.BM25B
+	norm := 1 - b + b*float64(idx.DocTokCounts[doc])/idx.avgDocLen()
+	score := 0.0
+	for _, t := range matched {
+		posting := idx.Terms[t]
+		if posting == nil {
+			continue
+		}
+		tf := idx.fieldTermFreq(doc, posting.Positions(doc))
+		if tf == 0 {
+			continue // also keeps k1 = 0 from dividing by zero
+		}
+		df := float64(idx.docFreq(t, posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		score += idf * tf * (k1 + 1) / (tf + k1*norm)
+	}
+	return score
+}
+
+// RecencyScore returns a scorer worth 1 for a doc dated now, halving every
+// halfLife; undated docs score 0
+func RecencyScore(halfLife time.Duration) ScoreFunc {
+	return func(idx *Index, doc int, matched []string) float64 {
+		d := idx.Docs[doc].ParsedDate
+		if d.IsZero() || halfLife <= 0 {
+			return 0
+		}
+		age := max(time.Since(d), 0)
+		return math.Exp2(-float64(age) / float64(halfLife))
+	}
+}
+
+// WeightedScorer is one component of a CompositeScorer
+type WeightedScorer struct {
+	Score  ScoreFunc
+	Weight float64
+}
+
+// CompositeScorer blends several scorers into their weighted sum, e.g.
+// TF-IDF plus BM25 plus a little recency. Install it with
+// idx.Scorer = c.Score.
+type CompositeScorer []WeightedScorer
+
+// Score is the weighted sum of the components' scores
+func (c CompositeScorer) Score(idx *Index, doc int, matched []string) float64 {
+	total := 0.0
+	for _, s := range c {
+		total += s.Weight * s.Score(idx, doc, matched)
+	}
+	return total
+}
@@ -0,0 +1,107 @@
+package gonews
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompositeScorer(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	idx := buildIndex(
+		Document{ID: 1, Title: "Budget vote", Date: "2019-03-01", Content: "budget budget budget"},
+		Document{ID: 2, Title: "Budget update", Date: today, Content: "the budget was discussed at length by the committee"},
+		Document{ID: 3, Title: "Budget", Content: "undated budget notes"},
+	)
+	recency := RecencyScore(24 * time.Hour)
+	tests := []struct {
+		name   string
+		scorer CompositeScorer
+		want   []int
+	}{
+		{"tfidf only", CompositeScorer{{TFIDFScore, 1}}, []int{1, 3, 2}},
+		{"recency dominates", CompositeScorer{{TFIDFScore, 1}, {recency, 100}}, []int{2, 1, 3}},
+		{"zero weight is ignored", CompositeScorer{{TFIDFScore, 1}, {recency, 0}}, []int{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx.Scorer = tt.scorer.Score
+			if got := resultIDs(idx.Search("budget")); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(budget) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	idx.Scorer = nil
+
+	// the blend is exactly the weighted sum of its parts
+	c := CompositeScorer{{TFIDFScore, 0.5}, {BM25Score, 2}, {recency, 3}}
+	matched := []string{"budget"}
+	for id := range idx.Docs {
+		want := 0.5*TFIDFScore(idx, id, matched) + 2*BM25Score(idx, id, matched) + 3*recency(idx, id, matched)
+		if got := c.Score(idx, id, matched); math.Abs(got-want) > 1e-9 {
+			t.Errorf("doc %d: Score = %v, want %v", id, got, want)
+		}
+	}
+	if got := (CompositeScorer{}).Score(idx, 1, matched); got != 0 {
+		t.Errorf("empty CompositeScorer = %v, want 0", got)
+	}
+}
+
+func TestBM25Params(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Content: "budget budget budget " + strings.Repeat("report ", 17)},
+		{ID: 2, Content: "budget vote"},
+	}
+	// one-word fillers bring the mean length down to 2 and both IDFs close
+	for id := 3; id <= 20; id++ {
+		docs = append(docs, Document{ID: id, Content: "weather"})
+	}
+	idx := buildIndex(docs...)
+	idx.Scorer = BM25Score
+	tests := []struct {
+		k1, b float64
+		query string
+		want  []int
+	}{
+		// k1 = 0 counts each term once: two terms beat one repeated
+		{0, 0, "budget OR vote", []int{2, 1}},
+		{20, 0, "budget OR vote", []int{1, 2}},
+		// b = 0 ignores length; b = 1 punishes doc 1's
+		{DefaultBM25K1, 0, "budget", []int{1, 2}},
+		{DefaultBM25K1, 1, "budget", []int{2, 1}},
+	}
+	for _, tt := range tests {
+		idx.BM25K1, idx.BM25B = tt.k1, tt.b
+		if got := resultIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("k1 %v, b %v: Search(%s) = %v, want %v", tt.k1, tt.b, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRecencyScore(t *testing.T) {
+	now := time.Now()
+	idx := buildIndex(
+		Document{ID: 1, ParsedDate: now},
+		Document{ID: 2, ParsedDate: now.Add(-48 * time.Hour)},
+		Document{ID: 3},
+	)
+	s := RecencyScore(24 * time.Hour)
+	tests := []struct {
+		doc  int
+		want float64
+	}{
+		{1, 1},
+		{2, 0.25},
+		{3, 0},
+	}
+	for _, tt := range tests {
+		if got := s(idx, tt.doc, nil); math.Abs(got-tt.want) > 1e-3 {
+			t.Errorf("RecencyScore(doc %d) = %v, want %v", tt.doc, got, tt.want)
+		}
+	}
+	if got := RecencyScore(0)(idx, 1, nil); got != 0 {
+		t.Errorf("zero half-life: %v, want 0", got)
+	}
+}
@@ -0,0 +1,808 @@
+package gonews
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultServerMaxResults caps HTTP responses when the index has no MaxResults
+const defaultServerMaxResults = 100
+
+// Server exposes an Index over HTTP (and through Search and Index, to other
+// transports such as the grpcapi package)
+type Server struct {
+	mu       sync.RWMutex // guards idx, which /reload swaps out
+	idx      *Index
+	reloadMu sync.Mutex // one reload or refresh at a time
+	writeMu  sync.Mutex // orders document writes, so Log matches the index
+
+	refreshIDs map[string]int // refresh key -> doc ID (see Refresh), under reloadMu
+
+	// Reload, when set, builds a fresh index from the source for POST /reload
+	Reload func() (*Index, error)
+
+	// Source, when set, reads the source's current documents for Refresh
+	// (POST /refresh, RefreshEvery)
+	Source func() ([]Document, error)
+
+	// Queries, when set, records every search and serves /suggest/queries
+	Queries *QuerySuggester
+
+	// Logger, when set, logs every HTTP request Handler serves, with its
+	// status and duration and, for searches, the query and hit count, and
+	// failures that don't fail a request, like a query log write
+	Logger *slog.Logger
+
+	// Log, when set, records each document write before it is applied, to
+	// be replayed on the next start (see OpenWAL). Snapshot, when set,
+	// persists the index so Log can be emptied, e.g. by saving it where the
+	// next start loads it from; Checkpoint runs it every CheckpointEvery
+	// logged writes (0 = only when called) and after a reload.
+	Log             *WAL
+	Snapshot        func(*Index) error
+	CheckpointEvery int
+
+	// MaxConcurrentSearches limits searches running at once (0 = unlimited).
+	// Extra requests wait up to SearchQueueWait for a slot, then get 429.
+	MaxConcurrentSearches int
+	SearchQueueWait       time.Duration
+
+	slotsOnce   sync.Once
+	searchSlots chan struct{}
+
+	metrics serverMetrics
+}
+
+func NewServer(idx *Index) *Server {
+	return &Server{idx: idx}
+}
+
+// Index returns the index currently being served
+func (s *Server) Index() *Index {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.idx
+}
+
+// Handler returns the HTTP routes:
+//
+//	GET    /search?q=...&n=10  run a query (n is capped by MaxResults;
+//	                           offset=20 skips the first 20 results,
+//	                           phrase=true searches q as one exact phrase,
+//	                           exclude=1,2,3 hides those doc IDs,
+//	                           should=... boosts docs also matching it,
+//	                           autocorrect=true returns did_you_mean's
+//	                           results when q finds nothing,
+//	                           facets=true adds match counts per month
+//	                           and source, mode=semantic ranks by meaning
+//	                           with the index's embeddings instead,
+//	                           mode=hybrid fuses both rankings,
+//	                           clusters=5 groups the top hits by topic)
+//	POST   /documents          add or replace a document from JSON
+//	DELETE /documents/{id}     remove a document
+//	GET    /validate?q=...     check query syntax without running it
+//	GET    /terms/{term}       document and collection frequency of a term
+//	GET    /similar/{id}?n=10  the docs most like doc id ("more like this")
+//	GET    /suggest?q=prefix&n=5  indexed terms completing the last word
+//	GET    /suggest/queries?q=prefix&n=5  popular past queries (needs Queries)
+//	POST   /reload             rebuild the index from the source (needs Reload)
+//	POST   /refresh            add new and changed docs from the source
+//	                           (needs Source)
+//	GET    /metrics            counters for Prometheus to scrape
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /validate", s.handleValidate)
+	mux.HandleFunc("GET /terms/{term}", s.handleTermInfo)
+	mux.HandleFunc("GET /similar/{id}", s.handleSimilar)
+	mux.HandleFunc("GET /suggest", s.handleSuggest)
+	mux.HandleFunc("GET /suggest/queries", s.handleSuggestQueries)
+	mux.HandleFunc("POST /documents", s.handleAddDocument)
+	mux.HandleFunc("PUT /documents/{id}", s.handleUpdateDocument)
+	mux.HandleFunc("DELETE /documents/{id}", s.handleDeleteDocument)
+	mux.HandleFunc("POST /reload", s.handleReload)
+	mux.HandleFunc("POST /refresh", s.handleRefresh)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	if s.Logger != nil {
+		return s.logRequests(mux)
+	}
+	return mux
+}
+
+// ListenAndServe serves Handler on addr until ctx is cancelled, then stops
+// accepting connections and lets in-flight requests finish, waiting at most
+// drain before giving up on them. It returns nil after a clean shutdown.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, drain time.Duration) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err // e.g. address in use
+	}
+	return s.Serve(ctx, ln, drain)
+}
+
+// Serve is ListenAndServe on an open listener, which it closes
+func (s *Server) Serve(ctx context.Context, ln net.Listener, drain time.Duration) error {
+	hs := &http.Server{Handler: s.Handler()}
+	errc := make(chan error, 1)
+	go func() { errc <- hs.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := hs.Shutdown(shutCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// parseIDList parses a comma-separated list of doc IDs into a set
+func parseIDList(s string) (map[int]struct{}, error) {
+	ids := make(map[int]struct{})
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		id, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+	return ids, nil
+}
+
+// SearchHit is one result of a Server search
+type SearchHit struct {
+	ID            int      `json:"id"`
+	SourceID      string   `json:"source_id,omitempty"`
+	Title         string   `json:"title"`
+	Author        string   `json:"author,omitempty"`
+	Source        string   `json:"source,omitempty"`
+	Lang          string   `json:"lang,omitempty"`
+	Date          string   `json:"date"`
+	Score         float64  `json:"score"`
+	MatchedTerms  []string `json:"matched_terms"`
+	MatchedFields []string `json:"matched_fields"`
+	Snippet       string   `json:"snippet"`
+	SnippetHTML   string   `json:"snippet_html"`           // snippet with matches in <em>, HTML-escaped
+	DuplicateOf   *int     `json:"duplicate_of,omitempty"` // the doc this one copies, under DuplicatesFlag
+	Similar       []int    `json:"similar,omitempty"`      // near-duplicates collapsed into this hit
+}
+
+// SearchResponse is a page of Server search results, as /search returns it
+type SearchResponse struct {
+	Query      string          `json:"query"`
+	DidYouMean string          `json:"did_you_mean,omitempty"` // spelling-corrected query, when q found nothing
+	Corrected  bool            `json:"corrected,omitempty"`    // results are for did_you_mean (autocorrect=true)
+	Total      int             `json:"total"`
+	Offset     int             `json:"offset"`
+	Truncated  bool            `json:"truncated"`
+	Results    []SearchHit     `json:"results"`
+	Facets     *Facets         `json:"facets,omitempty"`   // with facets=true
+	Clusters   []SearchCluster `json:"clusters,omitempty"` // with clusters=k
+}
+
+// SearchCluster is one topic among a Server search's top hits
+type SearchCluster struct {
+	Label []string `json:"label"` // its heaviest terms
+	IDs   []int    `json:"ids"`   // its docs, best ranked first
+}
+
+// SearchRequest is a search as /search takes it (see Handler)
+type SearchRequest struct {
+	Query       string
+	Phrase      bool             // search Query as one exact phrase
+	N           int              // results to return, capped by MaxResults
+	Offset      int              // results to skip first
+	Should      string           // boosts docs also matching it
+	Exclude     map[int]struct{} // doc IDs to hide
+	Autocorrect bool             // answer for DidYouMean when Query finds nothing
+	Facets      bool             // count all matches per month and source
+	Clusters    int              // group the top DefaultClusterHits hits into this many topics
+	Mode        SearchMode       // keyword by default
+}
+
+type documentResponse struct {
+	ID int `json:"id"`
+	N  int `json:"n"`
+}
+
+// acquireSearch takes a search slot, waiting up to SearchQueueWait. It
+// fails with ErrTooManySearches if no slot freed up in time, or with ctx's
+// error if the client gave up while queued.
+func (s *Server) acquireSearch(ctx context.Context) error {
+	s.slotsOnce.Do(func() {
+		if s.MaxConcurrentSearches > 0 {
+			s.searchSlots = make(chan struct{}, s.MaxConcurrentSearches)
+		}
+	})
+	if s.searchSlots == nil {
+		return nil
+	}
+	select {
+	case s.searchSlots <- struct{}{}:
+		return nil
+	default:
+	}
+	if s.SearchQueueWait <= 0 {
+		return ErrTooManySearches
+	}
+	timer := time.NewTimer(s.SearchQueueWait)
+	defer timer.Stop()
+	select {
+	case s.searchSlots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrTooManySearches
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) releaseSearch() {
+	if s.searchSlots != nil {
+		<-s.searchSlots
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	req := SearchRequest{Query: r.URL.Query().Get("q"), N: 10, Should: r.URL.Query().Get("should")}
+	req.Phrase, _ = strconv.ParseBool(r.URL.Query().Get("phrase"))
+	req.Autocorrect, _ = strconv.ParseBool(r.URL.Query().Get("autocorrect"))
+	req.Facets, _ = strconv.ParseBool(r.URL.Query().Get("facets"))
+	if v := r.URL.Query().Get("clusters"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid clusters")
+			return
+		}
+		req.Clusters = n
+	}
+	switch r.URL.Query().Get("mode") {
+	case "", "keyword":
+	case "semantic":
+		req.Mode = SearchSemantic
+	case "hybrid":
+		req.Mode = SearchHybrid
+	default:
+		writeError(w, http.StatusBadRequest, "invalid mode")
+		return
+	}
+	if v := r.URL.Query().Get("n"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid n")
+			return
+		}
+		req.N = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		req.Offset = n
+	}
+	if v := r.URL.Query().Get("exclude"); v != "" {
+		ids, err := parseIDList(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid exclude: "+err.Error())
+			return
+		}
+		req.Exclude = ids
+	}
+	// the request context cancels the search if the client goes away
+	resp, err := s.Search(r.Context(), req)
+	switch {
+	case errors.Is(err, ErrTooManySearches):
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, ErrNoVectors):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, ErrMalformedQuery):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil && r.Context().Err() != nil:
+		// client gave up; nobody is left to answer
+	case err != nil:
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// Search runs a search the way /search does: it waits for a slot under
+// MaxConcurrentSearches (ErrTooManySearches if none frees up in time),
+// suggests a corrected query when nothing matches, counts facets when asked,
+// records the query in Queries and returns at most N hits, capped by the
+// index's MaxResults (or 100 without one).
+func (s *Server) Search(ctx context.Context, req SearchRequest) (SearchResponse, error) {
+	start := time.Now()
+	resp, err := s.search(ctx, req)
+	s.metrics.observeSearch(start, err)
+	noteRequest(ctx, slog.String("query", req.Query))
+	if err == nil {
+		noteRequest(ctx, slog.Int("hits", resp.Total))
+	}
+	return resp, err
+}
+
+func (s *Server) search(ctx context.Context, req SearchRequest) (SearchResponse, error) {
+	q := req.Query
+	if req.Phrase {
+		q = PhraseQuery(q)
+	}
+	opts := SearchOptions{Should: req.Should, Offset: req.Offset, Exclude: req.Exclude}
+	idx := s.Index() // one index for the whole request, even across a reload
+	maxResults := idx.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultServerMaxResults
+	}
+	limit := min(req.N, maxResults)
+	if err := s.acquireSearch(ctx); err != nil {
+		return SearchResponse{}, err
+	}
+	if req.Mode != SearchKeyword {
+		return s.vectorSearch(ctx, idx, req, limit)
+	}
+	search := func(q string) (FacetedSearch, error) {
+		if req.Facets {
+			return idx.SearchFacets(ctx, q, opts)
+		}
+		results, total, err := idx.SearchWithOptions(ctx, q, opts)
+		return FacetedSearch{Results: results, Total: total}, err
+	}
+	fs, err := search(q)
+	didYouMean, corrected := "", false
+	if err == nil && fs.Total < DefaultCorrectionThreshold {
+		if fixed, ok := idx.CorrectQuery(q); ok {
+			didYouMean = fixed
+			if req.Autocorrect {
+				if ffs, ferr := search(fixed); ferr == nil && ffs.Total > fs.Total {
+					fs, corrected = ffs, true
+				}
+			}
+		}
+	}
+	var clusters []TopicCluster
+	if err == nil && req.Clusters > 0 {
+		clusters = idx.ClusterResults(fs.Results[:min(len(fs.Results), DefaultClusterHits)], req.Clusters)
+	}
+	s.releaseSearch()
+	if err != nil {
+		return SearchResponse{}, err
+	}
+	results, total := fs.Results, fs.Total
+	s.recordQuery(q)
+	resp := SearchResponse{Query: q, DidYouMean: didYouMean, Corrected: corrected, Total: total, Offset: opts.Offset, Truncated: total > opts.Offset+limit, Results: []SearchHit{}}
+	if req.Facets {
+		resp.Facets = &fs.Facets
+	}
+	for _, c := range clusters {
+		sc := SearchCluster{Label: c.Label, IDs: make([]int, len(c.Results))}
+		for i, r := range c.Results {
+			sc.IDs[i] = r.DocID
+		}
+		resp.Clusters = append(resp.Clusters, sc)
+	}
+	for i, res := range results {
+		if i >= limit {
+			break
+		}
+		if hit, ok := searchHit(idx, res); ok {
+			resp.Results = append(resp.Results, hit)
+		}
+	}
+	return resp, nil
+}
+
+// embedWritten embeds doc id, just added or updated, if the index does
+// semantic search and the doc has no current embedding. Failing to embed
+// doesn't fail the write; the error goes to Logger.
+func (s *Server) embedWritten(idx *Index, id int) {
+	if idx.Embedder == nil || idx.Vectors == nil || idx.Vectors.Has(id) {
+		return
+	}
+	if _, err := idx.embedDocs(context.Background(), []int{id}); err != nil && s.Logger != nil {
+		s.Logger.Warn("embedding failed", "id", id, "err", err)
+	}
+}
+
+// recordQuery adds q to Queries. Failing to log it doesn't fail the
+// search; the error goes to Logger.
+func (s *Server) recordQuery(q string) {
+	if s.Queries == nil {
+		return
+	}
+	if err := s.Queries.Add(q); err != nil && s.Logger != nil {
+		s.Logger.Warn("query log write failed", "query", q, "err", err)
+	}
+}
+
+// vectorSearch is search for a semantic or hybrid request, called holding
+// a search slot. Should, Autocorrect and Facets don't apply; Total counts
+// every doc ranked: for semantic search every doc with an embedding, for
+// hybrid search the fused candidates.
+func (s *Server) vectorSearch(ctx context.Context, idx *Index, req SearchRequest, limit int) (SearchResponse, error) {
+	search := idx.SemanticSearch
+	if req.Mode == SearchHybrid {
+		search = idx.HybridSearch
+	}
+	results, err := search(ctx, req.Query, 0)
+	s.releaseSearch()
+	if err != nil {
+		return SearchResponse{}, err
+	}
+	s.recordQuery(req.Query)
+	resp := SearchResponse{Query: req.Query, Offset: req.Offset, Results: []SearchHit{}}
+	for _, res := range results {
+		if _, hidden := req.Exclude[res.DocID]; hidden {
+			continue
+		}
+		resp.Total++
+		if resp.Total <= req.Offset || len(resp.Results) >= limit {
+			continue
+		}
+		if hit, ok := searchHit(idx, res); ok {
+			resp.Results = append(resp.Results, hit)
+		}
+	}
+	resp.Truncated = resp.Total > req.Offset+limit
+	return resp, nil
+}
+
+// searchHit describes a result for a response; false if its doc was
+// deleted since the search ran
+func searchHit(idx *Index, res SearchResult) (SearchHit, bool) {
+	d, ok := idx.Doc(res.DocID)
+	if !ok {
+		return SearchHit{}, false
+	}
+	snippet := idx.Analyzer.ResultSnippet(d, res)
+	hit := SearchHit{
+		ID:            d.ID,
+		SourceID:      d.SourceID,
+		Title:         d.Title,
+		Author:        d.Author,
+		Source:        d.Source,
+		Lang:          d.Lang,
+		Date:          d.Date,
+		Score:         res.Score,
+		MatchedTerms:  res.MatchedTerms,
+		MatchedFields: res.MatchedFields,
+		Snippet:       snippet,
+		SnippetHTML:   idx.Analyzer.HighlightSnippet(snippet, res.MatchedTerms, MarkupHTML),
+		Similar:       res.Similar,
+	}
+	if orig, ok := idx.DuplicateOf(d.ID); ok {
+		hit.DuplicateOf = &orig
+	}
+	return hit, true
+}
+
+// handleSimilar lists the docs most like a given one, as search hits
+func (s *Server) handleSimilar(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid document id")
+		return
+	}
+	idx := s.Index()
+	n := 10 // also for n=0, which Similar would take as no limit
+	if v := r.URL.Query().Get("n"); v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil || m < 0 {
+			writeError(w, http.StatusBadRequest, "invalid n")
+			return
+		}
+		if m > 0 {
+			n = m
+		}
+	}
+	maxResults := idx.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultServerMaxResults
+	}
+	if err := s.acquireSearch(r.Context()); err != nil {
+		status := http.StatusServiceUnavailable
+		if errors.Is(err, ErrTooManySearches) {
+			status = http.StatusTooManyRequests
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	results, err := idx.Similar(id, min(n, maxResults))
+	s.releaseSearch()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	hits := []SearchHit{}
+	for _, res := range results {
+		if hit, ok := searchHit(idx, res); ok {
+			hits = append(hits, hit)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "results": hits})
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"valid": true}
+	if err := ValidateQuery(r.URL.Query().Get("q")); err != nil {
+		resp["valid"] = false
+		resp["error"] = err.Error()
+		var qe *QueryError
+		if errors.As(err, &qe) {
+			resp["column"] = qe.Col
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTermInfo(w http.ResponseWriter, r *http.Request) {
+	term := r.PathValue("term")
+	df, tf, found := s.Index().TermInfo(term)
+	if !found {
+		writeError(w, http.StatusNotFound, "term not in index")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"term": term, "df": df, "tf": tf})
+}
+
+func (s *Server) handleSuggestQueries(w http.ResponseWriter, r *http.Request) {
+	if s.Queries == nil {
+		writeError(w, http.StatusNotFound, "query logging is not enabled")
+		return
+	}
+	k, ok := suggestCount(w, r)
+	if !ok {
+		return
+	}
+	writeSuggestions(w, s.Queries.Suggest(r.URL.Query().Get("q"), k))
+}
+
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	k, ok := suggestCount(w, r)
+	if !ok {
+		return
+	}
+	writeSuggestions(w, s.Index().Suggest(r.URL.Query().Get("q"), k))
+}
+
+// suggestCount reads the n parameter of a suggest request (default 5),
+// answering 400 itself when it's invalid
+func suggestCount(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("n")
+	if v == "" {
+		return 5, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, "invalid n")
+		return 0, false
+	}
+	return n, true
+}
+
+func writeSuggestions(w http.ResponseWriter, suggestions []string) {
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"suggestions": suggestions})
+}
+
+// AddDocument adds d to the index being served, replacing any doc with its
+// ID, once Log has recorded it. It returns the number of indexed docs.
+func (s *Server) AddDocument(d Document) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if s.Log != nil {
+		if err := s.Log.LogAdd(d); err != nil {
+			return 0, err
+		}
+	}
+	idx := s.Index()
+	idx.AddDocument(d)
+	s.embedWritten(idx, d.ID)
+	s.metrics.adds.Add(1)
+	s.maybeCheckpoint()
+	if err := idx.takeStoreErr(); err != nil {
+		return 0, err
+	}
+	return idx.DocCount(), nil
+}
+
+// UpdateDocument replaces the doc with d's ID, like AddDocument but
+// ErrDocNotFound if there is none
+func (s *Server) UpdateDocument(d Document) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	idx := s.Index()
+	if !idx.hasDoc(d.ID) {
+		return 0, fmt.Errorf("document %d: %w", d.ID, ErrDocNotFound)
+	}
+	if s.Log != nil {
+		if err := s.Log.LogAdd(d); err != nil {
+			return 0, err
+		}
+	}
+	// the doc may have gone since the check if idx was written directly
+	if err := idx.UpdateDocument(d); err != nil {
+		return 0, fmt.Errorf("update: %w", err)
+	}
+	s.embedWritten(idx, d.ID)
+	s.metrics.updates.Add(1)
+	s.maybeCheckpoint()
+	if err := idx.takeStoreErr(); err != nil {
+		return 0, err
+	}
+	return idx.DocCount(), nil
+}
+
+// DeleteDocument removes a doc from the index being served once Log has
+// recorded it; ErrDocNotFound if the ID is unknown
+func (s *Server) DeleteDocument(id int) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	idx := s.Index()
+	if !idx.hasDoc(id) {
+		return 0, fmt.Errorf("document %d: %w", id, ErrDocNotFound)
+	}
+	if s.Log != nil {
+		if err := s.Log.LogDelete(id); err != nil {
+			return 0, err
+		}
+	}
+	idx.DeleteDocument(id)
+	s.metrics.deletes.Add(1)
+	s.maybeCheckpoint()
+	if err := idx.takeStoreErr(); err != nil {
+		return 0, err
+	}
+	return idx.DocCount(), nil
+}
+
+// Checkpoint persists the index with Snapshot and empties Log, so a
+// restart has nothing to replay. Without both it does nothing.
+func (s *Server) Checkpoint() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.checkpoint()
+}
+
+func (s *Server) checkpoint() error {
+	if s.Log == nil || s.Snapshot == nil {
+		return nil
+	}
+	if err := s.Snapshot(s.Index()); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return s.Log.Reset()
+}
+
+// maybeCheckpoint checkpoints once CheckpointEvery writes are logged. A
+// failure keeps the log, and the next write tries again.
+func (s *Server) maybeCheckpoint() {
+	if s.Log != nil && s.CheckpointEvery > 0 && s.Log.Len() >= s.CheckpointEvery {
+		s.checkpoint()
+	}
+}
+
+func (s *Server) handleAddDocument(w http.ResponseWriter, r *http.Request) {
+	var d Document
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid document JSON: "+err.Error())
+		return
+	}
+	n, err := s.AddDocument(d)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, documentResponse{ID: d.ID, N: n})
+}
+
+// handleUpdateDocument replaces an existing document; the path ID wins
+// over any id in the body
+func (s *Server) handleUpdateDocument(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid document id")
+		return
+	}
+	var d Document
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid document JSON: "+err.Error())
+		return
+	}
+	d.ID = id
+	n, err := s.UpdateDocument(d)
+	if errors.Is(err, ErrDocNotFound) {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, documentResponse{ID: id, N: n})
+}
+
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid document id")
+		return
+	}
+	n, err := s.DeleteDocument(id)
+	if errors.Is(err, ErrDocNotFound) {
+		writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, documentResponse{ID: id, N: n})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// handleReload builds a new index off to the side and swaps it in, so
+// searches keep using the old one until the new one is complete. Documents
+// added through POST /documents since startup are not kept, and the new
+// index is checkpointed so Log doesn't bring them back on restart. Bodies
+// of docs the new index lacks are deleted from a shared Store.
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.Reload == nil {
+		writeError(w, http.StatusNotImplemented, "reload not configured")
+		return
+	}
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	idx, err := s.Reload()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writeMu.Lock()
+	s.mu.Lock()
+	old := s.idx
+	s.idx = idx
+	s.mu.Unlock()
+	s.refreshIDs = nil // rebuilt from the new index
+	err = idx.dropStaleContent(old)
+	if err == nil {
+		err = s.checkpoint()
+	}
+	s.writeMu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"n": idx.DocCount()})
+}
@@ -0,0 +1,187 @@
+package gonews
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// serve sends one request to h and returns the recorded response
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+// searchIDs runs q through /search and returns the hit IDs in rank order
+func searchIDs(t *testing.T, h http.Handler, q string) []int {
+	t.Helper()
+	rec := serve(h, "GET", "/search?q="+url.QueryEscape(q), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /search?q=%s: %d %s", q, rec.Code, rec.Body)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(resp.Results))
+	for i, hit := range resp.Results {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestServerDocuments(t *testing.T) {
+	h := NewServer(buildIndex(Document{ID: 1, Title: "Budget vote", Content: "parliament passes budget"})).Handler()
+	steps := []struct {
+		method, path, body string
+		status             int
+		n                  int // docs indexed afterwards
+		query              string
+		want               []int
+	}{
+		{"POST", "/documents", `{"id": 2, "title": "Election", "content": "polls open"}`, 200, 2, "polls", []int{2}},
+		{"POST", "/documents", `{"id": 2, "title": "Election", "content": "results announced"}`, 200, 2, "polls", nil},
+		{"GET", "/search?q=announced", "", 200, 2, "announced", []int{2}},
+		{"PUT", "/documents/1", `{"title": "Budget revised", "content": "new draft"}`, 200, 2, "draft", []int{1}},
+		{"PUT", "/documents/9", `{"title": "Nothing"}`, 404, 2, "nothing", nil},
+		{"DELETE", "/documents/1", "", 200, 1, "budget", nil},
+		{"DELETE", "/documents/1", "", 404, 1, "budget", nil},
+		{"DELETE", "/documents/x", "", 400, 1, "announced", []int{2}},
+		{"POST", "/documents", `{"id": `, 400, 1, "announced", []int{2}},
+	}
+	for _, st := range steps {
+		rec := serve(h, st.method, st.path, st.body)
+		if rec.Code != st.status {
+			t.Fatalf("%s %s = %d %s, want %d", st.method, st.path, rec.Code, rec.Body, st.status)
+		}
+		if st.status == http.StatusOK && st.method != "GET" {
+			var resp documentResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.N != st.n {
+				t.Errorf("%s %s reports n = %d, want %d", st.method, st.path, resp.N, st.n)
+			}
+		}
+		if got := searchIDs(t, h, st.query); !slices.Equal(got, st.want) {
+			t.Errorf("after %s %s: search %q = %v, want %v", st.method, st.path, st.query, got, st.want)
+		}
+	}
+}
+
+func TestServerMalformedQuery(t *testing.T) {
+	h := NewServer(buildIndex(Document{ID: 1, Title: "Budget vote", Content: "parliament passes budget"})).Handler()
+	for _, q := range []string{"(budget", "budget AND", "budget OR OR vote", "date:[2020", "date:[bogus TO 2023]", "lang:"} {
+		if rec := serve(h, "GET", "/search?q="+url.QueryEscape(q), ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /search?q=%s = %d %s, want 400", q, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestServerResultCap(t *testing.T) {
+	idx := NewIndex()
+	for i := 1; i <= 150; i++ {
+		idx.AddDocument(Document{ID: i, Title: fmt.Sprintf("Market report %d", i), Content: "markets moved"})
+	}
+	srv := NewServer(idx)
+	tests := []struct {
+		name       string
+		maxResults int
+		n          int
+		want       int
+		truncated  bool
+	}{
+		{"default cap", 0, 500, defaultServerMaxResults, true},
+		{"index cap", 20, 50, 20, true},
+		{"n below cap", 20, 5, 5, true},
+		{"everything fits", 200, 500, 150, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx.MaxResults = tt.maxResults
+			resp, err := srv.Search(context.Background(), SearchRequest{Query: "markets", N: tt.n})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Results) != tt.want || resp.Truncated != tt.truncated || resp.Total != 150 {
+				t.Errorf("got %d results, truncated %v, total %d; want %d, %v, 150", len(resp.Results), resp.Truncated, resp.Total, tt.want, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestServerSimilarCap(t *testing.T) {
+	idx := NewIndex()
+	for i := 1; i <= 150; i++ {
+		idx.AddDocument(Document{ID: i, Title: fmt.Sprintf("Market report %d", i), Content: "markets moved"})
+	}
+	h := NewServer(idx).Handler()
+	tests := []struct {
+		n    string
+		want int
+	}{
+		{"", 10},
+		{"0", 10},
+		{"3", 3},
+		{"500", defaultServerMaxResults},
+	}
+	for _, tt := range tests {
+		rec := serve(h, "GET", "/similar/1?n="+tt.n, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("n=%s: %d %s", tt.n, rec.Code, rec.Body)
+		}
+		var resp struct{ Results []SearchHit }
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != tt.want {
+			t.Errorf("n=%s: %d results, want %d", tt.n, len(resp.Results), tt.want)
+		}
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	s := NewServer(buildIndex(Document{ID: 1, Title: "Budget vote"}))
+	h := s.Handler()
+	if rec := serve(h, "POST", "/reload", ""); rec.Code != http.StatusNotImplemented {
+		t.Errorf("reload without Reload: status %d, want 501", rec.Code)
+	}
+
+	source := []Document{{ID: 1, Title: "Budget vote"}, {ID: 2, Title: "Election called"}}
+	var fail error
+	s.Reload = func() (*Index, error) {
+		if fail != nil {
+			return nil, fail
+		}
+		return buildIndex(source...), nil
+	}
+	serve(h, "POST", "/documents", `{"id": 3, "title": "Posted budget note"}`)
+	steps := []struct {
+		fail   error
+		status int
+		query  string
+		want   []int
+	}{
+		// the source's new doc appears; the POSTed one is gone
+		{nil, http.StatusOK, "election OR note", []int{2}},
+		// a failed reload keeps serving the current index
+		{fmt.Errorf("source unavailable"), http.StatusInternalServerError, "election", []int{2}},
+	}
+	for i, st := range steps {
+		fail = st.fail
+		rec := serve(h, "POST", "/reload", "")
+		if rec.Code != st.status {
+			t.Fatalf("step %d: status %d %s, want %d", i, rec.Code, rec.Body, st.status)
+		}
+		if got := searchIDs(t, h, st.query); !slices.Equal(got, st.want) {
+			t.Errorf("step %d: search %q = %v, want %v", i, st.query, got, st.want)
+		}
+	}
+}
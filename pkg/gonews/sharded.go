@@ -0,0 +1,217 @@
+package gonews
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ShardedIndex splits documents across several Indexes by a hash of their
+// ID: AddDocuments indexes the shards in parallel, and each search runs on
+// every shard at once and merges their top results. Shards score with the
+// whole collection's document count, frequencies and average length, so
+// results rank as they would in one Index. Write only through the
+// ShardedIndex, whose lock keeps a shard's search from reading another
+// shard mid-write. Duplicate detection and CollapseSimilar work within a
+// shard.
+type ShardedIndex struct {
+	mu     sync.RWMutex
+	Shards []*Index
+}
+
+// NewShardedIndex returns n empty shards (one per CPU if n <= 0), each an
+// EmptyCopy of template, so they share its settings; nil means NewIndex().
+func NewShardedIndex(n int, template *Index) *ShardedIndex {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if template == nil {
+		template = NewIndex()
+	}
+	s := &ShardedIndex{Shards: make([]*Index, n)}
+	for i := range s.Shards {
+		shard := template.EmptyCopy()
+		shard.sharded = s
+		s.Shards[i] = shard
+	}
+	return s
+}
+
+// shardOf returns the shard holding doc id. Fibonacci hashing spreads
+// sequential IDs evenly.
+func (s *ShardedIndex) shardOf(id int) int {
+	h := uint64(id) * 0x9E3779B97F4A7C15
+	return int(h >> 32 % uint64(len(s.Shards)))
+}
+
+// AddDocuments adds docs, each shard indexing its share concurrently
+func (s *ShardedIndex) AddDocuments(docs []Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := make([][]Document, len(s.Shards))
+	for _, d := range docs {
+		i := s.shardOf(d.ID)
+		parts[i] = append(parts[i], d)
+	}
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, d := range part {
+				s.Shards[i].AddDocument(d)
+			}
+		}()
+	}
+	wg.Wait()
+	s.changed()
+}
+
+// AddDocument adds d to its shard, replacing any doc with its ID
+func (s *ShardedIndex) AddDocument(d Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Shards[s.shardOf(d.ID)].AddDocument(d)
+	s.changed()
+}
+
+// DeleteDocument removes a doc; false if the ID is unknown
+func (s *ShardedIndex) DeleteDocument(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok := s.Shards[s.shardOf(id)].DeleteDocument(id)
+	if ok {
+		s.changed()
+	}
+	return ok
+}
+
+// changed clears every shard's result cache: a write to one shard changes
+// the collection statistics all of them score with
+func (s *ShardedIndex) changed() {
+	for _, shard := range s.Shards {
+		shard.cache.clear()
+	}
+}
+
+// Doc returns a document by ID, as Index.Doc
+func (s *ShardedIndex) Doc(id int) (Document, bool) {
+	return s.Shards[s.shardOf(id)].Doc(id)
+}
+
+// DocCount returns the number of documents across the shards
+func (s *ShardedIndex) DocCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, shard := range s.Shards {
+		n += shard.DocCount()
+	}
+	return n
+}
+
+// Search is Index.Search across the shards
+func (s *ShardedIndex) Search(query string) []SearchResult {
+	results, _, _ := s.search(context.Background(), query, SearchOptions{})
+	return results
+}
+
+// SearchPage is Index.SearchPage across the shards
+func (s *ShardedIndex) SearchPage(query string, offset, limit int) ([]SearchResult, int, error) {
+	return s.search(context.Background(), query, SearchOptions{Offset: offset, K: limit})
+}
+
+// SearchWithOptions is Index.SearchWithOptions across the shards
+func (s *ShardedIndex) SearchWithOptions(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, int, error) {
+	return s.search(ctx, query, opts)
+}
+
+// search runs the query on every shard concurrently, each keeping its top
+// Offset+K, and merges them in the first shard's order (they share it)
+func (s *ShardedIndex) search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shardOpts := opts
+	shardOpts.Offset = 0
+	if opts.K > 0 {
+		shardOpts.K = opts.Offset + opts.K
+	}
+	parts := make([][]SearchResult, len(s.Shards))
+	totals := make([]int, len(s.Shards))
+	errs := make([]error, len(s.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range s.Shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parts[i], totals[i], errs[i] = shard.search(ctx, query, shardOpts)
+		}()
+	}
+	wg.Wait()
+
+	first := s.Shards[0]
+	// a SortFunc sees one index: give it one holding the results' docs
+	view := &Index{Docs: make(map[int]Document), Rank: first.Rank, Sort: first.Sort}
+	var results []SearchResult
+	total := 0
+	for i, part := range parts {
+		if errs[i] != nil {
+			return nil, 0, errs[i]
+		}
+		total += totals[i]
+		for _, r := range part {
+			view.Docs[r.DocID] = s.Shards[i].Docs[r.DocID]
+		}
+		results = append(results, part...)
+	}
+	sort.Slice(results, func(i, j int) bool { return view.less(results[i], results[j]) })
+	if first.MaxResults > 0 && len(results) > first.MaxResults {
+		results = results[:first.MaxResults]
+	}
+	if opts.Offset > 0 {
+		results = results[min(opts.Offset, len(results)):]
+	}
+	if opts.K > 0 && len(results) > opts.K {
+		results = results[:opts.K]
+	}
+	return results, total, nil
+}
+
+// collectionN is the document count scores are relative to: the index's
+// own, or its ShardedIndex's
+func (idx *Index) collectionN() int {
+	if idx.sharded == nil {
+		return idx.N
+	}
+	n := 0
+	for _, shard := range idx.sharded.Shards {
+		n += shard.N
+	}
+	return n
+}
+
+// docFreq is the number of docs in the collection containing term, whose
+// posting in this index is p
+func (idx *Index) docFreq(term string, p *Posting) int {
+	if idx.sharded == nil {
+		return p.Len()
+	}
+	df := 0
+	for _, shard := range idx.sharded.Shards {
+		df += shard.Terms[term].Len()
+	}
+	return df
+}
+
+// collectionToks is the total token count across the collection
+func (idx *Index) collectionToks() int {
+	if idx.sharded == nil {
+		return idx.totalToks
+	}
+	n := 0
+	for _, shard := range idx.sharded.Shards {
+		n += shard.totalToks
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+package gonews
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// shardCorpus is n docs of random words of varying length and date, so
+// document frequencies and lengths differ from shard to shard
+func shardCorpus(n int) []Document {
+	words := strings.Fields("budget vote storm coast rates bank summit climate talks election court ruling oil prices strike union")
+	rng := rand.New(rand.NewSource(7))
+	docs := make([]Document, n)
+	for i := range docs {
+		pick := func(k int) string {
+			out := make([]string, k)
+			for j := range out {
+				// skewed, so some words are rare
+				out[j] = words[min(rng.Intn(len(words)), rng.Intn(len(words)))]
+			}
+			return strings.Join(out, " ")
+		}
+		docs[i] = Document{
+			ID:      i*7 + 1,
+			Title:   pick(2 + rng.Intn(4)),
+			Date:    fmt.Sprintf("2024-%02d-%02d", 1+rng.Intn(12), 1+rng.Intn(28)),
+			Content: pick(5 + rng.Intn(60)),
+		}
+	}
+	return docs
+}
+
+var shardQueries = []string{
+	"budget",
+	"union",
+	"storm OR strike",
+	"budget AND NOT vote",
+	`"oil prices"`,
+	"court ruling election",
+	"title:summit OR climate",
+}
+
+// checkShardedRanking checks sharded ranks every shardQueries page exactly
+// as single does
+func checkShardedRanking(t *testing.T, sharded *ShardedIndex, single *Index) {
+	t.Helper()
+	pages := []struct{ offset, k int }{{0, 0}, {0, 10}, {10, 10}, {37, 5}, {100000, 10}}
+	for _, q := range shardQueries {
+		for _, pg := range pages {
+			opts := SearchOptions{Offset: pg.offset, K: pg.k}
+			want, wantTotal, err := single.SearchWithOptions(context.Background(), q, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, total, err := sharded.SearchWithOptions(context.Background(), q, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != wantTotal || !reflect.DeepEqual(got, want) {
+				t.Errorf("%s offset %d k %d: sharded = %v (total %d), single = %v (total %d)",
+					q, pg.offset, pg.k, resultIDs(got), total, resultIDs(want), wantTotal)
+			}
+		}
+	}
+}
+
+func TestShardedRanksLikeOneIndex(t *testing.T) {
+	docs := shardCorpus(400)
+	tests := []struct {
+		name  string
+		setup func(*Index)
+	}{
+		{"tfidf", func(*Index) {}},
+		{"bm25", func(idx *Index) { idx.Scorer = BM25Score }},
+		{"pivoted length", func(idx *Index) { idx.LengthNorm = LengthNormPivoted }},
+		{"matched terms first", func(idx *Index) { idx.Rank = RankByMatchedTerms }},
+		{"sort by date", func(idx *Index) { idx.Sort = SortByDateDesc }},
+		{"max results", func(idx *Index) { idx.MaxResults = 25 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := NewIndex()
+			tt.setup(template)
+			single := template.EmptyCopy()
+			for _, d := range docs {
+				single.AddDocument(d)
+			}
+			sharded := NewShardedIndex(4, template)
+			sharded.AddDocuments(docs)
+			checkShardedRanking(t, sharded, single)
+		})
+	}
+}
+
+func TestShardedConcurrentAddDocuments(t *testing.T) {
+	docs := shardCorpus(600)
+	sharded := NewShardedIndex(3, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < len(docs); i += 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sharded.AddDocuments(docs[i : i+50])
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, q := range shardQueries {
+				if _, _, err := sharded.SearchPage(q, 0, 10); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := sharded.DocCount(); n != len(docs) {
+		t.Fatalf("DocCount = %d, want %d", n, len(docs))
+	}
+	checkShardedRanking(t, sharded, buildIndex(docs...))
+}
@@ -0,0 +1,103 @@
+package gonews
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeGracefulShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	s := NewServer(buildIndex(Document{ID: 1, Title: "Budget vote"}))
+	s.Reload = func() (*Index, error) {
+		close(started)
+		<-release
+		return buildIndex(Document{ID: 2, Title: "Election"}), nil
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + ln.Addr().String()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx, ln, 5*time.Second) }()
+
+	// a slow request is in flight when shutdown starts
+	inflight := make(chan int, 1)
+	go func() {
+		resp, err := client.Post(base+"/reload", "", nil)
+		if err != nil {
+			t.Error(err)
+			inflight <- 0
+			return
+		}
+		resp.Body.Close()
+		inflight <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	// new connections are refused once the listener closes
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := client.Get(base + "/search?q=budget")
+		if err != nil {
+			break
+		}
+		resp.Body.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still accepting requests after shutdown began")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("Serve returned %v before the in-flight request finished", err)
+	default:
+	}
+
+	close(release)
+	if code := <-inflight; code != http.StatusOK {
+		t.Errorf("in-flight request got %d, want 200", code)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve = %v, want nil after a clean shutdown", err)
+	}
+	if n := s.Index().DocCount(); n != 1 {
+		t.Errorf("reloaded index has %d docs, want 1", n)
+	}
+}
+
+func TestServeDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	s := NewServer(NewIndex())
+	s.Reload = func() (*Index, error) {
+		close(started)
+		<-release
+		return NewIndex(), nil
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx, ln, 50*time.Millisecond) }()
+	go func() {
+		if resp, err := http.Post("http://"+ln.Addr().String()+"/reload", "", nil); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+	if err := <-served; err == nil {
+		t.Error("Serve = nil, want an error when requests outlast the drain timeout")
+	}
+}
@@ -0,0 +1,91 @@
+package gonews
+
+import "math/bits"
+
+// DefaultSimilarBits is how many bits two SimHash fingerprints may differ
+// in for CollapseSimilar to treat the docs as near-duplicates
+const DefaultSimilarBits = 3
+
+// simHash is the 64-bit SimHash of a doc's terms, each weighted by its
+// occurrences: docs sharing most of their words get fingerprints differing
+// in few bits, so a syndicated copy with an edited sentence stays close
+func simHash(positions map[string][]int) uint64 {
+	var v [64]int
+	for tok, ps := range positions {
+		h, w := termHash(tok), len(ps)
+		for b := range v {
+			// +w for a set bit, -w otherwise; branch-free since hash bits are coin flips
+			v[b] += (int(h>>b&1)*2 - 1) * w
+		}
+	}
+	var fp uint64
+	for b, n := range v {
+		if n > 0 {
+			fp |= 1 << b
+		}
+	}
+	return fp
+}
+
+// termHash is FNV-1a with a final mix, so every output bit depends on the
+// whole term
+func termHash(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h = (h ^ uint64(s[i])) * 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
+
+// collapseSimilar folds each result into the best ranked earlier one whose
+// fingerprint is within SimilarBits, recording it in that one's Similar.
+// Fingerprints are split into SimilarBits+1 bands: two within the distance
+// must agree on at least one band, so only docs sharing a band are compared.
+func (idx *Index) collapseSimilar(results []SearchResult) []SearchResult {
+	maxBits := idx.SimilarBits
+	if maxBits <= 0 {
+		maxBits = DefaultSimilarBits
+	}
+	maxBits = min(maxBits, 63)
+	nbands := maxBits + 1
+	band := func(fp uint64, i int) uint64 {
+		lo, hi := i*64/nbands, (i+1)*64/nbands
+		return fp >> lo & (1<<(hi-lo) - 1)
+	}
+	buckets := make([]map[uint64][]int, nbands) // band value -> positions in kept
+	for i := range buckets {
+		buckets[i] = make(map[uint64][]int)
+	}
+	var fps []uint64 // fingerprint of each kept result
+	kept := results[:0]
+	for _, r := range results {
+		fp, ok := idx.Fingerprints[r.DocID]
+		if !ok {
+			kept = append(kept, r)
+			fps = append(fps, 0)
+			continue
+		}
+		into := -1
+		for i := range buckets {
+			for _, k := range buckets[i][band(fp, i)] {
+				if (into < 0 || k < into) && bits.OnesCount64(fp^fps[k]) <= maxBits {
+					into = k
+				}
+			}
+		}
+		if into >= 0 {
+			kept[into].Similar = append(kept[into].Similar, r.DocID)
+			continue
+		}
+		for i := range buckets {
+			b := band(fp, i)
+			buckets[i][b] = append(buckets[i][b], len(kept))
+		}
+		kept = append(kept, r)
+		fps = append(fps, fp)
+	}
+	return kept
+}
@@ -0,0 +1,109 @@
+package gonews
+
+import (
+	"math/bits"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rateStory is long enough for one edited word to move its fingerprint
+// only a little
+const rateStory = "The central bank raised interest rates by a quarter point on Tuesday, citing persistent inflation in housing and services, " +
+	"while signalling that further increases were likely before the end of the year. Officials said wage growth remained strong " +
+	"and that consumer spending had held up better than expected through the summer months. Markets had largely priced in the move, " +
+	"and bond yields edged lower after the announcement as traders looked ahead to next month's employment report. The governor " +
+	"told reporters the committee would watch incoming data closely and stood ready to act again if price pressures failed to ease."
+
+func TestSimHash(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: rateStory},
+		Document{ID: 2, Content: strings.ToUpper(rateStory)}, // the same terms
+		Document{ID: 3, Content: strings.Replace(rateStory, "governor", "chair", 1)},
+		Document{ID: 4, Content: rateStory + " The decision was unanimous."},
+		Document{ID: 5, Content: "Storms flooded coastal towns overnight as emergency crews evacuated residents from low lying streets, " +
+			"and the weather service warned of more heavy rain across the region this weekend. Several roads remain closed " +
+			"and power was cut to thousands of homes, while schools in the worst hit districts will stay shut until Monday."},
+	)
+	dist := func(a, b int) int { return bits.OnesCount64(idx.Fingerprints[a] ^ idx.Fingerprints[b]) }
+	if d := dist(1, 2); d != 0 {
+		t.Errorf("docs with the same terms differ in %d bits, want 0", d)
+	}
+	for _, id := range []int{3, 4} {
+		if d := dist(1, id); d > 8 {
+			t.Errorf("doc %d, a small edit, differs in %d bits", id, d)
+		}
+	}
+	if d := dist(1, 5); d < 16 {
+		t.Errorf("unrelated stories differ in only %d bits", d)
+	}
+}
+
+func TestCollapseSimilar(t *testing.T) {
+	idx := NewIndex()
+	idx.Fingerprints = map[int]uint64{
+		1: 0,
+		2: 0b111,              // 3 bits from 1
+		3: 0b1111 << 20,       // 4 bits from 1
+		4: 1 | 1<<20 | 1<<40,  // 3 bits from 1, each in its own band
+		5: 0b111 | 0b111<<60,  // 3 bits from 2 but 6 from 1, which 2 folded into
+		6: 0b1111<<20 | 1<<63, // 1 bit from 3
+		// 7 has no fingerprint
+	}
+	ranked := func() []SearchResult {
+		var rs []SearchResult
+		for _, id := range []int{1, 2, 3, 4, 5, 6, 7} {
+			rs = append(rs, SearchResult{DocID: id})
+		}
+		return rs
+	}
+	tests := []struct {
+		bits    int
+		kept    []int
+		similar map[int][]int
+	}{
+		{0, []int{1, 3, 5, 7}, map[int][]int{1: {2, 4}, 3: {6}}},
+		{3, []int{1, 3, 5, 7}, map[int][]int{1: {2, 4}, 3: {6}}},
+		// each result folds into the best ranked kept one in reach: 6 is
+		// near only 3, which folded into 1
+		{4, []int{1, 5, 6, 7}, map[int][]int{1: {2, 3, 4}}},
+		{1, []int{1, 2, 3, 4, 5, 7}, map[int][]int{3: {6}}},
+	}
+	for _, tt := range tests {
+		idx.SimilarBits = tt.bits
+		got := idx.collapseSimilar(ranked())
+		if ids := resultIDs(got); !slices.Equal(ids, tt.kept) {
+			t.Errorf("SimilarBits %d: kept %v, want %v", tt.bits, ids, tt.kept)
+		}
+		for _, r := range got {
+			if !slices.Equal(r.Similar, tt.similar[r.DocID]) {
+				t.Errorf("SimilarBits %d: doc %d Similar = %v, want %v", tt.bits, r.DocID, r.Similar, tt.similar[r.DocID])
+			}
+		}
+	}
+}
+
+func TestSearchCollapseSimilar(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: rateStory},
+		Document{ID: 2, Content: strings.Replace(rateStory, "Tuesday", "Wednesday", 1)},
+		Document{ID: 3, Content: "The bank kept interest rates where they were."},
+	)
+	if got := sortedIDs(idx.Search("rates")); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Search(rates) without collapsing = %v, want [1 2 3]", got)
+	}
+	idx.CollapseSimilar = true
+	results, total, err := idx.SearchTotal("rates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("Search(rates) collapsed = %v (total %d), want 2 results", results, total)
+	}
+	// the better ranked copy stays, listing the other
+	for _, r := range results {
+		if r.DocID != 3 && len(r.Similar) != 1 {
+			t.Errorf("doc %d Similar = %v, want the other copy", r.DocID, r.Similar)
+		}
+	}
+}
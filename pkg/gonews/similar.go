@@ -0,0 +1,84 @@
+package gonews
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultSimilarTerms is the SimilarTerms used when it is 0
+const DefaultSimilarTerms = 25
+
+// Similar returns up to k documents most like doc id (all of them if k is
+// 0), for "more like this" links. It takes the doc's SimilarTerms highest
+// TF-IDF terms that some other doc shares and ranks every doc holding any
+// of them as a search for those terms would, with the index's Scorer and
+// Sort. The doc itself is left out; ErrDocNotFound if id is unknown.
+func (idx *Index) Similar(id, k int) ([]SearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	d, ok := idx.Docs[id]
+	if !ok {
+		return nil, fmt.Errorf("document %d: %w", id, ErrDocNotFound)
+	}
+	rpn := idx.similarQuery(idx.withContent(d))
+	if len(rpn) == 0 {
+		return nil, nil
+	}
+	run := idx.newQueryRun(rpn)
+	var results []SearchResult
+	for doc := range idx.evaluateRPN(rpn) {
+		if doc != id {
+			results = append(results, run.result(doc))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return idx.less(results[i], results[j]) })
+	if idx.MaxResults > 0 && len(results) > idx.MaxResults {
+		results = results[:idx.MaxResults]
+	}
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// similarQuery is the RPN of an OR of d's top TF-IDF terms
+func (idx *Index) similarQuery(d Document) []string {
+	tf := make(map[string]int)
+	for _, t := range idx.Analyzer.docTokens(d) {
+		tf[t]++
+	}
+	type weighted struct {
+		term   string
+		weight float64
+	}
+	var terms []weighted
+	for t, n := range tf {
+		p, ok := idx.Terms[t]
+		if !ok || isOperator(t) {
+			continue
+		}
+		df := idx.docFreq(t, p)
+		if df < 2 { // only in d: matches nothing else
+			continue
+		}
+		terms = append(terms, weighted{t, float64(n) * idx.idf(float64(df))})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].weight != terms[j].weight {
+			return terms[i].weight > terms[j].weight
+		}
+		return terms[i].term < terms[j].term
+	})
+	n := idx.SimilarTerms
+	if n <= 0 {
+		n = DefaultSimilarTerms
+	}
+	var rpn []string
+	for i, t := range terms[:min(n, len(terms))] {
+		rpn = append(rpn, t.term)
+		if i > 0 {
+			rpn = append(rpn, "OR")
+		}
+	}
+	return rpn
+}
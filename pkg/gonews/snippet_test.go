@@ -0,0 +1,134 @@
+package gonews
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSentenceSnippet(t *testing.T) {
+	prev := SnippetSentences
+	SnippetSentences = true
+	t.Cleanup(func() { SnippetSentences = prev })
+
+	filler := strings.Repeat("lorem ipsum dolor ", 40)
+	tests := []struct {
+		name     string
+		analyzer Analyzer
+		content  string
+		terms    []string
+		want     string
+	}{
+		{"middle sentence", Analyzer{}, "Markets fell sharply. The central bank raised rates again today! Analysts were surprised.",
+			[]string{"rates"}, "The central bank raised rates again today!"},
+		{"first sentence", Analyzer{}, "Rates rose in March. Nothing else happened.",
+			[]string{"rates"}, "Rates rose in March."},
+		{"last sentence without period", Analyzer{}, "Earlier news. Then the minister resigned",
+			[]string{"minister"}, "Then the minister resigned"},
+		{"phrase", Analyzer{}, "Intro here. Global warming worsens? Outro.",
+			[]string{`PHRASE:global warming`}, "Global warming worsens?"},
+		{"matched phrase", Analyzer{}, "Intro here. Global warming worsens? Outro.",
+			[]string{"global warming"}, "Global warming worsens?"},
+		// the window counts positions, which stopwords don't take
+		{"no boundary nearby", Analyzer{}, filler + "the budget passed " + filler,
+			[]string{"budget"}, "...ipsum dolor lorem ipsum dolor lorem ipsum dolor the budget passed lorem ipsum dolor lorem ipsum dolor lorem ipsum dolor lorem..."},
+		// words are read as the index reads them
+		{"stemmed", Analyzer{Stemming: true}, "Polls closed. Elections were held today. Counting began.",
+			[]string{"elect"}, "Elections were held today."},
+		{"identifier part", Analyzer{SplitIdentifiers: true}, "Big week. OpenAI ships reactJS tools. More soon.",
+			[]string{"react"}, "OpenAI ships reactJS tools."},
+		{"any script", Analyzer{DetectLanguages: true}, "Intro here. Der Bundestag stimmte über den Haushalt ab. Outro.",
+			[]string{"über"}, "Der Bundestag stimmte über den Haushalt ab."},
+		{"no match", Analyzer{}, "Nothing here. Or here.", []string{"budget"}, "nothing here or here..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.analyzer.MakeSnippet(tt.content, tt.terms); got != tt.want {
+				t.Errorf("MakeSnippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetStability(t *testing.T) {
+	doc := Document{ID: 1, Title: "Weekly roundup",
+		Content: "Floods closed schools on Monday. Later in the week the budget vote was delayed, and storms returned. The budget passed on Friday."}
+	tests := []struct {
+		query string
+		terms []string // by first occurrence
+	}{
+		{"storms OR budget OR floods", []string{"floods", "budget", "storms"}},
+		{"vote AND budget", []string{"budget", "vote"}},
+		{`"budget vote" OR friday`, []string{"budget vote", "friday"}},
+	}
+	for _, tt := range tests {
+		var first SearchResult
+		var firstSnippet string
+		// map iteration differs between runs and indexes; the output must not
+		for i := range 20 {
+			idx := buildIndex(doc)
+			results := idx.Search(tt.query)
+			if len(results) != 1 {
+				t.Fatalf("Search(%s) found %d docs", tt.query, len(results))
+			}
+			r := results[0]
+			snippet := idx.Analyzer.ResultSnippet(doc, r)
+			if i == 0 {
+				first, firstSnippet = r, snippet
+				if !slices.Equal(r.MatchedTerms, tt.terms) {
+					t.Errorf("Search(%s) matched %q, want %q", tt.query, r.MatchedTerms, tt.terms)
+				}
+				continue
+			}
+			if !slices.Equal(r.MatchedTerms, first.MatchedTerms) || r.Score != first.Score || snippet != firstSnippet {
+				t.Fatalf("Search(%s) run %d: %q %v %q, first run %q %v %q", tt.query, i,
+					r.MatchedTerms, r.Score, snippet, first.MatchedTerms, first.Score, firstSnippet)
+			}
+		}
+	}
+}
+
+func TestBestPassages(t *testing.T) {
+	// w0 ... w99, with budget twice near the start, "budget vote" in the
+	// middle and vote alone at the end
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = fmt.Sprint("w", i)
+	}
+	words[10], words[12] = "budget", "budget"
+	words[50], words[51] = "budget", "vote"
+	words[90] = "vote"
+	content := strings.Join(words, " ")
+	passage := func(start int) string { return strings.Join(words[start:start+passageWindow], " ") }
+
+	var a Analyzer
+	tests := []struct {
+		name  string
+		terms []string
+		n     int
+		want  []string
+	}{
+		// both terms beat one term twice, which beats one term once
+		{"best first", []string{"budget", "vote"}, 1, []string{passage(42)}},
+		// passages don't overlap, and come back in document order
+		{"document order", []string{"budget", "vote"}, 2, []string{passage(2), passage(42)}},
+		{"all", []string{"budget", "vote"}, 5, []string{passage(2), passage(42), passage(80)}},
+		{"phrase", []string{"budget vote"}, 3, []string{passage(42)}},
+		{"no match", []string{"storm"}, 3, nil},
+		{"n = 0", []string{"budget"}, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := a.BestPassages(content, tt.terms, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: BestPassages(%q, %d) =\n%q\nwant\n%q", tt.name, tt.terms, tt.n, got, tt.want)
+		}
+	}
+
+	// terms are matched after analysis, and passages show the analyzed
+	// tokens, as MakeSnippet's window does
+	stemmed := Analyzer{Stemming: true}
+	got := stemmed.BestPassages("the elections were called early", []string{"elect"}, 1)
+	if want := []string{"elect were call earli"}; !slices.Equal(got, want) {
+		t.Errorf("stemmed BestPassages = %q, want %q", got, want)
+	}
+}
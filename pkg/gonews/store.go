@@ -0,0 +1,108 @@
+package gonews
+
+import "fmt"
+
+// DocStore keeps documents outside the index, so article bodies needn't
+// sit in memory. With Index.Store set, AddDocument puts each doc with a
+// body in the store and keeps it in Docs without its Content (see
+// StoredContent); Doc and the result rendering fetch the body back when
+// needed. Implementations must be safe for concurrent use. An index made
+// from another (EmptyCopy, Reanalyze, MergeIndexes) shares its store.
+type DocStore interface {
+	Put(d Document) error
+	Get(id int) (Document, error) // ErrDocNotFound if id was never put
+	Delete(id int) error
+}
+
+// storeContent puts d in the store, when the index has one, and returns d
+// as Docs keeps it: without its Content. If the store fails the body stays
+// in memory, and the error is kept for Flush (or the Server write) to
+// report.
+func (idx *Index) storeContent(d Document) Document {
+	if idx.Store == nil || d.Content == "" {
+		return d
+	}
+	if err := idx.Store.Put(d); err != nil {
+		idx.storeFailed(fmt.Errorf("store document %d: %w", d.ID, err))
+		return d
+	}
+	idx.StoredContent[d.ID] = true
+	d.Content = ""
+	return d
+}
+
+// withContent returns d with its Content, fetching it from the store if
+// that's where it is. A store error leaves the Content empty.
+func (idx *Index) withContent(d Document) Document {
+	if !idx.StoredContent[d.ID] || idx.Store == nil {
+		return d
+	}
+	if full, err := idx.Store.Get(d.ID); err == nil {
+		d.Content = full.Content
+	}
+	return d
+}
+
+// unstoreContent drops a deleted doc's body from the store
+func (idx *Index) unstoreContent(id int) {
+	if !idx.StoredContent[id] {
+		return
+	}
+	delete(idx.StoredContent, id)
+	if idx.Store != nil {
+		if err := idx.Store.Delete(id); err != nil {
+			idx.storeFailed(fmt.Errorf("delete stored document %d: %w", id, err))
+		}
+	}
+}
+
+// storeFailed keeps the first store error since the last takeStoreErr.
+// Callers hold the write lock.
+func (idx *Index) storeFailed(err error) {
+	if idx.storeErr == nil {
+		idx.storeErr = err
+	}
+}
+
+// takeStoreErr returns and clears the kept store error
+func (idx *Index) takeStoreErr() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	err := idx.storeErr
+	idx.storeErr = nil
+	return err
+}
+
+// flushStore flushes a Store that buffers its writes (one with a Flush
+// method, like sqlitestore's)
+func (idx *Index) flushStore() error {
+	if f, ok := idx.Store.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// dropStaleContent deletes from the store the bodies of docs old kept
+// there that idx, built to replace it on the same store, no longer has
+func (idx *Index) dropStaleContent(old *Index) error {
+	if idx.Store == nil || old.Store != idx.Store || old == idx {
+		return nil
+	}
+	old.mu.RLock()
+	stored := make([]int, 0, len(old.StoredContent))
+	for id := range old.StoredContent {
+		stored = append(stored, id)
+	}
+	old.mu.RUnlock()
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, id := range stored {
+		if idx.StoredContent[id] {
+			continue
+		}
+		if err := idx.Store.Delete(id); err != nil {
+			return fmt.Errorf("delete stored document %d: %w", id, err)
+		}
+	}
+	return nil
+}
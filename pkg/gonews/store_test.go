@@ -0,0 +1,95 @@
+package gonews
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// memStore is a DocStore in a map; Put fails while failPut is set
+type memStore struct {
+	mu      sync.Mutex
+	docs    map[int]Document
+	failPut error
+}
+
+func (m *memStore) Put(d Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failPut != nil {
+		return m.failPut
+	}
+	m.docs[d.ID] = d
+	return nil
+}
+
+func (m *memStore) Get(id int) (Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.docs[id]
+	if !ok {
+		return Document{}, fmt.Errorf("document %d: %w", id, ErrDocNotFound)
+	}
+	return d, nil
+}
+
+func (m *memStore) Delete(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.docs, id)
+	return nil
+}
+
+func TestStoreErrorReported(t *testing.T) {
+	broken := errors.New("disk full")
+	store := &memStore{docs: map[int]Document{}, failPut: broken}
+	idx := NewIndex()
+	idx.Store = store
+	idx.AddDocument(Document{ID: 1, Title: "Budget", Content: "the budget vote"})
+	if err := idx.Flush(); !errors.Is(err, broken) {
+		t.Errorf("Flush error %v, want %v", err, broken)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Errorf("second Flush error %v, want nil", err)
+	}
+	// the body stays in memory
+	if d, ok := idx.Doc(1); !ok || d.Content != "the budget vote" {
+		t.Errorf("Doc(1) = %q, %v", d.Content, ok)
+	}
+
+	s := NewServer(idx)
+	if _, err := s.AddDocument(Document{ID: 2, Content: "election called"}); !errors.Is(err, broken) {
+		t.Errorf("Server.AddDocument error %v, want %v", err, broken)
+	}
+	if _, err := s.UpdateDocument(Document{ID: 1, Content: "budget vote again"}); !errors.Is(err, broken) {
+		t.Errorf("Server.UpdateDocument error %v, want %v", err, broken)
+	}
+	store.failPut = nil
+	if _, err := s.UpdateDocument(Document{ID: 1, Content: "budget vote again"}); err != nil {
+		t.Errorf("Server.UpdateDocument error %v once the store recovered, want nil", err)
+	}
+}
+
+func TestReloadDropsStaleContent(t *testing.T) {
+	store := &memStore{docs: map[int]Document{}}
+	idx := NewIndex()
+	idx.Store = store
+	idx.AddDocument(Document{ID: 1, Content: "budget vote"})
+	idx.AddDocument(Document{ID: 2, Content: "election called"})
+
+	s := NewServer(idx)
+	s.Reload = func() (*Index, error) {
+		fresh := idx.EmptyCopy()
+		fresh.AddDocument(Document{ID: 2, Content: "election called again"})
+		return fresh, nil
+	}
+	if rec := serve(s.Handler(), "POST", "/reload", ""); rec.Code != 200 {
+		t.Fatalf("reload: %d %s", rec.Code, rec.Body)
+	}
+	if got := slices.Sorted(maps.Keys(store.docs)); !slices.Equal(got, []int{2}) {
+		t.Errorf("stored docs %v after reload, want [2]", got)
+	}
+}
@@ -0,0 +1,119 @@
+package gonews
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var _ StreamLoader = (*CSVLoader)(nil)
+
+// syntheticCSV has rows with unusable IDs, which get synthetic ones
+const syntheticCSV = "id,title,date,content,boost,summary,author,source,lang\n" +
+	"a1,First,2024-01-01,one,,,,wire,EN\n" +
+	"5,Second,2024-01-02,\"two,\nlines\",1.5,,,,\n" +
+	"b2,Third,2024-01-03,three,,,,,\n" +
+	"5,Fourth,2024-01-04,four,,,A. Writer,,\n" +
+	"2,Fifth,2024-01-05,five,,sum,,,\n"
+
+func TestStreamCSVMatchesLoad(t *testing.T) {
+	path := writeFile(t, "news.csv", syntheticCSV)
+	want, wantWarnings, err := LoadCSVColumns(path, CSVColumns{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Document
+	warnings, err := StreamCSV(path, CSVColumns{}, 0, func(d Document) error {
+		got = append(got, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rows with usable IDs come as read; the rest once the file is done
+	var titles []string
+	for _, d := range got {
+		titles = append(titles, d.Title)
+	}
+	if wantTitles := []string{"Second", "Fifth", "First", "Third", "Fourth"}; !slices.Equal(titles, wantTitles) {
+		t.Errorf("streamed %v, want %v", titles, wantTitles)
+	}
+	byID := func(docs []Document) []Document {
+		docs = slices.Clone(docs)
+		sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+		return docs
+	}
+	if !reflect.DeepEqual(byID(got), byID(want)) {
+		t.Errorf("streamed docs\n%+v\nwant LoadCSVColumns'\n%+v", byID(got), byID(want))
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings = %v, want %v", warnings, wantWarnings)
+	}
+
+	// CSVLoader.Stream keeps the warnings, as Load does
+	l := &CSVLoader{Path: path}
+	n := 0
+	if err := l.Stream(func(Document) error { n++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(want) || !reflect.DeepEqual(l.Warnings, wantWarnings) {
+		t.Errorf("CSVLoader.Stream: %d docs, warnings %v; want %d, %v", n, l.Warnings, len(want), wantWarnings)
+	}
+}
+
+func TestStreamCSVStops(t *testing.T) {
+	// the third row is bad, so reading past it would fail differently
+	path := writeFile(t, "news.csv", "id,title,date,content,boost\n"+
+		"1,A,2024-01-01,a,\n"+
+		"2,B,2024-01-02,b,\n"+
+		"3,C,2024-01-03,c,high\n")
+	stop := errors.New("stop")
+	var seen []int
+	_, err := StreamCSV(path, CSVColumns{}, 0, func(d Document) error {
+		seen = append(seen, d.ID)
+		if d.ID == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || !slices.Equal(seen, []int{1, 2}) {
+		t.Errorf("StreamCSV stopped by fn: saw %v, error %v; want [1 2], %v", seen, err, stop)
+	}
+
+	// the bad row ends the stream after the good ones were handed over
+	seen = nil
+	_, err = StreamCSV(path, CSVColumns{}, 0, func(d Document) error {
+		seen = append(seen, d.ID)
+		return nil
+	})
+	if err == nil || !slices.Equal(seen, []int{1, 2}) {
+		t.Errorf("StreamCSV over a bad boost: saw %v, error %v; want [1 2] and an error", seen, err)
+	}
+}
+
+func TestStreamCSVIndexes(t *testing.T) {
+	path := writeFile(t, "news.csv", syntheticCSV)
+	docs, err := LoadCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := buildIndex(docs...)
+	streamed := NewIndex()
+	if _, err := StreamCSV(path, CSVColumns{}, 0, func(d Document) error {
+		streamed.AddDocument(d)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if streamed.DocCount() != loaded.DocCount() || streamed.TermCount() != loaded.TermCount() {
+		t.Errorf("streamed index has %d docs, %d terms; loaded one %d, %d",
+			streamed.DocCount(), streamed.TermCount(), loaded.DocCount(), loaded.TermCount())
+	}
+	for _, q := range []string{"lines", "four OR five", "wire"} {
+		if got, want := resultIDs(streamed.Search(q)), resultIDs(loaded.Search(q)); !slices.Equal(got, want) {
+			t.Errorf("Search(%s) = %v on the streamed index, %v on the loaded one", q, got, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package gonews
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// QuerySuggester offers popular past queries for a typed prefix. Queries are
+// counted as they are added; with a log file they are also appended there
+// and re-ingested on the next start, so suggestions survive restarts.
+type QuerySuggester struct {
+	mu     sync.Mutex
+	counts map[string]int
+	log    *os.File
+}
+
+func NewQuerySuggester() *QuerySuggester {
+	return &QuerySuggester{counts: make(map[string]int)}
+}
+
+// OpenQueryLog loads the queries logged in path (one per line) and keeps the
+// file open so new queries are appended to it.
+func OpenQueryLog(path string) (*QuerySuggester, error) {
+	s := NewQuerySuggester()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		s.ingest(sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading query log %s: %w", path, err)
+	}
+	s.log = f
+	return s, nil
+}
+
+// normalizeQuery lowercases and collapses whitespace so trivially different
+// spellings of a query count together
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+func (s *QuerySuggester) ingest(q string) string {
+	q = normalizeQuery(q)
+	if q != "" {
+		s.counts[q]++
+	}
+	return q
+}
+
+// Add records a query that was run
+func (s *QuerySuggester) Add(query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := s.ingest(query)
+	if q == "" || s.log == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(s.log, q)
+	return err
+}
+
+// Suggest returns up to k past queries starting with prefix, most frequent first
+func (s *QuerySuggester) Suggest(prefix string, k int) []string {
+	prefix = normalizeQuery(prefix)
+	s.mu.Lock()
+	var out []string
+	for q := range s.counts {
+		if strings.HasPrefix(q, prefix) {
+			out = append(out, q)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if s.counts[out[i]] != s.counts[out[j]] {
+			return s.counts[out[i]] > s.counts[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	s.mu.Unlock()
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// Close closes the query log, if any
+func (s *QuerySuggester) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+// suggestTop is how many completions each trie node keeps precomputed
+const suggestTop = 10
+
+// termTrie holds the indexed terms for Index.Suggest. Each node keeps the
+// suggestTop terms below it with the highest document frequency, so the
+// common lookup costs only the prefix length; asking for more walks the
+// subtree.
+type termTrie struct {
+	labels []byte      // edge byte of each child, ascending
+	kids   []*termTrie // child per label
+	term   *termSuggestion
+	top    []termSuggestion // best terms in this subtree, best first
+}
+
+type termSuggestion struct {
+	term string
+	df   int
+}
+
+func betterSuggestion(a, b termSuggestion) bool {
+	if a.df != b.df {
+		return a.df > b.df
+	}
+	return a.term < b.term
+}
+
+func (t *termTrie) insert(s termSuggestion) {
+	n := t
+	for i := 0; i < len(s.term); i++ {
+		c := s.term[i]
+		j := sort.Search(len(n.labels), func(j int) bool { return n.labels[j] >= c })
+		if j == len(n.labels) || n.labels[j] != c {
+			n.labels = append(n.labels, 0)
+			copy(n.labels[j+1:], n.labels[j:])
+			n.labels[j] = c
+			n.kids = append(n.kids, nil)
+			copy(n.kids[j+1:], n.kids[j:])
+			n.kids[j] = &termTrie{}
+		}
+		n = n.kids[j]
+	}
+	n.term = &s
+}
+
+// rank fills top bottom-up from the children's tops
+func (t *termTrie) rank() {
+	var all []termSuggestion
+	if t.term != nil {
+		all = append(all, *t.term)
+	}
+	for _, k := range t.kids {
+		k.rank()
+		all = append(all, k.top...)
+	}
+	sort.Slice(all, func(i, j int) bool { return betterSuggestion(all[i], all[j]) })
+	t.top = all[:min(len(all), suggestTop)]
+}
+
+func (t *termTrie) find(prefix string) *termTrie {
+	n := t
+	for i := 0; i < len(prefix) && n != nil; i++ {
+		j := sort.Search(len(n.labels), func(j int) bool { return n.labels[j] >= prefix[i] })
+		if j == len(n.labels) || n.labels[j] != prefix[i] {
+			return nil
+		}
+		n = n.kids[j]
+	}
+	return n
+}
+
+func (t *termTrie) collect(out []termSuggestion) []termSuggestion {
+	if t.term != nil {
+		out = append(out, *t.term)
+	}
+	for _, k := range t.kids {
+		out = k.collect(out)
+	}
+	return out
+}
+
+// termTrie returns the suggestion trie, building it on first use after
+// the index changed (locking as termDictionary does)
+func (idx *Index) termTrie() *termTrie {
+	idx.lazyMu.Lock()
+	defer idx.lazyMu.Unlock()
+	if idx.trie != nil {
+		return idx.trie
+	}
+	t := &termTrie{}
+	for term, posting := range idx.Terms {
+		t.insert(termSuggestion{term, posting.Len()})
+	}
+	t.rank()
+	idx.trie = t
+	return t
+}
+
+// Suggest completes the last word of prefix to up to k indexed terms, most
+// frequent (by document frequency) first, for search-as-you-type. Earlier
+// words are kept as typed: "climate cha" may give "climate change". The
+// word is only lowercased, not stemmed, so with stemming on completions are
+// stems.
+func (idx *Index) Suggest(prefix string, k int) []string {
+	if k <= 0 {
+		return nil
+	}
+	head, word := "", strings.ToLower(prefix)
+	if i := strings.LastIndexAny(prefix, " \t"); i >= 0 {
+		head, word = prefix[:i+1], strings.ToLower(prefix[i+1:])
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	n := idx.termTrie().find(word)
+	if n == nil {
+		return nil
+	}
+	best := n.top
+	if k > len(best) && len(n.top) == suggestTop {
+		best = n.collect(nil)
+		sort.Slice(best, func(i, j int) bool { return betterSuggestion(best[i], best[j]) })
+	}
+	out := make([]string, 0, min(k, len(best)))
+	for _, s := range best[:min(k, len(best))] {
+		out = append(out, head+s.term)
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package gonews
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuerySuggester(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.log")
+	qs, err := OpenQueryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{"climate policy", "Climate  Policy", "climate change", "climate policy", "budget", "climate change", "climbing"} {
+		if err := qs.Add(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		prefix string
+		k      int
+		want   []string
+	}{
+		{"clim", 5, []string{"climate policy", "climate change", "climbing"}},
+		{"CLIMATE c", 5, []string{"climate change"}},
+		{"clim", 1, []string{"climate policy"}},
+		{"x", 5, nil},
+	}
+	check := func(qs *QuerySuggester) {
+		t.Helper()
+		for _, tt := range tests {
+			if got := qs.Suggest(tt.prefix, tt.k); !slices.Equal(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %q, want %q", tt.prefix, tt.k, got, tt.want)
+			}
+		}
+	}
+	check(qs)
+	if err := qs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a restart re-reads the log
+	reopened, err := OpenQueryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	check(reopened)
+}
+
+func TestServerQueryLogFailure(t *testing.T) {
+	qs, err := OpenQueryLog(filepath.Join(t.TempDir(), "queries.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs.log.Close() // every write now fails
+	var logged bytes.Buffer
+	srv := NewServer(buildIndex(Document{ID: 1, Content: "climate"}))
+	srv.Queries = qs
+	srv.Logger = slog.New(slog.NewTextHandler(&logged, nil))
+
+	resp, err := srv.Search(context.Background(), SearchRequest{Query: "climate", N: 10})
+	if err != nil || resp.Total != 1 {
+		t.Fatalf("Search = %+v, %v; want the hit despite the query log", resp, err)
+	}
+	if !strings.Contains(logged.String(), "query log write failed") {
+		t.Errorf("failure wasn't logged: %q", logged.String())
+	}
+	if got := qs.Suggest("clim", 5); !slices.Equal(got, []string{"climate"}) {
+		t.Errorf("Suggest = %q, want the query counted anyway", got)
+	}
+}
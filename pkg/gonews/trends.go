@@ -0,0 +1,138 @@
+package gonews
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrendInterval is the length of the time buckets Trends compares and
+// SearchHistogram counts in
+type TrendInterval int
+
+const (
+	TrendDay  TrendInterval = iota
+	TrendWeek               // starting on Monday
+	TrendMonth
+)
+
+// DefaultTrendMinDocs is the TrendOptions.MinDocs used when it is 0
+const DefaultTrendMinDocs = 5
+
+// TrendOptions configures Trends
+type TrendOptions struct {
+	Interval TrendInterval
+	K        int       // trends returned at most (0 = all)
+	MinDocs  int       // least docs in a bucket for a spike to count (0 means DefaultTrendMinDocs)
+	From, To time.Time // only report buckets starting within these days (zero = unbounded)
+}
+
+// Trend is a term used in unusually many documents during one bucket
+type Trend struct {
+	Term     string    `json:"term"`
+	Bucket   time.Time `json:"bucket"`   // start of the bucket, midnight UTC
+	Docs     int       `json:"docs"`     // docs of the bucket with the term
+	Expected float64   `json:"expected"` // docs expected at the term's overall rate
+	Score    float64   `json:"score"`    // (Docs - Expected) / sqrt(Expected)
+}
+
+// Trends finds the terms whose use spiked: for every term and bucket of
+// dated documents (by ParsedDate), it compares the docs with the term to
+// those expected if the term were spread evenly over time, given how many
+// docs each bucket has. Each term is reported once, for its biggest spike,
+// and terms are ordered by it. Numbers are skipped; undated docs don't
+// count.
+func (idx *Index) Trends(opts TrendOptions) []Trend {
+	minDocs := opts.MinDocs
+	if minDocs <= 0 {
+		minDocs = DefaultTrendMinDocs
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	bucketOf := make(map[int]time.Time)
+	bucketDocs := make(map[time.Time]int)
+	for id, d := range idx.Docs {
+		if !d.ParsedDate.IsZero() {
+			b := opts.Interval.bucket(d.ParsedDate)
+			bucketOf[id] = b
+			bucketDocs[b]++
+		}
+	}
+	dated := float64(len(bucketOf))
+
+	var trends []Trend
+	counts := make(map[time.Time]int)
+	for term, p := range idx.Terms {
+		if strings.Trim(term, "0123456789") == "" || p.Len() < minDocs {
+			continue
+		}
+		clear(counts)
+		total := 0
+		for _, id := range p.Docs() {
+			if b, ok := bucketOf[id]; ok {
+				counts[b]++
+				total++
+			}
+		}
+		best := Trend{Score: math.Inf(-1)}
+		for b, n := range counts {
+			if n < minDocs || (!opts.From.IsZero() && b.Before(opts.From)) || (!opts.To.IsZero() && b.After(opts.To)) {
+				continue
+			}
+			expected := float64(total) * float64(bucketDocs[b]) / dated
+			score := (float64(n) - expected) / math.Sqrt(expected)
+			if score > best.Score || (score == best.Score && b.Before(best.Bucket)) {
+				best = Trend{Term: term, Bucket: b, Docs: n, Expected: expected, Score: score}
+			}
+		}
+		if best.Term != "" && best.Score > 0 {
+			trends = append(trends, best)
+		}
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		return trends[i].Term < trends[j].Term
+	})
+	if opts.K > 0 && len(trends) > opts.K {
+		trends = trends[:opts.K]
+	}
+	return trends
+}
+
+// next returns the start of the bucket after the one starting at b
+func (iv TrendInterval) next(b time.Time) time.Time {
+	switch iv {
+	case TrendWeek:
+		return b.AddDate(0, 0, 7)
+	case TrendMonth:
+		return b.AddDate(0, 1, 0)
+	}
+	return b.AddDate(0, 0, 1)
+}
+
+// back returns the start of the bucket n before the one starting at b
+func (iv TrendInterval) back(b time.Time, n int) time.Time {
+	switch iv {
+	case TrendWeek:
+		return b.AddDate(0, 0, -7*n)
+	case TrendMonth:
+		return b.AddDate(0, -n, 0)
+	}
+	return b.AddDate(0, 0, -n)
+}
+
+// bucket returns the start of the bucket holding t, by its UTC date
+func (iv TrendInterval) bucket(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch iv {
+	case TrendWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case TrendMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
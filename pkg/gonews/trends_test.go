@@ -0,0 +1,114 @@
+package gonews
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrendIntervalBucket(t *testing.T) {
+	date := func(s string) time.Time {
+		d, ok := parseDate(s)
+		if !ok {
+			t.Fatalf("parseDate(%s) failed", s)
+		}
+		return d
+	}
+	tests := []struct {
+		iv   TrendInterval
+		in   string
+		want string
+	}{
+		{TrendDay, "2024-03-05T17:45:00Z", "2024-03-05"},
+		// buckets start at midnight UTC, whatever the doc's offset
+		{TrendDay, "2024-03-05T23:30:00-05:00", "2024-03-06"},
+		{TrendWeek, "2024-03-11", "2024-03-11"}, // a Monday
+		{TrendWeek, "2024-03-10", "2024-03-04"}, // a Sunday
+		{TrendWeek, "2024-03-01", "2024-02-26"}, // weeks cross months
+		{TrendWeek, "2025-01-01", "2024-12-30"}, // and years
+		{TrendMonth, "2024-02-29", "2024-02-01"},
+		{TrendMonth, "2024-03-31T23:30:00-05:00", "2024-04-01"},
+		{TrendMonth, "2024-12-31", "2024-12-01"},
+	}
+	for _, tt := range tests {
+		got := tt.iv.bucket(date(tt.in))
+		if want := date(tt.want); !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("interval %d: bucket(%s) = %v, want %s UTC", tt.iv, tt.in, got, tt.want)
+		}
+	}
+
+	steps := []struct {
+		iv         TrendInterval
+		from       string
+		next, back string // back goes 2 buckets
+	}{
+		{TrendDay, "2024-03-01", "2024-03-02", "2024-02-28"},
+		{TrendWeek, "2024-12-30", "2025-01-06", "2024-12-16"},
+		{TrendMonth, "2024-01-01", "2024-02-01", "2023-11-01"},
+	}
+	for _, tt := range steps {
+		b := date(tt.from)
+		if got := tt.iv.next(b); !got.Equal(date(tt.next)) {
+			t.Errorf("interval %d: next(%s) = %v, want %s", tt.iv, tt.from, got, tt.next)
+		}
+		if got := tt.iv.back(b, 2); !got.Equal(date(tt.back)) {
+			t.Errorf("interval %d: back(%s, 2) = %v, want %s", tt.iv, tt.from, got, tt.back)
+		}
+	}
+}
+
+func TestTrends(t *testing.T) {
+	// two market reports a day from Friday 1 March to Sunday 10 March, and
+	// an earthquake covered five times on the 5th
+	var docs []Document
+	for day := 1; day <= 10; day++ {
+		for range 2 {
+			docs = append(docs, Document{ID: len(docs) + 1, Title: "Market report", Date: fmt.Sprintf("2024-03-%02d", day)})
+		}
+	}
+	for range 5 {
+		docs = append(docs, Document{ID: len(docs) + 1, Title: "Market earthquake", Date: "2024-03-05"})
+	}
+	docs = append(docs, Document{ID: len(docs) + 1, Title: "Earthquake"}) // undated: ignored
+	idx := buildIndex(docs...)
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	// score is (docs - expected) / sqrt(expected), expected being the
+	// term's dated docs times the bucket's share of the 25 dated docs
+	trend := func(term string, bucket time.Time, docs, total, inBucket int) Trend {
+		expected := float64(total) * float64(inBucket) / 25
+		return Trend{term, bucket, docs, expected, (float64(docs) - expected) / math.Sqrt(expected)}
+	}
+	tests := []struct {
+		name string
+		opts TrendOptions
+		want []Trend
+	}{
+		// market is as common on the 5th as any day, report too rare a day
+		{"day", TrendOptions{Interval: TrendDay}, []Trend{trend("earthquake", day(5), 5, 5, 7)}},
+		// the week of the 4th has 19 docs, the three days before it 6
+		{"week", TrendOptions{Interval: TrendWeek}, []Trend{
+			trend("earthquake", day(4), 5, 5, 19),
+			trend("report", time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), 6, 20, 6),
+		}},
+		{"week top 1", TrendOptions{Interval: TrendWeek, K: 1}, []Trend{trend("earthquake", day(4), 5, 5, 19)}},
+		{"month", TrendOptions{Interval: TrendMonth}, nil}, // one bucket: nothing stands out
+		{"before the spike", TrendOptions{Interval: TrendDay, To: day(4)}, nil},
+		{"spike too small", TrendOptions{Interval: TrendDay, MinDocs: 6}, nil},
+	}
+	for _, tt := range tests {
+		got := idx.Trends(tt.opts)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Trends = %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i, w := range tt.want {
+			g := got[i]
+			if g.Term != w.Term || !g.Bucket.Equal(w.Bucket) || g.Docs != w.Docs ||
+				math.Abs(g.Expected-w.Expected) > 1e-9 || math.Abs(g.Score-w.Score) > 1e-9 {
+				t.Errorf("%s: trend %d = %+v, want %+v", tt.name, i, g, w)
+			}
+		}
+	}
+}
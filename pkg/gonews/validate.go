@@ -0,0 +1,115 @@
+package gonews
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks the internal consistency of the index and returns every
+// problem found (nil when the index is sound):
+// - every posting references an existing doc
+// - no term maps to an empty posting or an empty position list
+// - each doc's stored token count matches the positions recorded for it
+// - N matches the number of stored docs
+// - docs whose Content is in a Store exist, and the Store is set
+func (idx *Index) Validate() []error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var errs []error
+	if idx.N != len(idx.Docs) {
+		errs = append(errs, fmt.Errorf("N is %d but %d docs are stored", idx.N, len(idx.Docs)))
+	}
+
+	// walk terms in sorted order so the report is stable
+	terms := make([]string, 0, len(idx.Terms))
+	for t := range idx.Terms {
+		terms = append(terms, t)
+	}
+	sort.Strings(terms)
+
+	// docID -> positions used across all terms; an identifier and its parts
+	// share one, so they are counted once
+	seen := make(map[int]map[int]bool)
+	for _, t := range terms {
+		posting := idx.Terms[t]
+		if posting.Len() == 0 {
+			errs = append(errs, fmt.Errorf("term %q has an empty posting", t))
+			continue
+		}
+		if n := len(posting.Docs()); n != posting.Len() {
+			errs = append(errs, fmt.Errorf("term %q posting holds %d docs but counts %d", t, n, posting.Len()))
+		}
+		posting.Each(func(id int, positions []int) {
+			if _, ok := idx.Docs[id]; !ok {
+				errs = append(errs, fmt.Errorf("term %q references missing doc %d", t, id))
+			}
+			if len(positions) == 0 {
+				errs = append(errs, fmt.Errorf("term %q has no positions for doc %d", t, id))
+			}
+			count, ok := idx.DocTokCounts[id]
+			for _, p := range positions {
+				if ok && (p < 0 || p >= count) {
+					errs = append(errs, fmt.Errorf("term %q has position %d out of range for doc %d", t, p, id))
+					break
+				}
+			}
+			if seen[id] == nil {
+				seen[id] = make(map[int]bool)
+			}
+			for _, p := range positions {
+				seen[id][p] = true
+			}
+		})
+	}
+
+	var orphans []int
+	for id := range idx.DocTokCounts {
+		if _, ok := idx.Docs[id]; !ok {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Ints(orphans)
+	for _, id := range orphans {
+		errs = append(errs, fmt.Errorf("token count recorded for missing doc %d", id))
+	}
+
+	total := 0
+	for _, c := range idx.DocTokCounts {
+		total += c
+	}
+	if total != idx.totalToks {
+		errs = append(errs, fmt.Errorf("total token count is %d but docs hold %d", idx.totalToks, total))
+	}
+
+	for _, id := range sortedDocIDs(idx.Docs) {
+		count, ok := idx.DocTokCounts[id]
+		if !ok {
+			errs = append(errs, fmt.Errorf("doc %d has no token count", id))
+			continue
+		}
+		if len(seen[id]) != count {
+			errs = append(errs, fmt.Errorf("doc %d token count is %d but postings hold %d positions", id, count, len(seen[id])))
+		}
+		if ends := idx.FieldEnds[id]; len(ends) != len(indexedFields) || ends[len(ends)-1] != count {
+			errs = append(errs, fmt.Errorf("doc %d field ranges %v don't cover its %d tokens", id, ends, count))
+		}
+		if _, ok := idx.Fingerprints[id]; ok != (count > 0) {
+			errs = append(errs, fmt.Errorf("doc %d with %d tokens has fingerprint %v", id, count, ok))
+		}
+	}
+
+	stored := make([]int, 0, len(idx.StoredContent))
+	for id := range idx.StoredContent {
+		stored = append(stored, id)
+	}
+	sort.Ints(stored)
+	for _, id := range stored {
+		if _, ok := idx.Docs[id]; !ok {
+			errs = append(errs, fmt.Errorf("content of missing doc %d is marked as stored", id))
+		}
+	}
+	if len(stored) > 0 && idx.Store == nil {
+		errs = append(errs, fmt.Errorf("content of %d docs is in a store, but Store is not set", len(stored)))
+	}
+	return errs
+}
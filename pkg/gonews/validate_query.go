@@ -0,0 +1,245 @@
+package gonews
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// queryToken is a lexed query element with its 1-based column
+type queryToken struct {
+	text string
+	col  int
+}
+
+// ValidateQuery checks query syntax without an index: balanced quotes and
+// parentheses, that every operator has its operands, and that clauses
+// such as date ranges and field:word are complete. It runs the parser
+// searches use, so a query it accepts is read by the engine the way it was
+// checked. The error names the offending column so front ends can point
+// at it.
+func ValidateQuery(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return &QueryError{Col: 1, Msg: "empty query"}
+	}
+	lexed, err := lexQuery(query)
+	if err != nil {
+		return err
+	}
+	return checkQuery(lexed)
+}
+
+// checkQuery returns the first syntax error among lexed query tokens
+func checkQuery(toks []queryToken) error {
+	var open []queryToken // unclosed '('
+	needOperand := true
+	var lastOp queryToken // operator awaiting its right operand
+	for i, t := range toks {
+		u := strings.ToUpper(t.text)
+		_, near := nearOperator(t.text)
+		switch {
+		case t.text == "(":
+			open = append(open, t)
+			needOperand = true
+			lastOp = queryToken{}
+		case t.text == ")":
+			if len(open) == 0 {
+				return queryErrorf(t.col, "unmatched ')' at column %d", t.col)
+			}
+			if needOperand {
+				if lastOp.text != "" {
+					return queryErrorf(lastOp.col, "operator %s at column %d is missing its right operand", lastOp.text, lastOp.col)
+				}
+				return queryErrorf(open[len(open)-1].col, "empty parentheses at column %d", open[len(open)-1].col)
+			}
+			open = open[:len(open)-1]
+		case u == "AND" || u == "OR":
+			if needOperand {
+				return queryErrorf(t.col, "operator %s at column %d is missing its left operand", u, t.col)
+			}
+			needOperand = true
+			lastOp = queryToken{text: u, col: t.col}
+		case near:
+			if i == 0 || !isPlainWord(toks[i-1].text) || i+1 == len(toks) || !isPlainWord(toks[i+1].text) {
+				return queryErrorf(t.col, "%s at column %d needs a plain word on each side", u, t.col)
+			}
+			needOperand = true
+			lastOp = queryToken{text: u, col: t.col}
+		case strings.HasPrefix(u, "NEAR/"):
+			return queryErrorf(t.col, "NEAR/ at column %d must be followed by a distance of at least 1", t.col)
+		case u == "NOT" || t.text == excludeMarker:
+			// unary: may start an operand, but needs one after it
+			needOperand = true
+			lastOp = queryToken{text: u, col: t.col}
+			if t.text == excludeMarker {
+				lastOp.text = "-"
+			}
+		default:
+			if err := clauseError(t); err != nil {
+				return err
+			}
+			needOperand = false
+			lastOp = queryToken{}
+		}
+	}
+	if needOperand && lastOp.text != "" {
+		return queryErrorf(lastOp.col, "operator %s at column %d is missing its right operand", lastOp.text, lastOp.col)
+	}
+	if len(open) > 0 {
+		return queryErrorf(open[len(open)-1].col, "unclosed '(' at column %d", open[len(open)-1].col)
+	}
+	return nil
+}
+
+// clauseError rejects a query word that QueryToRPN would misread: an
+// incomplete clause (title:, lang:, date:[bogus TO 2023], amount:>), an
+// _exists_ field docs don't have (matching nothing), a bare wildcard or a
+// malformed ~ edit distance (searched as a plain word)
+func clauseError(t queryToken) error {
+	if strings.HasPrefix(t.text, `"`) || strings.HasPrefix(t.text, `^"`) {
+		return nil // a phrase; lexQuery checked it
+	}
+	if field, ok := parseExistsToken(t.text); ok && !slices.Contains(existsFields, field) {
+		return queryErrorf(t.col, "unknown field %q in _exists_ at column %d", field, t.col)
+	}
+	if field, word, ok := strings.Cut(t.text, ":"); ok && word == "" && slices.Contains(indexedFields, strings.ToLower(field)) {
+		return queryErrorf(t.col, "%s at column %d needs a word after it", t.text, t.col)
+	}
+	if _, _, ok, err := splitFuzzyWord(t.text); ok && err != nil {
+		return queryErrorf(t.col, "%v at column %d", err, t.col)
+	}
+	if lang, ok := parseLangToken(t.text); ok && !validLangCode(lang) {
+		return queryErrorf(t.col, "lang: at column %d needs a language code like en or bn", t.col)
+	}
+	if strings.Trim(t.text, "*") == "" {
+		return queryErrorf(t.col, "wildcard at column %d needs at least one other character", t.col)
+	}
+	if isDateRangeToken(t.text) {
+		if _, err := parseDateRangeToken(t.text); err != nil {
+			return queryErrorf(t.col, "%v at column %d", err, t.col)
+		}
+	}
+	if isRangeToken(t.text) {
+		if _, err := parseRangeToken(t.text); err != nil {
+			return queryErrorf(t.col, "%v at column %d", err, t.col)
+		}
+	}
+	return nil
+}
+
+// isPlainWord reports whether a lexed token is an ordinary word: not an
+// operator, phrase, wildcard, fuzzy word or field:value clause
+func isPlainWord(tok string) bool {
+	return isPlainTerm(tok) && tok != excludeMarker && !strings.ContainsAny(tok, `"*~`)
+}
+
+// lexQuery splits a query into the tokens QueryToRPN parses, keeping
+// columns. Phrases come back with their quotes, ^ anchor and ~N slop; a
+// leading '-' is its own excludeMarker token. Bad syntax is the first
+// error, but the tokens are still the best reading of the whole query:
+// an unterminated phrase or '[' runs to the end, an empty phrase is
+// dropped and a '~' without a number is left to the next word.
+func lexQuery(q string) ([]queryToken, error) {
+	var toks []queryToken
+	var firstErr error
+	fail := func(col int, format string, args ...any) {
+		if firstErr == nil {
+			firstErr = queryErrorf(col, format, args...)
+		}
+	}
+	cur, curCol := "", 0
+	flush := func() {
+		if cur != "" {
+			toks = append(toks, queryToken{text: cur, col: curCol})
+			cur = ""
+		}
+	}
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case c == '"':
+			if cur == "-" {
+				toks = append(toks, queryToken{text: excludeMarker, col: curCol})
+				cur = ""
+			}
+			anchor := ""
+			if cur == "^" {
+				anchor, cur = "^", ""
+			}
+			flush()
+			col := i + 1 - len(anchor)
+			end := strings.IndexByte(q[i+1:], '"')
+			if end == -1 {
+				fail(i+1, "unterminated quote at column %d", i+1)
+				end = len(q) - i - 1
+			}
+			phrase := q[i+1 : i+1+end]
+			i += end + 1
+			slop := ""
+			if i+1 < len(q) && q[i+1] == '~' {
+				j := i + 2
+				for j < len(q) && q[j] >= '0' && q[j] <= '9' {
+					j++
+				}
+				if j == i+2 {
+					fail(i+2, "'~' at column %d must be followed by a number", i+2)
+				} else {
+					slop = q[i+1 : j]
+					i = j - 1
+				}
+			}
+			if strings.TrimSpace(phrase) == "" {
+				fail(col+len(anchor), "empty phrase at column %d", col+len(anchor))
+				continue
+			}
+			toks = append(toks, queryToken{text: anchor + `"` + phrase + `"` + slop, col: col})
+		case isQuerySpace(c):
+			flush()
+		case c == '[':
+			end := strings.IndexByte(q[i:], ']')
+			if end < 0 {
+				fail(i+1, "unclosed '[' at column %d", i+1)
+				end = len(q) - i - 1
+			}
+			if cur == "" {
+				curCol = i + 1
+			}
+			cur += q[i : i+end+1]
+			i += end
+		case c == '(' || c == ')':
+			flush()
+			toks = append(toks, queryToken{text: string(c), col: i + 1})
+		case c == '-' && cur == "" && i+1 < len(q) && !isQuerySpace(q[i+1]) && q[i+1] != '"':
+			toks = append(toks, queryToken{text: excludeMarker, col: i + 1})
+		default:
+			if cur == "" {
+				curCol = i + 1
+			}
+			cur += q[i : i+1]
+		}
+	}
+	flush()
+	return toks, firstErr
+}
+
+// isQuerySpace reports whether c separates query words
+func isQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// lexedPhrase splits a lexed phrase token into its text, slop and anchor
+func lexedPhrase(tok string) (text string, slop int, anchored, ok bool) {
+	anchored = strings.HasPrefix(tok, `^"`)
+	rest := strings.TrimPrefix(tok, "^")
+	if !strings.HasPrefix(rest, `"`) {
+		return "", 0, false, false
+	}
+	end := strings.LastIndexByte(rest, '"')
+	if end == 0 {
+		return "", 0, false, false
+	}
+	if n := rest[end+1:]; n != "" {
+		slop, _ = strconv.Atoi(strings.TrimPrefix(n, "~"))
+	}
+	return rest[1:end], slop, anchored, true
+}
@@ -0,0 +1,78 @@
+package gonews
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		col   int // 0 if valid
+	}{
+		{"climate", 0},
+		{"(climate OR weather) AND policy", 0},
+		{`"global warming" NOT policy`, 0},
+		{"((a OR b) AND (c OR d))", 0},
+		{"", 1},
+		{"(climate OR weather", 1},
+		{"climate OR weather)", 19},
+		{"climate AND ()", 13},
+		{`"global warming`, 1},
+		{`climate AND "warming`, 13},
+		{`climate ""`, 9},
+		{"AND climate", 1},
+		{"climate OR", 9},
+		{"climate AND OR policy", 13},
+		{"NOT", 1},
+		{"(climate AND)", 10},
+		{`"global warming"~x`, 17},
+		{"climate OR OR policy", 12},
+		{"date:[2020", 6},
+		{"date:[bogus TO 2023]", 1},
+		{"amount:>", 1},
+		{"lang:", 1},
+		{"climate title:", 9},
+		{"climate\tAND\tpolicy", 0},
+	}
+	for _, tt := range tests {
+		err := ValidateQuery(tt.query)
+		if tt.col == 0 {
+			if err != nil {
+				t.Errorf("ValidateQuery(%q) = %v, want nil", tt.query, err)
+			}
+			continue
+		}
+		var qe *QueryError
+		if !errors.As(err, &qe) || !errors.Is(err, ErrMalformedQuery) {
+			t.Errorf("ValidateQuery(%q) = %v, want a QueryError", tt.query, err)
+			continue
+		}
+		if qe.Col != tt.col {
+			t.Errorf("ValidateQuery(%q) points at column %d (%v), want %d", tt.query, qe.Col, err, tt.col)
+		}
+	}
+}
+
+// ValidateQuery and the search parser read a query the same way
+func TestValidateQueryMatchesParser(t *testing.T) {
+	tests := []struct{ query, same string }{
+		{"climate\tAND\tpolicy", "climate AND policy"},
+		{"climate\n-policy", "climate -policy"},
+		{`budget"vote"`, `budget "vote"`},
+	}
+	var a Analyzer
+	for _, tt := range tests {
+		if err := ValidateQuery(tt.query); err != nil {
+			t.Errorf("ValidateQuery(%q) = %v, want nil", tt.query, err)
+		}
+		if got, want := a.QueryToRPN(tt.query), a.QueryToRPN(tt.same); !slices.Equal(got, want) {
+			t.Errorf("QueryToRPN(%q) = %q, want %q as for %q", tt.query, got, want, tt.same)
+		}
+	}
+	want := []string{"PHRASE^~2:budget vote", "FIELD:title:election", "OR"}
+	if got := a.QueryToRPN(`^"budget vote"~2 OR title:election`); !slices.Equal(got, want) {
+		t.Errorf("QueryToRPN = %q, want %q", got, want)
+	}
+}
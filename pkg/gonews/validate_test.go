@@ -0,0 +1,54 @@
+package gonews
+
+import "testing"
+
+func validateFixture() *Index {
+	return buildIndex(
+		Document{ID: 1, Title: "Budget vote", Content: "Parliament passes the budget"},
+		Document{ID: 2, Title: "Election night", Content: "Polls close at eight"},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(idx *Index)
+	}{
+		{"posting for missing doc", func(idx *Index) {
+			p := &Posting{}
+			p.set(99, []int{0})
+			idx.Terms["ghost"] = p
+		}},
+		{"empty posting", func(idx *Index) { idx.Terms["empty"] = &Posting{} }},
+		{"token count too high", func(idx *Index) { idx.DocTokCounts[1]++ }},
+		{"token count missing", func(idx *Index) { delete(idx.DocTokCounts, 2) }},
+		{"orphan token count", func(idx *Index) { idx.DocTokCounts[7] = 3 }},
+		{"wrong N", func(idx *Index) { idx.N = 5 }},
+		{"dangling posting after delete", func(idx *Index) {
+			delete(idx.Docs, 2)
+			idx.N = len(idx.Docs)
+		}},
+	}
+	if errs := validateFixture().Validate(); errs != nil {
+		t.Fatalf("Validate on a sound index = %v, want nil", errs)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := validateFixture()
+			tt.corrupt(idx)
+			if errs := idx.Validate(); len(errs) == 0 {
+				t.Error("Validate found nothing")
+			}
+		})
+	}
+}
+
+func TestValidateAfterUpdates(t *testing.T) {
+	idx := validateFixture()
+	idx.AddDocument(Document{ID: 1, Title: "Budget revised", Content: "A new budget draft"})
+	idx.DeleteDocument(2)
+	idx.AddDocument(Document{ID: 3, Title: "Markets", Content: "Stocks rally"})
+	if errs := idx.Validate(); errs != nil {
+		t.Errorf("Validate = %v, want nil", errs)
+	}
+}
@@ -0,0 +1,300 @@
+package gonews
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNoVectors is returned by SemanticSearch on an index without Vectors
+// or without an Embedder for the query
+var ErrNoVectors = errors.New("semantic search needs document embeddings and an embedder")
+
+// VectorIndex holds one embedding per document and finds those closest to
+// a query embedding by cosine similarity, comparing against every document
+// (exact, not approximate, top-k). Vectors are stored normalized. It is
+// safe for concurrent use.
+type VectorIndex struct {
+	mu    sync.RWMutex
+	dim   int
+	vecs  map[int][]float32
+	texts map[int]uint64 // per doc, the embedHash of the text its vector is of, when known
+}
+
+func NewVectorIndex() *VectorIndex {
+	return &VectorIndex{vecs: make(map[int][]float32), texts: make(map[int]uint64)}
+}
+
+// Dim returns the length of the stored vectors (0 while empty)
+func (v *VectorIndex) Dim() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.dim
+}
+
+// Len returns the number of documents with an embedding
+func (v *VectorIndex) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.vecs)
+}
+
+// Has reports whether doc id has an embedding
+func (v *VectorIndex) Has(id int) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.vecs[id]
+	return ok
+}
+
+// Add stores vec as doc id's embedding, replacing any it had. The first
+// vector fixes the dimension; later ones of another length are an error.
+// An all-zero vector is similar to nothing and only removes the old one.
+func (v *VectorIndex) Add(id int, vec []float32) error {
+	return v.add(id, vec, 0)
+}
+
+// add is Add recording the embedHash of the text vec is of (0 for unknown)
+func (v *VectorIndex) add(id int, vec []float32, text uint64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(vec) == 0 || (v.dim != 0 && len(vec) != v.dim) {
+		return fmt.Errorf("embedding of document %d has %d dimensions, want %d", id, len(vec), v.dim)
+	}
+	delete(v.vecs, id)
+	delete(v.texts, id)
+	unit := normalize(vec)
+	if unit == nil {
+		return nil
+	}
+	v.dim = len(vec)
+	v.vecs[id] = unit
+	if text != 0 {
+		v.texts[id] = text
+	}
+	return nil
+}
+
+// get returns doc id's embedding and the embedHash of its text (0 for
+// unknown)
+func (v *VectorIndex) get(id int) ([]float32, uint64, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	vec, ok := v.vecs[id]
+	return vec, v.texts[id], ok
+}
+
+// Delete removes doc id's embedding
+func (v *VectorIndex) Delete(id int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.vecs, id)
+	delete(v.texts, id)
+}
+
+// Search returns the k documents whose embeddings are most similar to
+// query (all of them if k is 0), best first, scored by cosine similarity
+func (v *VectorIndex) Search(query []float32, k int) []SearchResult {
+	return v.search(query, k, nil)
+}
+
+// search is Search over the docs keep accepts (all when keep is nil)
+func (v *VectorIndex) search(query []float32, k int, keep func(id int) bool) []SearchResult {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	q := normalize(query)
+	if q == nil || len(q) != v.dim {
+		return nil
+	}
+	top := &resultHeap{}
+	for id, vec := range v.vecs {
+		if keep != nil && !keep(id) {
+			continue
+		}
+		r := SearchResult{DocID: id, Score: dot(q, vec)}
+		switch {
+		case k <= 0 || top.Len() < k:
+			heap.Push(top, r)
+		case top.less(top.results[0], r):
+			top.results[0] = r
+			heap.Fix(top, 0)
+		}
+	}
+	results := top.results
+	sort.Slice(results, func(i, j int) bool { return top.less(results[j], results[i]) })
+	return results
+}
+
+// resultHeap is a min-heap of results, the worst on top, for keeping the
+// best k
+type resultHeap struct {
+	results []SearchResult
+}
+
+// less orders by score, then by ID descending so ties rank lower IDs first
+func (h *resultHeap) less(a, b SearchResult) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.DocID > b.DocID
+}
+
+func (h *resultHeap) Len() int           { return len(h.results) }
+func (h *resultHeap) Less(i, j int) bool { return h.less(h.results[i], h.results[j]) }
+func (h *resultHeap) Swap(i, j int)      { h.results[i], h.results[j] = h.results[j], h.results[i] }
+func (h *resultHeap) Push(x any)         { h.results = append(h.results, x.(SearchResult)) }
+func (h *resultHeap) Pop() any {
+	r := h.results[len(h.results)-1]
+	h.results = h.results[:len(h.results)-1]
+	return r
+}
+
+// normalize returns vec scaled to unit length, nil for a zero vector
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, x := range vec {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 || math.IsNaN(sum) || math.IsInf(sum, 0) {
+		return nil
+	}
+	norm := math.Sqrt(sum)
+	unit := make([]float32, len(vec))
+	for i, x := range vec {
+		unit[i] = float32(float64(x) / norm)
+	}
+	return unit
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// savedVector is one line of an embeddings file
+type savedVector struct {
+	ID        int       `json:"id"`
+	Embedding []float32 `json:"embedding"`
+	TextHash  uint64    `json:"text_hash,omitempty"` // see VectorIndex.texts
+}
+
+// LoadVectors reads document embeddings from JSON Lines, one
+// {"id": 42, "embedding": [0.12, -0.5, ...]} object per line, as Save
+// writes them or another tool exported them. Save adds a hash of the text
+// each was computed from, which lets EmbedDocuments tell stale ones. The file may be gzip or zstd
+// compressed. Blank lines are skipped.
+func LoadVectors(path string) (*VectorIndex, error) {
+	f, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	v := NewVectorIndex()
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			var sv savedVector
+			if jerr := json.Unmarshal(b, &sv); jerr != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, jerr)
+			}
+			if aerr := v.add(sv.ID, sv.Embedding, sv.TextHash); aerr != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, aerr)
+			}
+		}
+		if err == io.EOF {
+			return v, nil
+		}
+	}
+}
+
+// Save writes the embeddings to path in the format LoadVectors reads,
+// atomically, ordered by doc ID. The stored vectors are normalized.
+func (v *VectorIndex) Save(path string) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	ids := make([]int, 0, len(v.vecs))
+	for id := range v.vecs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, id := range ids {
+		if err = enc.Encode(savedVector{ID: id, Embedding: v.vecs[id], TextHash: v.texts[id]}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("save embeddings: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SemanticSearch ranks documents by how close their embeddings in Vectors
+// are to the query's, as Embedder computes it, so a query finds articles
+// about the same thing in other words ("economic downturn" finds
+// "recession"). It returns up to k results (all if k is 0), capped by
+// MaxResults; docs deleted since they were embedded are left out, and docs
+// without an embedding are never found. ErrNoVectors without Vectors or
+// Embedder.
+func (idx *Index) SemanticSearch(ctx context.Context, query string, k int) ([]SearchResult, error) {
+	return idx.semanticSearch(ctx, query, k, nil)
+}
+
+// semanticSearch is SemanticSearch over the docs in within (all if nil)
+func (idx *Index) semanticSearch(ctx context.Context, query string, k int, within map[int]struct{}) ([]SearchResult, error) {
+	if idx.Vectors == nil || idx.Embedder == nil {
+		return nil, ErrNoVectors
+	}
+	vecs, err := idx.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("embed query: got %d embeddings for 1 text", len(vecs))
+	}
+	if idx.MaxResults > 0 && (k <= 0 || k > idx.MaxResults) {
+		k = idx.MaxResults
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.Vectors.search(vecs[0], k, func(id int) bool {
+		if _, ok := idx.Docs[id]; !ok {
+			return false
+		}
+		if within != nil {
+			_, ok := within[id]
+			return ok
+		}
+		return true
+	}), nil
+}
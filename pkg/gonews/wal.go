@@ -0,0 +1,134 @@
+package gonews
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WAL is a write-ahead log of document writes: each AddDocument or
+// DeleteDocument is appended and synced to disk before it is applied, so
+// after a crash Replay redoes the writes made since the index was last
+// saved. Entries are JSON lines. Replaying is idempotent (each entry
+// leaves its doc the same however often it runs), so a crash between
+// saving the index and Reset only means some entries are replayed twice.
+type WAL struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries int // appended since the last Reset
+}
+
+// walEntry is one logged write
+type walEntry struct {
+	Op  string    `json:"op"` // "add" or "delete"
+	Doc *Document `json:"doc,omitempty"`
+	ID  int       `json:"id,omitempty"`
+}
+
+// OpenWAL opens the log at path, creating it if needed. Call Replay before
+// appending, so writes from before a crash come first.
+func OpenWAL(path string) (*WAL, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &WAL{f: f}, nil
+}
+
+// Replay applies the logged writes to idx in order and returns how many
+// there were. A last entry cut short by a crash was never applied, so it
+// is dropped from the file; a bad entry before the end is ErrInvalidIndex.
+func (w *WAL) Replay(idx *Index) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(w.f)
+	n, good := 0, int64(0)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				// torn final write: cut it off so appends start clean
+				if err := w.f.Truncate(good); err != nil {
+					return n, err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		var e walEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return n, fmt.Errorf("write-ahead log %s entry %d: %v: %w", w.f.Name(), n+1, err, ErrInvalidIndex)
+		}
+		switch {
+		case e.Op == "add" && e.Doc != nil:
+			idx.AddDocument(*e.Doc)
+		case e.Op == "delete":
+			idx.DeleteDocument(e.ID)
+		default:
+			return n, fmt.Errorf("write-ahead log %s entry %d: unknown op %q: %w", w.f.Name(), n+1, e.Op, ErrInvalidIndex)
+		}
+		good += int64(len(line))
+		n++
+	}
+	w.entries = n
+	return n, nil
+}
+
+// LogAdd records that d is about to be added
+func (w *WAL) LogAdd(d Document) error {
+	return w.append(walEntry{Op: "add", Doc: &d})
+}
+
+// LogDelete records that the doc with the given ID is about to be deleted
+func (w *WAL) LogDelete(id int) error {
+	return w.append(walEntry{Op: "delete", ID: id})
+}
+
+func (w *WAL) append(e walEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write-ahead log: %w", err)
+	}
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("write-ahead log: %w", err)
+	}
+	w.entries++
+	return nil
+}
+
+// Len is how many entries the log holds
+func (w *WAL) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.entries
+}
+
+// Reset empties the log, once the index with its writes has been saved
+func (w *WAL) Reset() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.f.Truncate(0); err != nil {
+		return fmt.Errorf("write-ahead log: %w", err)
+	}
+	w.entries = 0
+	return w.f.Sync()
+}
+
+// Close closes the log file
+func (w *WAL) Close() error {
+	return w.f.Close()
+}
@@ -0,0 +1,236 @@
+package gonews
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// walSource is the corpus a crashed server rebuilds from without a checkpoint
+var walSource = []Document{
+	{ID: 1, Title: "Budget vote", Content: "the budget vote passed"},
+	{ID: 2, Title: "Storm warning", Content: "a storm is coming"},
+	{ID: 3, Title: "Rates hold", Content: "rates stay where they are"},
+}
+
+// walWrites adds, updates and deletes docs through srv, in that order
+func walWrites(t *testing.T, srv *Server) {
+	t.Helper()
+	if _, err := srv.AddDocument(Document{ID: 4, Title: "Summit ends", Content: "the summit ended early"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateDocument(Document{ID: 2, Title: "Storm passes", Content: "the storm passed at night"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.DeleteDocument(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.AddDocument(Document{ID: 5, Title: "Budget talks", Content: "budget talks resume"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// walWant is the corpus after walWrites, as docTitles reports it
+var walWant = map[int]string{1: "Budget vote", 2: "Storm passes", 4: "Summit ends", 5: "Budget talks"}
+
+// docTitles maps each doc of idx to its title
+func docTitles(idx *Index) map[int]string {
+	titles := make(map[int]string)
+	idx.Each(func(d Document) bool {
+		titles[d.ID] = d.Title
+		return true
+	})
+	return titles
+}
+
+// checkRecovered checks idx holds walWant's docs and searches like it
+func checkRecovered(t *testing.T, idx *Index) {
+	t.Helper()
+	if got := docTitles(idx); !reflect.DeepEqual(got, walWant) {
+		t.Errorf("recovered docs = %v, want %v", got, walWant)
+	}
+	if got := sortedIDs(idx.Search("budget")); !reflect.DeepEqual(got, []int{1, 5}) {
+		t.Errorf("Search(budget) = %v, want [1 5]", got)
+	}
+	if got := resultIDs(idx.Search("coming OR rates")); len(got) != 0 {
+		t.Errorf("Search(coming OR rates) = %v, want the replaced and deleted text gone", got)
+	}
+}
+
+// walServer serves a fresh index of walSource, logging writes to a WAL in
+// dir and checkpointing to dir/index.gob every checkpointEvery writes
+func walServer(t *testing.T, dir string, checkpointEvery int) (*Server, *WAL) {
+	t.Helper()
+	wal, err := OpenWAL(filepath.Join(dir, "writes.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(buildIndex(walSource...))
+	srv.Log = wal
+	srv.CheckpointEvery = checkpointEvery
+	srv.Snapshot = func(idx *Index) error { return idx.Save(filepath.Join(dir, "index.gob")) }
+	return srv, wal
+}
+
+// restart reopens dir as a -wal server would after a crash: from the
+// checkpoint if there is one, else from the source, then replaying the
+// log. It returns the index and how many writes were replayed.
+func restart(t *testing.T, dir string) (*Index, int) {
+	t.Helper()
+	idx := buildIndex(walSource...)
+	if loaded, err := LoadIndex(filepath.Join(dir, "index.gob")); err == nil {
+		idx = loaded
+	} else if !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	wal, err := OpenWAL(filepath.Join(dir, "writes.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	n, err := wal.Replay(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wal.Len() != n {
+		t.Errorf("Len after Replay = %d, want %d", wal.Len(), n)
+	}
+	return idx, n
+}
+
+func TestWALCrashBeforeCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	srv, wal := walServer(t, dir, 0)
+	walWrites(t, srv)
+	wal.Close() // crash: no checkpoint was ever taken
+
+	idx, n := restart(t, dir)
+	if n != 4 {
+		t.Errorf("replayed %d writes, want 4", n)
+	}
+	checkRecovered(t, idx)
+}
+
+func TestWALCrashAfterCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	srv, wal := walServer(t, dir, 2)
+	walWrites(t, srv) // checkpoints after the 2nd and 4th write
+	if wal.Len() != 0 {
+		t.Fatalf("Len after checkpoint = %d, want 0", wal.Len())
+	}
+	if _, err := srv.AddDocument(Document{ID: 6, Title: "Late edition", Content: "late news"}); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	idx, n := restart(t, dir)
+	if n != 1 {
+		t.Errorf("replayed %d writes, want only the one since the checkpoint", n)
+	}
+	if d, ok := idx.Doc(6); !ok || d.Title != "Late edition" {
+		t.Errorf("doc 6 = %v, %v; want the write after the checkpoint", d, ok)
+	}
+	idx.DeleteDocument(6)
+	checkRecovered(t, idx)
+
+	// the log only holds what followed the checkpoint, so replaying it on
+	// a rebuild from the source loses the checkpointed writes
+	fresh := buildIndex(walSource...)
+	wal, err := OpenWAL(filepath.Join(dir, "writes.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	if _, err := wal.Replay(fresh); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fresh.Doc(4); ok {
+		t.Error("a source rebuild plus the log has doc 4, want it only in the checkpoint")
+	}
+}
+
+func TestWALCrashBetweenSaveAndReset(t *testing.T) {
+	dir := t.TempDir()
+	srv, wal := walServer(t, dir, 0)
+	walWrites(t, srv)
+	// crash after Snapshot but before Reset: every entry is replayed onto
+	// an index that already has it
+	if err := srv.Snapshot(srv.Index()); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	idx, n := restart(t, dir)
+	if n != 4 {
+		t.Errorf("replayed %d writes, want 4", n)
+	}
+	checkRecovered(t, idx)
+}
+
+func TestWALTornLastEntry(t *testing.T) {
+	dir := t.TempDir()
+	srv, wal := walServer(t, dir, 0)
+	walWrites(t, srv)
+	wal.Close()
+	path := filepath.Join(dir, "writes.wal")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// crash halfway through writing a 5th entry
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"add","doc":{"id":9,"title":"Ha`)
+	f.Close()
+
+	idx, n := restart(t, dir)
+	if n != 4 {
+		t.Errorf("replayed %d writes, want 4 without the torn one", n)
+	}
+	checkRecovered(t, idx)
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != fi.Size() {
+		t.Errorf("log is %d bytes after Replay, want the torn entry cut back to %d", after.Size(), fi.Size())
+	}
+
+	// appends after the cut replay cleanly
+	wal, err = OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.Replay(NewIndex()); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.LogAdd(Document{ID: 7, Title: "After the crash"}); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+	idx, n = restart(t, dir)
+	if d, ok := idx.Doc(7); n != 5 || !ok || d.Title != "After the crash" {
+		t.Errorf("after appending: replayed %d, doc 7 = %v, %v; want 5 and the new doc", n, d, ok)
+	}
+}
+
+func TestWALCorruptEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.wal")
+	lines := `{"op":"add","doc":{"id":1,"title":"a"}}` + "\n" + "not json\n" + `{"op":"delete","id":1}` + "\n"
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wal, err := OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wal.Close()
+	n, err := wal.Replay(NewIndex())
+	if !errors.Is(err, ErrInvalidIndex) || n != 1 {
+		t.Errorf("Replay = %d, %v; want 1, ErrInvalidIndex", n, err)
+	}
+}
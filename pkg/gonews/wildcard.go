@@ -0,0 +1,162 @@
+package gonews
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultMaxWildcardTerms caps wildcard expansion when
+// Index.MaxWildcardTerms is unset
+const DefaultMaxWildcardTerms = 100
+
+// wildcardToken marks a query word containing '*' (covid*, *tion, co*id)
+func wildcardToken(pattern string) string { return "WILD:" + pattern }
+
+func parseWildcardToken(tok string) (string, bool) {
+	return strings.CutPrefix(tok, "WILD:")
+}
+
+// isWildcard reports whether a query word is a usable wildcard pattern:
+// it has a '*' and at least one other character
+func isWildcard(word string) bool {
+	return strings.Contains(word, "*") && strings.Trim(word, "*") != ""
+}
+
+// termDict is the sorted term dictionary used to expand wildcards: terms
+// in order for prefix lookups, and reversed terms in order for suffixes
+type termDict struct {
+	sorted   []string
+	reversed []string // each term spelled backwards
+}
+
+// termDictionary returns the dictionary, building it on first use after
+// the terms changed. Safe under the read lock: builds are serialized by
+// lazyMu, and writers (who hold the write lock) only drop it.
+func (idx *Index) termDictionary() *termDict {
+	idx.lazyMu.Lock()
+	defer idx.lazyMu.Unlock()
+	if idx.dict != nil {
+		return idx.dict
+	}
+	d := &termDict{sorted: make([]string, 0, len(idx.Terms)), reversed: make([]string, 0, len(idx.Terms))}
+	for t := range idx.Terms {
+		d.sorted = append(d.sorted, t)
+		d.reversed = append(d.reversed, reverseString(t))
+	}
+	sort.Strings(d.sorted)
+	sort.Strings(d.reversed)
+	idx.dict = d
+	return d
+}
+
+// withPrefix returns the entries of a sorted list starting with prefix
+func withPrefix(list []string, prefix string) []string {
+	i := sort.SearchStrings(list, prefix)
+	j := i
+	for j < len(list) && strings.HasPrefix(list[j], prefix) {
+		j++
+	}
+	return list[i:j]
+}
+
+// expandWildcard returns the indexed terms matching pattern, at most
+// MaxWildcardTerms of them (the most common ones), in sorted order. The
+// literal text before the first '*' narrows the search through the
+// dictionary, or the text after the last '*' when the pattern starts with one.
+func (idx *Index) expandWildcard(pattern string) []string {
+	d := idx.termDictionary()
+	first, last := strings.Index(pattern, "*"), strings.LastIndex(pattern, "*")
+	var candidates []string
+	switch {
+	case first > 0:
+		candidates = withPrefix(d.sorted, pattern[:first])
+	case last < len(pattern)-1:
+		for _, r := range withPrefix(d.reversed, reverseString(pattern[last+1:])) {
+			candidates = append(candidates, reverseString(r))
+		}
+	default:
+		candidates = d.sorted // *infix*: nothing to narrow by
+	}
+	var out []string
+	for _, t := range candidates {
+		if wildcardMatch(pattern, t) {
+			out = append(out, t)
+		}
+	}
+	limit := idx.MaxWildcardTerms
+	if limit <= 0 {
+		limit = DefaultMaxWildcardTerms
+	}
+	if len(out) > limit {
+		sort.Slice(out, func(i, j int) bool {
+			if a, b := idx.Terms[out[i]].Len(), idx.Terms[out[j]].Len(); a != b {
+				return a > b
+			}
+			return out[i] < out[j]
+		})
+		out = out[:limit]
+	}
+	sort.Strings(out)
+	return out
+}
+
+// expandTerms replaces each wildcard and fuzzy token in rpn with its
+// matching terms joined by OR, so the rest of the search sees ordinary
+// terms. A token matching nothing stays as is and matches no docs.
+func (idx *Index) expandTerms(rpn []string) []string {
+	var out []string
+	for _, tok := range rpn {
+		var terms []string
+		if pattern, ok := parseWildcardToken(tok); ok {
+			terms = idx.expandWildcard(pattern)
+		} else if term, dist, ok := parseFuzzyToken(tok); ok {
+			terms = idx.fuzzyExpand(term, dist)
+		}
+		if len(terms) == 0 {
+			out = append(out, tok)
+			continue
+		}
+		out = append(out, terms[0])
+		for _, t := range terms[1:] {
+			out = append(out, t, "OR")
+		}
+	}
+	return out
+}
+
+// parseQuery is QueryToRPN with wildcard and fuzzy terms expanded against
+// the index. Bad syntax is a *QueryError, as from ValidateQuery, rather
+// than QueryToRPN's best guess.
+func (idx *Index) parseQuery(query string) ([]string, error) {
+	rpn, err := idx.Analyzer.compileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return idx.expandTerms(rpn), nil
+}
+
+// wildcardMatch reports whether s matches pattern, where '*' stands for
+// any run of characters (including none)
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, p)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(p):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
@@ -0,0 +1,74 @@
+package gonews
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"covid*", "covid", true},
+		{"covid*", "covidiots", true},
+		{"covid*", "cov", false},
+		{"*tion", "nation", true},
+		{"*tion", "nationals", false},
+		{"co*id", "colloid", true},
+		{"co*id", "covidiots", false},
+		{"*idio*", "covidiots", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestWildcardSearch(t *testing.T) {
+	idx := buildIndex(
+		Document{ID: 1, Content: "covid cases rise in the nation"},
+		Document{ID: 2, Content: "covidiots ignore the vaccination drive"},
+		Document{ID: 3, Content: "colloid chemistry at the station"},
+		Document{ID: 4, Content: "budget talks"},
+		Document{ID: 5, Content: "budget vaccination plan"},
+		Document{ID: 6, Content: "covid budget"},
+	)
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"covid*", []int{1, 2, 6}},            // prefix
+		{"*tion", []int{1, 2, 3, 5}},          // suffix
+		{"co*id", []int{1, 3, 6}},             // both ends fixed
+		{"*idio*", []int{2}},                  // infix
+		{"*zzz*", nil},                        // matches no term
+		{"budget -vacc*", []int{4, 6}},        // NOT over an expansion
+		{"budget AND NOT *tion", []int{4, 6}}, // likewise
+		{"covid* AND budget", []int{6}},
+	}
+	for _, tt := range tests {
+		if got := sortedIDs(idx.Search(tt.query)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// past the cap, the terms in the most docs are kept
+	idx.MaxWildcardTerms = 1
+	if got, want := idx.expandWildcard("covid*"), []string{"covid"}; !slices.Equal(got, want) {
+		t.Errorf("expandWildcard(covid*) capped at 1 = %v, want %v", got, want)
+	}
+	if got := sortedIDs(idx.Search("covid*")); !slices.Equal(got, []int{1, 6}) {
+		t.Errorf("Search(covid*) capped at 1 = %v, want [1 6]", got)
+	}
+	idx.MaxWildcardTerms = 0
+
+	// new terms are found once added
+	idx.AddDocument(Document{ID: 7, Content: "covidence"})
+	if got := sortedIDs(idx.Search("covid*")); !slices.Equal(got, []int{1, 2, 6, 7}) {
+		t.Errorf("Search(covid*) after adding doc 7 = %v, want [1 2 6 7]", got)
+	}
+}
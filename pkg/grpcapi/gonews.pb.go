@@ -0,0 +1,925 @@
+// GoNews search service: the HTTP server's search and document endpoints
+// over gRPC. Field meanings follow the HTTP JSON API (see README).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: gonews.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Phrase        bool                   `protobuf:"varint,2,opt,name=phrase,proto3" json:"phrase,omitempty"`           // search query as one exact phrase
+	N             int32                  `protobuf:"varint,3,opt,name=n,proto3" json:"n,omitempty"`                     // results to return; 0 means 10, capped by the server
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`           // results to skip first
+	Should        string                 `protobuf:"bytes,5,opt,name=should,proto3" json:"should,omitempty"`            // boosts docs also matching it
+	Exclude       []int64                `protobuf:"varint,6,rep,packed,name=exclude,proto3" json:"exclude,omitempty"`  // doc IDs to hide
+	Autocorrect   bool                   `protobuf:"varint,7,opt,name=autocorrect,proto3" json:"autocorrect,omitempty"` // answer for did_you_mean when query finds nothing
+	Facets        bool                   `protobuf:"varint,8,opt,name=facets,proto3" json:"facets,omitempty"`           // count all matches per month and source
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_gonews_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPhrase() bool {
+	if x != nil {
+		return x.Phrase
+	}
+	return false
+}
+
+func (x *SearchRequest) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetShould() string {
+	if x != nil {
+		return x.Should
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetExclude() []int64 {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetAutocorrect() bool {
+	if x != nil {
+		return x.Autocorrect
+	}
+	return false
+}
+
+func (x *SearchRequest) GetFacets() bool {
+	if x != nil {
+		return x.Facets
+	}
+	return false
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	DidYouMean    string                 `protobuf:"bytes,2,opt,name=did_you_mean,json=didYouMean,proto3" json:"did_you_mean,omitempty"` // spelling-corrected query, when query found nothing
+	Corrected     bool                   `protobuf:"varint,3,opt,name=corrected,proto3" json:"corrected,omitempty"`                      // results are for did_you_mean
+	Total         int64                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`                              // matches before paging
+	Offset        int32                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	Truncated     bool                   `protobuf:"varint,6,opt,name=truncated,proto3" json:"truncated,omitempty"` // more results follow this page
+	Results       []*SearchHit           `protobuf:"bytes,7,rep,name=results,proto3" json:"results,omitempty"`
+	Facets        *Facets                `protobuf:"bytes,8,opt,name=facets,proto3" json:"facets,omitempty"` // set when the request asked for facets
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_gonews_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchResponse) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetDidYouMean() string {
+	if x != nil {
+		return x.DidYouMean
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetCorrected() bool {
+	if x != nil {
+		return x.Corrected
+	}
+	return false
+}
+
+func (x *SearchResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *SearchResponse) GetResults() []*SearchHit {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetFacets() *Facets {
+	if x != nil {
+		return x.Facets
+	}
+	return nil
+}
+
+type FacetCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Count         int64                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FacetCount) Reset() {
+	*x = FacetCount{}
+	mi := &file_gonews_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FacetCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FacetCount) ProtoMessage() {}
+
+func (x *FacetCount) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FacetCount.ProtoReflect.Descriptor instead.
+func (*FacetCount) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FacetCount) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *FacetCount) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Facets struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Months        []*FacetCount          `protobuf:"bytes,1,rep,name=months,proto3" json:"months,omitempty"`   // "2006-01", newest first
+	Sources       []*FacetCount          `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"` // most matches first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Facets) Reset() {
+	*x = Facets{}
+	mi := &file_gonews_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Facets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Facets) ProtoMessage() {}
+
+func (x *Facets) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Facets.ProtoReflect.Descriptor instead.
+func (*Facets) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Facets) GetMonths() []*FacetCount {
+	if x != nil {
+		return x.Months
+	}
+	return nil
+}
+
+func (x *Facets) GetSources() []*FacetCount {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type SearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceId      string                 `protobuf:"bytes,2,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Author        string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	Date          string                 `protobuf:"bytes,5,opt,name=date,proto3" json:"date,omitempty"`
+	Score         float64                `protobuf:"fixed64,6,opt,name=score,proto3" json:"score,omitempty"`
+	MatchedTerms  []string               `protobuf:"bytes,7,rep,name=matched_terms,json=matchedTerms,proto3" json:"matched_terms,omitempty"`
+	MatchedFields []string               `protobuf:"bytes,8,rep,name=matched_fields,json=matchedFields,proto3" json:"matched_fields,omitempty"`
+	Snippet       string                 `protobuf:"bytes,9,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	SnippetHtml   string                 `protobuf:"bytes,10,opt,name=snippet_html,json=snippetHtml,proto3" json:"snippet_html,omitempty"` // snippet with matches in <em>, HTML-escaped
+	Source        string                 `protobuf:"bytes,11,opt,name=source,proto3" json:"source,omitempty"`
+	DuplicateOf   *int64                 `protobuf:"varint,12,opt,name=duplicate_of,json=duplicateOf,proto3,oneof" json:"duplicate_of,omitempty"` // the doc this one copies, with -duplicates flag
+	Similar       []int64                `protobuf:"varint,13,rep,packed,name=similar,proto3" json:"similar,omitempty"`                           // near-duplicates collapsed into this hit
+	Lang          string                 `protobuf:"bytes,14,opt,name=lang,proto3" json:"lang,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_gonews_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchHit) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SearchHit) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *SearchHit) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *SearchHit) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *SearchHit) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *SearchHit) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SearchHit) GetMatchedTerms() []string {
+	if x != nil {
+		return x.MatchedTerms
+	}
+	return nil
+}
+
+func (x *SearchHit) GetMatchedFields() []string {
+	if x != nil {
+		return x.MatchedFields
+	}
+	return nil
+}
+
+func (x *SearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+func (x *SearchHit) GetSnippetHtml() string {
+	if x != nil {
+		return x.SnippetHtml
+	}
+	return ""
+}
+
+func (x *SearchHit) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SearchHit) GetDuplicateOf() int64 {
+	if x != nil && x.DuplicateOf != nil {
+		return *x.DuplicateOf
+	}
+	return 0
+}
+
+func (x *SearchHit) GetSimilar() []int64 {
+	if x != nil {
+		return x.Similar
+	}
+	return nil
+}
+
+func (x *SearchHit) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+type Document struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	Content       string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Summary       string                 `protobuf:"bytes,5,opt,name=summary,proto3" json:"summary,omitempty"`
+	Author        string                 `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	SourceId      string                 `protobuf:"bytes,7,opt,name=source_id,json=sourceId,proto3" json:"source_id,omitempty"`
+	Boost         float64                `protobuf:"fixed64,8,opt,name=boost,proto3" json:"boost,omitempty"`
+	Source        string                 `protobuf:"bytes,9,opt,name=source,proto3" json:"source,omitempty"` // publisher or section, counted by facets
+	Lang          string                 `protobuf:"bytes,10,opt,name=lang,proto3" json:"lang,omitempty"`    // ISO 639-1 code; detected when empty with -detect-lang
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Document) Reset() {
+	*x = Document{}
+	mi := &file_gonews_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Document) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Document) ProtoMessage() {}
+
+func (x *Document) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Document.ProtoReflect.Descriptor instead.
+func (*Document) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Document) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Document) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Document) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Document) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Document) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Document) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Document) GetSourceId() string {
+	if x != nil {
+		return x.SourceId
+	}
+	return ""
+}
+
+func (x *Document) GetBoost() float64 {
+	if x != nil {
+		return x.Boost
+	}
+	return 0
+}
+
+func (x *Document) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Document) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+type AddDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Document      *Document              `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddDocumentRequest) Reset() {
+	*x = AddDocumentRequest{}
+	mi := &file_gonews_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddDocumentRequest) ProtoMessage() {}
+
+func (x *AddDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddDocumentRequest.ProtoReflect.Descriptor instead.
+func (*AddDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddDocumentRequest) GetDocument() *Document {
+	if x != nil {
+		return x.Document
+	}
+	return nil
+}
+
+type UpdateDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Document      *Document              `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"` // replaces the document with its id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateDocumentRequest) Reset() {
+	*x = UpdateDocumentRequest{}
+	mi := &file_gonews_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDocumentRequest) ProtoMessage() {}
+
+func (x *UpdateDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDocumentRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateDocumentRequest) GetDocument() *Document {
+	if x != nil {
+		return x.Document
+	}
+	return nil
+}
+
+type DeleteDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDocumentRequest) Reset() {
+	*x = DeleteDocumentRequest{}
+	mi := &file_gonews_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDocumentRequest) ProtoMessage() {}
+
+func (x *DeleteDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDocumentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteDocumentRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	N             int64                  `protobuf:"varint,2,opt,name=n,proto3" json:"n,omitempty"` // documents in the index afterwards
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DocumentResponse) Reset() {
+	*x = DocumentResponse{}
+	mi := &file_gonews_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DocumentResponse) ProtoMessage() {}
+
+func (x *DocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gonews_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DocumentResponse.ProtoReflect.Descriptor instead.
+func (*DocumentResponse) Descriptor() ([]byte, []int) {
+	return file_gonews_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DocumentResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DocumentResponse) GetN() int64 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+var File_gonews_proto protoreflect.FileDescriptor
+
+const file_gonews_proto_rawDesc = "" +
+	"\n" +
+	"\fgonews.proto\x12\tgonews.v1\"\xcf\x01\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x16\n" +
+	"\x06phrase\x18\x02 \x01(\bR\x06phrase\x12\f\n" +
+	"\x01n\x18\x03 \x01(\x05R\x01n\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06should\x18\x05 \x01(\tR\x06should\x12\x18\n" +
+	"\aexclude\x18\x06 \x03(\x03R\aexclude\x12 \n" +
+	"\vautocorrect\x18\a \x01(\bR\vautocorrect\x12\x16\n" +
+	"\x06facets\x18\b \x01(\bR\x06facets\"\x8d\x02\n" +
+	"\x0eSearchResponse\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12 \n" +
+	"\fdid_you_mean\x18\x02 \x01(\tR\n" +
+	"didYouMean\x12\x1c\n" +
+	"\tcorrected\x18\x03 \x01(\bR\tcorrected\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x03R\x05total\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\x12\x1c\n" +
+	"\ttruncated\x18\x06 \x01(\bR\ttruncated\x12.\n" +
+	"\aresults\x18\a \x03(\v2\x14.gonews.v1.SearchHitR\aresults\x12)\n" +
+	"\x06facets\x18\b \x01(\v2\x11.gonews.v1.FacetsR\x06facets\"8\n" +
+	"\n" +
+	"FacetCount\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\"h\n" +
+	"\x06Facets\x12-\n" +
+	"\x06months\x18\x01 \x03(\v2\x15.gonews.v1.FacetCountR\x06months\x12/\n" +
+	"\asources\x18\x02 \x03(\v2\x15.gonews.v1.FacetCountR\asources\"\x98\x03\n" +
+	"\tSearchHit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1b\n" +
+	"\tsource_id\x18\x02 \x01(\tR\bsourceId\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x12\x12\n" +
+	"\x04date\x18\x05 \x01(\tR\x04date\x12\x14\n" +
+	"\x05score\x18\x06 \x01(\x01R\x05score\x12#\n" +
+	"\rmatched_terms\x18\a \x03(\tR\fmatchedTerms\x12%\n" +
+	"\x0ematched_fields\x18\b \x03(\tR\rmatchedFields\x12\x18\n" +
+	"\asnippet\x18\t \x01(\tR\asnippet\x12!\n" +
+	"\fsnippet_html\x18\n" +
+	" \x01(\tR\vsnippetHtml\x12\x16\n" +
+	"\x06source\x18\v \x01(\tR\x06source\x12&\n" +
+	"\fduplicate_of\x18\f \x01(\x03H\x00R\vduplicateOf\x88\x01\x01\x12\x18\n" +
+	"\asimilar\x18\r \x03(\x03R\asimilar\x12\x12\n" +
+	"\x04lang\x18\x0e \x01(\tR\x04langB\x0f\n" +
+	"\r_duplicate_of\"\xef\x01\n" +
+	"\bDocument\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x18\n" +
+	"\asummary\x18\x05 \x01(\tR\asummary\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author\x12\x1b\n" +
+	"\tsource_id\x18\a \x01(\tR\bsourceId\x12\x14\n" +
+	"\x05boost\x18\b \x01(\x01R\x05boost\x12\x16\n" +
+	"\x06source\x18\t \x01(\tR\x06source\x12\x12\n" +
+	"\x04lang\x18\n" +
+	" \x01(\tR\x04lang\"E\n" +
+	"\x12AddDocumentRequest\x12/\n" +
+	"\bdocument\x18\x01 \x01(\v2\x13.gonews.v1.DocumentR\bdocument\"H\n" +
+	"\x15UpdateDocumentRequest\x12/\n" +
+	"\bdocument\x18\x01 \x01(\v2\x13.gonews.v1.DocumentR\bdocument\"'\n" +
+	"\x15DeleteDocumentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"0\n" +
+	"\x10DocumentResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\f\n" +
+	"\x01n\x18\x02 \x01(\x03R\x01n2\xb4\x02\n" +
+	"\x06GoNews\x12=\n" +
+	"\x06Search\x12\x18.gonews.v1.SearchRequest\x1a\x19.gonews.v1.SearchResponse\x12I\n" +
+	"\vAddDocument\x12\x1d.gonews.v1.AddDocumentRequest\x1a\x1b.gonews.v1.DocumentResponse\x12O\n" +
+	"\x0eUpdateDocument\x12 .gonews.v1.UpdateDocumentRequest\x1a\x1b.gonews.v1.DocumentResponse\x12O\n" +
+	"\x0eDeleteDocument\x12 .gonews.v1.DeleteDocumentRequest\x1a\x1b.gonews.v1.DocumentResponseB%\n" +
+	"\rcom.gonews.v1P\x01Z\x12gonews/pkg/grpcapib\x06proto3"
+
+var (
+	file_gonews_proto_rawDescOnce sync.Once
+	file_gonews_proto_rawDescData []byte
+)
+
+func file_gonews_proto_rawDescGZIP() []byte {
+	file_gonews_proto_rawDescOnce.Do(func() {
+		file_gonews_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gonews_proto_rawDesc), len(file_gonews_proto_rawDesc)))
+	})
+	return file_gonews_proto_rawDescData
+}
+
+var file_gonews_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_gonews_proto_goTypes = []any{
+	(*SearchRequest)(nil),         // 0: gonews.v1.SearchRequest
+	(*SearchResponse)(nil),        // 1: gonews.v1.SearchResponse
+	(*FacetCount)(nil),            // 2: gonews.v1.FacetCount
+	(*Facets)(nil),                // 3: gonews.v1.Facets
+	(*SearchHit)(nil),             // 4: gonews.v1.SearchHit
+	(*Document)(nil),              // 5: gonews.v1.Document
+	(*AddDocumentRequest)(nil),    // 6: gonews.v1.AddDocumentRequest
+	(*UpdateDocumentRequest)(nil), // 7: gonews.v1.UpdateDocumentRequest
+	(*DeleteDocumentRequest)(nil), // 8: gonews.v1.DeleteDocumentRequest
+	(*DocumentResponse)(nil),      // 9: gonews.v1.DocumentResponse
+}
+var file_gonews_proto_depIdxs = []int32{
+	4,  // 0: gonews.v1.SearchResponse.results:type_name -> gonews.v1.SearchHit
+	3,  // 1: gonews.v1.SearchResponse.facets:type_name -> gonews.v1.Facets
+	2,  // 2: gonews.v1.Facets.months:type_name -> gonews.v1.FacetCount
+	2,  // 3: gonews.v1.Facets.sources:type_name -> gonews.v1.FacetCount
+	5,  // 4: gonews.v1.AddDocumentRequest.document:type_name -> gonews.v1.Document
+	5,  // 5: gonews.v1.UpdateDocumentRequest.document:type_name -> gonews.v1.Document
+	0,  // 6: gonews.v1.GoNews.Search:input_type -> gonews.v1.SearchRequest
+	6,  // 7: gonews.v1.GoNews.AddDocument:input_type -> gonews.v1.AddDocumentRequest
+	7,  // 8: gonews.v1.GoNews.UpdateDocument:input_type -> gonews.v1.UpdateDocumentRequest
+	8,  // 9: gonews.v1.GoNews.DeleteDocument:input_type -> gonews.v1.DeleteDocumentRequest
+	1,  // 10: gonews.v1.GoNews.Search:output_type -> gonews.v1.SearchResponse
+	9,  // 11: gonews.v1.GoNews.AddDocument:output_type -> gonews.v1.DocumentResponse
+	9,  // 12: gonews.v1.GoNews.UpdateDocument:output_type -> gonews.v1.DocumentResponse
+	9,  // 13: gonews.v1.GoNews.DeleteDocument:output_type -> gonews.v1.DocumentResponse
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_gonews_proto_init() }
+func file_gonews_proto_init() {
+	if File_gonews_proto != nil {
+		return
+	}
+	file_gonews_proto_msgTypes[4].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gonews_proto_rawDesc), len(file_gonews_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gonews_proto_goTypes,
+		DependencyIndexes: file_gonews_proto_depIdxs,
+		MessageInfos:      file_gonews_proto_msgTypes,
+	}.Build()
+	File_gonews_proto = out.File
+	file_gonews_proto_goTypes = nil
+	file_gonews_proto_depIdxs = nil
+}
@@ -0,0 +1,246 @@
+// GoNews search service: the HTTP server's search and document endpoints
+// over gRPC. Field meanings follow the HTTP JSON API (see README).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gonews.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GoNews_Search_FullMethodName         = "/gonews.v1.GoNews/Search"
+	GoNews_AddDocument_FullMethodName    = "/gonews.v1.GoNews/AddDocument"
+	GoNews_UpdateDocument_FullMethodName = "/gonews.v1.GoNews/UpdateDocument"
+	GoNews_DeleteDocument_FullMethodName = "/gonews.v1.GoNews/DeleteDocument"
+)
+
+// GoNewsClient is the client API for GoNews service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GoNewsClient interface {
+	// Search runs a query, like GET /search
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// AddDocument adds a document, replacing one with the same id, like POST /documents
+	AddDocument(ctx context.Context, in *AddDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error)
+	// UpdateDocument replaces an existing document, like PUT /documents/{id}; NOT_FOUND if unknown
+	UpdateDocument(ctx context.Context, in *UpdateDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error)
+	// DeleteDocument removes a document, like DELETE /documents/{id}; NOT_FOUND if unknown
+	DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error)
+}
+
+type goNewsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoNewsClient(cc grpc.ClientConnInterface) GoNewsClient {
+	return &goNewsClient{cc}
+}
+
+func (c *goNewsClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, GoNews_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goNewsClient) AddDocument(ctx context.Context, in *AddDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DocumentResponse)
+	err := c.cc.Invoke(ctx, GoNews_AddDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goNewsClient) UpdateDocument(ctx context.Context, in *UpdateDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DocumentResponse)
+	err := c.cc.Invoke(ctx, GoNews_UpdateDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goNewsClient) DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DocumentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DocumentResponse)
+	err := c.cc.Invoke(ctx, GoNews_DeleteDocument_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GoNewsServer is the server API for GoNews service.
+// All implementations must embed UnimplementedGoNewsServer
+// for forward compatibility.
+type GoNewsServer interface {
+	// Search runs a query, like GET /search
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// AddDocument adds a document, replacing one with the same id, like POST /documents
+	AddDocument(context.Context, *AddDocumentRequest) (*DocumentResponse, error)
+	// UpdateDocument replaces an existing document, like PUT /documents/{id}; NOT_FOUND if unknown
+	UpdateDocument(context.Context, *UpdateDocumentRequest) (*DocumentResponse, error)
+	// DeleteDocument removes a document, like DELETE /documents/{id}; NOT_FOUND if unknown
+	DeleteDocument(context.Context, *DeleteDocumentRequest) (*DocumentResponse, error)
+	mustEmbedUnimplementedGoNewsServer()
+}
+
+// UnimplementedGoNewsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGoNewsServer struct{}
+
+func (UnimplementedGoNewsServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedGoNewsServer) AddDocument(context.Context, *AddDocumentRequest) (*DocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddDocument not implemented")
+}
+func (UnimplementedGoNewsServer) UpdateDocument(context.Context, *UpdateDocumentRequest) (*DocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDocument not implemented")
+}
+func (UnimplementedGoNewsServer) DeleteDocument(context.Context, *DeleteDocumentRequest) (*DocumentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDocument not implemented")
+}
+func (UnimplementedGoNewsServer) mustEmbedUnimplementedGoNewsServer() {}
+func (UnimplementedGoNewsServer) testEmbeddedByValue()                {}
+
+// UnsafeGoNewsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoNewsServer will
+// result in compilation errors.
+type UnsafeGoNewsServer interface {
+	mustEmbedUnimplementedGoNewsServer()
+}
+
+func RegisterGoNewsServer(s grpc.ServiceRegistrar, srv GoNewsServer) {
+	// If the following call pancis, it indicates UnimplementedGoNewsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GoNews_ServiceDesc, srv)
+}
+
+func _GoNews_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoNewsServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoNews_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoNewsServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoNews_AddDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoNewsServer).AddDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoNews_AddDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoNewsServer).AddDocument(ctx, req.(*AddDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoNews_UpdateDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoNewsServer).UpdateDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoNews_UpdateDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoNewsServer).UpdateDocument(ctx, req.(*UpdateDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoNews_DeleteDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoNewsServer).DeleteDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoNews_DeleteDocument_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoNewsServer).DeleteDocument(ctx, req.(*DeleteDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GoNews_ServiceDesc is the grpc.ServiceDesc for GoNews service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoNews_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gonews.v1.GoNews",
+	HandlerType: (*GoNewsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _GoNews_Search_Handler,
+		},
+		{
+			MethodName: "AddDocument",
+			Handler:    _GoNews_AddDocument_Handler,
+		},
+		{
+			MethodName: "UpdateDocument",
+			Handler:    _GoNews_UpdateDocument_Handler,
+		},
+		{
+			MethodName: "DeleteDocument",
+			Handler:    _GoNews_DeleteDocument_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gonews.proto",
+}
@@ -0,0 +1,171 @@
+// Package grpcapi serves a gonews.Server over gRPC using the schema in
+// gonews.proto, for services that want typed clients instead of the JSON
+// API. It lives outside package gonews so only programs using it depend
+// on gRPC.
+//
+// gonews.pb.go and gonews_grpc.pb.go are generated; after editing the
+// schema, run go generate with protoc, protoc-gen-go and
+// protoc-gen-go-grpc installed.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gonews.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gonews/pkg/gonews"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Register adds the GoNews service, backed by srv, to g. Searches share
+// srv's MaxConcurrentSearches slots and Queries log with its HTTP API, and
+// document writes go through the same write-ahead Log.
+func Register(g *grpc.Server, srv *gonews.Server) {
+	RegisterGoNewsServer(g, &service{srv: srv})
+}
+
+type service struct {
+	UnimplementedGoNewsServer
+	srv *gonews.Server
+}
+
+func (s *service) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	if req.GetN() < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "n and offset must not be negative")
+	}
+	n := int(req.GetN())
+	if n == 0 {
+		n = 10 // proto3 can't tell an unset n from 0
+	}
+	var exclude map[int]struct{}
+	if len(req.GetExclude()) > 0 {
+		exclude = make(map[int]struct{}, len(req.GetExclude()))
+		for _, id := range req.GetExclude() {
+			exclude[int(id)] = struct{}{}
+		}
+	}
+	resp, err := s.srv.Search(ctx, gonews.SearchRequest{
+		Query:       req.GetQuery(),
+		Phrase:      req.GetPhrase(),
+		N:           n,
+		Offset:      int(req.GetOffset()),
+		Should:      req.GetShould(),
+		Exclude:     exclude,
+		Autocorrect: req.GetAutocorrect(),
+		Facets:      req.GetFacets(),
+	})
+	switch {
+	case errors.Is(err, gonews.ErrTooManySearches):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, gonews.ErrMalformedQuery):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	out := &SearchResponse{
+		Query:      resp.Query,
+		DidYouMean: resp.DidYouMean,
+		Corrected:  resp.Corrected,
+		Total:      int64(resp.Total),
+		Offset:     int32(resp.Offset),
+		Truncated:  resp.Truncated,
+		Results:    make([]*SearchHit, len(resp.Results)),
+	}
+	for i, h := range resp.Results {
+		hit := &SearchHit{
+			Id:            int64(h.ID),
+			SourceId:      h.SourceID,
+			Title:         h.Title,
+			Author:        h.Author,
+			Source:        h.Source,
+			Lang:          h.Lang,
+			Date:          h.Date,
+			Score:         h.Score,
+			MatchedTerms:  h.MatchedTerms,
+			MatchedFields: h.MatchedFields,
+			Snippet:       h.Snippet,
+			SnippetHtml:   h.SnippetHTML,
+		}
+		for _, id := range h.Similar {
+			hit.Similar = append(hit.Similar, int64(id))
+		}
+		if h.DuplicateOf != nil {
+			hit.DuplicateOf = proto.Int64(int64(*h.DuplicateOf))
+		}
+		out.Results[i] = hit
+	}
+	if resp.Facets != nil {
+		out.Facets = &Facets{Months: facetCounts(resp.Facets.Months), Sources: facetCounts(resp.Facets.Sources)}
+	}
+	return out, nil
+}
+
+func facetCounts(fs []gonews.FacetCount) []*FacetCount {
+	out := make([]*FacetCount, len(fs))
+	for i, f := range fs {
+		out[i] = &FacetCount{Value: f.Value, Count: int64(f.Count)}
+	}
+	return out
+}
+
+func (s *service) AddDocument(_ context.Context, req *AddDocumentRequest) (*DocumentResponse, error) {
+	d := req.GetDocument()
+	if d == nil {
+		return nil, status.Error(codes.InvalidArgument, "document is required")
+	}
+	n, err := s.srv.AddDocument(document(d))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &DocumentResponse{Id: d.GetId(), N: int64(n)}, nil
+}
+
+func (s *service) UpdateDocument(_ context.Context, req *UpdateDocumentRequest) (*DocumentResponse, error) {
+	d := req.GetDocument()
+	if d == nil {
+		return nil, status.Error(codes.InvalidArgument, "document is required")
+	}
+	n, err := s.srv.UpdateDocument(document(d))
+	if errors.Is(err, gonews.ErrDocNotFound) {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("document %d not found", d.GetId()))
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &DocumentResponse{Id: d.GetId(), N: int64(n)}, nil
+}
+
+// document converts a request's document to the index's
+func document(d *Document) gonews.Document {
+	return gonews.Document{
+		ID:       int(d.GetId()),
+		Title:    d.GetTitle(),
+		Date:     d.GetDate(),
+		Content:  d.GetContent(),
+		Summary:  d.GetSummary(),
+		Author:   d.GetAuthor(),
+		Source:   d.GetSource(),
+		Lang:     d.GetLang(),
+		SourceID: d.GetSourceId(),
+		Boost:    d.GetBoost(),
+	}
+}
+
+func (s *service) DeleteDocument(_ context.Context, req *DeleteDocumentRequest) (*DocumentResponse, error) {
+	n, err := s.srv.DeleteDocument(int(req.GetId()))
+	if errors.Is(err, gonews.ErrDocNotFound) {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("document %d not found", req.GetId()))
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &DocumentResponse{Id: req.GetId(), N: int64(n)}, nil
+}
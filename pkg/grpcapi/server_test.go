@@ -0,0 +1,140 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"gonews/pkg/gonews"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves idx over an in-memory connection and returns a client for it
+func dial(t *testing.T, idx *gonews.Index) GoNewsClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	Register(g, gonews.NewServer(idx))
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewGoNewsClient(conn)
+}
+
+// hitIDs lists the IDs of a search's results in order
+func hitIDs(resp *SearchResponse) []int64 {
+	var ids []int64
+	for _, h := range resp.GetResults() {
+		ids = append(ids, h.GetId())
+	}
+	return ids
+}
+
+func TestSearchAndUpdateRoundTrip(t *testing.T) {
+	idx := gonews.NewIndex()
+	idx.AddDocument(gonews.Document{ID: 1, Title: "Budget vote delayed", Date: "2024-03-01", Content: "The budget vote was delayed again", Source: "wire"})
+	idx.AddDocument(gonews.Document{ID: 2, Title: "Storm hits coast", Date: "2024-03-02", Content: "A storm closed the harbour"})
+	client := dial(t, idx)
+	ctx := context.Background()
+
+	resp, err := client.Search(ctx, &SearchRequest{Query: "budget", Facets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(resp); len(got) != 1 || got[0] != 1 || resp.GetTotal() != 1 {
+		t.Fatalf("Search(budget) = %v of %d, want [1] of 1", got, resp.GetTotal())
+	}
+	hit := resp.GetResults()[0]
+	if hit.GetTitle() != "Budget vote delayed" || hit.GetSource() != "wire" || hit.GetScore() <= 0 {
+		t.Errorf("hit = %v", hit)
+	}
+	if f := resp.GetFacets().GetSources(); len(f) != 1 || f[0].GetValue() != "wire" || f[0].GetCount() != 1 {
+		t.Errorf("source facets = %v, want wire (1)", f)
+	}
+
+	upd, err := client.UpdateDocument(ctx, &UpdateDocumentRequest{Document: &Document{Id: 2, Title: "Storm delays budget", Content: "The storm pushed the budget back"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.GetId() != 2 || upd.GetN() != 2 {
+		t.Errorf("UpdateDocument = id %d, n %d, want id 2, n 2", upd.GetId(), upd.GetN())
+	}
+	if resp, err = client.Search(ctx, &SearchRequest{Query: "budget"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(resp); len(got) != 2 {
+		t.Errorf("Search(budget) after update = %v, want both docs", got)
+	}
+	if resp, err = client.Search(ctx, &SearchRequest{Query: "harbour"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(resp); len(got) != 0 {
+		t.Errorf("Search(harbour) after update = %v, want the old text gone", got)
+	}
+
+	if _, err := client.DeleteDocument(ctx, &DeleteDocumentRequest{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = client.Search(ctx, &SearchRequest{Query: "budget"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(resp); len(got) != 1 || got[0] != 2 {
+		t.Errorf("Search(budget) after delete = %v, want [2]", got)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	idx := gonews.NewIndex()
+	idx.AddDocument(gonews.Document{ID: 1, Title: "Budget vote", Content: "budget"})
+	client := dial(t, idx)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"malformed query", func() error {
+			_, err := client.Search(ctx, &SearchRequest{Query: "budget~x"})
+			return err
+		}, codes.InvalidArgument},
+		{"unclosed parenthesis", func() error {
+			_, err := client.Search(ctx, &SearchRequest{Query: "(budget"})
+			return err
+		}, codes.InvalidArgument},
+		{"negative n", func() error {
+			_, err := client.Search(ctx, &SearchRequest{Query: "budget", N: -1})
+			return err
+		}, codes.InvalidArgument},
+		{"update unknown id", func() error {
+			_, err := client.UpdateDocument(ctx, &UpdateDocumentRequest{Document: &Document{Id: 9, Title: "x"}})
+			return err
+		}, codes.NotFound},
+		{"update without document", func() error {
+			_, err := client.UpdateDocument(ctx, &UpdateDocumentRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"delete unknown id", func() error {
+			_, err := client.DeleteDocument(ctx, &DeleteDocumentRequest{Id: 9})
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
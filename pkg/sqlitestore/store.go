@@ -0,0 +1,152 @@
+// Package sqlitestore keeps GoNews documents in a SQLite database, as a
+// gonews.DocStore: with it set as Index.Store, only the inverted index and
+// the documents' metadata stay in memory, and article bodies are read back
+// when results are shown. It lives outside package gonews so only programs
+// using it depend on the SQLite driver.
+package sqlitestore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gonews/pkg/gonews"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS documents (
+	id        INTEGER PRIMARY KEY,
+	title     TEXT NOT NULL,
+	date      TEXT NOT NULL,
+	content   TEXT NOT NULL,
+	summary   TEXT NOT NULL,
+	author    TEXT NOT NULL,
+	source    TEXT NOT NULL,
+	lang      TEXT NOT NULL,
+	source_id TEXT NOT NULL,
+	boost     REAL NOT NULL
+)`
+
+// Store is a gonews.DocStore backed by one SQLite file. Puts are batched
+// in a transaction that commits every batchSize rows, before a Get or
+// Delete, and on Flush or Close.
+type Store struct {
+	db               *sql.DB
+	put, get, delete *sql.Stmt
+
+	mu      sync.Mutex
+	tx      *sql.Tx   // open batch of Puts, nil between batches
+	txPut   *sql.Stmt // put bound to tx
+	pending int       // rows put in tx
+}
+
+// batchSize is how many Puts share a transaction
+const batchSize = 1000
+
+var _ gonews.DocStore = (*Store)(nil)
+
+// Open opens the database at path, creating it if needed
+func Open(path string) (*Store, error) {
+	// WAL lets searches read while documents are written; NORMAL sync
+	// skips an fsync per insert, at worst losing the last writes on power
+	// loss, which re-indexing the source repairs. Pragmas in the DSN apply
+	// to every pooled connection.
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open store %s: %w", path, err)
+	}
+	s := &Store{db: db}
+	for _, p := range []struct {
+		stmt **sql.Stmt
+		q    string
+	}{
+		{&s.put, `INSERT INTO documents (id, title, date, content, summary, author, source, lang, source_id, boost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET title = excluded.title, date = excluded.date, content = excluded.content,
+			summary = excluded.summary, author = excluded.author, source = excluded.source, lang = excluded.lang,
+			source_id = excluded.source_id, boost = excluded.boost`},
+		{&s.get, `SELECT id, title, date, content, summary, author, source, lang, source_id, boost FROM documents WHERE id = ?`},
+		{&s.delete, `DELETE FROM documents WHERE id = ?`},
+	} {
+		if *p.stmt, err = db.Prepare(p.q); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("open store %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Put stores d, replacing any document with its ID. The row is committed
+// with the rest of its batch.
+func (s *Store) Put(d gonews.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tx == nil {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		s.tx, s.txPut = tx, tx.Stmt(s.put)
+	}
+	if _, err := s.txPut.Exec(d.ID, d.Title, d.Date, d.Content, d.Summary, d.Author, d.Source, d.Lang, d.SourceID, d.Boost); err != nil {
+		return err
+	}
+	s.pending++
+	if s.pending >= batchSize {
+		return s.commit()
+	}
+	return nil
+}
+
+// Flush commits the Puts not committed yet
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit()
+}
+
+// commit ends the open batch, if any. Callers hold mu.
+func (s *Store) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Commit()
+	s.tx, s.txPut, s.pending = nil, nil, 0
+	return err
+}
+
+// Get returns the document with the given ID
+func (s *Store) Get(id int) (gonews.Document, error) {
+	if err := s.Flush(); err != nil {
+		return gonews.Document{}, err
+	}
+	var d gonews.Document
+	err := s.get.QueryRow(id).Scan(&d.ID, &d.Title, &d.Date, &d.Content, &d.Summary, &d.Author, &d.Source, &d.Lang, &d.SourceID, &d.Boost)
+	if errors.Is(err, sql.ErrNoRows) {
+		return gonews.Document{}, fmt.Errorf("document %d: %w", id, gonews.ErrDocNotFound)
+	}
+	return d, err
+}
+
+// Delete removes the document with the given ID, if stored
+func (s *Store) Delete(id int) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	_, err := s.delete.Exec(id)
+	return err
+}
+
+// Close commits any pending Puts and closes the database
+func (s *Store) Close() error {
+	err := s.Flush()
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package sqlitestore
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gonews/pkg/gonews"
+)
+
+// openStore opens a store in a fresh directory, closed when the test ends
+func openStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docs.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestStore(t *testing.T) {
+	s, _ := openStore(t)
+	doc := gonews.Document{ID: 1, Title: "Budget vote", Date: "2024-03-01", Content: "the budget vote passed",
+		Summary: "It passed", Author: "A. Writer", Source: "wire", Lang: "en", SourceID: "ap-1", Boost: 1.5}
+	if err := s.Put(doc); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get(1); err != nil || !reflect.DeepEqual(got, doc) {
+		t.Errorf("Get(1) = %+v, %v; want %+v", got, err, doc)
+	}
+
+	// a Put with the same ID replaces the document
+	doc.Content = "the budget vote failed"
+	if err := s.Put(doc); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Get(1); err != nil || got.Content != doc.Content {
+		t.Errorf("Get(1) after replacing = %q, %v; want %q", got.Content, err, doc.Content)
+	}
+
+	if err := s.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{1, 2} {
+		if _, err := s.Get(id); !errors.Is(err, gonews.ErrDocNotFound) {
+			t.Errorf("Get(%d) error = %v, want %v", id, err, gonews.ErrDocNotFound)
+		}
+	}
+	if err := s.Delete(2); err != nil {
+		t.Errorf("Delete of a missing doc = %v, want nil", err)
+	}
+}
+
+func TestBatchedPuts(t *testing.T) {
+	s, _ := openStore(t)
+	n := batchSize + 5
+	for id := 1; id <= n; id++ {
+		if err := s.Put(gonews.Document{ID: id, Content: fmt.Sprint("body ", id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first batch committed on its own; the rest waits
+	if s.pending != 5 {
+		t.Errorf("%d rows pending, want 5", s.pending)
+	}
+	for _, id := range []int{1, batchSize, n} {
+		if got, err := s.Get(id); err != nil || got.Content != fmt.Sprint("body ", id) {
+			t.Errorf("Get(%d) = %q, %v", id, got.Content, err)
+		}
+	}
+
+	// Get and Delete commit the batch first, so they see its rows
+	if err := s.Put(gonews.Document{ID: n + 1, Content: "late"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(n + 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(n + 1); !errors.Is(err, gonews.ErrDocNotFound) {
+		t.Errorf("Get of a doc deleted before its batch committed: error %v, want %v", err, gonews.ErrDocNotFound)
+	}
+	if s.pending != 0 {
+		t.Errorf("%d rows pending after Get, want 0", s.pending)
+	}
+}
+
+func TestFlushAndClose(t *testing.T) {
+	s, path := openStore(t)
+	if err := s.Flush(); err != nil {
+		t.Errorf("Flush with nothing pending = %v", err)
+	}
+	if err := s.Put(gonews.Document{ID: 1, Content: "flushed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(gonews.Document{ID: 2, Content: "committed by Close"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	for id, want := range map[int]string{1: "flushed", 2: "committed by Close"} {
+		if got, err := reopened.Get(id); err != nil || got.Content != want {
+			t.Errorf("Get(%d) after reopening = %q, %v; want %q", id, got.Content, err, want)
+		}
+	}
+}
+
+func TestIndexStore(t *testing.T) {
+	s, _ := openStore(t)
+	idx := gonews.NewIndex()
+	idx.Store = s
+	idx.AddDocument(gonews.Document{ID: 1, Title: "Budget vote", Content: "the budget vote passed"})
+	idx.AddDocument(gonews.Document{ID: 2, Title: "Storm warning", Content: "a storm is coming"})
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !idx.StoredContent[1] || idx.Docs[1].Content != "" {
+		t.Errorf("doc 1 body kept in memory, want it in the store")
+	}
+	results := idx.Search("storm")
+	if len(results) != 1 || results[0].DocID != 2 {
+		t.Fatalf("Search(storm) = %v, want doc 2", results)
+	}
+	if d, ok := idx.Doc(2); !ok || d.Content != "a storm is coming" {
+		t.Errorf("Doc(2) = %q, %v; want its body from the store", d.Content, ok)
+	}
+}